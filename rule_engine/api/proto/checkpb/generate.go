@@ -0,0 +1,9 @@
+// Package checkpb 规则检查gRPC接口的消息和服务定义，由api/proto/check.proto生成，不要手工修改生成的文件
+//
+// 需要protoc、protoc-gen-go和protoc-gen-go-grpc：
+//
+//	go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.3
+//	go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1
+package checkpb
+
+//go:generate protoc -I .. --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative check.proto
@@ -4,20 +4,28 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
-	goredis "github.com/go-redis/redis/v8"
+	"github.com/xwaf/rule_engine/internal/bot"
 	"github.com/xwaf/rule_engine/internal/config"
+	"github.com/xwaf/rule_engine/internal/geoip"
 	"github.com/xwaf/rule_engine/internal/handler"
-	"github.com/xwaf/rule_engine/internal/repository"
-	"github.com/xwaf/rule_engine/internal/repository/mysql"
-	redisrepo "github.com/xwaf/rule_engine/internal/repository/redis"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/proxy"
+	"github.com/xwaf/rule_engine/internal/reqbody"
 	"github.com/xwaf/rule_engine/internal/router"
+	"github.com/xwaf/rule_engine/internal/rpc"
 	"github.com/xwaf/rule_engine/internal/server"
 	"github.com/xwaf/rule_engine/internal/service"
 	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+	"github.com/xwaf/rule_engine/pkg/tracing"
 )
 
 var (
@@ -45,45 +53,253 @@ func main() {
 	}
 	defer logger.Sync()
 
-	// 初始化MySQL
-	if err := mysql.InitMySQL(&mysql.Config{
-		Username: cfg.MySQL.Username,
-		Password: cfg.MySQL.Password,
-		Addr:     cfg.MySQL.Host,
-		Port:     fmt.Sprintf("%d", cfg.MySQL.Port),
-		Database: cfg.MySQL.Database,
-	}); err != nil {
-		logger.Fatal("初始化MySQL失败: %v", err)
+	// migrate子命令：执行表结构迁移后退出，不启动服务
+	if flag.NArg() > 0 {
+		if flag.Arg(0) != "migrate" {
+			fmt.Printf("未知的命令: %s\n%s\n", flag.Arg(0), migrateUsage)
+			os.Exit(2)
+		}
+		if err := runMigrate(cfg, flag.Args()[1:]); err != nil {
+			fmt.Printf("迁移失败: %v\n", err)
+			logger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 初始化监控指标：限制标签基数
+	metricsCfg := cfg.Metrics
+	if metricsCfg == nil {
+		metricsCfg = &config.MetricsConfig{Enabled: true, Path: "/metrics"}
+	}
+	metrics.SetLabelPolicy(metrics.LabelPolicy{
+		MaxRules: metricsCfg.MaxRuleLabels,
+		MaxSites: metricsCfg.MaxSiteLabels,
+		MaxNodes: metricsCfg.MaxNodeLabels,
+	})
+
+	// 初始化链路追踪：未开启时Span只传播链路信息，不记录也不导出
+	tracer := &tracingSwitch{}
+	if err := tracer.apply(cfg.Tracing); err != nil {
+		logger.Fatal("初始化链路追踪失败: %v", err)
 	}
 
-	// 获取数据库连接
-	db := mysql.GetDB()
-	sqlDB, err := db.DB()
+	// 初始化存储和仓库，数据库和Redis的指标、链路回调始终注册，以便重新加载配置后开启链路追踪
+	healthCfg := cfg.Health
+	if healthCfg == nil {
+		healthCfg = &config.HealthConfig{RedisRequired: true}
+	}
+	var store *storage
+	switch cfg.StorageDriver() {
+	case config.StorageSQLite:
+		store, err = openSQLite(cfg.Storage)
+	default:
+		store, err = openMySQL(cfg, healthCfg)
+	}
 	if err != nil {
-		logger.Fatal("获取原始数据库连接失败: %v", err)
+		logger.Fatal("初始化存储失败: %v", err)
+	}
+	if err := metrics.RegisterGormCallbacks(store.db); err != nil {
+		logger.Fatal("注册数据库指标回调失败: %v", err)
 	}
+	if err := tracing.RegisterGormCallbacks(store.db); err != nil {
+		logger.Fatal("注册数据库链路回调失败: %v", err)
+	}
+	ruleRepo := store.rules
+	ipRepo := store.ips
+	cacheRepo := store.cache
+	ruleCache := store.ruleCache
+	ruleCache.SetRuleTTL(time.Duration(cfg.Rule.CacheTTL) * time.Second)
+	ccRepo := store.cc
+	versionRepo := store.versions
+	releaseRepo := store.releases
+	changeRepo := store.changes
+	bypassRepo := store.bypasses
 
-	// 初始化Redis客户端
-	redisClient := goredis.NewClient(&goredis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	// 初始化请求信息补充（地理位置、机器人识别）
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if store.run != nil {
+		go store.run(ctx)
+	}
+
+	var enrichers []service.RequestEnricher
+	if cfg.GeoIP != nil && cfg.GeoIP.Enabled {
+		locator, err := geoip.NewMMDBLocator(cfg.GeoIP.CityDB, cfg.GeoIP.ASNDB)
+		if err != nil {
+			logger.Fatal("初始化GeoIP失败: %v", err)
+		}
+		go locator.Watch(ctx, time.Duration(cfg.GeoIP.ReloadInterval)*time.Second)
+		enrichers = append(enrichers, locator)
+	}
+
+	// 初始化机器人识别
+	if cfg.Bot != nil && cfg.Bot.Enabled {
+		opts := bot.Options{
+			VerifyCrawlers: cfg.Bot.VerifyCrawlers,
+			DNSTimeout:     time.Duration(cfg.Bot.DNSTimeout) * time.Millisecond,
+			CacheTTL:       time.Duration(cfg.Bot.CacheTTL) * time.Second,
+			PendingTTL:     time.Duration(cfg.Bot.PendingTTL) * time.Second,
+			MaxLookups:     cfg.Bot.MaxLookups,
+		}
+		if cfg.Bot.SignaturesFile != "" {
+			signatures, err := bot.LoadSignatures(cfg.Bot.SignaturesFile)
+			if err != nil {
+				logger.Fatal("加载机器人签名失败: %v", err)
+			}
+			opts.Signatures = signatures
+		}
+		classifier, err := bot.NewClassifier(nil, opts)
+		if err != nil {
+			logger.Fatal("初始化机器人识别失败: %v", err)
+		}
+		enrichers = append(enrichers, classifier)
+	}
 
-	// 初始化仓库
-	ruleRepo := mysql.NewRuleRepository(db, redisClient)
-	ipRepo := mysql.NewIPRuleRepository(sqlDB)
-	cacheRepo := redisrepo.NewCacheRepository(redisClient)
-	ccRepo := mysql.NewCCRuleRepository(sqlDB)
-	versionRepo := mysql.NewRuleVersionRepository(sqlDB)
+	// 请求体解压和解析，解析出的字段用于 request_args_post、request_body_json、request_body_xml 规则变量
+	bodyOpts := reqbody.Options{}
+	uploadOpts := service.UploadEventOptions{}
+	if cfg.Body != nil {
+		bodyOpts.MaxBodySize = cfg.Body.MaxBodySize
+		bodyOpts.MaxDepth = cfg.Body.MaxDepth
+		bodyOpts.MaxFields = cfg.Body.MaxFields
+		bodyOpts.MaxFileSize = cfg.Body.MaxFileSize
+		bodyOpts.MaxUploadSize = cfg.Body.MaxUploadSize
+		bodyOpts.BlockedExtensions = cfg.Body.BlockedExtensions
+		bodyOpts.UndecodableAction = model.ActionType(cfg.Body.UndecodableAction)
+		bodyOpts.UploadAction = model.ActionType(cfg.Body.UploadAction)
+		uploadOpts.Buffer = cfg.Body.UploadEventBuffer
+	}
+	enrichers = append(enrichers, reqbody.NewProcessor(bodyOpts))
+
+	// 上传违规事件在请求体处理后记录，后台批量写入
+	uploadService := service.NewUploadEventService(store.uploads, uploadOpts)
+	go uploadService.Run(ctx)
+	enrichers = append(enrichers, uploadService)
 
 	// 初始化服务
 	ruleFactory := service.NewDefaultRuleFactory()
-	ruleService := service.NewRuleService(ruleRepo, ruleFactory, cacheRepo.(repository.RuleCache))
+
+	// 响应检查服务需最后补充请求信息，以记录完整的请求阶段上下文
+	responseOpts := service.ResponseOptions{}
+	if cfg.Response != nil {
+		responseOpts.MaxBodySize = cfg.Response.MaxBodySize
+		responseOpts.ContextTTL = time.Duration(cfg.Response.ContextTTL) * time.Second
+		responseOpts.MaxContexts = cfg.Response.MaxContexts
+	}
+	responseService := service.NewResponseService(ruleRepo, ruleFactory, responseOpts)
+	enrichers = append(enrichers, responseService)
+
+	// 旁路配置在规则检查前生效，启动时加载失败则退出，避免误拦截已放行的请求
+	bypassOpts := service.BypassOptions{}
+	if cfg.Bypass != nil {
+		bypassOpts.MaxDuration = time.Duration(cfg.Bypass.MaxDuration) * time.Second
+		bypassOpts.RefreshInterval = time.Duration(cfg.Bypass.RefreshInterval) * time.Second
+		bypassOpts.AttemptBuffer = cfg.Bypass.AttemptBuffer
+		bypassOpts.TokenHeader = cfg.Bypass.TokenHeader
+		bypassOpts.TokenMaxTTL = time.Duration(cfg.Bypass.TokenMaxTTL) * time.Second
+	}
+	bypassService := service.NewBypassService(bypassRepo, bypassOpts)
+	if err := bypassService.Reload(ctx); err != nil {
+		logger.Fatal("加载旁路配置失败: %v", err)
+	}
+	go bypassService.Run(ctx)
+
 	ipService := service.NewIPRuleService(ipRepo, cacheRepo)
+	ruleService := service.NewRuleService(ruleRepo, ruleFactory, ruleCache, ipService, bypassService, enrichers...)
+	// 规则快照在后台加载，加载完成前就绪检查返回未就绪
+	go ruleService.RunSync(ctx,
+		time.Duration(cfg.Rule.SyncInterval)*time.Second,
+		time.Duration(cfg.Rule.VersionCheckInterval)*time.Second,
+	)
+
+	// 批量检查逐个调用规则服务的无副作用检查，与单个检查接口的判定相同，并行数在所有批量请求间共享
+	batchOpts := service.BatchCheckOptions{}
+	if cfg.Batch != nil {
+		batchOpts.MaxItems = cfg.Batch.MaxItems
+		batchOpts.Workers = cfg.Batch.Workers
+	}
+	batchService := service.NewBatchCheckService(ruleService, batchOpts)
 	ccService := service.NewCCRuleService(ccRepo, cacheRepo)
 	versionService := service.NewRuleVersionService(versionRepo)
-	configService := service.NewWAFConfigService(mysql.NewWAFConfigRepository(sqlDB), cacheRepo)
+	configService := service.NewWAFConfigService(store.configs, cacheRepo)
+	releaseService := service.NewReleaseService(releaseRepo, ruleService)
+	reviewCfg := cfg.Review
+	if reviewCfg == nil {
+		reviewCfg = &config.ReviewConfig{}
+	}
+	changeService := service.NewChangeRequestService(changeRepo, ruleService, ipService, ccService, releaseService, reviewCfg.ApproverRole)
+	templateService, err := service.NewTemplateService(ruleService, cfg.Rule.TemplateFiles...)
+	if err != nil {
+		logger.Fatal("加载规则模板失败: %v", err)
+	}
+
+	// 节点心跳按引擎当前规则版本计算落后版本数，后台定期标记心跳超时的节点
+	nodeOpts := service.NodeOptions{}
+	if cfg.Node != nil {
+		nodeOpts.HeartbeatInterval = time.Duration(cfg.Node.HeartbeatInterval) * time.Second
+		nodeOpts.StaleMissed = cfg.Node.StaleMissed
+		nodeOpts.DownMissed = cfg.Node.DownMissed
+		nodeOpts.CheckInterval = time.Duration(cfg.Node.CheckInterval) * time.Second
+		nodeOpts.RegisterToken = cfg.Node.RegisterToken
+	}
+	nodeService := service.NewNodeService(store.nodes, ruleService, nodeOpts)
+	go nodeService.Run(ctx)
+
+	// 初始化健康检查：规则快照未加载或必需的依赖不可用时未就绪，数据库不可用时使用已加载的快照继续检查
+	nodeID, _ := os.Hostname()
+	healthService := service.NewHealthService(service.HealthOptions{
+		NodeID:          nodeID,
+		Timeout:         time.Duration(healthCfg.Timeout) * time.Millisecond,
+		DegradedLatency: time.Duration(healthCfg.DegradedLatency) * time.Millisecond,
+	}, append(store.checks, service.RuleSnapshotCheck(ruleService, maxSnapshotAge(healthCfg, cfg.Rule)))...)
+
+	// 配置热更新：日志、规则同步间隔、规则缓存时间和链路追踪导出可在线修改，其余配置项修改后需要重启
+	reloader := config.NewReloader(configFile, cfg)
+	reloader.Register(
+		config.Applier{
+			Name:  "log",
+			Paths: []string{"log"},
+			Apply: func(_, next *config.Config) error {
+				return logger.Reconfigure(next.Log)
+			},
+		},
+		config.Applier{
+			Name:  "rule_sync",
+			Paths: []string{"rule.sync_interval", "rule.version_check_interval"},
+			Apply: func(_, next *config.Config) error {
+				ruleService.SetSyncIntervals(
+					time.Duration(next.Rule.SyncInterval)*time.Second,
+					time.Duration(next.Rule.VersionCheckInterval)*time.Second,
+				)
+				return nil
+			},
+		},
+		config.Applier{
+			Name:  "rule_cache",
+			Paths: []string{"rule.cache_ttl"},
+			Apply: func(_, next *config.Config) error {
+				ruleCache.SetRuleTTL(time.Duration(next.Rule.CacheTTL) * time.Second)
+				return nil
+			},
+		},
+		config.Applier{
+			Name:  "tracing",
+			Paths: []string{"tracing"},
+			Apply: func(_, next *config.Config) error {
+				return tracer.apply(next.Tracing)
+			},
+		},
+		config.Applier{
+			// 敏感配置文件每次加载时重新读取，修改路径无需额外处理
+			Name:  "secrets_file",
+			Paths: []string{"config.secrets_file"},
+			Apply: func(_, _ *config.Config) error { return nil },
+		},
+	)
+	if cfg.Source != nil && cfg.Source.WatchInterval > 0 {
+		go reloader.Watch(ctx, time.Duration(cfg.Source.WatchInterval)*time.Second)
+	}
 
 	// 初始化处理器
 	ruleHandler := handler.NewRuleHandler(ruleService, versionService)
@@ -91,14 +307,43 @@ func main() {
 	ccHandler := handler.NewCCRuleHandler(ccService)
 	versionHandler := handler.NewRuleVersionHandler(versionService)
 	configHandler := handler.NewConfigHandler(configService)
+	responseHandler := handler.NewResponseCheckHandler(responseService)
+	batchHandler := handler.NewBatchCheckHandler(batchService)
+	templateHandler := handler.NewTemplateHandler(templateService)
+	releaseHandler := handler.NewReleaseHandler(releaseService)
+	changeHandler := handler.NewChangeRequestHandler(changeService)
+	bypassHandler := handler.NewBypassHandler(bypassService)
+	nodeHandler := handler.NewNodeHandler(nodeService)
+	uploadHandler := handler.NewUploadHandler(uploadService)
+	healthHandler := handler.NewHealthHandler(healthService)
+	reloadHandler := handler.NewConfigReloadHandler(reloader)
 
 	// 设置路由
+	trustedProxies, err := cfg.Server.TrustedNets()
+	if err != nil {
+		logger.Fatal("解析认证网关地址失败: %v", err)
+	}
 	routerConfig := &router.RouterConfig{
-		RuleHandler:    ruleHandler,
-		IPHandler:      ipHandler,
-		CCHandler:      ccHandler,
-		VersionHandler: versionHandler,
-		ConfigHandler:  configHandler,
+		RuleHandler:     ruleHandler,
+		IPHandler:       ipHandler,
+		CCHandler:       ccHandler,
+		VersionHandler:  versionHandler,
+		ConfigHandler:   configHandler,
+		ResponseHandler: responseHandler,
+		BatchHandler:    batchHandler,
+		TemplateHandler: templateHandler,
+		ReleaseHandler:  releaseHandler,
+		ChangeHandler:   changeHandler,
+		BypassHandler:   bypassHandler,
+		NodeHandler:     nodeHandler,
+		UploadHandler:   uploadHandler,
+		HealthHandler:   healthHandler,
+		ReloadHandler:   reloadHandler,
+		EnforceReview:   reviewCfg.Enforced(),
+		TrustedProxies:  trustedProxies,
+	}
+	if metricsCfg.Enabled && metricsCfg.AdminPort == 0 {
+		routerConfig.MetricsPath = metricsCfg.Path
 	}
 	r, err := router.SetupRouter(routerConfig)
 	if err != nil {
@@ -118,16 +363,166 @@ func main() {
 		}
 	}()
 
+	// 配置管理端口时指标接口只在管理端口提供
+	var adminSrv *http.Server
+	if metricsCfg.Enabled && metricsCfg.AdminPort > 0 {
+		mux := http.NewServeMux()
+		mux.Handle(metricsCfg.Path, metrics.MetricsHandler())
+		adminSrv = &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, metricsCfg.AdminPort),
+			Handler:           mux,
+			ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		}
+		go func() {
+			logger.Infof("启动管理端口: %s", adminSrv.Addr)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("启动管理端口失败: %v", err)
+			}
+		}()
+	}
+
+	// gRPC检查接口与REST接口使用同一个规则服务
+	var grpcSrv *rpc.Server
+	if cfg.GRPC != nil && cfg.GRPC.Enabled {
+		lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.GRPC.Port))
+		if err != nil {
+			logger.Fatal("监听gRPC端口失败: %v", err)
+		}
+		grpcSrv = rpc.NewServer(ruleService, rpc.Options{
+			MaxMessageSize:       cfg.GRPC.MaxMessageSize,
+			MaxConcurrentStreams: uint32(cfg.GRPC.MaxConcurrentStreams),
+			KeepaliveTime:        time.Duration(cfg.GRPC.KeepaliveTime) * time.Second,
+		})
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				logger.Fatal("启动gRPC服务失败: %v", err)
+			}
+		}()
+	}
+
+	// 代理模式直接接收客户端连接，在引擎内计算JA3/JA4指纹后检查请求
+	var proxySrv *proxy.Server
+	if cfg.Proxy != nil && cfg.Proxy.Enabled {
+		proxySrv, err = proxy.NewServer(ruleService, proxy.Options{
+			Upstream:     cfg.Proxy.Upstream,
+			MaxBodySize:  cfg.Proxy.MaxBodySize,
+			ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+			WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		})
+		if err != nil {
+			logger.Fatal("创建代理模式服务失败: %v", err)
+		}
+		lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Proxy.Port))
+		if err != nil {
+			logger.Fatal("监听代理模式端口失败: %v", err)
+		}
+		go func() {
+			if err := proxySrv.ServeTLS(lis, cfg.Proxy.CertFile, cfg.Proxy.KeyFile); err != nil {
+				logger.Fatal("启动代理模式服务失败: %v", err)
+			}
+		}()
+	}
+
 	logger.Info("服务启动成功，监听端口: %d", cfg.Server.Port)
 
-	// 等待中断信号
+	// 收到SIGHUP时重新加载配置，收到中断信号时退出
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	for waiting := true; waiting; {
+		select {
+		case <-hup:
+			reloader.Reload(config.TriggerSignal)
+		case <-quit:
+			waiting = false
+		}
+	}
+	signal.Stop(hup)
 
 	// 优雅关闭
 	logger.Info("正在关闭服务...")
 	if err := srv.Stop(context.Background()); err != nil {
 		logger.Error("关闭服务失败: %v", err)
 	}
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer shutdownCancel()
+	if grpcSrv != nil {
+		grpcSrv.Stop(shutdownCtx)
+	}
+	if proxySrv != nil {
+		if err := proxySrv.Stop(shutdownCtx); err != nil {
+			logger.Error("关闭代理模式服务失败: %v", err)
+		}
+	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("关闭管理端口失败: %v", err)
+		}
+	}
+	if err := tracer.shutdown(shutdownCtx); err != nil {
+		logger.Error("导出剩余链路数据失败: %v", err)
+	}
+}
+
+// tracingSwitch 当前链路追踪导出，重新加载配置时替换
+type tracingSwitch struct {
+	mu    sync.Mutex
+	close func(context.Context) error
+}
+
+// apply 按配置重新初始化链路追踪，先启动新的导出再关闭旧的导出
+func (t *tracingSwitch) apply(cfg *config.TracingConfig) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev := t.close
+	t.close = nil
+	if cfg != nil && cfg.Enabled {
+		shutdown, err := tracing.Init(tracing.Options{
+			ServiceName:   cfg.ServiceName,
+			Exporter:      cfg.Exporter,
+			Endpoint:      cfg.Endpoint,
+			Headers:       cfg.Headers,
+			FilePath:      cfg.FilePath,
+			SampleRatio:   cfg.SampleRatio,
+			BatchSize:     cfg.BatchSize,
+			QueueSize:     cfg.QueueSize,
+			FlushInterval: time.Duration(cfg.FlushInterval) * time.Second,
+			Timeout:       time.Duration(cfg.Timeout) * time.Second,
+		})
+		if err != nil {
+			t.close = prev
+			return err
+		}
+		t.close = shutdown
+	}
+	if prev != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := prev(ctx); err != nil {
+			logger.Warnf("关闭原链路追踪导出失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// shutdown 导出剩余链路数据并关闭链路追踪
+func (t *tracingSwitch) shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.close == nil {
+		return nil
+	}
+	err := t.close(ctx)
+	t.close = nil
+	return err
+}
+
+// maxSnapshotAge 规则快照最长未更新时间，未配置时为同步间隔的3倍
+func maxSnapshotAge(health *config.HealthConfig, rule *config.RuleConfig) time.Duration {
+	if health.MaxSnapshotAge > 0 {
+		return time.Duration(health.MaxSnapshotAge) * time.Second
+	}
+	return 3 * time.Duration(rule.SyncInterval) * time.Second
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/config"
+	"github.com/xwaf/rule_engine/internal/migrate"
+	"github.com/xwaf/rule_engine/scripts/migrations"
+)
+
+const migrateUsage = `用法: rule-engine [-config 配置文件] migrate <命令>
+
+命令:
+  up              执行所有未执行的迁移
+  down [N]        回退最近执行的N个迁移，默认1个
+  status          查看各版本的执行状态
+  force VERSION   将数据库记为已执行到VERSION，不执行脚本，用于修复执行失败的迁移或接入已有数据库`
+
+// runMigrate 执行migrate子命令
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少迁移命令\n%s", migrateUsage)
+	}
+	if cfg.StorageDriver() != config.StorageMySQL {
+		return fmt.Errorf("迁移只用于MySQL存储，%s存储在启动时自动创建表结构", cfg.StorageDriver())
+	}
+
+	var (
+		steps   = 1
+		version int64
+	)
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			return fmt.Errorf("%s命令不需要参数\n%s", args[0], migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return fmt.Errorf("down命令最多一个参数\n%s", migrateUsage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("无效的回退版本数: %s", args[1])
+			}
+			steps = n
+		}
+	case "force":
+		if len(args) != 2 {
+			return fmt.Errorf("force命令需要指定版本号\n%s", migrateUsage)
+		}
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("无效的版本号: %s", args[1])
+		}
+		version = v
+	default:
+		return fmt.Errorf("未知的迁移命令: %s\n%s", args[0], migrateUsage)
+	}
+
+	sqlDB, err := connectMySQL(cfg)
+	if err != nil {
+		return err
+	}
+	migrator, err := newMigrator(cfg, sqlDB)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已执行%d个迁移\n", len(done))
+	case "down":
+		done, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已回退%d个迁移\n", len(done))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrateStatus(statuses)
+	case "force":
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		fmt.Printf("数据库结构版本已设置为%d\n", version)
+	}
+	return nil
+}
+
+// newMigrator 创建MySQL表结构迁移，使用内嵌的迁移脚本
+func newMigrator(cfg *config.Config, sqlDB *sql.DB) (*migrate.Migrator, error) {
+	opts := migrate.Options{}
+	if cfg.Migration != nil {
+		opts.LockTimeout = time.Duration(cfg.Migration.LockTimeout) * time.Second
+	}
+	return migrate.New(sqlDB, migrations.FS, opts)
+}
+
+// printMigrateStatus 输出各版本的执行状态
+func printMigrateStatus(statuses []*migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+	for _, s := range statuses {
+		state, at := "未执行", ""
+		switch {
+		case s.Dirty:
+			state = "未完成"
+		case s.Applied:
+			state = "已执行"
+		}
+		if s.AppliedAt != nil {
+			at = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+	}
+	w.Flush()
+}
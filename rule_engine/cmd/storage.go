@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/xwaf/rule_engine/internal/config"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/internal/repository/mysql"
+	redisrepo "github.com/xwaf/rule_engine/internal/repository/redis"
+	"github.com/xwaf/rule_engine/internal/repository/sqlite"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+	"github.com/xwaf/rule_engine/pkg/tracing"
+	"gorm.io/gorm"
+)
+
+// storage 按存储驱动初始化的仓库
+type storage struct {
+	db        *gorm.DB
+	rules     repository.RuleRepository
+	ips       repository.IPRuleRepository
+	cc        repository.CCRuleRepository
+	versions  repository.RuleVersionRepository
+	configs   repository.WAFConfigRepository
+	releases  repository.ReleaseRepository
+	changes   repository.ChangeRequestRepository
+	bypasses  repository.BypassRepository
+	nodes     repository.NodeRepository
+	uploads   repository.UploadEventRepository
+	cache     repository.CacheRepository
+	ruleCache repository.RuleCache
+	checks    []service.HealthCheck     // 存储组件的健康检查
+	run       func(ctx context.Context) // 存储的后台任务，可为空
+}
+
+// connectMySQL 连接MySQL，返回原始数据库连接
+func connectMySQL(cfg *config.Config) (*sql.DB, error) {
+	if err := mysql.InitMySQL(&mysql.Config{
+		Username: cfg.MySQL.Username,
+		Password: cfg.MySQL.Password,
+		Addr:     cfg.MySQL.Host,
+		Port:     fmt.Sprintf("%d", cfg.MySQL.Port),
+		Database: cfg.MySQL.Database,
+	}); err != nil {
+		return nil, fmt.Errorf("初始化MySQL失败: %v", err)
+	}
+	sqlDB, err := mysql.GetDB().DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取原始数据库连接失败: %v", err)
+	}
+	return sqlDB, nil
+}
+
+// openMySQL 初始化MySQL存储，缓存使用Redis；开启自动迁移时先执行未执行的表结构迁移
+func openMySQL(cfg *config.Config, healthCfg *config.HealthConfig) (*storage, error) {
+	sqlDB, err := connectMySQL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db := mysql.GetDB()
+	if cfg.Migration != nil && cfg.Migration.Auto {
+		migrator, err := newMigrator(cfg, sqlDB)
+		if err != nil {
+			return nil, err
+		}
+		done, err := migrator.Up(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("执行表结构迁移失败: %v", err)
+		}
+		logger.Infof("表结构迁移完成，本次执行%d个迁移", len(done))
+	}
+
+	redisClient := goredis.NewClient(&goredis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	pingCtx, pingCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	if err := redisClient.Ping(pingCtx).Err(); err != nil {
+		logger.Warnf("Redis不可用，恢复前就绪检查将返回未就绪或降级: %v", err)
+	}
+	pingCancel()
+	redisClient.AddHook(metrics.RedisHook{})
+	redisClient.AddHook(tracing.RedisHook{})
+
+	cache := redisrepo.NewCacheRepository(redisClient)
+	return &storage{
+		db:        db,
+		rules:     mysql.NewRuleRepository(db, redisClient),
+		ips:       mysql.NewIPRuleRepository(sqlDB),
+		cc:        mysql.NewCCRuleRepository(sqlDB),
+		versions:  mysql.NewRuleVersionRepository(sqlDB),
+		configs:   mysql.NewWAFConfigRepository(sqlDB),
+		releases:  mysql.NewReleaseRepository(db),
+		changes:   mysql.NewChangeRequestRepository(db),
+		bypasses:  mysql.NewBypassRepository(db),
+		nodes:     mysql.NewNodeRepository(db),
+		uploads:   mysql.NewUploadEventRepository(db),
+		cache:     cache,
+		ruleCache: cache.(repository.RuleCache),
+		checks: []service.HealthCheck{
+			service.PingCheck("mysql", false, sqlDB.PingContext),
+			service.PingCheck("redis", healthCfg.RedisRequired, func(ctx context.Context) error {
+				return redisClient.Ping(ctx).Err()
+			}),
+		},
+	}, nil
+}
+
+// openSQLite 初始化内嵌SQLite存储，数据和缓存保存在同一个数据库文件中
+func openSQLite(cfg *config.StorageConfig) (*storage, error) {
+	db, err := sqlite.Open(&sqlite.Config{
+		Path:        cfg.Path,
+		BusyTimeout: cfg.BusyTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("初始化SQLite失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取原始数据库连接失败: %v", err)
+	}
+	logger.Infof("使用SQLite存储: %s", cfg.Path)
+
+	cache := sqlite.NewCache(db)
+	purgeInterval := time.Duration(cfg.PurgeInterval) * time.Second
+	return &storage{
+		db:        db,
+		rules:     sqlite.NewRuleRepository(db),
+		ips:       sqlite.NewIPRuleRepository(db),
+		cc:        sqlite.NewCCRuleRepository(db),
+		versions:  sqlite.NewRuleVersionRepository(db),
+		configs:   sqlite.NewWAFConfigRepository(db),
+		releases:  sqlite.NewReleaseRepository(db),
+		changes:   sqlite.NewChangeRequestRepository(db),
+		bypasses:  sqlite.NewBypassRepository(db),
+		nodes:     sqlite.NewNodeRepository(db),
+		uploads:   sqlite.NewUploadEventRepository(db),
+		cache:     cache,
+		ruleCache: cache,
+		checks: []service.HealthCheck{
+			service.PingCheck("sqlite", false, sqlDB.PingContext),
+		},
+		run: func(ctx context.Context) {
+			cache.Run(ctx, purgeInterval)
+		},
+	}, nil
+}
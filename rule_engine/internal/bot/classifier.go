@@ -0,0 +1,300 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// Resolver DNS解析接口，*net.Resolver 已实现该接口，测试或内网环境可替换
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// crawlerDomains 搜索引擎爬虫反向解析域名后缀
+var crawlerDomains = map[string][]string{
+	"Googlebot": {".googlebot.com", ".google.com", ".googleusercontent.com"},
+	"Bingbot":   {".search.msn.com"},
+}
+
+// 各分类的基础评分
+var categoryScores = map[model.BotCategory]int{
+	model.BotCategoryBrowser:     0,
+	model.BotCategoryCrawler:     0,
+	model.BotCategoryFakeCrawler: 90,
+	model.BotCategoryScanner:     100,
+	model.BotCategoryHTTPLibrary: 60,
+	model.BotCategoryUnknown:     30,
+}
+
+// pendingScore 爬虫DNS验证完成前的评分，与未知客户端相同
+const pendingScore = 30
+
+// 爬虫DNS验证默认参数
+const (
+	defaultPendingTTL = 30 * time.Second // 验证中或临时错误结果的缓存时间，过期后重新验证
+	defaultMaxLookups = 64               // 同时进行的验证数
+)
+
+// verifyResult 爬虫DNS验证结果
+type verifyResult int
+
+const (
+	verifyPending verifyResult = iota // 验证中，或因DNS临时错误暂时无法验证
+	verifyPassed
+	verifyFailed
+)
+
+// Options 分类器配置
+type Options struct {
+	VerifyCrawlers bool          // 是否通过DNS验证爬虫身份
+	DNSTimeout     time.Duration // DNS查询超时
+	CacheTTL       time.Duration // 验证结果缓存时间
+	PendingTTL     time.Duration // 验证中和DNS临时错误的缓存时间，期间按未验证处理
+	MaxLookups     int           // 同时进行的DNS验证数，超出时本次不验证
+	Signatures     []Signature   // 额外签名，优先于内置签名匹配
+}
+
+// Classifier 客户端分类器
+type Classifier struct {
+	signatures []Signature
+	resolver   Resolver
+	opts       Options
+	verified   *cache.Cache
+	lookups    chan struct{}
+}
+
+// NewClassifier 创建客户端分类器，resolver为空时使用系统DNS
+func NewClassifier(resolver Resolver, opts Options) (*Classifier, error) {
+	signatures, err := compileSignatures(append(append([]Signature{}, opts.Signatures...), defaultSignatures...))
+	if err != nil {
+		return nil, err
+	}
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if opts.DNSTimeout <= 0 {
+		opts.DNSTimeout = 2 * time.Second
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = time.Hour
+	}
+	if opts.PendingTTL <= 0 {
+		opts.PendingTTL = defaultPendingTTL
+	}
+	if opts.MaxLookups <= 0 {
+		opts.MaxLookups = defaultMaxLookups
+	}
+	return &Classifier{
+		signatures: signatures,
+		resolver:   resolver,
+		opts:       opts,
+		verified:   cache.New(opts.CacheTTL, 2*opts.CacheTTL),
+		lookups:    make(chan struct{}, opts.MaxLookups),
+	}, nil
+}
+
+// Classify 对请求进行分类并评分
+func (c *Classifier) Classify(ctx context.Context, req *model.CheckRequest) *model.BotInfo {
+	ua := req.Headers.GetFold("User-Agent")
+	info := &model.BotInfo{Category: model.BotCategoryUnknown}
+
+	if ua == "" {
+		info.Reasons = append(info.Reasons, "缺少User-Agent")
+	} else {
+		for _, sig := range c.signatures {
+			if sig.re.MatchString(ua) {
+				info.Category = sig.Category
+				info.Name = sig.Name
+				info.Reasons = append(info.Reasons, fmt.Sprintf("命中签名: %s", sig.Name))
+				break
+			}
+		}
+	}
+
+	// 验证搜索引擎爬虫身份，验证在后台进行，完成前按未验证处理
+	pending := false
+	if info.Category == model.BotCategoryCrawler && c.opts.VerifyCrawlers {
+		switch c.verifyCrawler(info.Name, req.ClientIP) {
+		case verifyPassed:
+			info.Verified = true
+		case verifyFailed:
+			info.Category = model.BotCategoryFakeCrawler
+			info.Reasons = append(info.Reasons, "爬虫DNS验证失败")
+		default:
+			pending = true
+			info.Reasons = append(info.Reasons, "爬虫DNS验证中")
+		}
+	}
+
+	info.Score = categoryScores[info.Category]
+	if pending {
+		info.Score = pendingScore
+	}
+	if ua == "" {
+		info.Score += 20
+	}
+	if info.Category == model.BotCategoryBrowser || info.Category == model.BotCategoryUnknown {
+		score, reasons := headerAnomalies(req.Headers, ua, info.Name)
+		info.Score += score
+		info.Reasons = append(info.Reasons, reasons...)
+	}
+	if info.Score > 100 {
+		info.Score = 100
+	}
+	return info
+}
+
+// Enrich 为检查请求填充客户端分类，前端已传入时不覆盖
+func (c *Classifier) Enrich(ctx context.Context, req *model.CheckRequest) error {
+	if req == nil || req.Bot != nil {
+		return nil
+	}
+	req.Bot = c.Classify(ctx, req)
+	return nil
+}
+
+// verifyCrawler 查询爬虫身份验证结果，没有结果时在后台发起验证并返回验证中
+// 同一爬虫和IP只发起一次验证；验证中和DNS临时错误只缓存PendingTTL，过期后重新验证
+func (c *Classifier) verifyCrawler(name, ip string) verifyResult {
+	suffixes, ok := crawlerDomains[name]
+	if !ok || net.ParseIP(ip) == nil {
+		return verifyFailed
+	}
+
+	key := name + "|" + ip
+	if v, found := c.verified.Get(key); found {
+		return v.(verifyResult)
+	}
+	// Add在键已存在时失败，保证并发请求只发起一次验证
+	if err := c.verified.Add(key, verifyPending, c.opts.PendingTTL); err != nil {
+		return verifyPending
+	}
+	select {
+	case c.lookups <- struct{}{}:
+	default:
+		// 验证数已满，下次请求再验证
+		c.verified.Delete(key)
+		return verifyPending
+	}
+
+	go func() {
+		defer func() { <-c.lookups }()
+		result, err := c.lookupCrawler(suffixes, ip)
+		if err != nil && isTemporary(err) {
+			// DNS超时等临时错误只短暂缓存，避免误判长期生效
+			c.verified.Set(key, verifyPending, c.opts.PendingTTL)
+			return
+		}
+		c.verified.SetDefault(key, result)
+	}()
+	return verifyPending
+}
+
+// lookupCrawler 反向解析IP得到域名，校验域名后缀后再正向解析确认IP
+func (c *Classifier) lookupCrawler(suffixes []string, ip string) (verifyResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.DNSTimeout)
+	defer cancel()
+
+	hosts, err := c.resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		return verifyFailed, err
+	}
+	for _, host := range hosts {
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if !hasSuffix(host, suffixes) {
+			continue
+		}
+		addrs, err := c.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.IP.Equal(net.ParseIP(ip)) {
+				return verifyPassed, nil
+			}
+		}
+	}
+	return verifyFailed, nil
+}
+
+// singletonHeaders 只能出现一次的请求头，重复出现通常是请求走私或参数污染尝试
+var singletonHeaders = map[string]bool{
+	"host":           true,
+	"content-length": true,
+	"content-type":   true,
+	"user-agent":     true,
+}
+
+// headerAnomalies 计算请求头异常评分
+func headerAnomalies(headers model.Fields, ua, browser string) (int, []string) {
+	score := 0
+	var reasons []string
+	add := func(points int, reason string) {
+		score += points
+		reasons = append(reasons, reason)
+	}
+
+	if headers.GetFold("Accept") == "" {
+		add(15, "缺少Accept")
+	}
+	if headers.GetFold("Accept-Language") == "" {
+		add(20, "缺少Accept-Language")
+	}
+	if headers.GetFold("Accept-Encoding") == "" {
+		add(10, "缺少Accept-Encoding")
+	}
+
+	// 与声明的浏览器不符的请求头组合
+	switch browser {
+	case "firefox", "safari":
+		if headers.GetFold("Sec-CH-UA") != "" {
+			add(25, "非Chromium浏览器携带Client Hints")
+		}
+	case "msie":
+		if headers.GetFold("Sec-Fetch-Mode") != "" || headers.GetFold("Sec-CH-UA") != "" {
+			add(25, "IE浏览器携带Fetch Metadata或Client Hints")
+		}
+	case "chrome", "edge":
+		if chUA := headers.GetFold("Sec-CH-UA"); chUA != "" && !strings.Contains(strings.ToLower(chUA), "chrom") && !strings.Contains(strings.ToLower(chUA), "edge") {
+			add(25, "Sec-CH-UA与User-Agent不一致")
+		}
+	}
+	if ua != "" && len(ua) < 10 {
+		add(15, "User-Agent过短")
+	}
+	if headers.GetFold("Connection") != "" && headers.GetFold("Proxy-Connection") != "" {
+		add(10, "同时携带Connection和Proxy-Connection")
+	}
+	for _, name := range headers.Duplicates(true) {
+		if singletonHeaders[strings.ToLower(name)] {
+			add(20, fmt.Sprintf("重复的%s请求头", name))
+		}
+	}
+
+	return score, reasons
+}
+
+// hasSuffix 判断域名是否以任一后缀结尾
+func hasSuffix(host string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTemporary 判断DNS错误是否为临时错误
+func isTemporary(err error) bool {
+	if dnsErr, ok := err.(*net.DNSError); ok {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Signature User-Agent签名
+type Signature struct {
+	Name     string            `yaml:"name" json:"name"`         // 签名名称
+	Category model.BotCategory `yaml:"category" json:"category"` // 客户端分类
+	Pattern  string            `yaml:"pattern" json:"pattern"`   // User-Agent正则(不区分大小写)
+
+	re *regexp.Regexp
+}
+
+// defaultSignatures 内置签名库，按顺序匹配，扫描器优先于HTTP库和浏览器
+var defaultSignatures = []Signature{
+	// 安全扫描器
+	{Name: "sqlmap", Category: model.BotCategoryScanner, Pattern: `sqlmap`},
+	{Name: "nikto", Category: model.BotCategoryScanner, Pattern: `nikto`},
+	{Name: "masscan", Category: model.BotCategoryScanner, Pattern: `masscan`},
+	{Name: "nmap", Category: model.BotCategoryScanner, Pattern: `nmap|nse/`},
+	{Name: "zgrab", Category: model.BotCategoryScanner, Pattern: `zgrab`},
+	{Name: "nuclei", Category: model.BotCategoryScanner, Pattern: `nuclei`},
+	{Name: "acunetix", Category: model.BotCategoryScanner, Pattern: `acunetix|acunetix-wvs`},
+	{Name: "netsparker", Category: model.BotCategoryScanner, Pattern: `netsparker`},
+	{Name: "appscan", Category: model.BotCategoryScanner, Pattern: `appscan`},
+	{Name: "awvs", Category: model.BotCategoryScanner, Pattern: `wvs\b`},
+	{Name: "burp", Category: model.BotCategoryScanner, Pattern: `burp(suite|collaborator)?`},
+	{Name: "dirbuster", Category: model.BotCategoryScanner, Pattern: `dirbuster|gobuster|dirsearch|feroxbuster|ffuf`},
+	{Name: "wpscan", Category: model.BotCategoryScanner, Pattern: `wpscan`},
+	{Name: "w3af", Category: model.BotCategoryScanner, Pattern: `w3af`},
+	{Name: "openvas", Category: model.BotCategoryScanner, Pattern: `openvas`},
+	{Name: "hydra", Category: model.BotCategoryScanner, Pattern: `hydra`},
+	{Name: "whatweb", Category: model.BotCategoryScanner, Pattern: `whatweb`},
+	{Name: "zap", Category: model.BotCategoryScanner, Pattern: `owasp[ _-]?zap|zaproxy`},
+	{Name: "xray", Category: model.BotCategoryScanner, Pattern: `\bxray\b`},
+
+	// HTTP库与命令行工具
+	{Name: "curl", Category: model.BotCategoryHTTPLibrary, Pattern: `^curl/`},
+	{Name: "wget", Category: model.BotCategoryHTTPLibrary, Pattern: `^wget/`},
+	{Name: "python-requests", Category: model.BotCategoryHTTPLibrary, Pattern: `python-requests|python-urllib|aiohttp|httpx`},
+	{Name: "go-http-client", Category: model.BotCategoryHTTPLibrary, Pattern: `go-http-client`},
+	{Name: "java", Category: model.BotCategoryHTTPLibrary, Pattern: `^java/|apache-httpclient|okhttp`},
+	{Name: "node", Category: model.BotCategoryHTTPLibrary, Pattern: `node-fetch|axios|undici|got \(`},
+	{Name: "libwww-perl", Category: model.BotCategoryHTTPLibrary, Pattern: `libwww-perl|lwp::simple`},
+	{Name: "php", Category: model.BotCategoryHTTPLibrary, Pattern: `guzzlehttp|^php/`},
+	{Name: "ruby", Category: model.BotCategoryHTTPLibrary, Pattern: `^ruby|faraday`},
+	{Name: "powershell", Category: model.BotCategoryHTTPLibrary, Pattern: `windowspowershell`},
+	{Name: "scrapy", Category: model.BotCategoryHTTPLibrary, Pattern: `scrapy`},
+	{Name: "headless-chrome", Category: model.BotCategoryHTTPLibrary, Pattern: `headlesschrome|phantomjs|puppeteer|playwright`},
+
+	// 搜索引擎爬虫（需DNS验证）
+	{Name: "Googlebot", Category: model.BotCategoryCrawler, Pattern: `googlebot|google-inspectiontool|adsbot-google|mediapartners-google`},
+	{Name: "Bingbot", Category: model.BotCategoryCrawler, Pattern: `bingbot|adidxbot|bingpreview|msnbot`},
+
+	// 浏览器
+	{Name: "edge", Category: model.BotCategoryBrowser, Pattern: `edg(e|a|ios)?/\d+`},
+	{Name: "chrome", Category: model.BotCategoryBrowser, Pattern: `(chrome|crios)/\d+`},
+	{Name: "firefox", Category: model.BotCategoryBrowser, Pattern: `(firefox|fxios)/\d+`},
+	{Name: "safari", Category: model.BotCategoryBrowser, Pattern: `version/\d+.*safari/`},
+	{Name: "msie", Category: model.BotCategoryBrowser, Pattern: `msie \d+|trident/\d+`},
+}
+
+// compileSignatures 编译签名正则
+func compileSignatures(signatures []Signature) ([]Signature, error) {
+	compiled := make([]Signature, 0, len(signatures))
+	for _, sig := range signatures {
+		if sig.Name == "" || sig.Pattern == "" {
+			return nil, errors.NewError(errors.ErrConfig, "签名名称和匹配模式不能为空")
+		}
+		switch sig.Category {
+		case model.BotCategoryBrowser, model.BotCategoryCrawler, model.BotCategoryScanner, model.BotCategoryHTTPLibrary:
+		default:
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的签名分类: %s", sig.Category))
+		}
+		re, err := regexp.Compile("(?i)" + sig.Pattern)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("编译签名[%s]失败: %v", sig.Name, err))
+		}
+		sig.re = re
+		compiled = append(compiled, sig)
+	}
+	return compiled, nil
+}
+
+// LoadSignatures 从YAML文件加载额外签名，文件内容为签名列表
+func LoadSignatures(path string) ([]Signature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取签名文件失败: %v", err))
+	}
+	var signatures []Signature
+	if err := yaml.Unmarshal(data, &signatures); err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("解析签名文件失败: %v", err))
+	}
+	return signatures, nil
+}
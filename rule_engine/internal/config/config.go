@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/xwaf/rule_engine/internal/errors"
 	"github.com/xwaf/rule_engine/internal/server"
@@ -12,11 +13,61 @@ import (
 
 // Config 配置结构
 type Config struct {
-	Server *server.Config    `yaml:"server"`
-	MySQL  *MySQLConfig      `yaml:"mysql"`
-	Redis  *RedisConfig      `yaml:"redis"`
-	Log    *logger.LogConfig `yaml:"log"`
-	Rule   *RuleConfig       `yaml:"rule"`
+	Server    *server.Config     `yaml:"server"`
+	GRPC      *GRPCConfig        `yaml:"grpc"`
+	Proxy     *ProxyConfig       `yaml:"proxy"`
+	Storage   *StorageConfig     `yaml:"storage"`
+	MySQL     *MySQLConfig       `yaml:"mysql"`
+	Migration *MigrationConfig   `yaml:"migration"`
+	Redis     *RedisConfig       `yaml:"redis"`
+	Log       *logger.LogConfig  `yaml:"log"`
+	Rule      *RuleConfig        `yaml:"rule"`
+	GeoIP     *GeoIPConfig       `yaml:"geoip"`
+	Bot       *BotConfig         `yaml:"bot"`
+	Body      *RequestBodyConfig `yaml:"request_body"`
+	Response  *ResponseConfig    `yaml:"response"`
+	Batch     *BatchCheckConfig  `yaml:"batch_check"`
+	Review    *ReviewConfig      `yaml:"review"`
+	Bypass    *BypassConfig      `yaml:"bypass"`
+	Node      *NodeConfig        `yaml:"node"`
+	Metrics   *MetricsConfig     `yaml:"metrics"`
+	Tracing   *TracingConfig     `yaml:"tracing"`
+	Health    *HealthConfig      `yaml:"health"`
+	Source    *SourceConfig      `yaml:"config"`
+}
+
+// SourceConfig 配置加载设置
+type SourceConfig struct {
+	SecretsFile   string `yaml:"secrets_file"`   // 敏感配置文件，结构与本文件相同，优先级最高
+	WatchInterval int    `yaml:"watch_interval"` // 配置文件变化检查间隔(秒)，0表示只在收到SIGHUP时重新加载
+}
+
+// 存储驱动
+const (
+	StorageMySQL  = "mysql"  // MySQL存储，缓存使用Redis
+	StorageSQLite = "sqlite" // 内嵌SQLite存储，数据和缓存保存在同一个文件中，无需MySQL和Redis
+)
+
+// StorageConfig 存储配置
+type StorageConfig struct {
+	Driver        string `yaml:"driver"`         // 存储驱动：mysql、sqlite，默认mysql
+	Path          string `yaml:"path"`           // SQLite数据库文件路径
+	BusyTimeout   int    `yaml:"busy_timeout"`   // SQLite等待写锁的超时(毫秒)
+	PurgeInterval int    `yaml:"purge_interval"` // SQLite过期缓存清理间隔(秒)
+}
+
+// StorageDriver 存储驱动，未配置时为mysql
+func (c *Config) StorageDriver() string {
+	if c.Storage == nil || c.Storage.Driver == "" {
+		return StorageMySQL
+	}
+	return c.Storage.Driver
+}
+
+// MigrationConfig 数据库结构迁移配置，只用于MySQL存储
+type MigrationConfig struct {
+	Auto        bool `yaml:"auto"`         // 启动时执行未执行的迁移
+	LockTimeout int  `yaml:"lock_timeout"` // 等待其他节点完成迁移的超时(秒)
 }
 
 // RedisConfig Redis配置
@@ -43,13 +94,148 @@ type MySQLConfig struct {
 
 // RuleConfig 规则配置
 type RuleConfig struct {
-	SyncInterval         int `yaml:"sync_interval"`
-	CacheTTL             int `yaml:"cache_ttl"`
-	VersionCheckInterval int `yaml:"version_check_interval"`
+	SyncInterval         int      `yaml:"sync_interval"`
+	CacheTTL             int      `yaml:"cache_ttl"`
+	VersionCheckInterval int      `yaml:"version_check_interval"`
+	TemplateFiles        []string `yaml:"template_files"` // 规则模板文件或目录
+}
+
+// GeoIPConfig 地理位置数据库配置
+type GeoIPConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	CityDB         string `yaml:"city_db"`         // GeoLite2/GeoIP2 City 数据库路径
+	ASNDB          string `yaml:"asn_db"`          // GeoLite2 ASN 数据库路径
+	ReloadInterval int    `yaml:"reload_interval"` // 文件变化检查间隔(秒)
+}
+
+// BotConfig 机器人识别配置
+type BotConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	VerifyCrawlers bool   `yaml:"verify_crawlers"` // 是否通过反向+正向DNS验证搜索引擎爬虫
+	DNSTimeout     int    `yaml:"dns_timeout"`     // DNS查询超时(毫秒)
+	CacheTTL       int    `yaml:"cache_ttl"`       // 爬虫验证结果缓存时间(秒)
+	PendingTTL     int    `yaml:"pending_ttl"`     // 验证中和DNS临时错误的缓存时间(秒)，期间按未验证处理
+	MaxLookups     int    `yaml:"max_lookups"`     // 同时进行的DNS验证数
+	SignaturesFile string `yaml:"signatures_file"` // 额外的User-Agent签名文件
+}
+
+// BatchCheckConfig 批量检查配置
+type BatchCheckConfig struct {
+	MaxItems int `yaml:"max_items"` // 单次最多检查的请求数
+	Workers  int `yaml:"workers"`   // 所有批量检查共享的并行数，0表示CPU数
+}
+
+// RequestBodyConfig 请求体处理配置
+type RequestBodyConfig struct {
+	MaxBodySize int `yaml:"max_body_size"` // 解压后请求体最大长度(字节)，超出部分不解析
+	MaxDepth    int `yaml:"max_depth"`     // JSON和XML最大嵌套层数
+	MaxFields   int `yaml:"max_fields"`    // 最多解析的字段数
+
+	MaxFileSize       int      `yaml:"max_file_size"`      // 单个上传文件最大长度(字节)
+	MaxUploadSize     int      `yaml:"max_upload_size"`    // 上传文件总长度(字节)
+	BlockedExtensions []string `yaml:"blocked_extensions"` // 禁止上传的扩展名，为空时使用默认列表
+
+	UndecodableAction string `yaml:"undecodable_action"` // 请求体无法完整解码时的处理，block(默认)或log
+
+	UploadAction      string `yaml:"upload_action"`       // 上传文件有违规时的处理，block(默认)或log
+	UploadEventBuffer int    `yaml:"upload_event_buffer"` // 待写入的上传违规事件缓冲数
+}
+
+// ResponseConfig 响应阶段检查配置
+type ResponseConfig struct {
+	MaxBodySize int `yaml:"max_body_size"` // 响应体最大检查长度(字节)
+	ContextTTL  int `yaml:"context_ttl"`   // 请求上下文保留时间(秒)
+	MaxContexts int `yaml:"max_contexts"`  // 请求上下文最大数量
+}
+
+// ReviewConfig 规则变更审批配置
+type ReviewConfig struct {
+	Enforce      *bool  `yaml:"enforce"`       // 是否强制通过变更请求修改规则、名单和CC规则，未配置时开启
+	ApproverRole string `yaml:"approver_role"` // 审批人角色，为空时使用approver
+}
+
+// Enforced 是否强制审批，未配置review段或enforce时默认开启，需显式设为false关闭
+func (c *ReviewConfig) Enforced() bool {
+	return c == nil || c.Enforce == nil || *c.Enforce
+}
+
+// MetricsConfig 监控指标配置
+type MetricsConfig struct {
+	Enabled       bool   `yaml:"enabled"`         // 是否提供Prometheus指标接口
+	Path          string `yaml:"path"`            // 指标接口路径
+	AdminPort     int    `yaml:"admin_port"`      // 管理端口，大于0时指标接口只在该端口提供
+	MaxRuleLabels int    `yaml:"max_rule_labels"` // rule_id标签的最大取值数，超出记为other
+	MaxSiteLabels int    `yaml:"max_site_labels"` // site标签的最大取值数，超出记为other
+	MaxNodeLabels int    `yaml:"max_node_labels"` // node_id标签的最大取值数，超出记为other
+}
+
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	Timeout         int  `yaml:"timeout"`          // 单个组件检查超时(毫秒)
+	DegradedLatency int  `yaml:"degraded_latency"` // 检查耗时超过该值时记为降级(毫秒)
+	MaxSnapshotAge  int  `yaml:"max_snapshot_age"` // 规则快照最长未更新时间(秒)，0时为规则同步间隔的3倍
+	RedisRequired   bool `yaml:"redis_required"`   // Redis不可用时是否未就绪
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Enabled       bool              `yaml:"enabled"`        // 是否开启链路追踪
+	ServiceName   string            `yaml:"service_name"`   // 服务名
+	Exporter      string            `yaml:"exporter"`       // 导出方式：otlp、file
+	Endpoint      string            `yaml:"endpoint"`       // OTLP/HTTP地址
+	Headers       map[string]string `yaml:"headers"`        // OTLP请求附加的请求头
+	FilePath      string            `yaml:"file_path"`      // 文件导出路径
+	SampleRatio   float64           `yaml:"sample_ratio"`   // 无上游采样决定时的采样比例(0-1)
+	BatchSize     int               `yaml:"batch_size"`     // 每批导出的最大Span数
+	QueueSize     int               `yaml:"queue_size"`     // 待导出Span队列长度
+	FlushInterval int               `yaml:"flush_interval"` // 导出间隔(秒)
+	Timeout       int               `yaml:"timeout"`        // 单次导出超时(秒)
+}
+
+// BypassConfig 旁路配置
+type BypassConfig struct {
+	MaxDuration     int    `yaml:"max_duration"`     // 旁路配置最大有效期(秒)
+	RefreshInterval int    `yaml:"refresh_interval"` // 旁路配置刷新间隔(秒)
+	AttemptBuffer   int    `yaml:"attempt_buffer"`   // 待写入的旁路尝试记录缓冲数
+	TokenHeader     string `yaml:"token_header"`     // 旁路令牌请求头
+	TokenMaxTTL     int    `yaml:"token_max_ttl"`    // 旁路令牌最大有效期(秒)
+}
+
+// GRPCConfig gRPC检查接口配置
+type GRPCConfig struct {
+	Enabled              bool `yaml:"enabled"`                // 是否开启gRPC检查接口
+	Port                 int  `yaml:"port"`                   // 监听端口，地址与HTTP服务相同
+	MaxMessageSize       int  `yaml:"max_message_size"`       // 单个消息最大字节数
+	MaxConcurrentStreams int  `yaml:"max_concurrent_streams"` // 每个连接的最大并发流数，0表示不限制
+	KeepaliveTime        int  `yaml:"keepalive_time"`         // 连接空闲该时长后发送keepalive探测(秒)，0表示使用默认值
+}
+
+// ProxyConfig 代理模式配置，引擎直接终止客户端TLS连接并计算JA3/JA4指纹
+type ProxyConfig struct {
+	Enabled     bool   `yaml:"enabled"`       // 是否开启代理模式
+	Port        int    `yaml:"port"`          // 监听端口，地址与HTTP服务相同
+	Upstream    string `yaml:"upstream"`      // 放行请求转发的上游地址
+	CertFile    string `yaml:"cert_file"`     // TLS证书文件
+	KeyFile     string `yaml:"key_file"`      // TLS私钥文件
+	MaxBodySize int64  `yaml:"max_body_size"` // 参与检查的请求体最大字节数，0表示使用默认值
+}
+
+// NodeConfig WAF节点配置
+type NodeConfig struct {
+	HeartbeatInterval int    `yaml:"heartbeat_interval"` // 节点心跳间隔(秒)
+	StaleMissed       int    `yaml:"stale_missed"`       // 错过该次数的心跳后视为stale
+	DownMissed        int    `yaml:"down_missed"`        // 错过该次数的心跳后标记为下线
+	CheckInterval     int    `yaml:"check_interval"`     // 检查下线节点的间隔(秒)，0表示等于心跳间隔
+	RegisterToken     string `yaml:"register_token"`     // 注册令牌，节点注册时需提供，为空时不检查
 }
 
 // LoadConfig 加载配置
+// 依次应用配置文件、XWAF_前缀的环境变量和敏感配置文件，后者覆盖前者，最后验证所有配置段
 func LoadConfig(filename string) (*Config, error) {
+	return load(filename, os.LookupEnv)
+}
+
+func load(filename string, lookup func(string) (string, bool)) (*Config, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取配置文件失败: %v", err))
@@ -60,17 +246,85 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("解析配置文件失败: %v", err))
 	}
 
+	// 环境变量覆盖配置文件
+	if err := applyEnv(&cfg, lookup); err != nil {
+		return nil, errors.NewError(errors.ErrConfig, err.Error())
+	}
+
+	// 敏感配置文件覆盖环境变量，只需包含需要覆盖的配置项
+	if path := cfg.SecretsFile(); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取敏感配置文件失败: %v", err))
+		}
+		// 解析错误可能包含文件内容，不返回错误详情
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("解析敏感配置文件失败: %s", path))
+		}
+	}
+
 	// 验证配置
 	if err := validateConfig(&cfg); err != nil {
-		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("配置验证失败: %v", err))
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("配置验证失败: %s", detail(err)))
 	}
 
 	return &cfg, nil
 }
 
-// validateConfig 验证配置
+// SecretsFile 敏感配置文件路径，未配置时为空
+func (c *Config) SecretsFile() string {
+	if c.Source == nil {
+		return ""
+	}
+	return c.Source.SecretsFile
+}
+
+// validateConfig 验证配置，逐段检查并一次报告所有错误
 func validateConfig(cfg *Config) error {
-	// 验证服务器配置
+	var problems []string
+	for _, validate := range []func(*Config) error{
+		validateServer,
+		validateGRPC,
+		validateProxy,
+		validateStorage,
+		validateMySQL,
+		validateMigration,
+		validateRedis,
+		validateLog,
+		validateRule,
+		validateGeoIP,
+		validateBot,
+		validateRequestBody,
+		validateResponse,
+		validateBatchCheck,
+		validateReview,
+		validateMetrics,
+		validateHealth,
+		validateTracing,
+		validateBypass,
+		validateNode,
+		validateSource,
+	} {
+		if err := validate(cfg); err != nil {
+			problems = append(problems, detail(err))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.NewError(errors.ErrConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// detail 错误详情，配置错误只保留详情，避免重复的错误码前缀
+func detail(err error) string {
+	if e, ok := err.(*errors.Error); ok && e.Details != nil {
+		return fmt.Sprint(e.Details)
+	}
+	return err.Error()
+}
+
+// validateServer 验证服务器配置
+func validateServer(cfg *Config) error {
 	if cfg.Server == nil {
 		return errors.NewError(errors.ErrConfig, "服务器配置不能为空")
 	}
@@ -83,11 +337,80 @@ func validateConfig(cfg *Config) error {
 	if cfg.Server.WriteTimeout <= 0 {
 		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的写入超时时间: %d", cfg.Server.WriteTimeout))
 	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的关闭超时时间: %d", cfg.Server.ShutdownTimeout))
+	}
+	if _, err := cfg.Server.TrustedNets(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateGRPC 验证gRPC检查接口配置
+func validateGRPC(cfg *Config) error {
+	if cfg.GRPC == nil || !cfg.GRPC.Enabled {
+		return nil
+	}
+	port := cfg.GRPC.Port
+	if port <= 0 || port > 65535 || cfg.Server != nil && port == cfg.Server.Port || cfg.Metrics != nil && port == cfg.Metrics.AdminPort {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的gRPC端口: %d", port))
+	}
+	if cfg.GRPC.MaxMessageSize < 0 || cfg.GRPC.MaxConcurrentStreams < 0 || cfg.GRPC.KeepaliveTime < 0 {
+		return errors.NewError(errors.ErrConfig, "gRPC消息大小、并发流数和keepalive间隔不能为负数")
+	}
+	return nil
+}
+
+// validateProxy 验证代理模式配置
+func validateProxy(cfg *Config) error {
+	if cfg.Proxy == nil || !cfg.Proxy.Enabled {
+		return nil
+	}
+	port := cfg.Proxy.Port
+	if port <= 0 || port > 65535 || cfg.Server != nil && port == cfg.Server.Port ||
+		cfg.GRPC != nil && cfg.GRPC.Enabled && port == cfg.GRPC.Port || cfg.Metrics != nil && port == cfg.Metrics.AdminPort {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的代理模式端口: %d", port))
+	}
+	if cfg.Proxy.Upstream == "" {
+		return errors.NewError(errors.ErrConfig, "代理模式上游地址不能为空")
+	}
+	if cfg.Proxy.CertFile == "" || cfg.Proxy.KeyFile == "" {
+		return errors.NewError(errors.ErrConfig, "代理模式需配置TLS证书和私钥文件")
+	}
+	if cfg.Proxy.MaxBodySize < 0 {
+		return errors.NewError(errors.ErrConfig, "代理模式请求体大小不能为负数")
+	}
+	return nil
+}
+
+// validateStorage 验证存储配置
+func validateStorage(cfg *Config) error {
+	switch cfg.StorageDriver() {
+	case StorageMySQL:
+	case StorageSQLite:
+		if cfg.Storage.Path == "" {
+			return errors.NewError(errors.ErrConfig, "SQLite数据库文件路径不能为空")
+		}
+		if cfg.Storage.BusyTimeout < 0 || cfg.Storage.PurgeInterval < 0 {
+			return errors.NewError(errors.ErrConfig, "SQLite等待超时和缓存清理间隔不能为负数")
+		}
+	default:
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的存储驱动: %q", cfg.Storage.Driver))
+	}
+	return nil
+}
 
-	// 验证MySQL配置
+// validateMySQL 验证MySQL配置，使用SQLite存储时不需要
+func validateMySQL(cfg *Config) error {
+	if cfg.StorageDriver() != StorageMySQL {
+		return nil
+	}
 	if cfg.MySQL == nil {
 		return errors.NewError(errors.ErrConfig, "MySQL配置不能为空")
 	}
+	if cfg.MySQL.Host == "" || cfg.MySQL.Username == "" || cfg.MySQL.Database == "" {
+		return errors.NewError(errors.ErrConfig, "MySQL地址、用户名和数据库名不能为空")
+	}
 	if cfg.MySQL.Port <= 0 || cfg.MySQL.Port > 65535 {
 		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的MySQL端口: %d", cfg.MySQL.Port))
 	}
@@ -100,11 +423,40 @@ func validateConfig(cfg *Config) error {
 	if cfg.MySQL.ConnMaxLifetime <= 0 {
 		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的连接最大生命周期: %d", cfg.MySQL.ConnMaxLifetime))
 	}
+	if cfg.MySQL.ConnMaxIdleTime < 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的连接最大空闲时间: %d", cfg.MySQL.ConnMaxIdleTime))
+	}
+	return nil
+}
+
+// validateRedis 验证Redis配置，使用SQLite存储时不需要
+func validateRedis(cfg *Config) error {
+	if cfg.StorageDriver() != StorageMySQL {
+		return nil
+	}
+	if cfg.Redis == nil {
+		return errors.NewError(errors.ErrConfig, "Redis配置不能为空")
+	}
+	if cfg.Redis.Host == "" {
+		return errors.NewError(errors.ErrConfig, "Redis地址不能为空")
+	}
+	if cfg.Redis.Port <= 0 || cfg.Redis.Port > 65535 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的Redis端口: %d", cfg.Redis.Port))
+	}
+	if cfg.Redis.DB < 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的Redis数据库: %d", cfg.Redis.DB))
+	}
+	return nil
+}
 
-	// 验证日志配置
+// validateLog 验证日志配置
+func validateLog(cfg *Config) error {
 	if cfg.Log == nil {
 		return errors.NewError(errors.ErrConfig, "日志配置不能为空")
 	}
+	if _, err := logger.ParseLevel(cfg.Log.Level); err != nil {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的日志级别: %q", cfg.Log.Level))
+	}
 	if cfg.Log.MaxSize <= 0 {
 		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的日志文件最大大小: %d", cfg.Log.MaxSize))
 	}
@@ -114,8 +466,11 @@ func validateConfig(cfg *Config) error {
 	if cfg.Log.MaxBackups <= 0 {
 		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的日志文件最大备份数: %d", cfg.Log.MaxBackups))
 	}
+	return nil
+}
 
-	// 验证规则配置
+// validateRule 验证规则配置
+func validateRule(cfg *Config) error {
 	if cfg.Rule == nil {
 		return errors.NewError(errors.ErrConfig, "规则配置不能为空")
 	}
@@ -128,6 +483,209 @@ func validateConfig(cfg *Config) error {
 	if cfg.Rule.VersionCheckInterval <= 0 {
 		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的规则版本检查间隔: %d", cfg.Rule.VersionCheckInterval))
 	}
+	return nil
+}
+
+// validateGeoIP 验证地理位置配置
+func validateGeoIP(cfg *Config) error {
+	if cfg.GeoIP != nil && cfg.GeoIP.Enabled {
+		if cfg.GeoIP.CityDB == "" && cfg.GeoIP.ASNDB == "" {
+			return errors.NewError(errors.ErrConfig, "启用GeoIP时City和ASN数据库路径不能同时为空")
+		}
+		if cfg.GeoIP.ReloadInterval <= 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的GeoIP重载间隔: %d", cfg.GeoIP.ReloadInterval))
+		}
+	}
+	return nil
+}
+
+// validateBot 验证机器人识别配置
+func validateBot(cfg *Config) error {
+	if cfg.Bot != nil && cfg.Bot.Enabled {
+		if cfg.Bot.DNSTimeout < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的DNS查询超时: %d", cfg.Bot.DNSTimeout))
+		}
+		if cfg.Bot.CacheTTL < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的爬虫验证缓存时间: %d", cfg.Bot.CacheTTL))
+		}
+		if cfg.Bot.PendingTTL < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的爬虫验证中缓存时间: %d", cfg.Bot.PendingTTL))
+		}
+		if cfg.Bot.MaxLookups < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的DNS验证并发数: %d", cfg.Bot.MaxLookups))
+		}
+	}
+	return nil
+}
 
+// validateRequestBody 验证请求体处理配置
+func validateRequestBody(cfg *Config) error {
+	if cfg.Body == nil {
+		return nil
+	}
+	if cfg.Body.MaxBodySize < 0 || cfg.Body.MaxDepth < 0 || cfg.Body.MaxFields < 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的请求体处理配置: max_body_size=%d, max_depth=%d, max_fields=%d",
+			cfg.Body.MaxBodySize, cfg.Body.MaxDepth, cfg.Body.MaxFields))
+	}
+	if cfg.Body.MaxFileSize < 0 || cfg.Body.MaxUploadSize < 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的上传文件大小限制: max_file_size=%d, max_upload_size=%d",
+			cfg.Body.MaxFileSize, cfg.Body.MaxUploadSize))
+	}
+	for _, ext := range cfg.Body.BlockedExtensions {
+		if ext = strings.TrimPrefix(strings.TrimSpace(ext), "."); ext == "" || strings.ContainsAny(ext, "./\\") {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的禁止上传扩展名: %q", ext))
+		}
+	}
+	switch cfg.Body.UndecodableAction {
+	case "", "block", "log":
+	default:
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的请求体解码失败处理: %s，可选值为block、log", cfg.Body.UndecodableAction))
+	}
+	switch cfg.Body.UploadAction {
+	case "", "block", "log":
+	default:
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的上传文件违规处理: %s，可选值为block、log", cfg.Body.UploadAction))
+	}
+	if cfg.Body.UploadEventBuffer < 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的上传违规事件缓冲数: %d", cfg.Body.UploadEventBuffer))
+	}
+	return nil
+}
+
+// validateResponse 验证响应检查配置
+func validateResponse(cfg *Config) error {
+	if cfg.Response != nil {
+		if cfg.Response.MaxBodySize < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的响应体最大检查长度: %d", cfg.Response.MaxBodySize))
+		}
+		if cfg.Response.ContextTTL < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的请求上下文保留时间: %d", cfg.Response.ContextTTL))
+		}
+		if cfg.Response.MaxContexts < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的请求上下文最大数量: %d", cfg.Response.MaxContexts))
+		}
+	}
+	return nil
+}
+
+// validateBatchCheck 验证批量检查配置
+func validateBatchCheck(cfg *Config) error {
+	if cfg.Batch != nil && (cfg.Batch.MaxItems < 0 || cfg.Batch.Workers < 0) {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的批量检查配置: max_items=%d, workers=%d", cfg.Batch.MaxItems, cfg.Batch.Workers))
+	}
+	return nil
+}
+
+// validateReview 验证变更审批配置
+func validateReview(cfg *Config) error {
+	if cfg.Review != nil && strings.ContainsAny(cfg.Review.ApproverRole, ", ") {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的审批人角色: %q", cfg.Review.ApproverRole))
+	}
+	return nil
+}
+
+// validateMetrics 验证监控指标配置
+func validateMetrics(cfg *Config) error {
+	if cfg.Metrics != nil {
+		if cfg.Metrics.Enabled && !strings.HasPrefix(cfg.Metrics.Path, "/") {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的指标接口路径: %q", cfg.Metrics.Path))
+		}
+		if cfg.Metrics.AdminPort < 0 || cfg.Metrics.AdminPort > 65535 || cfg.Metrics.AdminPort > 0 && cfg.Server != nil && cfg.Metrics.AdminPort == cfg.Server.Port {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的管理端口: %d", cfg.Metrics.AdminPort))
+		}
+		if cfg.Metrics.MaxRuleLabels < 0 || cfg.Metrics.MaxSiteLabels < 0 || cfg.Metrics.MaxNodeLabels < 0 {
+			return errors.NewError(errors.ErrConfig, "指标标签数量上限不能为负数")
+		}
+	}
+	return nil
+}
+
+// validateMigration 验证数据库结构迁移配置
+func validateMigration(cfg *Config) error {
+	if cfg.Migration != nil && cfg.Migration.LockTimeout < 0 {
+		return errors.NewError(errors.ErrConfig, "迁移锁等待超时不能为负数")
+	}
+	return nil
+}
+
+// validateHealth 验证健康检查配置
+func validateHealth(cfg *Config) error {
+	if cfg.Health != nil && (cfg.Health.Timeout < 0 || cfg.Health.DegradedLatency < 0 || cfg.Health.MaxSnapshotAge < 0) {
+		return errors.NewError(errors.ErrConfig, "健康检查参数不能为负数")
+	}
+	return nil
+}
+
+// validateTracing 验证链路追踪配置
+func validateTracing(cfg *Config) error {
+	if cfg.Tracing != nil && cfg.Tracing.Enabled {
+		switch cfg.Tracing.Exporter {
+		case "otlp":
+			if !strings.HasPrefix(cfg.Tracing.Endpoint, "http://") && !strings.HasPrefix(cfg.Tracing.Endpoint, "https://") {
+				return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的OTLP导出地址: %q", cfg.Tracing.Endpoint))
+			}
+		case "file":
+			if cfg.Tracing.FilePath == "" {
+				return errors.NewError(errors.ErrConfig, "链路文件路径不能为空")
+			}
+		default:
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的链路导出方式: %q", cfg.Tracing.Exporter))
+		}
+		if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的采样比例: %v", cfg.Tracing.SampleRatio))
+		}
+		if cfg.Tracing.BatchSize < 0 || cfg.Tracing.QueueSize < 0 || cfg.Tracing.FlushInterval < 0 || cfg.Tracing.Timeout < 0 {
+			return errors.NewError(errors.ErrConfig, "链路导出参数不能为负数")
+		}
+	}
+	return nil
+}
+
+// validateBypass 验证旁路配置
+func validateBypass(cfg *Config) error {
+	if cfg.Bypass != nil {
+		if cfg.Bypass.MaxDuration < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的旁路最大有效期: %d", cfg.Bypass.MaxDuration))
+		}
+		if cfg.Bypass.RefreshInterval < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的旁路刷新间隔: %d", cfg.Bypass.RefreshInterval))
+		}
+		if cfg.Bypass.AttemptBuffer < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的旁路尝试记录缓冲数: %d", cfg.Bypass.AttemptBuffer))
+		}
+		if cfg.Bypass.TokenHeader != "" && strings.ContainsAny(cfg.Bypass.TokenHeader, " :\r\n") {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的旁路令牌请求头: %q", cfg.Bypass.TokenHeader))
+		}
+		if cfg.Bypass.TokenMaxTTL < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的旁路令牌最大有效期: %d", cfg.Bypass.TokenMaxTTL))
+		}
+	}
+	return nil
+}
+
+// validateNode 验证WAF节点配置
+func validateNode(cfg *Config) error {
+	if cfg.Node != nil {
+		if cfg.Node.HeartbeatInterval < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的节点心跳间隔: %d", cfg.Node.HeartbeatInterval))
+		}
+		if cfg.Node.StaleMissed < 0 || cfg.Node.DownMissed < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的节点心跳错过次数: stale=%d, down=%d", cfg.Node.StaleMissed, cfg.Node.DownMissed))
+		}
+		if cfg.Node.StaleMissed > 0 && cfg.Node.DownMissed > 0 && cfg.Node.DownMissed <= cfg.Node.StaleMissed {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("节点下线的心跳错过次数(%d)必须大于stale的次数(%d)", cfg.Node.DownMissed, cfg.Node.StaleMissed))
+		}
+		if cfg.Node.CheckInterval < 0 {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的节点检查间隔: %d", cfg.Node.CheckInterval))
+		}
+	}
+	return nil
+}
+
+// validateSource 验证配置加载设置
+func validateSource(cfg *Config) error {
+	if cfg.Source != nil && cfg.Source.WatchInterval < 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的配置文件检查间隔: %d", cfg.Source.WatchInterval))
+	}
 	return nil
 }
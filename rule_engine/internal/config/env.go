@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 环境变量前缀
+// 配置项按yaml路径映射为环境变量，例如 mysql.password 对应 XWAF_MYSQL_PASSWORD
+const EnvPrefix = "XWAF"
+
+// applyEnv 用环境变量覆盖配置，未设置的环境变量不影响原值
+// 支持字符串、整数、浮点数、布尔值(及其指针)、逗号分隔的字符串列表和 k=v 逗号分隔的字符串映射
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	return applyEnvValue(reflect.ValueOf(cfg).Elem(), EnvPrefix, lookup)
+}
+
+func applyEnvValue(v reflect.Value, name string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := yamlName(field)
+		if key == "" {
+			continue
+		}
+		envName := name + "_" + strings.ToUpper(key)
+		fv := v.Field(i)
+
+		switch {
+		case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct:
+			// 对应配置段不存在时，只在设置了该段的环境变量时创建
+			target := reflect.New(fv.Type().Elem())
+			if !fv.IsNil() {
+				target = fv
+			}
+			if err := applyEnvValue(target.Elem(), envName, lookup); err != nil {
+				return err
+			}
+			if fv.IsNil() && !target.Elem().IsZero() {
+				fv.Set(target)
+			}
+		case fv.Kind() == reflect.Struct:
+			if err := applyEnvValue(fv, envName, lookup); err != nil {
+				return err
+			}
+		default:
+			raw, ok := lookup(envName)
+			if !ok {
+				continue
+			}
+			if err := setFromString(fv, raw); err != nil {
+				return fmt.Errorf("环境变量%s无效: %v", envName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setFromString 按字段类型解析环境变量
+func setFromString(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		// 指针用于区分未配置和零值，设置了环境变量时创建
+		target := reflect.New(v.Type().Elem())
+		if err := setFromString(target.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(target)
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型: %s", v.Type())
+		}
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型: %s", v.Type())
+		}
+		m := make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("映射项应为key=value: %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("不支持的类型: %s", v.Type())
+	}
+	return nil
+}
+
+// yamlName 字段的yaml名称，忽略的字段返回空
+func yamlName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("yaml")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+)
+
+// 重新加载触发方式
+const (
+	TriggerSignal = "signal" // 收到SIGHUP
+	TriggerFile   = "file"   // 配置文件或敏感配置文件变化
+	TriggerAPI    = "api"    // 管理接口
+)
+
+// Applier 配置热更新处理，Paths中的配置项变化时调用Apply
+// Paths为yaml路径，"log"表示整个配置段，"rule.sync_interval"表示单个配置项
+type Applier struct {
+	Name  string
+	Paths []string
+	Apply func(old, new *Config) error
+}
+
+// handles 配置项是否由该处理负责
+func (a *Applier) handles(path string) bool {
+	for _, p := range a.Paths {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadResult 一次重新加载的结果
+type ReloadResult struct {
+	Time            time.Time `json:"time"`
+	Trigger         string    `json:"trigger"`
+	Applied         []string  `json:"applied"`          // 已生效的配置项
+	RestartRequired []string  `json:"restart_required"` // 与启动时不同、需要重启才能生效的配置项
+	Error           string    `json:"error,omitempty"`  // 加载、验证或应用失败的原因
+}
+
+// Reloader 监听配置变化并热更新可在线修改的配置项
+// 配置项只报告路径，不记录取值，避免泄露敏感配置
+type Reloader struct {
+	path     string
+	boot     *Config // 启动时的配置，用于判断哪些配置项需要重启
+	mu       sync.Mutex
+	current  *Config
+	appliers []Applier
+	last     *ReloadResult
+}
+
+// NewReloader 创建配置重新加载器，cfg为启动时加载的配置
+func NewReloader(path string, cfg *Config) *Reloader {
+	return &Reloader{
+		path:    path,
+		boot:    cfg,
+		current: cfg,
+	}
+}
+
+// Register 注册热更新处理，应在Watch之前调用
+func (r *Reloader) Register(appliers ...Applier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, appliers...)
+}
+
+// Current 当前生效的配置
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Status 最近一次重新加载的结果，未重新加载过时返回nil
+func (r *Reloader) Status() *ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Reload 重新加载配置
+// 加载或验证失败时保持原配置；可热更新的配置项逐个处理，处理失败的配置项保持原值
+func (r *Reloader) Reload(trigger string) *ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := &ReloadResult{
+		Time:            time.Now(),
+		Trigger:         trigger,
+		Applied:         []string{},
+		RestartRequired: []string{},
+	}
+	r.last = result
+
+	next, err := LoadConfig(r.path)
+	if err != nil {
+		result.Error = err.Error()
+		result.RestartRequired = r.restartRequired(r.current)
+		logger.Errorf("重新加载配置失败，保持原配置: Trigger=%s, Error=%v", trigger, err)
+		metrics.RecordConfigReload("error", len(result.RestartRequired))
+		return result
+	}
+
+	// 按处理分组变化的配置项，没有处理的配置项需要重启
+	changed := Diff(r.current, next)
+	var failed []string
+	for i := range r.appliers {
+		applier := &r.appliers[i]
+		var paths []string
+		for _, path := range changed {
+			if applier.handles(path) {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		if err := applier.Apply(r.current, next); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", applier.Name, err))
+			// 保持原值，下次重新加载时再次尝试
+			next = restore(next, r.current, applier)
+			continue
+		}
+		result.Applied = append(result.Applied, paths...)
+	}
+	r.current = next
+	result.RestartRequired = r.restartRequired(next)
+	if len(failed) > 0 {
+		result.Error = strings.Join(failed, "; ")
+	}
+
+	status := "ok"
+	if result.Error != "" {
+		status = "error"
+		logger.Errorf("配置部分生效失败: Trigger=%s, Error=%s", trigger, result.Error)
+	}
+	if len(result.Applied) > 0 {
+		logger.Infof("配置已重新加载: Trigger=%s, Applied=%s", trigger, strings.Join(result.Applied, ","))
+	}
+	if len(result.RestartRequired) > 0 {
+		logger.Warnf("配置变化需要重启才能生效: %s", strings.Join(result.RestartRequired, ","))
+	}
+	metrics.RecordConfigReload(status, len(result.RestartRequired))
+	return result
+}
+
+// restartRequired 与启动时不同且没有热更新处理的配置项
+func (r *Reloader) restartRequired(cfg *Config) []string {
+	pending := []string{}
+	for _, path := range Diff(r.boot, cfg) {
+		if !r.live(path) {
+			pending = append(pending, path)
+		}
+	}
+	return pending
+}
+
+// live 配置项是否可热更新
+func (r *Reloader) live(path string) bool {
+	for i := range r.appliers {
+		if r.appliers[i].handles(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// restore 把applier负责的配置段恢复为原值，配置段粒度与Paths一致
+func restore(next, old *Config, applier *Applier) *Config {
+	restored := *next
+	nv := reflect.ValueOf(&restored).Elem()
+	ov := reflect.ValueOf(old).Elem()
+	for _, path := range applier.Paths {
+		section, field, _ := strings.Cut(path, ".")
+		ni, oi := fieldByYAML(nv, section), fieldByYAML(ov, section)
+		if !ni.IsValid() || !oi.IsValid() {
+			continue
+		}
+		if field == "" || oi.IsNil() || ni.IsNil() {
+			ni.Set(oi)
+			continue
+		}
+		// 只恢复单个配置项时复制配置段，避免修改原配置
+		copied := reflect.New(ni.Type().Elem())
+		copied.Elem().Set(ni.Elem())
+		if f := fieldByYAML(copied.Elem(), field); f.IsValid() {
+			f.Set(fieldByYAML(oi.Elem(), field))
+		}
+		ni.Set(copied)
+	}
+	return &restored
+}
+
+// fieldByYAML 按yaml名称获取字段
+func fieldByYAML(v reflect.Value, name string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// Diff 返回两份配置中取值不同的配置项路径，按路径排序
+func Diff(a, b *Config) []string {
+	var paths []string
+	diffValue(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), "", &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+func diffValue(a, b reflect.Value, prefix string, paths *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Ptr && fa.Type().Elem().Kind() == reflect.Struct {
+			// 未配置的配置段按零值比较
+			if fa.IsNil() {
+				fa = reflect.New(fa.Type().Elem())
+			}
+			if fb.IsNil() {
+				fb = reflect.New(fb.Type().Elem())
+			}
+			diffValue(fa.Elem(), fb.Elem(), path, paths)
+			continue
+		}
+		if fa.Kind() == reflect.Struct {
+			diffValue(fa, fb, path, paths)
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// Watch 定时检查配置文件和敏感配置文件，修改时间或大小变化时重新加载，直到ctx取消
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := r.fingerprint()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := r.fingerprint()
+			if current == last {
+				continue
+			}
+			last = current
+			r.Reload(TriggerFile)
+		}
+	}
+}
+
+// fingerprint 配置文件的修改时间和大小
+func (r *Reloader) fingerprint() string {
+	files := []string{r.path}
+	if secrets := r.Current().SecretsFile(); secrets != "" {
+		files = append(files, secrets)
+	}
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:missing;", file)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String()
+}
@@ -92,6 +92,11 @@ func (e *Error) IsNotFound() bool {
 	return e.Code == ErrRuleNotFound
 }
 
+// IsConflict 判断是否为规则冲突错误
+func (e *Error) IsConflict() bool {
+	return e.Code == ErrRuleConflict
+}
+
 // IsValidationError 判断是否为验证错误
 func (e *Error) IsValidationError() bool {
 	return e.Code == ErrValidation || e.Code == ErrInvalidParams || e.Code == ErrRuleValidation
@@ -139,6 +144,8 @@ func (e *Error) GetHTTPStatus() int {
 		return 400 // Bad Request
 	case e.IsNotFound():
 		return 404 // Not Found
+	case e.IsConflict():
+		return 409 // Conflict
 	case e.IsSecurityError():
 		return 403 // Forbidden
 	case e.IsRequestError():
@@ -0,0 +1,174 @@
+package fingerprint
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// TLS扩展类型
+const (
+	extServerName          = 0x0000
+	extSupportedGroups     = 0x000a
+	extECPointFormats      = 0x000b
+	extSignatureAlgorithms = 0x000d
+	extALPN                = 0x0010
+	extSupportedVersions   = 0x002b
+)
+
+// ClientHello 解析后的TLS ClientHello
+type ClientHello struct {
+	Version             uint16   // ClientHello中的协议版本
+	CipherSuites        []uint16 // 密码套件（保持原始顺序）
+	Extensions          []uint16 // 扩展类型（保持原始顺序）
+	SupportedGroups     []uint16 // 椭圆曲线
+	ECPointFormats      []uint8  // 椭圆曲线点格式
+	SignatureAlgorithms []uint16 // 签名算法
+	SupportedVersions   []uint16 // supported_versions扩展中的版本
+	ALPN                []string // ALPN协议
+	ServerName          string   // SNI
+}
+
+// ParseClientHello 解析TLS记录层中的ClientHello，data需以TLS记录头开始
+func ParseClientHello(data []byte) (*ClientHello, error) {
+	// 记录层: type(1) version(2) length(2)
+	if len(data) < 5 || data[0] != 0x16 {
+		return nil, errors.NewError(errors.ErrInvalidParams, "不是TLS握手记录")
+	}
+	recordLen := int(binary.BigEndian.Uint16(data[3:5]))
+	if len(data) < 5+recordLen {
+		return nil, errors.NewError(errors.ErrInvalidParams, "TLS记录不完整")
+	}
+	return ParseHandshake(data[5 : 5+recordLen])
+}
+
+// ParseHandshake 解析握手层的ClientHello消息
+func ParseHandshake(data []byte) (*ClientHello, error) {
+	// 握手层: type(1) length(3)
+	if len(data) < 4 || data[0] != 0x01 {
+		return nil, errors.NewError(errors.ErrInvalidParams, "不是ClientHello消息")
+	}
+	msgLen := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if len(data) < 4+msgLen {
+		return nil, errors.NewError(errors.ErrInvalidParams, "ClientHello消息不完整")
+	}
+
+	r := &reader{buf: data[4 : 4+msgLen]}
+	hello := &ClientHello{}
+
+	hello.Version = r.uint16()
+	r.skip(32) // random
+	r.skip(int(r.uint8()))
+
+	ciphers := r.bytes(int(r.uint16()))
+	for i := 0; i+1 < len(ciphers); i += 2 {
+		hello.CipherSuites = append(hello.CipherSuites, binary.BigEndian.Uint16(ciphers[i:]))
+	}
+	r.skip(int(r.uint8())) // compression methods
+	if r.err != nil {
+		return nil, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("解析ClientHello失败: %v", r.err))
+	}
+
+	// 无扩展的旧客户端
+	if r.remaining() == 0 {
+		return hello, nil
+	}
+
+	exts := &reader{buf: r.bytes(int(r.uint16()))}
+	for exts.remaining() > 0 && exts.err == nil {
+		extType := exts.uint16()
+		ext := &reader{buf: exts.bytes(int(exts.uint16()))}
+		hello.Extensions = append(hello.Extensions, extType)
+
+		switch extType {
+		case extServerName:
+			names := &reader{buf: ext.bytes(int(ext.uint16()))}
+			for names.remaining() > 0 && names.err == nil {
+				nameType := names.uint8()
+				name := names.bytes(int(names.uint16()))
+				if nameType == 0 && hello.ServerName == "" {
+					hello.ServerName = string(name)
+				}
+			}
+		case extSupportedGroups:
+			hello.SupportedGroups = ext.uint16List(int(ext.uint16()))
+		case extECPointFormats:
+			hello.ECPointFormats = append([]uint8(nil), ext.bytes(int(ext.uint8()))...)
+		case extSignatureAlgorithms:
+			hello.SignatureAlgorithms = ext.uint16List(int(ext.uint16()))
+		case extALPN:
+			protos := &reader{buf: ext.bytes(int(ext.uint16()))}
+			for protos.remaining() > 0 && protos.err == nil {
+				if proto := protos.bytes(int(protos.uint8())); len(proto) > 0 {
+					hello.ALPN = append(hello.ALPN, string(proto))
+				}
+			}
+		case extSupportedVersions:
+			hello.SupportedVersions = ext.uint16List(int(ext.uint8()))
+		}
+	}
+	if r.err != nil || exts.err != nil {
+		return nil, errors.NewError(errors.ErrInvalidParams, "解析ClientHello扩展失败")
+	}
+
+	return hello, nil
+}
+
+// isGREASE 判断是否为GREASE保留值(RFC 8701)
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// reader 带越界检查的字节读取器
+type reader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *reader) remaining() int {
+	return len(r.buf) - r.pos
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.remaining() < n {
+		r.err = fmt.Errorf("数据越界: 需要%d字节, 剩余%d字节", n, r.remaining())
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *reader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *reader) uint16List(n int) []uint16 {
+	b := r.bytes(n)
+	list := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		list = append(list, binary.BigEndian.Uint16(b[i:]))
+	}
+	return list
+}
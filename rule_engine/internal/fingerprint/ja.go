@@ -0,0 +1,150 @@
+package fingerprint
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JA3 计算JA3指纹，返回原始字符串和MD5
+// 格式: SSLVersion,Ciphers,Extensions,EllipticCurves,EllipticCurvePointFormats
+func JA3(hello *ClientHello) (string, string) {
+	fields := []string{
+		strconv.Itoa(int(hello.Version)),
+		joinUint16(hello.CipherSuites, "-"),
+		joinUint16(hello.Extensions, "-"),
+		joinUint16(hello.SupportedGroups, "-"),
+	}
+	formats := make([]string, 0, len(hello.ECPointFormats))
+	for _, f := range hello.ECPointFormats {
+		formats = append(formats, strconv.Itoa(int(f)))
+	}
+	fields = append(fields, strings.Join(formats, "-"))
+
+	raw := strings.Join(fields, ",")
+	sum := md5.Sum([]byte(raw))
+	return raw, hex.EncodeToString(sum[:])
+}
+
+// JA4 计算JA4指纹(TLS over TCP)
+// 格式: {协议}{版本}{SNI}{密码套件数}{扩展数}{ALPN}_{排序后密码套件哈希}_{排序后扩展+签名算法哈希}
+func JA4(hello *ClientHello) string {
+	ciphers := filterGREASE(hello.CipherSuites)
+	extensions := filterGREASE(hello.Extensions)
+
+	sni := "i"
+	if hello.ServerName != "" {
+		sni = "d"
+	}
+
+	a := fmt.Sprintf("t%s%s%02d%02d%s",
+		ja4Version(hello), sni, min(len(ciphers), 99), min(len(extensions), 99), ja4ALPN(hello.ALPN))
+
+	// 密码套件排序后哈希
+	b := "000000000000"
+	if len(ciphers) > 0 {
+		b = hash12(strings.Join(sortedHex(ciphers), ","))
+	}
+
+	// 扩展排序后（去掉SNI和ALPN）拼接签名算法（保持原始顺序）后哈希
+	c := "000000000000"
+	var exts []uint16
+	for _, e := range extensions {
+		if e != extServerName && e != extALPN {
+			exts = append(exts, e)
+		}
+	}
+	if len(exts) > 0 {
+		input := strings.Join(sortedHex(exts), ",")
+		if algs := filterGREASE(hello.SignatureAlgorithms); len(algs) > 0 {
+			input += "_" + strings.Join(toHex(algs), ",")
+		}
+		c = hash12(input)
+	}
+
+	return a + "_" + b + "_" + c
+}
+
+// ja4Version JA4版本字段，优先取supported_versions中的最高版本
+func ja4Version(hello *ClientHello) string {
+	version := hello.Version
+	for _, v := range filterGREASE(hello.SupportedVersions) {
+		if v > version {
+			version = v
+		}
+	}
+	switch version {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	default:
+		return "00"
+	}
+}
+
+// ja4ALPN 取第一个ALPN协议的首尾字符，非字母数字时取十六进制的首尾字符
+func ja4ALPN(protos []string) string {
+	if len(protos) == 0 || protos[0] == "" {
+		return "00"
+	}
+	p := protos[0]
+	first, last := p[0], p[len(p)-1]
+	if isAlnum(first) && isAlnum(last) {
+		return string([]byte{first, last})
+	}
+	h := hex.EncodeToString([]byte(p))
+	return string([]byte{h[0], h[len(h)-1]})
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// hash12 SHA256前12位十六进制
+func hash12(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func filterGREASE(values []uint16) []uint16 {
+	result := make([]uint16, 0, len(values))
+	for _, v := range values {
+		if !isGREASE(v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func toHex(values []uint16) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, fmt.Sprintf("%04x", v))
+	}
+	return result
+}
+
+func sortedHex(values []uint16) []string {
+	result := toHex(values)
+	sort.Strings(result)
+	return result
+}
+
+func joinUint16(values []uint16, sep string) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range filterGREASE(values) {
+		parts = append(parts, strconv.Itoa(int(v)))
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,132 @@
+package fingerprint
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// maxHelloSize ClientHello记录最大长度（记录头 + 16KB负载）
+const maxHelloSize = 5 + 16384
+
+// contextKey 上下文键
+type contextKey struct{}
+
+// Listener 在代理模式下记录每个连接ClientHello的监听器，
+// 需位于 tls.NewListener 之下，使其读取到的是未解密的握手数据
+type Listener struct {
+	net.Listener
+}
+
+// NewListener 包装监听器
+func NewListener(inner net.Listener) *Listener {
+	return &Listener{Listener: inner}
+}
+
+// Accept 接受连接并开始记录ClientHello
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{Conn: conn}, nil
+}
+
+// Conn 记录首个TLS记录的连接
+type Conn struct {
+	net.Conn
+
+	mutex    sync.Mutex
+	captured []byte
+	done     bool
+	info     *model.FingerprintInfo
+}
+
+// Read 读取数据，同时记录首个TLS记录
+func (c *Conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.capture(b[:n])
+	}
+	return n, err
+}
+
+// capture 记录数据直到得到完整的TLS记录
+func (c *Conn) capture(data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.done {
+		return
+	}
+	c.captured = append(c.captured, data...)
+
+	if len(c.captured) >= 5 {
+		// 非TLS握手直接放弃
+		if c.captured[0] != 0x16 {
+			c.done = true
+			c.captured = nil
+			return
+		}
+		recordLen := int(c.captured[3])<<8 | int(c.captured[4])
+		if len(c.captured) >= 5+recordLen {
+			c.captured = c.captured[:5+recordLen]
+			c.done = true
+			return
+		}
+	}
+	if len(c.captured) >= maxHelloSize {
+		c.done = true
+		c.captured = nil
+	}
+}
+
+// Fingerprint 获取连接的TLS指纹，握手数据不完整时返回nil
+func (c *Conn) Fingerprint() *model.FingerprintInfo {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.info != nil || !c.done || c.captured == nil {
+		return c.info
+	}
+	hello, err := ParseClientHello(c.captured)
+	if err != nil {
+		c.captured = nil
+		return nil
+	}
+	raw, hash := JA3(hello)
+	c.info = &model.FingerprintInfo{
+		JA3:    hash,
+		JA3Raw: raw,
+		JA4:    JA4(hello),
+	}
+	c.captured = nil
+	return c.info
+}
+
+// ConnContext 用于 http.Server.ConnContext，将连接记录到请求上下文
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	// tls.Conn 通过 NetConn 暴露底层连接
+	for conn != nil {
+		if fc, ok := conn.(*Conn); ok {
+			return context.WithValue(ctx, contextKey{}, fc)
+		}
+		inner, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		conn = inner.NetConn()
+	}
+	return ctx
+}
+
+// FromContext 从请求上下文获取客户端TLS指纹
+func FromContext(ctx context.Context) *model.FingerprintInfo {
+	fc, ok := ctx.Value(contextKey{}).(*Conn)
+	if !ok {
+		return nil
+	}
+	return fc.Fingerprint()
+}
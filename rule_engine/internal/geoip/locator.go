@@ -0,0 +1,201 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// Locator 地理位置查询接口
+type Locator interface {
+	// Lookup 查询IP的地理位置与ASN信息，未收录的IP返回空的GeoInfo
+	Lookup(ip string) (*model.GeoInfo, error)
+}
+
+// dbFile 可热加载的MMDB文件
+type dbFile struct {
+	path    string
+	reader  *Reader
+	modTime time.Time
+	size    int64
+}
+
+// MMDBLocator 基于本地MMDB文件（City + ASN）的地理位置查询
+type MMDBLocator struct {
+	mutex sync.RWMutex
+	city  *dbFile
+	asn   *dbFile
+}
+
+// NewMMDBLocator 创建MMDB地理位置查询器，cityPath和asnPath至少配置一个
+func NewMMDBLocator(cityPath, asnPath string) (*MMDBLocator, error) {
+	if cityPath == "" && asnPath == "" {
+		return nil, errors.NewError(errors.ErrConfig, "City和ASN数据库路径不能同时为空")
+	}
+
+	l := &MMDBLocator{}
+	if cityPath != "" {
+		f, err := loadDBFile(cityPath)
+		if err != nil {
+			return nil, err
+		}
+		l.city = f
+	}
+	if asnPath != "" {
+		f, err := loadDBFile(asnPath)
+		if err != nil {
+			return nil, err
+		}
+		l.asn = f
+	}
+	return l, nil
+}
+
+// loadDBFile 加载MMDB文件
+func loadDBFile(path string) (*dbFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("获取MMDB文件信息失败: %v", err))
+	}
+	reader, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &dbFile{
+		path:    path,
+		reader:  reader,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}, nil
+}
+
+// Lookup 查询IP的地理位置与ASN信息
+func (l *MMDBLocator) Lookup(ip string) (*model.GeoInfo, error) {
+	addr := net.ParseIP(strings.TrimSpace(ip))
+	if addr == nil {
+		return nil, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("无效的IP地址: %s", ip))
+	}
+
+	l.mutex.RLock()
+	city, asn := l.city, l.asn
+	l.mutex.RUnlock()
+
+	geo := &model.GeoInfo{}
+	if city != nil {
+		record, err := city.reader.Lookup(addr)
+		if err != nil {
+			return nil, err
+		}
+		geo.Country = lookupString(record, "country", "iso_code")
+		if geo.Country == "" {
+			geo.Country = lookupString(record, "registered_country", "iso_code")
+		}
+		if subdivisions, ok := record["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+			if first, ok := subdivisions[0].(map[string]interface{}); ok {
+				geo.Region = lookupString(first, "iso_code")
+			}
+		}
+		geo.City = lookupString(record, "city", "names", "en")
+	}
+	if asn != nil {
+		record, err := asn.reader.Lookup(addr)
+		if err != nil {
+			return nil, err
+		}
+		geo.ASN = toUint64(record["autonomous_system_number"])
+		geo.ASOrg = toString(record["autonomous_system_organization"])
+	}
+	return geo, nil
+}
+
+// Enrich 为检查请求填充地理位置信息，前端已传入时不覆盖
+func (l *MMDBLocator) Enrich(ctx context.Context, req *model.CheckRequest) error {
+	if req == nil || req.Geo != nil || req.ClientIP == "" {
+		return nil
+	}
+	geo, err := l.Lookup(req.ClientIP)
+	if err != nil {
+		return err
+	}
+	req.Geo = geo
+	return nil
+}
+
+// Watch 定期检查MMDB文件变化并热加载，直到ctx取消
+func (l *MMDBLocator) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.reload()
+		}
+	}
+}
+
+// reload 重新加载发生变化的数据库文件
+func (l *MMDBLocator) reload() {
+	l.mutex.RLock()
+	city, asn := l.city, l.asn
+	l.mutex.RUnlock()
+
+	newCity := reloadIfChanged(city)
+	newASN := reloadIfChanged(asn)
+	if newCity == city && newASN == asn {
+		return
+	}
+
+	l.mutex.Lock()
+	l.city, l.asn = newCity, newASN
+	l.mutex.Unlock()
+}
+
+// reloadIfChanged 文件变化时重新加载，失败则继续使用旧数据
+func reloadIfChanged(f *dbFile) *dbFile {
+	if f == nil {
+		return nil
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		logger.Warnf("获取MMDB文件信息失败: Path=%s, Error=%v", f.path, err)
+		return f
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f
+	}
+
+	loaded, err := loadDBFile(f.path)
+	if err != nil {
+		logger.Errorf("重新加载MMDB文件失败: Path=%s, Error=%v", f.path, err)
+		return f
+	}
+	logger.Infof("重新加载MMDB文件成功: Path=%s, Type=%s", f.path, loaded.reader.Metadata().DatabaseType)
+	return loaded
+}
+
+// lookupString 按路径读取嵌套map中的字符串
+func lookupString(record map[string]interface{}, path ...string) string {
+	var current interface{} = record
+	for _, key := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		current = m[key]
+	}
+	return toString(current)
+}
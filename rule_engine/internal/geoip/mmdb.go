@@ -0,0 +1,391 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// metadataMarker MMDB元数据起始标记
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// 数据段字段类型
+const (
+	typeExtended  = 0
+	typePointer   = 1
+	typeString    = 2
+	typeDouble    = 3
+	typeBytes     = 4
+	typeUint16    = 5
+	typeUint32    = 6
+	typeMap       = 7
+	typeInt32     = 8
+	typeUint64    = 9
+	typeUint128   = 10
+	typeArray     = 11
+	typeContainer = 12
+	typeEndMarker = 13
+	typeBool      = 14
+	typeFloat     = 15
+)
+
+// maxDecodeDepth 数据解码最大嵌套深度，防止损坏文件导致无限递归
+const maxDecodeDepth = 64
+
+// Metadata MMDB元数据
+type Metadata struct {
+	DatabaseType string `json:"database_type"`
+	NodeCount    uint   `json:"node_count"`
+	RecordSize   uint   `json:"record_size"`
+	IPVersion    uint   `json:"ip_version"`
+	BuildEpoch   uint64 `json:"build_epoch"`
+}
+
+// Reader MMDB文件读取器，只依赖标准库
+type Reader struct {
+	buf       []byte
+	metadata  Metadata
+	treeSize  uint
+	dataStart uint
+	ipv4Start uint
+}
+
+// Open 打开MMDB文件
+func Open(path string) (*Reader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取MMDB文件失败: %v", err))
+	}
+	return FromBytes(data)
+}
+
+// FromBytes 从内存数据创建MMDB读取器
+func FromBytes(buf []byte) (*Reader, error) {
+	idx := bytes.LastIndex(buf, metadataMarker)
+	if idx < 0 {
+		return nil, errors.NewError(errors.ErrConfig, "无效的MMDB文件: 未找到元数据")
+	}
+	metaStart := uint(idx + len(metadataMarker))
+
+	d := &decoder{buf: buf[metaStart:]}
+	raw, _, err := d.decode(0, 0)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("解析MMDB元数据失败: %v", err))
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.NewError(errors.ErrConfig, "无效的MMDB元数据格式")
+	}
+
+	meta := Metadata{
+		DatabaseType: toString(fields["database_type"]),
+		NodeCount:    uint(toUint64(fields["node_count"])),
+		RecordSize:   uint(toUint64(fields["record_size"])),
+		IPVersion:    uint(toUint64(fields["ip_version"])),
+		BuildEpoch:   toUint64(fields["build_epoch"]),
+	}
+	switch meta.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("不支持的MMDB记录大小: %d", meta.RecordSize))
+	}
+
+	treeSize := meta.NodeCount * meta.RecordSize / 4
+	if treeSize+16 > uint(idx) {
+		return nil, errors.NewError(errors.ErrConfig, "无效的MMDB文件: 搜索树越界")
+	}
+
+	r := &Reader{
+		buf:       buf[:idx],
+		metadata:  meta,
+		treeSize:  treeSize,
+		dataStart: treeSize + 16,
+	}
+	if r.ipv4Start, err = r.findIPv4Start(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Metadata 获取元数据
+func (r *Reader) Metadata() Metadata {
+	return r.metadata
+}
+
+// Lookup 查找IP对应的数据记录，未找到时返回nil
+func (r *Reader) Lookup(ip net.IP) (map[string]interface{}, error) {
+	if ip == nil {
+		return nil, errors.NewError(errors.ErrInvalidParams, "IP地址不能为空")
+	}
+
+	addr := ip.To4()
+	node := r.ipv4Start
+	if addr == nil {
+		if r.metadata.IPVersion == 4 {
+			return nil, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("IPv4数据库不支持IPv6地址: %s", ip))
+		}
+		addr = ip.To16()
+		node = 0
+	}
+
+	var err error
+	nodeCount := r.metadata.NodeCount
+	bitCount := uint(len(addr) * 8)
+	for i := uint(0); i < bitCount && node < nodeCount; i++ {
+		bit := uint(addr[i>>3]>>(7-(i&7))) & 1
+		if node, err = r.readNode(node, bit); err != nil {
+			return nil, err
+		}
+	}
+
+	if node == nodeCount {
+		return nil, nil
+	}
+	if node < nodeCount {
+		return nil, errors.NewError(errors.ErrSystem, "无效的MMDB搜索树")
+	}
+
+	offset := node - nodeCount - 16
+	d := &decoder{buf: r.buf[r.dataStart:]}
+	value, _, err := d.decode(offset, 0)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("解析MMDB数据失败: %v", err))
+	}
+	record, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.NewError(errors.ErrSystem, "无效的MMDB数据记录")
+	}
+	return record, nil
+}
+
+// findIPv4Start 计算IPv4地址在搜索树中的起始节点
+// IPv6数据库中IPv4地址位于 ::/96 子树下
+func (r *Reader) findIPv4Start() (uint, error) {
+	if r.metadata.IPVersion == 4 {
+		return 0, nil
+	}
+	node := uint(0)
+	for i := 0; i < 96 && node < r.metadata.NodeCount; i++ {
+		next, err := r.readNode(node, 0)
+		if err != nil {
+			return 0, err
+		}
+		node = next
+	}
+	return node, nil
+}
+
+// readNode 读取搜索树节点的左/右记录
+func (r *Reader) readNode(node, bit uint) (uint, error) {
+	size := r.metadata.RecordSize
+	base := node * size / 4
+	if base+size/4 > r.treeSize {
+		return 0, errors.NewError(errors.ErrSystem, "MMDB搜索树节点越界")
+	}
+	b := r.buf[base:]
+
+	switch size {
+	case 24:
+		off := bit * 3
+		return uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2]), nil
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6]), nil
+	default:
+		off := bit * 4
+		return uint(binary.BigEndian.Uint32(b[off : off+4])), nil
+	}
+}
+
+// decoder MMDB数据段解码器
+type decoder struct {
+	buf []byte
+}
+
+// decode 从offset处解码一个值，返回值和下一个字段的偏移
+func (d *decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("数据嵌套过深")
+	}
+	if offset >= uint(len(d.buf)) {
+		return nil, 0, fmt.Errorf("数据偏移越界: %d", offset)
+	}
+
+	ctrl := d.buf[offset]
+	offset++
+	typeNum := uint(ctrl >> 5)
+
+	if typeNum == typePointer {
+		pointer, next, err := d.decodePointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+
+	if typeNum == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return nil, 0, fmt.Errorf("扩展类型越界")
+		}
+		typeNum = 7 + uint(d.buf[offset])
+		offset++
+	}
+
+	size, offset, err := d.decodeSize(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch typeNum {
+	case typeMap:
+		result := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, next2, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			result[toString(key)] = value
+			offset = next2
+		}
+		return result, offset, nil
+	case typeArray:
+		result := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			result = append(result, value)
+			offset = next
+		}
+		return result, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEndMarker:
+		return nil, offset, nil
+	}
+
+	end := offset + size
+	if end > uint(len(d.buf)) {
+		return nil, 0, fmt.Errorf("字段长度越界: %d", end)
+	}
+	data := d.buf[offset:end]
+
+	switch typeNum {
+	case typeString:
+		return string(data), end, nil
+	case typeBytes:
+		return append([]byte(nil), data...), end, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("无效的double长度: %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), end, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("无效的float长度: %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), end, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("无效的整数长度: %d", size)
+		}
+		var v uint64
+		for _, b := range data {
+			v = v<<8 | uint64(b)
+		}
+		return v, end, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("无效的int32长度: %d", size)
+		}
+		var v uint32
+		for _, b := range data {
+			v = v<<8 | uint32(b)
+		}
+		return int64(int32(v)), end, nil
+	case typeUint128:
+		return append([]byte(nil), data...), end, nil
+	default:
+		return nil, 0, fmt.Errorf("未知的数据类型: %d", typeNum)
+	}
+}
+
+// decodeSize 解析字段长度
+func (d *decoder) decodeSize(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1F)
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	extra := size - 28
+	if offset+extra > uint(len(d.buf)) {
+		return 0, 0, fmt.Errorf("字段长度越界")
+	}
+	b := d.buf[offset : offset+extra]
+	switch size {
+	case 29:
+		size = 29 + uint(b[0])
+	case 30:
+		size = 285 + (uint(b[0])<<8 | uint(b[1]))
+	default:
+		size = 65821 + (uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]))
+	}
+	return size, offset + extra, nil
+}
+
+// decodePointer 解析指针，返回指向的偏移和指针之后的偏移
+func (d *decoder) decodePointer(ctrl byte, offset uint) (uint, uint, error) {
+	ss := uint(ctrl>>3) & 0x3
+	n := ss + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, fmt.Errorf("指针越界")
+	}
+	b := d.buf[offset : offset+n]
+
+	var pointer uint
+	switch ss {
+	case 0:
+		pointer = uint(ctrl&0x7)<<8 | uint(b[0])
+	case 1:
+		pointer = (uint(ctrl&0x7)<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 2:
+		pointer = (uint(ctrl&0x7)<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		pointer = uint(binary.BigEndian.Uint32(b))
+	}
+	return pointer, offset + n, nil
+}
+
+// toString 将解码值转换为字符串
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+// toUint64 将解码值转换为无符号整数
+func toUint64(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		if n > 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// BatchCheckHandler 批量检查处理器
+type BatchCheckHandler struct {
+	batchService service.BatchCheckService
+}
+
+// NewBatchCheckHandler 创建批量检查处理器
+func NewBatchCheckHandler(batchService service.BatchCheckService) *BatchCheckHandler {
+	if batchService == nil {
+		panic(errors.NewError(errors.ErrConfig, "批量检查服务不能为空"))
+	}
+	return &BatchCheckHandler{
+		batchService: batchService,
+	}
+}
+
+// CheckBatch 批量检查规则匹配，单个请求失败时在对应结果中返回错误
+func (h *BatchCheckHandler) CheckBatch(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req model.BatchCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求数据格式错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求数据格式错误: %v", err)))
+		return
+	}
+	logger.Infof("批量检查规则匹配: RequestID=%s, Count=%d", requestID, len(req.Items))
+
+	result, err := h.batchService.CheckBatch(c.Request.Context(), &req)
+	if err != nil {
+		logger.Errorf("批量检查规则匹配失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+
+	logger.Infof("批量检查完成: RequestID=%s, Total=%d, Matched=%d, Failed=%d", requestID, result.Total, result.Matched, result.Failed)
+	Success(c, result)
+}
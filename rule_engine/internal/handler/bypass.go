@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// BypassHandler 旁路配置处理器
+type BypassHandler struct {
+	bypassService service.BypassService
+}
+
+// NewBypassHandler 创建旁路配置处理器
+func NewBypassHandler(bypassService service.BypassService) *BypassHandler {
+	if bypassService == nil {
+		panic(errors.NewError(errors.ErrConfig, "旁路服务不能为空"))
+	}
+	return &BypassHandler{
+		bypassService: bypassService,
+	}
+}
+
+// bypassRequest 创建和修改旁路配置
+type bypassRequest struct {
+	Mode      model.BypassMode `json:"mode" binding:"required"`     // 旁路模式
+	IPs       []string         `json:"ips"`                         // IP列表
+	URLs      []string         `json:"urls"`                        // URL列表(正则)
+	Headers   []string         `json:"headers"`                     // Header列表
+	StartTime int64            `json:"start_time"`                  // 开始时间(Unix秒)
+	EndTime   int64            `json:"end_time" binding:"required"` // 结束时间(Unix秒)
+	Reason    string           `json:"reason" binding:"required"`   // 旁路原因
+}
+
+// CreateBypass 创建旁路配置
+func (h *BypassHandler) CreateBypass(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("创建旁路配置: RequestID=%s", requestID)
+
+	var req bypassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	config := req.toConfig()
+	config.CreatedBy = userID
+	config.UpdatedBy = userID
+	if err := h.bypassService.CreateBypass(c.Request.Context(), config); err != nil {
+		logger.Errorf("创建旁路配置失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, config)
+}
+
+// UpdateBypass 修改旁路配置
+func (h *BypassHandler) UpdateBypass(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := bypassID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("修改旁路配置: RequestID=%s, BypassID=%d", requestID, id)
+
+	var req bypassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	config := req.toConfig()
+	config.ID = id
+	config.UpdatedBy = userID
+	if err := h.bypassService.UpdateBypass(c.Request.Context(), config); err != nil {
+		logger.Errorf("修改旁路配置失败: RequestID=%s, BypassID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, config)
+}
+
+// DeleteBypass 删除旁路配置
+func (h *BypassHandler) DeleteBypass(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := bypassID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("删除旁路配置: RequestID=%s, BypassID=%d, Operator=%d", requestID, id, getUserID(c))
+
+	if err := h.bypassService.DeleteBypass(c.Request.Context(), id); err != nil {
+		logger.Errorf("删除旁路配置失败: RequestID=%s, BypassID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// GetBypass 获取旁路配置
+func (h *BypassHandler) GetBypass(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := bypassID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("获取旁路配置: RequestID=%s, BypassID=%d", requestID, id)
+
+	config, err := h.bypassService.GetBypass(c.Request.Context(), id)
+	if err != nil {
+		logger.Errorf("获取旁路配置失败: RequestID=%s, BypassID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, config)
+}
+
+// ListBypasses 获取旁路配置列表，active=true时只返回未过期的配置
+func (h *BypassHandler) ListBypasses(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取旁路配置列表: RequestID=%s", requestID)
+
+	page, size, ok := bypassPage(c)
+	if !ok {
+		return
+	}
+	activeOnly := c.Query("active") == "true"
+
+	configs, total, err := h.bypassService.ListBypasses(c.Request.Context(), activeOnly, page, size)
+	if err != nil {
+		logger.Errorf("获取旁路配置列表失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"total": total,
+		"items": configs,
+	})
+}
+
+// ListAttempts 获取旁路尝试记录
+func (h *BypassHandler) ListAttempts(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取旁路尝试记录: RequestID=%s", requestID)
+
+	page, size, ok := bypassPage(c)
+	if !ok {
+		return
+	}
+	query := &model.BypassAttemptQuery{
+		BypassID:  parseInt64(c.Query("bypass_id")),
+		TokenID:   c.Query("token_id"),
+		IP:        c.Query("ip"),
+		StartTime: parseInt64(c.Query("start_time")),
+		EndTime:   parseInt64(c.Query("end_time")),
+	}
+	if v := c.Query("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("无效的success参数: %s", v)))
+			return
+		}
+		query.Success = &success
+	}
+
+	attempts, total, err := h.bypassService.ListAttempts(c.Request.Context(), query, page, size)
+	if err != nil {
+		logger.Errorf("获取旁路尝试记录失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"total": total,
+		"items": attempts,
+	})
+}
+
+// issueTokenRequest 签发旁路令牌
+type issueTokenRequest struct {
+	Subject string   `json:"subject" binding:"required"`     // 令牌使用方
+	Methods []string `json:"methods"`                        // 允许的请求方法，为空时不限制
+	Paths   []string `json:"paths" binding:"required,min=1"` // 允许的路径，以*结尾时按前缀匹配
+	TTL     int64    `json:"ttl" binding:"required"`         // 有效期(秒)
+}
+
+// IssueToken 签发旁路令牌
+func (h *BypassHandler) IssueToken(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("签发旁路令牌: RequestID=%s", requestID)
+
+	var req issueTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	claims := &model.BypassTokenClaims{
+		Subject:  req.Subject,
+		Methods:  req.Methods,
+		Paths:    req.Paths,
+		IssuedBy: userID,
+	}
+	token, err := h.bypassService.IssueToken(c.Request.Context(), claims, time.Duration(req.TTL)*time.Second)
+	if err != nil {
+		logger.Errorf("签发旁路令牌失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"token":  token,
+		"claims": claims,
+	})
+}
+
+// ListKeys 获取旁路令牌密钥，不返回密钥内容
+func (h *BypassHandler) ListKeys(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取旁路令牌密钥: RequestID=%s", requestID)
+
+	keys, err := h.bypassService.ListKeys(c.Request.Context())
+	if err != nil {
+		logger.Errorf("获取旁路令牌密钥失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, keys)
+}
+
+// RotateKey 轮换旁路令牌密钥
+func (h *BypassHandler) RotateKey(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("轮换旁路令牌密钥: RequestID=%s", requestID)
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	key, err := h.bypassService.RotateKey(c.Request.Context(), userID)
+	if err != nil {
+		logger.Errorf("轮换旁路令牌密钥失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, key)
+}
+
+// RevokeKey 吊销旁路令牌密钥
+func (h *BypassHandler) RevokeKey(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	keyID := c.Param("key_id")
+	logger.Infof("吊销旁路令牌密钥: RequestID=%s, KeyID=%s, Operator=%d", requestID, keyID, getUserID(c))
+
+	if err := h.bypassService.RevokeKey(c.Request.Context(), keyID); err != nil {
+		logger.Errorf("吊销旁路令牌密钥失败: RequestID=%s, KeyID=%s, Error=%v", requestID, keyID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// toConfig 转换为旁路配置
+func (r *bypassRequest) toConfig() *model.BypassConfig {
+	return &model.BypassConfig{
+		Mode:      r.Mode,
+		IPs:       r.IPs,
+		URLs:      r.URLs,
+		Headers:   r.Headers,
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+		Reason:    r.Reason,
+	}
+}
+
+// bypassID 解析旁路配置ID
+func bypassID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无效的旁路配置ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+// bypassPage 解析分页参数
+func bypassPage(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "页码必须大于0"))
+		return 0, 0, false
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if err != nil || size < 1 || size > 100 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "页大小必须在1-100之间"))
+		return 0, 0, false
+	}
+	return page, size, true
+}
@@ -0,0 +1,330 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// ChangeRequestHandler 规则变更请求处理器
+type ChangeRequestHandler struct {
+	changeService service.ChangeRequestService
+}
+
+// NewChangeRequestHandler 创建规则变更请求处理器
+func NewChangeRequestHandler(changeService service.ChangeRequestService) *ChangeRequestHandler {
+	if changeService == nil {
+		panic(errors.NewError(errors.ErrConfig, "变更请求服务不能为空"))
+	}
+	return &ChangeRequestHandler{
+		changeService: changeService,
+	}
+}
+
+// changeRequestRequest 创建和修改变更请求
+type changeRequestRequest struct {
+	Title       string              `json:"title" binding:"required"`       // 标题
+	Description string              `json:"description"`                    // 说明
+	Items       []*model.ChangeItem `json:"items" binding:"required,min=1"` // 变更项
+}
+
+// commentRequest 评论和审批意见
+type commentRequest struct {
+	Comment string `json:"comment"`
+}
+
+// CreateChangeRequest 创建变更请求草稿
+func (h *ChangeRequestHandler) CreateChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("创建变更请求: RequestID=%s", requestID)
+
+	var req changeRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	cr := &model.ChangeRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		Items:       req.Items,
+	}
+	if err := h.changeService.Create(c.Request.Context(), cr, userID); err != nil {
+		logger.Errorf("创建变更请求失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// UpdateChangeRequest 修改变更请求
+func (h *ChangeRequestHandler) UpdateChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("修改变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	var req changeRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	cr := &model.ChangeRequest{
+		ID:          id,
+		Title:       req.Title,
+		Description: req.Description,
+		Items:       req.Items,
+	}
+	if err := h.changeService.Update(c.Request.Context(), cr, userID); err != nil {
+		logger.Errorf("修改变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// GetChangeRequest 获取变更请求及历史记录
+func (h *ChangeRequestHandler) GetChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("获取变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	cr, err := h.changeService.Get(c.Request.Context(), id)
+	if err != nil {
+		logger.Errorf("获取变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// ListChangeRequests 获取变更请求列表
+func (h *ChangeRequestHandler) ListChangeRequests(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取变更请求列表: RequestID=%s", requestID)
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "页码必须大于0"))
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if err != nil || size < 1 || size > 100 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "页大小必须在1-100之间"))
+		return
+	}
+
+	crs, total, err := h.changeService.List(c.Request.Context(), model.ChangeRequestStatus(c.Query("status")), page, size)
+	if err != nil {
+		logger.Errorf("获取变更请求列表失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"total": total,
+		"items": crs,
+	})
+}
+
+// SubmitChangeRequest 执行检查并提交审批
+func (h *ChangeRequestHandler) SubmitChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("提交变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	cr, err := h.changeService.Submit(c.Request.Context(), id, userID)
+	if err != nil {
+		logger.Errorf("提交变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// CommentChangeRequest 添加评论
+func (h *ChangeRequestHandler) CommentChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("评论变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	req, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	event, err := h.changeService.Comment(c.Request.Context(), id, userID, req.Comment)
+	if err != nil {
+		logger.Errorf("评论变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, event)
+}
+
+// ApproveChangeRequest 批准变更请求
+func (h *ChangeRequestHandler) ApproveChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("批准变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	req, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	cr, err := h.changeService.Approve(c.Request.Context(), id, userID, getUserRoles(c), req.Comment)
+	if err != nil {
+		logger.Errorf("批准变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// RejectChangeRequest 驳回变更请求
+func (h *ChangeRequestHandler) RejectChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("驳回变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	req, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	cr, err := h.changeService.Reject(c.Request.Context(), id, userID, getUserRoles(c), req.Comment)
+	if err != nil {
+		logger.Errorf("驳回变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// PublishChangeRequest 发布已批准的变更请求
+func (h *ChangeRequestHandler) PublishChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("发布变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	cr, err := h.changeService.Publish(c.Request.Context(), id, userID, getUserRoles(c))
+	if err != nil {
+		logger.Errorf("发布变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// CancelChangeRequest 取消变更请求
+func (h *ChangeRequestHandler) CancelChangeRequest(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, ok := changeRequestID(c)
+	if !ok {
+		return
+	}
+	logger.Infof("取消变更请求: RequestID=%s, ChangeRequestID=%d", requestID, id)
+
+	req, ok := bindComment(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	cr, err := h.changeService.Cancel(c.Request.Context(), id, userID, req.Comment)
+	if err != nil {
+		logger.Errorf("取消变更请求失败: RequestID=%s, ChangeRequestID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, cr)
+}
+
+// changeRequestID 解析变更请求ID，失败时写入错误响应
+func changeRequestID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无效的变更请求ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+// requireUserID 获取当前用户ID，失败时写入错误响应
+func requireUserID(c *gin.Context) (int64, bool) {
+	userID := getUserID(c)
+	if userID <= 0 {
+		logger.Errorf("获取用户ID失败: RequestID=%s", c.GetString("request_id"))
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无法获取用户ID"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// bindComment 解析可选的评论内容
+func bindComment(c *gin.Context) (*commentRequest, bool) {
+	var req commentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Errorf("请求参数错误: RequestID=%s, Error=%v", c.GetString("request_id"), err)
+			Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+			return nil, false
+		}
+	}
+	return &req, true
+}
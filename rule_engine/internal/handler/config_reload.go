@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/config"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// ConfigReloadHandler 服务配置重新加载处理器
+type ConfigReloadHandler struct {
+	reloader *config.Reloader
+}
+
+// NewConfigReloadHandler 创建配置重新加载处理器
+func NewConfigReloadHandler(reloader *config.Reloader) *ConfigReloadHandler {
+	if reloader == nil {
+		panic(errors.NewError(errors.ErrConfig, "配置重新加载器不能为空"))
+	}
+	return &ConfigReloadHandler{
+		reloader: reloader,
+	}
+}
+
+// GetReloadStatus 获取最近一次重新加载结果，未重新加载过时返回空
+func (h *ConfigReloadHandler) GetReloadStatus(c *gin.Context) {
+	Success(c, h.reloader.Status())
+}
+
+// Reload 重新加载配置文件和敏感配置文件
+// 加载或验证失败时保持原配置并返回错误，需要重启才能生效的配置项在结果中列出
+func (h *ConfigReloadHandler) Reload(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("重新加载服务配置: RequestID=%s", requestID)
+
+	result := h.reloader.Reload(config.TriggerAPI)
+	if result.Error != "" {
+		logger.Errorf("重新加载服务配置失败: RequestID=%s, Error=%s", requestID, result.Error)
+		Error(c, errors.NewError(errors.ErrConfig, result.Error))
+		return
+	}
+	Success(c, result)
+}
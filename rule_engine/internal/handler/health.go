@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// HealthHandler 存活和就绪检查处理器
+// 供负载均衡和容器编排探测使用，直接返回健康报告，不使用统一响应格式
+type HealthHandler struct {
+	healthService service.HealthService
+}
+
+// NewHealthHandler 创建健康检查处理器
+func NewHealthHandler(healthService service.HealthService) *HealthHandler {
+	if healthService == nil {
+		panic(errors.NewError(errors.ErrConfig, "健康检查服务不能为空"))
+	}
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Liveness 存活检查，进程能处理请求即返回200
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, h.healthService.Liveness())
+}
+
+// Readiness 就绪检查，未就绪时返回503，降级时仍返回200
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthService.Readiness(c.Request.Context())
+	if !report.Ready {
+		requestID := c.GetString("request_id")
+		for _, component := range report.Components {
+			if component.Critical && component.Status != model.HealthUp {
+				logger.Warnf("服务未就绪: RequestID=%s, Component=%s, Status=%s, Message=%s",
+					requestID, component.Name, component.Status, component.Message)
+			}
+		}
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+const (
+	nodeTokenHeader     = "X-WAF-Node-Token"     // 节点令牌请求头
+	registerTokenHeader = "X-WAF-Register-Token" // 注册令牌请求头
+)
+
+// NodeHandler WAF节点处理器
+type NodeHandler struct {
+	nodeService service.NodeService
+}
+
+// NewNodeHandler 创建WAF节点处理器
+func NewNodeHandler(nodeService service.NodeService) *NodeHandler {
+	if nodeService == nil {
+		panic(errors.NewError(errors.ErrConfig, "节点服务不能为空"))
+	}
+	return &NodeHandler{
+		nodeService: nodeService,
+	}
+}
+
+// Register 节点注册，节点令牌可以放在请求体或X-WAF-Node-Token请求头中
+func (h *NodeHandler) Register(c *gin.Context) {
+	requestID := c.GetString("request_id")
+
+	var req model.NodeRegistration
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+	logger.Infof("节点注册: RequestID=%s, NodeID=%s", requestID, req.NodeID)
+	if req.Token == "" {
+		req.Token = c.GetHeader(nodeTokenHeader)
+	}
+	if req.Address == "" {
+		req.Address = c.ClientIP()
+	}
+	req.RegisterToken = c.GetHeader(registerTokenHeader)
+
+	ack, err := h.nodeService.Register(c.Request.Context(), &req)
+	if err != nil {
+		logger.Errorf("节点注册失败: RequestID=%s, NodeID=%s, Error=%v", requestID, req.NodeID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, ack)
+}
+
+// Heartbeat 节点心跳，节点令牌放在X-WAF-Node-Token请求头中
+func (h *NodeHandler) Heartbeat(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	nodeID := c.Param("node_id")
+
+	var hb model.NodeHeartbeat
+	if err := c.ShouldBindJSON(&hb); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, NodeID=%s, Error=%v", requestID, nodeID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+
+	ack, err := h.nodeService.Heartbeat(c.Request.Context(), nodeID, c.GetHeader(nodeTokenHeader), &hb)
+	if err != nil {
+		logger.Errorf("节点心跳失败: RequestID=%s, NodeID=%s, Error=%v", requestID, nodeID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, ack)
+}
+
+// GetNode 获取节点
+func (h *NodeHandler) GetNode(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	nodeID := c.Param("node_id")
+	logger.Infof("获取节点: RequestID=%s, NodeID=%s", requestID, nodeID)
+
+	node, err := h.nodeService.GetNode(c.Request.Context(), nodeID)
+	if err != nil {
+		logger.Errorf("获取节点失败: RequestID=%s, NodeID=%s, Error=%v", requestID, nodeID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, node)
+}
+
+// ListNodes 获取节点列表，可按status过滤
+func (h *NodeHandler) ListNodes(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取节点列表: RequestID=%s", requestID)
+
+	nodes, err := h.nodeService.ListNodes(c.Request.Context(), model.NodeStatus(c.Query("status")))
+	if err != nil {
+		logger.Errorf("获取节点列表失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"total": len(nodes),
+		"items": nodes,
+	})
+}
+
+// DeleteNode 删除节点
+func (h *NodeHandler) DeleteNode(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	nodeID := c.Param("node_id")
+	logger.Infof("删除节点: RequestID=%s, NodeID=%s", requestID, nodeID)
+
+	if err := h.nodeService.DeleteNode(c.Request.Context(), nodeID); err != nil {
+		logger.Errorf("删除节点失败: RequestID=%s, NodeID=%s, Error=%v", requestID, nodeID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, nil)
+}
+
+// FleetStatus 获取节点总体状态
+func (h *NodeHandler) FleetStatus(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取节点总体状态: RequestID=%s", requestID)
+
+	status, err := h.nodeService.FleetStatus(c.Request.Context())
+	if err != nil {
+		logger.Errorf("获取节点总体状态失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, status)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// ReleaseHandler 规则集发布处理器
+type ReleaseHandler struct {
+	releaseService service.ReleaseService
+}
+
+// NewReleaseHandler 创建规则集发布处理器
+func NewReleaseHandler(releaseService service.ReleaseService) *ReleaseHandler {
+	if releaseService == nil {
+		panic(errors.NewError(errors.ErrConfig, "发布服务不能为空"))
+	}
+	return &ReleaseHandler{
+		releaseService: releaseService,
+	}
+}
+
+// releaseRequest 发布和回滚请求
+type releaseRequest struct {
+	Description string `json:"description"` // 发布说明
+}
+
+// Publish 发布当前规则集
+func (h *ReleaseHandler) Publish(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("发布规则集: RequestID=%s", requestID)
+
+	var req releaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+			Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+			return
+		}
+	}
+
+	userID := getUserID(c)
+	if userID <= 0 {
+		logger.Errorf("获取用户ID失败: RequestID=%s", requestID)
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无法获取用户ID"))
+		return
+	}
+
+	release, err := h.releaseService.Publish(c.Request.Context(), userID, req.Description)
+	if err != nil {
+		logger.Errorf("发布规则集失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, release)
+}
+
+// ListReleases 获取发布列表
+func (h *ReleaseHandler) ListReleases(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取发布列表: RequestID=%s", requestID)
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "页码必须大于0"))
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if err != nil || size < 1 || size > 100 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "页大小必须在1-100之间"))
+		return
+	}
+
+	releases, total, err := h.releaseService.ListReleases(c.Request.Context(), page, size)
+	if err != nil {
+		logger.Errorf("获取发布列表失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"total": total,
+		"items": releases,
+	})
+}
+
+// GetRelease 获取发布及快照
+func (h *ReleaseHandler) GetRelease(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无效的发布ID"))
+		return
+	}
+	logger.Infof("获取发布: RequestID=%s, ReleaseID=%d", requestID, id)
+
+	release, err := h.releaseService.GetRelease(c.Request.Context(), id)
+	if err != nil {
+		logger.Errorf("获取发布失败: RequestID=%s, ReleaseID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, release)
+}
+
+// DiffReleases 比较两次发布，未指定to时与当前规则集比较
+func (h *ReleaseHandler) DiffReleases(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil || from <= 0 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无效的起始发布ID"))
+		return
+	}
+	to := parseInt64(c.Query("to"))
+	if to < 0 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无效的目标发布ID"))
+		return
+	}
+	logger.Infof("比较发布: RequestID=%s, From=%d, To=%d", requestID, from, to)
+
+	diff, err := h.releaseService.Diff(c.Request.Context(), from, to)
+	if err != nil {
+		logger.Errorf("比较发布失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, diff)
+}
+
+// Rollback 回滚到历史发布
+func (h *ReleaseHandler) Rollback(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无效的发布ID"))
+		return
+	}
+	logger.Infof("回滚规则集: RequestID=%s, ReleaseID=%d", requestID, id)
+
+	var req releaseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+			Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+			return
+		}
+	}
+
+	userID := getUserID(c)
+	if userID <= 0 {
+		logger.Errorf("获取用户ID失败: RequestID=%s", requestID)
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无法获取用户ID"))
+		return
+	}
+
+	release, err := h.releaseService.Rollback(c.Request.Context(), id, userID, req.Description)
+	if err != nil {
+		logger.Errorf("回滚规则集失败: RequestID=%s, ReleaseID=%d, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, release)
+}
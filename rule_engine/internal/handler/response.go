@@ -62,6 +62,8 @@ func getHTTPStatus(code int) int {
 	switch {
 	case code == 0:
 		return http.StatusOK
+	case code == int(errors.ErrRuleConflict):
+		return http.StatusConflict
 	case code < 1000:
 		return http.StatusInternalServerError
 	case code < 2000:
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// ResponseCheckHandler 响应检查处理器
+type ResponseCheckHandler struct {
+	responseService service.ResponseService
+}
+
+// NewResponseCheckHandler 创建响应检查处理器
+func NewResponseCheckHandler(responseService service.ResponseService) *ResponseCheckHandler {
+	if responseService == nil {
+		panic(errors.NewError(errors.ErrConfig, "响应检查服务不能为空"))
+	}
+	return &ResponseCheckHandler{
+		responseService: responseService,
+	}
+}
+
+// CheckResponse 检查响应阶段规则
+func (h *ResponseCheckHandler) CheckResponse(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("检查响应规则: RequestID=%s", requestID)
+
+	var req model.ResponseCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求数据格式错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求数据格式错误: %v", err)))
+		return
+	}
+
+	// 验证请求参数
+	if err := req.Validate(); err != nil {
+		logger.Errorf("请求参数验证失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+
+	result, err := h.responseService.CheckResponse(c.Request.Context(), &req)
+	if err != nil {
+		logger.Errorf("检查响应规则失败: RequestID=%s, CheckRequestID=%s, Error=%v", requestID, req.RequestID, err)
+		Error(c, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检查响应规则失败: %v", err)))
+		return
+	}
+
+	if result.Matched {
+		logger.Infof("响应规则匹配成功: RequestID=%s, CheckRequestID=%s, Rule=%s, Action=%s", requestID, req.RequestID, matchedRuleName(result), result.Action)
+	}
+
+	Success(c, result)
+}
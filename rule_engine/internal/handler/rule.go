@@ -187,7 +187,7 @@ func (h *RuleHandler) CreateRule(c *gin.Context) {
 
 	if err := h.ruleService.CreateRule(c.Request.Context(), &rule); err != nil {
 		logger.Errorf("创建规则失败: RequestID=%s, Error=%v", requestID, err)
-		Error(c, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("创建规则失败: %v", err)))
+		Error(c, wrapRuleError(err, fmt.Sprintf("创建规则失败: %v", err)))
 		return
 	}
 
@@ -263,7 +263,7 @@ func (h *RuleHandler) UpdateRule(c *gin.Context) {
 
 	if err := h.ruleService.UpdateRule(c.Request.Context(), &rule); err != nil {
 		logger.Errorf("更新规则失败: RequestID=%s, Error=%v", requestID, err)
-		Error(c, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("更新规则失败: %v", err)))
+		Error(c, wrapRuleError(err, fmt.Sprintf("更新规则失败: %v", err)))
 		return
 	}
 
@@ -369,7 +369,8 @@ func (h *RuleHandler) CheckRule(c *gin.Context) {
 	}
 
 	if result.Matched {
-		logger.Infof("规则匹配成功: RequestID=%s, Rule=%s, Action=%s", requestID, result.MatchedRule.Name, result.Action)
+		logger.Infof("规则匹配成功: RequestID=%s, Source=%s, Rule=%s, Action=%s, Message=%s",
+			requestID, result.Source, matchedRuleName(result), result.Action, result.Message)
 	} else {
 		logger.Infof("未匹配任何规则: RequestID=%s", requestID)
 	}
@@ -377,6 +378,66 @@ func (h *RuleHandler) CheckRule(c *gin.Context) {
 	Success(c, result)
 }
 
+// LintRules 检查整个规则集
+func (h *RuleHandler) LintRules(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("检查规则集: RequestID=%s", requestID)
+
+	report, err := h.ruleService.LintRules(c.Request.Context())
+	if err != nil {
+		logger.Errorf("检查规则集失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, report)
+}
+
+// ListExpiringRules 获取即将过期的规则，within为时间范围(秒)，默认24小时
+func (h *RuleHandler) ListExpiringRules(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	within, err := strconv.ParseInt(c.DefaultQuery("within", "86400"), 10, 64)
+	if err != nil || within <= 0 || within > maxExpiringWithin {
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("时间范围必须在1-%d秒之间", maxExpiringWithin)))
+		return
+	}
+	logger.Infof("获取即将过期的规则: RequestID=%s, Within=%d", requestID, within)
+
+	expirations, err := h.ruleService.ListExpiringRules(c.Request.Context(), time.Duration(within)*time.Second)
+	if err != nil {
+		logger.Errorf("获取即将过期的规则失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"total": len(expirations),
+		"items": expirations,
+	})
+}
+
+// maxExpiringWithin 查询即将过期规则的最大时间范围(秒)
+const maxExpiringWithin = 366 * 24 * 3600
+
+// LintRule 检查单条规则，不保存规则
+func (h *RuleHandler) LintRule(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("检查规则: RequestID=%s", requestID)
+
+	var rule model.Rule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		logger.Errorf("请求数据格式错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求数据格式错误: %v", err)))
+		return
+	}
+
+	report, err := h.ruleService.LintRule(c.Request.Context(), &rule)
+	if err != nil {
+		logger.Errorf("检查规则失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, report)
+}
+
 // GetRule 获取单个规则
 func (h *RuleHandler) GetRule(c *gin.Context) {
 	id := c.Param("id")
@@ -454,7 +515,7 @@ func (h *RuleHandler) BatchCreateRules(c *gin.Context) {
 	}
 
 	if err := h.ruleService.BatchCreateRules(c.Request.Context(), rules); err != nil {
-		Error(c, errors.NewError(errors.ErrRuleEngine, err.Error()))
+		Error(c, wrapRuleError(err, err.Error()))
 		return
 	}
 
@@ -508,7 +569,7 @@ func (h *RuleHandler) BatchUpdateRules(c *gin.Context) {
 	}
 
 	if err := h.ruleService.BatchUpdateRules(c.Request.Context(), rules); err != nil {
-		Error(c, errors.NewError(errors.ErrRuleEngine, err.Error()))
+		Error(c, wrapRuleError(err, err.Error()))
 		return
 	}
 
@@ -606,7 +667,7 @@ func (h *RuleHandler) ImportRules(c *gin.Context) {
 	}
 
 	if err := h.ruleService.ImportRules(c.Request.Context(), rules); err != nil {
-		Error(c, errors.NewError(errors.ErrRuleEngine, err.Error()))
+		Error(c, wrapRuleError(err, err.Error()))
 		return
 	}
 
@@ -733,6 +794,24 @@ func getUserID(c *gin.Context) int64 {
 	return 0
 }
 
+// getUserRoles 获取当前用户角色
+func getUserRoles(c *gin.Context) []string {
+	if v, exists := c.Get("user_roles"); exists {
+		if roles, ok := v.([]string); ok {
+			return roles
+		}
+	}
+	return nil
+}
+
+// wrapRuleError 包装规则服务错误，规则冲突错误原样返回以保留检查报告
+func wrapRuleError(err error, details string) *errors.Error {
+	if e, ok := err.(*errors.Error); ok && e.IsConflict() {
+		return e
+	}
+	return errors.NewError(errors.ErrRuleEngine, details)
+}
+
 // toString 将对象转换为字符串
 func toString(v interface{}) string {
 	if v == nil {
@@ -749,3 +828,11 @@ func (h *RuleHandler) createAuditLog(ctx context.Context, log *model.RuleAuditLo
 	}
 	return nil
 }
+
+// matchedRuleName 命中规则的名称，名单、请求体等非规则判定时为空
+func matchedRuleName(result *model.CheckResult) string {
+	if result.MatchedRule == nil {
+		return ""
+	}
+	return result.MatchedRule.Name
+}
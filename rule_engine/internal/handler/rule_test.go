@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository/memory"
+	"github.com/xwaf/rule_engine/internal/reqbody"
+	"github.com/xwaf/rule_engine/internal/service"
+)
+
+// checkFixture 使用内存仓储的检查接口
+type checkFixture struct {
+	router   *gin.Engine
+	ips      service.IPRuleService
+	bypasses service.BypassService
+}
+
+// newCheckFixture 创建检查接口，enrichers为请求信息补充
+func newCheckFixture(t *testing.T, enrichers ...service.RequestEnricher) *checkFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s := memory.NewStore()
+	cache := memory.NewCache()
+	ips := service.NewIPRuleService(memory.NewIPRuleRepository(s), cache)
+	bypasses := service.NewBypassService(memory.NewBypassRepository(s), service.BypassOptions{})
+	if err := bypasses.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rules := service.NewRuleService(memory.NewRuleRepository(s), service.NewDefaultRuleFactory(), cache, ips, bypasses, enrichers...)
+	h := NewRuleHandler(rules, service.NewRuleVersionService(memory.NewRuleVersionRepository(s)))
+
+	r := gin.New()
+	r.POST("/rules/check", h.CheckRule)
+	return &checkFixture{router: r, ips: ips, bypasses: bypasses}
+}
+
+// check 提交检查请求，返回HTTP状态码和检查结果
+func (f *checkFixture) check(t *testing.T, req *model.CheckRequest) (int, *model.CheckResult) {
+	t.Helper()
+	if len(req.RuleTypes) == 0 {
+		req.RuleTypes = []model.RuleType{model.RuleTypeRegex}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rules/check", bytes.NewReader(body)))
+
+	var resp struct {
+		Code int                `json:"code"`
+		Data *model.CheckResult `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("响应不是JSON: %d %s", w.Code, w.Body.String())
+	}
+	if resp.Code != 0 || resp.Data == nil {
+		t.Fatalf("检查失败: %d %s", w.Code, w.Body.String())
+	}
+	return w.Code, resp.Data
+}
+
+func TestCheckRuleBlacklist(t *testing.T) {
+	f := newCheckFixture(t)
+	ctx := context.Background()
+	err := f.ips.CreateIPRule(ctx, &model.IPRule{
+		EntryType: model.IPEntryTypeIP,
+		IP:        "203.0.113.7",
+		IPType:    model.IPListTypeBlack,
+		BlockType: model.BlockTypePermanent,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ips.LoadLists(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	code, result := f.check(t, &model.CheckRequest{ClientIP: "203.0.113.7", URI: "/", Method: http.MethodGet})
+	if code != http.StatusOK {
+		t.Fatalf("状态码为%d，期望200", code)
+	}
+	if !result.Matched || result.Action != model.ActionBlock || result.Source != model.CheckSourceList || result.MatchedRule != nil {
+		t.Fatalf("黑名单判定错误: %+v", result)
+	}
+
+	_, result = f.check(t, &model.CheckRequest{ClientIP: "203.0.113.8", URI: "/", Method: http.MethodGet})
+	if result.Matched {
+		t.Fatalf("未在名单中的IP被判定命中: %+v", result)
+	}
+}
+
+func TestCheckRuleBlacklistBeforeBypass(t *testing.T) {
+	f := newCheckFixture(t)
+	ctx := context.Background()
+	err := f.ips.CreateIPRule(ctx, &model.IPRule{
+		EntryType: model.IPEntryTypeIP,
+		IP:        "203.0.113.7",
+		IPType:    model.IPListTypeBlack,
+		BlockType: model.BlockTypePermanent,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.ips.LoadLists(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有Header条件的部分旁路可被伪造，不允许创建
+	end := time.Now().Add(time.Hour).Unix()
+	err = f.bypasses.CreateBypass(ctx, &model.BypassConfig{Mode: model.BypassModePartial, Headers: []string{"X-Debug"}, EndTime: end, Reason: "test"})
+	if err == nil {
+		t.Fatal("只有Header条件的部分旁路创建成功")
+	}
+	err = f.bypasses.CreateBypass(ctx, &model.BypassConfig{Mode: model.BypassModeComplete, EndTime: end, Reason: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, result := f.check(t, &model.CheckRequest{ClientIP: "203.0.113.7", URI: "/", Method: http.MethodGet})
+	if !result.Matched || result.Action != model.ActionBlock || result.Source != model.CheckSourceList {
+		t.Fatalf("完全旁路绕过了黑名单: %+v", result)
+	}
+	_, result = f.check(t, &model.CheckRequest{ClientIP: "203.0.113.8", URI: "/", Method: http.MethodGet})
+	if result.Matched || result.Action != model.ActionAllow {
+		t.Fatalf("完全旁路未生效: %+v", result)
+	}
+}
+
+func TestCheckRuleUndecodableBody(t *testing.T) {
+	f := newCheckFixture(t, reqbody.NewProcessor(reqbody.Options{}))
+	code, result := f.check(t, &model.CheckRequest{
+		ClientIP:     "203.0.113.9",
+		URI:          "/api/orders",
+		Method:       http.MethodPost,
+		Body:         "not base64!",
+		BodyEncoding: model.BodyEncodingBase64,
+	})
+	if code != http.StatusOK {
+		t.Fatalf("状态码为%d，期望200", code)
+	}
+	if !result.Matched || result.Action != model.ActionBlock || result.Source != model.CheckSourceRequestBody || result.Message == "" {
+		t.Fatalf("无法解码的请求体未拦截: %+v", result)
+	}
+}
+
+func TestCheckRuleUploadViolation(t *testing.T) {
+	body := "--b\r\n" +
+		"Content-Disposition: form-data; name=\"avatar\"; filename=\"shell.php.jpg\"\r\n" +
+		"Content-Type: image/jpeg\r\n\r\n" +
+		"<?php system($_GET['c']); ?>\r\n" +
+		"--b--\r\n"
+	upload := func() *model.CheckRequest {
+		return &model.CheckRequest{
+			ClientIP: "203.0.113.10",
+			URI:      "/api/upload",
+			Method:   http.MethodPost,
+			Headers:  model.Fields{{Name: "Content-Type", Value: "multipart/form-data; boundary=b"}},
+			Body:     body,
+		}
+	}
+
+	f := newCheckFixture(t, reqbody.NewProcessor(reqbody.Options{}))
+	code, result := f.check(t, upload())
+	if code != http.StatusOK {
+		t.Fatalf("状态码为%d，期望200", code)
+	}
+	if !result.Matched || result.Action != model.ActionBlock || result.Source != model.CheckSourceRequestBody {
+		t.Fatalf("违规上传未拦截: %+v", result)
+	}
+	if len(result.Uploads) != 1 || len(result.Uploads[0].Violations) == 0 {
+		t.Fatalf("未返回上传文件检查结果: %+v", result.Uploads)
+	}
+
+	f = newCheckFixture(t, reqbody.NewProcessor(reqbody.Options{UploadAction: model.ActionLog}))
+	_, result = f.check(t, upload())
+	if result.Matched || len(result.Uploads) != 1 {
+		t.Fatalf("upload_action为log时不应拦截: %+v", result)
+	}
+}
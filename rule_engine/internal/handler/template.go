@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// TemplateHandler 规则模板处理器
+type TemplateHandler struct {
+	templateService service.TemplateService
+}
+
+// NewTemplateHandler 创建规则模板处理器
+func NewTemplateHandler(templateService service.TemplateService) *TemplateHandler {
+	if templateService == nil {
+		panic(errors.NewError(errors.ErrConfig, "规则模板服务不能为空"))
+	}
+	return &TemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// ListTemplates 获取规则模板列表
+func (h *TemplateHandler) ListTemplates(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取规则模板列表: RequestID=%s", requestID)
+
+	templates := h.templateService.ListTemplates(c.Request.Context(), model.RuleType(c.Query("type")))
+	Success(c, gin.H{
+		"templates": templates,
+		"total":     len(templates),
+	})
+}
+
+// GetTemplate 获取规则模板
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id := c.Param("id")
+	logger.Infof("获取规则模板: RequestID=%s, TemplateID=%s", requestID, id)
+
+	tpl, err := h.templateService.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		logger.Errorf("获取规则模板失败: RequestID=%s, TemplateID=%s, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, tpl)
+}
+
+// Instantiate 使用模板创建规则
+func (h *TemplateHandler) Instantiate(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id := c.Param("id")
+	logger.Infof("使用模板创建规则: RequestID=%s, TemplateID=%s", requestID, id)
+
+	var req model.TemplateInstantiateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Errorf("请求参数错误: RequestID=%s, Error=%v", requestID, err)
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("请求参数错误: %v", err)))
+		return
+	}
+
+	// 设置创建者，预览时不要求登录用户
+	userID := getUserID(c)
+	if userID <= 0 && !req.DryRun {
+		logger.Errorf("获取用户ID失败: RequestID=%s", requestID)
+		Error(c, errors.NewError(errors.ErrInvalidParams, "无法获取用户ID"))
+		return
+	}
+	req.Operator = userID
+
+	rules, err := h.templateService.Instantiate(c.Request.Context(), id, &req)
+	if err != nil {
+		logger.Errorf("使用模板创建规则失败: RequestID=%s, TemplateID=%s, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+
+	Success(c, gin.H{
+		"rules":   rules,
+		"dry_run": req.DryRun,
+	})
+}
+
+// ListUpgrades 获取可升级到最新模板版本的规则
+func (h *TemplateHandler) ListUpgrades(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	id := c.Param("id")
+	logger.Infof("获取可升级规则: RequestID=%s, TemplateID=%s", requestID, id)
+
+	upgrades, err := h.templateService.ListUpgrades(c.Request.Context(), id)
+	if err != nil {
+		logger.Errorf("获取可升级规则失败: RequestID=%s, TemplateID=%s, Error=%v", requestID, id, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"upgrades": upgrades,
+		"total":    len(upgrades),
+	})
+}
+
+// ReloadTemplates 重新加载模板文件
+func (h *TemplateHandler) ReloadTemplates(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("重新加载规则模板: RequestID=%s", requestID)
+
+	if err := h.templateService.Reload(c.Request.Context()); err != nil {
+		logger.Errorf("重新加载规则模板失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, nil)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// UploadHandler 上传违规事件处理器
+type UploadHandler struct {
+	uploadService service.UploadEventService
+}
+
+// NewUploadHandler 创建上传违规事件处理器
+func NewUploadHandler(uploadService service.UploadEventService) *UploadHandler {
+	if uploadService == nil {
+		panic(errors.NewError(errors.ErrConfig, "上传违规事件服务不能为空"))
+	}
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// ListEvents 获取上传违规事件
+func (h *UploadHandler) ListEvents(c *gin.Context) {
+	requestID := c.GetString("request_id")
+	logger.Infof("获取上传违规事件: RequestID=%s", requestID)
+
+	page, size, ok := bypassPage(c)
+	if !ok {
+		return
+	}
+	query := &model.UploadEventQuery{
+		IP:        c.Query("ip"),
+		RequestID: c.Query("request_id"),
+		Violation: c.Query("violation"),
+		StartTime: parseInt64(c.Query("start_time")),
+		EndTime:   parseInt64(c.Query("end_time")),
+	}
+	if query.Violation != "" && !model.IsUploadViolation(query.Violation) {
+		Error(c, errors.NewError(errors.ErrInvalidParams, fmt.Sprintf("无效的违规类型: %s", query.Violation)))
+		return
+	}
+
+	events, total, err := h.uploadService.ListEvents(c.Request.Context(), query, page, size)
+	if err != nil {
+		logger.Errorf("获取上传违规事件失败: RequestID=%s, Error=%v", requestID, err)
+		Error(c, err)
+		return
+	}
+	Success(c, gin.H{
+		"total": total,
+		"items": events,
+	})
+}
@@ -0,0 +1,251 @@
+package leakage
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// 内置检测器名称
+const (
+	DetectorStackTrace = "stack_trace" // 程序异常堆栈
+	DetectorSQLError   = "sql_error"   // 数据库错误信息
+	DetectorCardNumber = "card_number" // 银行卡号
+	DetectorInternalIP = "internal_ip" // 内网IP地址
+)
+
+// maskText 脱敏替换文本
+const maskText = "******"
+
+// Finding 泄露检测结果
+type Finding struct {
+	Detector string `json:"detector"` // 检测器名称
+	Sample   string `json:"sample"`   // 已脱敏的命中片段
+	Offset   int    `json:"offset"`   // 命中位置
+}
+
+// detector 泄露检测器
+type detector struct {
+	name     string
+	patterns []*regexp.Regexp
+	verify   func(match string) bool // 对正则命中结果的二次校验，可为空
+}
+
+// detectors 内置检测器
+var detectors = []*detector{
+	{
+		name: DetectorStackTrace,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*at [\w$.<>]+\([\w$]+\.(java|kt|scala):\d+\)`),                   // Java
+			regexp.MustCompile(`Traceback \(most recent call last\):`),                                  // Python
+			regexp.MustCompile(`(?m)^goroutine \d+ \[[\w ]+\]:`),                                        // Go
+			regexp.MustCompile(`(?m)^\s*at [\w$.<>]+ in [^\n]+:line \d+`),                               // .NET
+			regexp.MustCompile(`(?i)(Fatal error|Warning|Parse error): .+ in /[^\s]+\.php on line \d+`), // PHP
+			regexp.MustCompile(`(?m)^\s*at .+ \((/|[A-Za-z]:\\)[^)]+\.js:\d+:\d+\)`),                    // Node.js
+		},
+	},
+	{
+		name: DetectorSQLError,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)You have an error in your SQL syntax`),
+			regexp.MustCompile(`(?i)(mysql_fetch_|mysqli_|PDOException|SQLSTATE\[\w+\])`),
+			regexp.MustCompile(`(?i)(ORA-\d{5}|PLS-\d{5})`),
+			regexp.MustCompile(`(?i)(PostgreSQL.*ERROR|pg_query\(\)|PSQLException)`),
+			regexp.MustCompile(`(?i)(Microsoft OLE DB Provider for SQL Server|Unclosed quotation mark after the character string|SqlException)`),
+			regexp.MustCompile(`(?i)(SQLite(3)?::|sqlite3\.OperationalError|SQLITE_ERROR)`),
+		},
+	},
+	{
+		name: DetectorCardNumber,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		},
+		verify: isCardNumber,
+	},
+	{
+		name: DetectorInternalIP,
+		patterns: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:10|127|172|192)\.\d{1,3}\.\d{1,3}\.\d{1,3}\b`),
+		},
+		verify: isInternalIP,
+	},
+}
+
+// Names 获取所有内置检测器名称
+func Names() []string {
+	names := make([]string, 0, len(detectors))
+	for _, d := range detectors {
+		names = append(names, d.name)
+	}
+	return names
+}
+
+// selectDetectors 按名称选择检测器，为空或 "all" 时返回全部
+func selectDetectors(names []string) ([]*detector, error) {
+	if len(names) == 0 {
+		return detectors, nil
+	}
+	var selected []*detector
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			return detectors, nil
+		}
+		found := false
+		for _, d := range detectors {
+			if d.name == name {
+				selected = append(selected, d)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("未知的泄露检测器: %s", name))
+		}
+	}
+	if len(selected) == 0 {
+		return detectors, nil
+	}
+	return selected, nil
+}
+
+// match 命中的文本区间
+type match struct {
+	detector   string
+	start, end int
+}
+
+// findMatches 查找所有命中区间
+func findMatches(text string, names []string) ([]match, error) {
+	selected, err := selectDetectors(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []match
+	for _, d := range selected {
+		for _, re := range d.patterns {
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				if d.verify != nil && !d.verify(text[loc[0]:loc[1]]) {
+					continue
+				}
+				matches = append(matches, match{detector: d.name, start: loc[0], end: loc[1]})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+	return matches, nil
+}
+
+// Scan 扫描文本中的敏感信息泄露，names为空时使用全部检测器
+func Scan(text string, names []string) ([]Finding, error) {
+	matches, err := findMatches(text, names)
+	if err != nil {
+		return nil, err
+	}
+	findings := make([]Finding, 0, len(matches))
+	for _, m := range matches {
+		findings = append(findings, Finding{
+			Detector: m.detector,
+			Sample:   maskSample(text[m.start:m.end]),
+			Offset:   m.start,
+		})
+	}
+	return findings, nil
+}
+
+// Mask 将文本中命中的敏感信息替换为脱敏文本
+func Mask(text string, names []string) (string, error) {
+	matches, err := findMatches(text, names)
+	if err != nil {
+		return "", err
+	}
+	return MaskRanges(text, matches2ranges(matches)), nil
+}
+
+// MaskRanges 将指定区间替换为脱敏文本，区间可重叠
+func MaskRanges(text string, ranges [][]int) string {
+	if len(ranges) == 0 {
+		return text
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		if r[1] <= last {
+			continue
+		}
+		if r[0] > last {
+			b.WriteString(text[last:r[0]])
+		}
+		b.WriteString(maskText)
+		last = r[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func matches2ranges(matches []match) [][]int {
+	ranges := make([][]int, 0, len(matches))
+	for _, m := range matches {
+		ranges = append(ranges, []int{m.start, m.end})
+	}
+	return ranges
+}
+
+// maskSample 结果片段只保留首尾字符，避免在日志和事件中再次泄露
+func maskSample(s string) string {
+	if len(s) > 80 {
+		s = s[:80]
+	}
+	if len(s) <= 4 {
+		return maskText
+	}
+	return s[:2] + maskText + s[len(s)-2:]
+}
+
+// isCardNumber 校验银行卡号（长度13-19位且通过Luhn校验）
+func isCardNumber(s string) bool {
+	digits := make([]int, 0, len(s))
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			digits = append(digits, int(c-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isInternalIP 校验是否为私有或回环地址
+func isInternalIP(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && (ip.IsPrivate() || ip.IsLoopback())
+}
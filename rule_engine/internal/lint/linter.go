@@ -0,0 +1,401 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// MaxPatternLength rules.pattern 字段长度 VARCHAR(255)
+const MaxPatternLength = 255
+
+// Options 检查配置
+type Options struct {
+	MaxPatternLength int // 匹配模式最大长度
+	CostWarning      int // 正则编译后指令数超过该值时警告
+	CostError        int // 正则编译后指令数超过该值时报错
+}
+
+// DefaultOptions 默认检查配置
+func DefaultOptions() Options {
+	return Options{
+		MaxPatternLength: MaxPatternLength,
+		CostWarning:      500,
+		CostError:        3000,
+	}
+}
+
+// regexRuleTypes 使用正则表达式作为匹配模式的规则类型
+var regexRuleTypes = map[model.RuleType]bool{
+	model.RuleTypeRegex: true,
+	model.RuleTypeIP:    true,
+}
+
+// ruleParams 规则参数中与正则相关的字段
+type ruleParams struct {
+	Operator   string `json:"operator"`    // 指纹规则匹配操作符，regex时匹配模式为正则
+	URIPattern string `json:"uri_pattern"` // 生效的URI正则
+}
+
+// parseParams 解析规则参数，参数格式错误由规则校验处理，这里按未配置处理
+func parseParams(rule *model.Rule) ruleParams {
+	var params ruleParams
+	if rule.Params != "" {
+		_ = json.Unmarshal([]byte(rule.Params), &params)
+	}
+	return params
+}
+
+// patternIsRegex 规则的匹配模式是否为正则，指纹规则在operator为regex时使用正则
+func patternIsRegex(rule *model.Rule, params ruleParams) bool {
+	if regexRuleTypes[rule.Type] {
+		return true
+	}
+	return rule.Type == model.RuleTypeFingerprint && params.Operator == "regex"
+}
+
+// probes 用于判断正则是否匹配任意输入的样本
+var probes = []string{"", "a", "Z9", "/", " ", "\n", "xyz-123_!@#", "中文"}
+
+// Linter 规则检查器
+type Linter struct {
+	opts Options
+}
+
+// New 创建规则检查器
+func New(opts Options) *Linter {
+	defaults := DefaultOptions()
+	if opts.MaxPatternLength <= 0 {
+		opts.MaxPatternLength = defaults.MaxPatternLength
+	}
+	if opts.CostWarning <= 0 {
+		opts.CostWarning = defaults.CostWarning
+	}
+	if opts.CostError <= 0 {
+		opts.CostError = defaults.CostError
+	}
+	return &Linter{opts: opts}
+}
+
+// analyzed 预处理后的规则
+type analyzed struct {
+	rule       *model.Rule
+	re         *regexp.Regexp // 非正则类型或编译失败时为nil
+	tree       *syntax.Regexp // 简化后的语法树
+	key        string         // 规范化后的模式，用于判断模式是否等价
+	literal    string         // 纯字面量模式的文本
+	pureLit    bool           // 是否为不带锚点的纯字面量
+	foldCase   bool           // 字面量是否忽略大小写
+	witness    string         // 能被该模式匹配的样本
+	hasWitness bool
+	matchesAll bool
+}
+
+// CheckAll 检查整个规则集
+func (l *Linter) CheckAll(rules []*model.Rule) *model.LintReport {
+	return l.Check(rules, nil)
+}
+
+// Check 检查待保存的规则，并检查其与已有规则之间的覆盖和冲突关系
+// existing 中与待检查规则ID相同的规则会被忽略（更新场景）
+func (l *Linter) Check(candidates, existing []*model.Rule) *model.LintReport {
+	report := &model.LintReport{Findings: make([]*model.LintFinding, 0)}
+
+	ids := make(map[int64]bool, len(candidates))
+	cands := make([]*analyzed, 0, len(candidates))
+	for _, rule := range candidates {
+		a := l.analyze(rule, report)
+		cands = append(cands, a)
+		if rule.ID > 0 {
+			ids[rule.ID] = true
+		}
+	}
+
+	others := make([]*analyzed, 0, len(existing))
+	for _, rule := range existing {
+		if ids[rule.ID] {
+			continue
+		}
+		others = append(others, l.analyze(rule, nil))
+	}
+
+	for i, a := range cands {
+		for _, b := range cands[i+1:] {
+			l.checkPair(a, b, report)
+		}
+		for _, b := range others {
+			l.checkPair(a, b, report)
+		}
+	}
+	return report
+}
+
+// analyze 预处理规则，report不为空时记录单条规则的检查结果
+func (l *Linter) analyze(rule *model.Rule, report *model.LintReport) *analyzed {
+	a := &analyzed{rule: rule}
+	add := func(level model.LintLevel, code model.LintCode, msg string) {
+		if report != nil {
+			report.Add(&model.LintFinding{
+				Level:    level,
+				Code:     code,
+				RuleID:   rule.ID,
+				RuleName: rule.Name,
+				Message:  msg,
+			})
+		}
+	}
+
+	if n := utf8.RuneCountInString(rule.Pattern); n > l.opts.MaxPatternLength {
+		add(model.LintError, model.LintPatternTooLong, fmt.Sprintf("匹配模式长度%d超过上限%d", n, l.opts.MaxPatternLength))
+	}
+
+	// uri_pattern 和规则变量中的键正则只检查单条规则，不参与规则间比较
+	params := parseParams(rule)
+	if params.URIPattern != "" {
+		l.checkRegex("uri_pattern", params.URIPattern, add)
+	}
+	if model.IsTargetVariable(rule.RuleVariable) {
+		if selector, err := model.ParseTargetSelector(rule.RuleVariable); err == nil {
+			for _, pattern := range selector.KeyPatterns() {
+				l.checkRegex("规则变量", pattern, add)
+			}
+		}
+	}
+
+	if !patternIsRegex(rule, params) {
+		if report != nil {
+			report.Skip(rule.Type)
+		}
+		return a
+	}
+
+	re, parsed := l.checkRegex("", rule.Pattern, add)
+	if re == nil {
+		return a
+	}
+	a.re = re
+	a.tree = parsed.Simplify()
+	a.key = a.tree.String()
+
+	if a.tree.Op == syntax.OpLiteral {
+		a.pureLit = true
+		a.literal = string(a.tree.Rune)
+		a.foldCase = a.tree.Flags&syntax.FoldCase != 0
+	}
+	if w, ok := witness(a.tree); ok && re.MatchString(w) {
+		a.witness, a.hasWitness = w, true
+	}
+
+	// 匹配范围检查
+	a.matchesAll = true
+	for _, p := range probes {
+		if !re.MatchString(p) {
+			a.matchesAll = false
+			break
+		}
+	}
+	if a.matchesAll {
+		add(model.LintError, model.LintMatchesAll, "正则表达式可匹配任意输入")
+	} else if re.MatchString("") {
+		add(model.LintWarning, model.LintMatchesEmpty, "正则表达式可匹配空字符串")
+	}
+	return a
+}
+
+// checkRegex 检查正则能否编译、匹配代价和嵌套量词，编译失败时返回nil
+// label为正则所在位置，检查匹配模式时为空
+func (l *Linter) checkRegex(label, pattern string, add func(model.LintLevel, model.LintCode, string)) (*regexp.Regexp, *syntax.Regexp) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		add(model.LintError, model.LintInvalidPattern, fmt.Sprintf("%s正则表达式无效: %v", label, err))
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		add(model.LintError, model.LintInvalidPattern, fmt.Sprintf("%s正则表达式无效: %v", label, err))
+		return nil, nil
+	}
+
+	// 匹配代价检查：RE2按编译后的指令逐字节执行，指令数即每字节的最大代价
+	if prog, err := syntax.Compile(parsed.Simplify()); err == nil {
+		cost := len(prog.Inst)
+		switch {
+		case cost > l.opts.CostError:
+			add(model.LintError, model.LintRegexCost, fmt.Sprintf("%s正则表达式代价过高: %d条指令，上限%d", label, cost, l.opts.CostError))
+		case cost > l.opts.CostWarning:
+			add(model.LintWarning, model.LintRegexCost, fmt.Sprintf("%s正则表达式代价较高: %d条指令，建议不超过%d", label, cost, l.opts.CostWarning))
+		}
+	}
+	if nestedQuantifier(parsed, false) {
+		add(model.LintWarning, model.LintNestedQuantifier, fmt.Sprintf("%s存在嵌套的无界量词，同步到回溯型正则引擎时有ReDoS风险", label))
+	}
+	return re, parsed
+}
+
+// checkPair 检查两条规则之间的覆盖和冲突关系
+func (l *Linter) checkPair(a, b *analyzed, report *model.LintReport) {
+	if a.re == nil || b.re == nil || !comparable(a.rule, b.rule) {
+		return
+	}
+
+	first, second := a, b
+	if b.rule.Priority > a.rule.Priority {
+		first, second = b, a
+	}
+
+	// 优先级更高且始终生效的允许/阻止规则覆盖了另一条规则
+	if first.rule.Priority > second.rule.Priority && isTerminal(first.rule.Action) && alwaysActive(first.rule) && covers(first, second) {
+		report.Add(&model.LintFinding{
+			Level:         model.LintWarning,
+			Code:          model.LintShadowed,
+			RuleID:        second.rule.ID,
+			RuleName:      second.rule.Name,
+			RelatedRuleID: first.rule.ID,
+			RelatedName:   first.rule.Name,
+			Message:       fmt.Sprintf("规则被更高优先级(%d)的%s规则覆盖，永远不会命中", first.rule.Priority, first.rule.Action),
+		})
+		return
+	}
+
+	// 允许和阻止规则匹配范围重叠
+	if isOpposite(a.rule.Action, b.rule.Action) && overlaps(a, b) {
+		report.Add(&model.LintFinding{
+			Level:         model.LintWarning,
+			Code:          model.LintOverlap,
+			RuleID:        a.rule.ID,
+			RuleName:      a.rule.Name,
+			RelatedRuleID: b.rule.ID,
+			RelatedName:   b.rule.Name,
+			Message:       fmt.Sprintf("%s规则与%s规则匹配范围重叠，实际动作取决于优先级", a.rule.Action, b.rule.Action),
+		})
+	}
+}
+
+// comparable 两条规则是否作用于同一请求字段且在同一阶段生效
+func comparable(a, b *model.Rule) bool {
+	if a.Status != model.StatusEnabled || b.Status != model.StatusEnabled {
+		return false
+	}
+	if a.GetPhase() != b.GetPhase() || target(a) != target(b) {
+		return false
+	}
+	// URI生效范围不同的规则互不覆盖
+	return a.Params == b.Params
+}
+
+// target 规则检查的请求字段，IP规则固定检查客户端IP
+func target(rule *model.Rule) string {
+	if rule.Type == model.RuleTypeIP {
+		return "client_ip"
+	}
+	return string(rule.RuleVariable)
+}
+
+// alwaysActive 规则没有生效时间、过期时间和周期计划的限制
+func alwaysActive(rule *model.Rule) bool {
+	return rule.StartTime == 0 && rule.EndTime == 0 && rule.Schedule == ""
+}
+
+func isTerminal(action model.ActionType) bool {
+	return action == model.ActionAllow || action == model.ActionBlock
+}
+
+func isOpposite(a, b model.ActionType) bool {
+	return a == model.ActionAllow && b == model.ActionBlock || a == model.ActionBlock && b == model.ActionAllow
+}
+
+// covers 判断a命中的输入是否包含了b命中的全部输入
+func covers(a, b *analyzed) bool {
+	if a.matchesAll || a.key == b.key {
+		return true
+	}
+	if !a.pureLit || a.literal == "" {
+		return false
+	}
+	// b的每次匹配都以该前缀开头，前缀包含a的字面量时b的输入一定能被a匹配
+	prefix, _ := b.re.LiteralPrefix()
+	if a.foldCase {
+		return strings.Contains(strings.ToLower(prefix), strings.ToLower(a.literal))
+	}
+	return strings.Contains(prefix, a.literal)
+}
+
+// overlaps 判断两条规则是否存在同时命中的输入
+func overlaps(a, b *analyzed) bool {
+	if covers(a, b) || covers(b, a) {
+		return true
+	}
+	return a.hasWitness && b.re.MatchString(a.witness) || b.hasWitness && a.re.MatchString(b.witness)
+}
+
+// witness 根据语法树构造一个能被匹配的样本，无法构造时返回false
+func witness(re *syntax.Regexp) (string, bool) {
+	var b strings.Builder
+	if !writeWitness(&b, re) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func writeWitness(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return false
+		}
+		r := re.Rune[0]
+		// 优先使用可见字符
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			if re.Rune[i+1] >= 0x21 {
+				r = max(re.Rune[i], 0x21)
+				break
+			}
+		}
+		b.WriteRune(r)
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte('a')
+	case syntax.OpCapture:
+		return writeWitness(b, re.Sub[0])
+	case syntax.OpPlus:
+		return writeWitness(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for i := 0; i < re.Min; i++ {
+			if !writeWitness(b, re.Sub[0]) {
+				return false
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeWitness(b, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		return writeWitness(b, re.Sub[0])
+	}
+	// 空匹配、锚点、可选和星号量词不需要输出
+	return true
+}
+
+// nestedQuantifier 判断是否存在嵌套的无界量词，如 (a+)+ 或 (a*b?)*
+func nestedQuantifier(re *syntax.Regexp, inside bool) bool {
+	unbounded := re.Op == syntax.OpStar || re.Op == syntax.OpPlus || re.Op == syntax.OpRepeat && re.Max == -1
+	if unbounded && inside {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if nestedQuantifier(sub, inside || unbounded) {
+			return true
+		}
+	}
+	return false
+}
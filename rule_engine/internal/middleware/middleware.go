@@ -1,7 +1,10 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/xwaf/rule_engine/internal/errors"
@@ -22,7 +25,7 @@ func Cors() gin.HandlerFunc {
 		// 设置CORS响应头
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, traceparent")
 
 		// 处理OPTIONS请求
 		if method == "OPTIONS" {
@@ -58,6 +61,72 @@ func RequestID() gin.HandlerFunc {
 	}
 }
 
+// Identity 用户身份中间件
+// 从认证网关注入的 X-User-ID、X-User-Roles（逗号分隔）请求头读取当前用户和角色
+// 只有直接连接来自trusted中的地址时才读取这两个请求头，其他来源的请求按匿名用户处理；
+// 直接连接地址取自TCP连接，不受 X-Forwarded-For 影响
+func Identity(trusted []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !fromTrustedProxy(c.Request.RemoteAddr, trusted) {
+			if c.GetHeader("X-User-ID") != "" || c.GetHeader("X-User-Roles") != "" {
+				logger.Warnf("忽略非认证网关传入的身份请求头: RequestID=%s, RemoteAddr=%s",
+					c.GetString("request_id"), c.Request.RemoteAddr)
+			}
+			c.Set("user_roles", []string{})
+			c.Next()
+			return
+		}
+
+		if v := c.GetHeader("X-User-ID"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id <= 0 {
+				handler.Error(c, errors.NewError(errors.ErrInvalidParams, "无效的用户ID"))
+				c.Abort()
+				return
+			}
+			c.Set("user_id", id)
+		}
+
+		roles := make([]string, 0)
+		for _, role := range strings.Split(c.GetHeader("X-User-Roles"), ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				roles = append(roles, role)
+			}
+		}
+		c.Set("user_roles", roles)
+		c.Next()
+	}
+}
+
+// fromTrustedProxy 判断直接连接地址是否为认证网关
+func fromTrustedProxy(remoteAddr string, trusted []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReviewRequired 变更审批中间件
+// 开启强制审批后，规则、名单、CC规则只能通过变更请求发布，直接修改的接口返回权限不足
+func ReviewRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Warnf("拒绝未经审批的规则修改: RequestID=%s, Method=%s, Path=%s",
+			c.GetString("request_id"), c.Request.Method, c.Request.URL.Path)
+		handler.Error(c, errors.NewError(errors.ErrPermDenied, "已开启变更审批，请通过变更请求修改规则"))
+		c.Abort()
+	}
+}
+
 // ErrorHandler 错误处理中间件
 // 统一处理请求过程中的错误，包括panic和普通错误
 func ErrorHandler() gin.HandlerFunc {
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xwaf/rule_engine/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing 链路追踪中间件
+// 按W3C traceparent获取上游链路，没有时由X-Request-ID生成链路ID，为请求创建服务端Span，需放在RequestID之后
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetString("request_id")
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		// 请求ID由本服务生成时同样作为链路ID，便于按请求ID查找链路
+		ctx = tracing.ContextWithRequestID(ctx, requestID)
+
+		ctx, span := tracing.Start(ctx, c.Request.Method, trace.SpanKindServer,
+			attribute.String("http.method", c.Request.Method),
+			attribute.String("http.target", c.Request.URL.Path),
+			attribute.String("request_id", requestID),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		span.SetName(c.Request.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int64("http.status_code", int64(status)),
+		)
+		if status >= http.StatusInternalServerError {
+			tracing.RecordError(span, fmt.Errorf("HTTP %d", status))
+		} else if len(c.Errors) > 0 {
+			tracing.RecordError(span, c.Errors.Last().Err)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+// Package migrate MySQL表结构版本迁移
+// 已执行的版本记录在schema_version表中，多个节点同时迁移时通过MySQL命名锁串行执行
+// MySQL的DDL不能回滚，脚本执行失败时该版本记为未完成(dirty)，修复后需要通过Force指定当前版本
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+const (
+	// versionTable 已执行版本记录表
+	versionTable = "schema_version"
+	// lockName 迁移命名锁，同一MySQL实例上的所有库共用
+	lockName = "xwaf_schema_migrate"
+	// defaultLockTimeout 默认等待其他节点完成迁移的时间
+	defaultLockTimeout = 60 * time.Second
+)
+
+// Status 迁移版本状态
+type Status struct {
+	Version   int64      `json:"version"`    // 版本号
+	Name      string     `json:"name"`       // 名称
+	Applied   bool       `json:"applied"`    // 是否已执行
+	Dirty     bool       `json:"dirty"`      // 是否执行未完成
+	AppliedAt *time.Time `json:"applied_at"` // 执行时间
+}
+
+// Options 迁移选项
+type Options struct {
+	LockTimeout time.Duration // 等待迁移锁的超时，默认60秒
+}
+
+// Migrator 表结构迁移
+type Migrator struct {
+	db          *sql.DB
+	migrations  []*Migration
+	lockTimeout time.Duration
+}
+
+// applied 数据库中的版本记录
+type applied struct {
+	version   int64
+	name      string
+	dirty     bool
+	appliedAt time.Time
+}
+
+// New 创建表结构迁移，读取fsys中的迁移脚本
+func New(db *sql.DB, fsys fs.FS, opts Options) (*Migrator, error) {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = defaultLockTimeout
+	}
+	return &Migrator{db: db, migrations: migrations, lockTimeout: opts.LockTimeout}, nil
+}
+
+// Up 按版本号升序执行所有未执行的迁移，返回本次执行的版本
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(records); err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			if err := m.checkLegacy(ctx, conn); err != nil {
+				return err
+			}
+		}
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+			if err := m.up(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本号倒序回退最近执行的steps个版本，返回本次回退的版本
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps <= 0 {
+		return nil, errors.NewError(errors.ErrValidation, "回退的版本数必须大于0")
+	}
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkDirty(records); err != nil {
+			return err
+		}
+		versions := sortedVersions(records)
+		for i := len(versions) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.find(versions[i])
+			if migration == nil {
+				return errors.NewError(errors.ErrConfig, fmt.Sprintf("程序中没有迁移版本%d，不能回退", versions[i]))
+			}
+			if err := m.down(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 所有版本的执行状态，按版本号升序，包括数据库中有记录但程序中没有的版本
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取数据库连接失败: %v", err))
+	}
+	defer conn.Close()
+	records, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, 0, len(m.migrations))
+	known := make(map[int64]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := &Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := records[migration.Version]; ok {
+			at := record.appliedAt
+			status.Applied, status.Dirty, status.AppliedAt = true, record.dirty, &at
+		}
+		statuses = append(statuses, status)
+	}
+	for _, version := range sortedVersions(records) {
+		if known[version] {
+			continue
+		}
+		record := records[version]
+		at := record.appliedAt
+		statuses = append(statuses, &Status{Version: version, Name: record.name, Applied: true, Dirty: record.dirty, AppliedAt: &at})
+	}
+	sortStatuses(statuses)
+	return statuses, nil
+}
+
+// Force 将数据库记为已执行到指定版本且没有未完成的版本，不执行脚本
+// 用于修复执行失败的迁移，或接入由init.sql等方式创建、没有迁移记录的数据库；version为0时清空记录
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version < 0 {
+		return errors.NewError(errors.ErrValidation, "版本号不能为负数")
+	}
+	if version > 0 && m.find(version) == nil {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("程序中没有迁移版本%d", version))
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("开始事务失败: %v", err))
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+versionTable); err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("清空迁移记录失败: %v", err))
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO "+versionTable+" (version, name, dirty) VALUES (?, ?, 0)",
+				migration.Version, migration.Name,
+			); err != nil {
+				return errors.NewError(errors.ErrSystem, fmt.Sprintf("写入迁移记录失败: %v", err))
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("提交事务失败: %v", err))
+		}
+		logger.Infof("数据库结构版本已设置为%d", version)
+		return nil
+	})
+}
+
+// up 执行一个版本的升级脚本，执行前记为未完成，全部成功后清除标记
+func (m *Migrator) up(ctx context.Context, conn *sql.Conn, migration *Migration) error {
+	if _, err := conn.ExecContext(ctx,
+		"INSERT INTO "+versionTable+" (version, name, dirty) VALUES (?, ?, 1)",
+		migration.Version, migration.Name,
+	); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("写入迁移记录失败: %v", err))
+	}
+	if err := execScript(ctx, conn, migration, migration.Up); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx,
+		"UPDATE "+versionTable+" SET dirty = 0 WHERE version = ?", migration.Version,
+	); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新迁移记录失败: %v", err))
+	}
+	logger.Infof("已执行迁移: %06d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+// down 执行一个版本的回退脚本，全部成功后删除版本记录
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, migration *Migration) error {
+	if migration.Down == "" {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("迁移版本%d没有回退脚本", migration.Version))
+	}
+	if _, err := conn.ExecContext(ctx,
+		"UPDATE "+versionTable+" SET dirty = 1 WHERE version = ?", migration.Version,
+	); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新迁移记录失败: %v", err))
+	}
+	if err := execScript(ctx, conn, migration, migration.Down); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx,
+		"DELETE FROM "+versionTable+" WHERE version = ?", migration.Version,
+	); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("删除迁移记录失败: %v", err))
+	}
+	logger.Infof("已回退迁移: %06d_%s", migration.Version, migration.Name)
+	return nil
+}
+
+// execScript 逐条执行脚本中的语句
+func execScript(ctx context.Context, conn *sql.Conn, migration *Migration, script string) error {
+	for i, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf(
+				"执行迁移%06d_%s的第%d条语句失败，该版本已记为未完成: %v", migration.Version, migration.Name, i+1, err))
+		}
+	}
+	return nil
+}
+
+// withLock 创建版本记录表并持有迁移锁执行fn，命名锁属于连接，因此所有语句在同一连接上执行
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("获取数据库连接失败: %v", err))
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.lockTimeout/time.Second)).Scan(&locked); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("获取迁移锁失败: %v", err))
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("等待迁移锁超时(%s)，可能有其他节点正在迁移", m.lockTimeout))
+	}
+	defer func() {
+		// 使用新的上下文释放锁，避免ctx取消后锁留在连接上
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(releaseCtx, "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			logger.Warnf("释放迁移锁失败: %v", err)
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureVersionTable 创建版本记录表
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+versionTable+` (
+			version    BIGINT NOT NULL COMMENT '版本号',
+			name       VARCHAR(255) NOT NULL COMMENT '名称',
+			dirty      TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否执行未完成',
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '执行时间',
+			PRIMARY KEY (version)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='数据库结构版本表'
+	`)
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建版本记录表失败: %v", err))
+	}
+	return nil
+}
+
+// applied 读取已执行的版本，版本记录表不存在时返回空
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]*applied, error) {
+	records := make(map[int64]*applied)
+	exists, err := tableExists(ctx, conn, versionTable)
+	if err != nil || !exists {
+		return records, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, dirty, applied_at FROM "+versionTable)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("读取迁移记录失败: %v", err))
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var record applied
+		if err := rows.Scan(&record.version, &record.name, &record.dirty, &record.appliedAt); err != nil {
+			return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("扫描迁移记录失败: %v", err))
+		}
+		records[record.version] = &record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("遍历迁移记录失败: %v", err))
+	}
+	return records, nil
+}
+
+// checkLegacy 没有迁移记录但已有规则表时拒绝迁移，避免在旧版init.sql创建的库上重复建表
+func (m *Migrator) checkLegacy(ctx context.Context, conn *sql.Conn) error {
+	exists, err := tableExists(ctx, conn, "rules")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.NewError(errors.ErrConfig,
+			"数据库已有表但没有迁移记录，请确认表结构对应的版本后执行 migrate force <版本号>")
+	}
+	return nil
+}
+
+// find 查找程序中的迁移版本
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// tableExists 当前库中是否存在表
+func tableExists(ctx context.Context, conn *sql.Conn, table string) (bool, error) {
+	var n int
+	err := conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", table,
+	).Scan(&n)
+	if err != nil {
+		return false, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询表%s失败: %v", table, err))
+	}
+	return n > 0, nil
+}
+
+// checkDirty 有未完成的版本时拒绝继续迁移
+func checkDirty(records map[int64]*applied) error {
+	for _, version := range sortedVersions(records) {
+		if records[version].dirty {
+			return errors.NewError(errors.ErrConfig, fmt.Sprintf(
+				"迁移版本%d未完成，请手动修复表结构后执行 migrate force <版本号>", version))
+		}
+	}
+	return nil
+}
+
+// sortedVersions 按升序返回已执行的版本号
+func sortedVersions(records map[int64]*applied) []int64 {
+	versions := make([]int64, 0, len(records))
+	for version := range records {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// sortStatuses 按版本号升序排列
+func sortStatuses(statuses []*Status) {
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+}
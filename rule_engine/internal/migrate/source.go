@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version int64  // 版本号
+	Name    string // 名称
+	Up      string // 升级脚本
+	Down    string // 回退脚本，为空时不能回退该版本
+}
+
+// fileNamePattern 迁移脚本文件名：版本号_名称.up.sql 或 版本号_名称.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load 读取目录下的迁移脚本，按版本号升序返回
+// 文件名不符合格式、同一版本名称不一致或缺少升级脚本时返回错误
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取迁移脚本目录失败: %v", err))
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		m := fileNamePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("迁移脚本文件名格式错误: %s", entry.Name()))
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("迁移脚本版本号无效: %s", entry.Name()))
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取迁移脚本失败: %s: %v", entry.Name(), err))
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		} else if migration.Name != m[2] {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("迁移版本%d的名称不一致: %s, %s", version, migration.Name, m[2]))
+		}
+		if m[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("迁移版本%d缺少升级脚本", migration.Version))
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements 按分号拆分SQL语句，忽略引号内的分号和注释，不支持DELIMITER
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte // 当前所在引号，0表示不在引号内
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		if quote != 0 {
+			current.WriteByte(c)
+			switch {
+			case c == '\\' && quote != '`' && i+1 < len(script):
+				i++
+				current.WriteByte(script[i])
+			case c == quote && i+1 < len(script) && script[i+1] == quote:
+				// 连续两个引号表示引号本身
+				i++
+				current.WriteByte(script[i])
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '#' || (c == '-' && strings.HasPrefix(script[i:], "-- ")) || strings.HasPrefix(script[i:], "--\n"):
+			// 单行注释
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// BatchCheckRequest 批量检查请求，用于离线分析和日志回放
+type BatchCheckRequest struct {
+	Items []*CheckRequest `json:"items"` // 检查请求，按顺序返回结果
+}
+
+// Validate 验证请求参数，单个请求的参数在检查时验证
+func (r *BatchCheckRequest) Validate(maxItems int) error {
+	if len(r.Items) == 0 {
+		return errors.NewError(errors.ErrValidation, "items不能为空")
+	}
+	if maxItems > 0 && len(r.Items) > maxItems {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("单次最多检查%d个请求，当前%d个", maxItems, len(r.Items)))
+	}
+	return nil
+}
+
+// BatchCheckError 单个请求的检查错误
+type BatchCheckError struct {
+	Code    errors.ErrorCode `json:"code"`    // 错误码
+	Message string           `json:"message"` // 错误信息
+}
+
+// BatchCheckItem 单个请求的检查结果，Result和Error只有一个非空
+type BatchCheckItem struct {
+	Index  int              `json:"index"`            // 请求在items中的下标
+	Result *CheckResult     `json:"result,omitempty"` // 检查结果
+	Error  *BatchCheckError `json:"error,omitempty"`  // 检查失败时的错误
+}
+
+// BatchCheckResult 批量检查结果
+type BatchCheckResult struct {
+	Total   int               `json:"total"`   // 请求数
+	Matched int               `json:"matched"` // 命中规则的请求数
+	Failed  int               `json:"failed"`  // 检查失败的请求数
+	Items   []*BatchCheckItem `json:"items"`   // 与请求顺序相同
+}
@@ -0,0 +1,22 @@
+package model
+
+// BotCategory 客户端分类
+type BotCategory string
+
+const (
+	BotCategoryBrowser     BotCategory = "browser"      // 浏览器
+	BotCategoryCrawler     BotCategory = "crawler"      // 已验证的搜索引擎爬虫
+	BotCategoryFakeCrawler BotCategory = "fake_crawler" // 冒充搜索引擎的爬虫
+	BotCategoryScanner     BotCategory = "scanner"      // 安全扫描器
+	BotCategoryHTTPLibrary BotCategory = "http_library" // HTTP库/命令行工具
+	BotCategoryUnknown     BotCategory = "unknown"      // 未知客户端
+)
+
+// BotInfo 客户端分类结果
+type BotInfo struct {
+	Category BotCategory `json:"category"` // 分类
+	Name     string      `json:"name"`     // 命中的签名名称，如 sqlmap、curl、Googlebot
+	Score    int         `json:"score"`    // 机器人评分(0-100)，越高越可能是恶意机器人
+	Verified bool        `json:"verified"` // 爬虫身份是否已通过DNS验证
+	Reasons  []string    `json:"reasons"`  // 评分原因
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/xwaf/rule_engine/internal/errors"
@@ -14,32 +15,70 @@ type BypassMode string
 
 const (
 	BypassModeNone     BypassMode = "none"     // 不启用旁路
-	BypassModeMonitor  BypassMode = "monitor"  // 监控模式
-	BypassModePartial  BypassMode = "partial"  // 部分旁路
-	BypassModeComplete BypassMode = "complete" // 完全旁路
+	BypassModeMonitor  BypassMode = "monitor"  // 监控模式：规则照常检查，命中后只记录不拦截
+	BypassModePartial  BypassMode = "partial"  // 部分旁路：符合条件的请求跳过检查
+	BypassModeComplete BypassMode = "complete" // 完全旁路：生效期间所有请求跳过检查
+
+	// BypassModeToken 签名令牌旁路，仅用于旁路尝试记录
+	BypassModeToken BypassMode = "token"
 )
 
+// headerNamePattern Header名称格式
+var headerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
 // BypassConfig 旁路配置
+//
+// IPs、URLs、Headers 中已配置的每一类条件都必须满足（同一类条件任一满足即可），
+// 例如同时配置IP和URL时只对该IP访问该URL的请求旁路，便于添加范围尽量小的临时例外。
+// URL和Header可由客户端任意构造，部分旁路和监控模式必须配置IP条件，URL和Header只用于进一步缩小范围
 type BypassConfig struct {
-	Mode      BypassMode `json:"mode"`       // 旁路模式
-	IPs       []string   `json:"ips"`        // 允许旁路的IP列表
-	URLs      []string   `json:"urls"`       // 允许旁路的URL列表
-	Headers   []string   `json:"headers"`    // 允许旁路的Header列表
-	StartTime int64      `json:"start_time"` // 旁路开始时间
-	EndTime   int64      `json:"end_time"`   // 旁路结束时间
-	Reason    string     `json:"reason"`     // 旁路原因
+	ID        int64      `json:"id" gorm:"primaryKey"`
+	Mode      BypassMode `json:"mode"`                           // 旁路模式
+	IPs       []string   `json:"ips" gorm:"serializer:json"`     // 允许旁路的IP或CIDR列表
+	URLs      []string   `json:"urls" gorm:"serializer:json"`    // 允许旁路的URL列表(正则)
+	Headers   []string   `json:"headers" gorm:"serializer:json"` // 允许旁路的Header列表，请求包含该Header即满足
+	StartTime int64      `json:"start_time"`                     // 旁路开始时间(Unix秒)，0表示立即生效
+	EndTime   int64      `json:"end_time"`                       // 旁路结束时间(Unix秒)
+	Reason    string     `json:"reason"`                         // 旁路原因
+	CreatedBy int64      `json:"created_by"`                     // 创建者
+	UpdatedBy int64      `json:"updated_by"`                     // 更新者
+	CreatedAt time.Time  `json:"created_at"`                     // 创建时间
+	UpdatedAt time.Time  `json:"updated_at"`                     // 更新时间
+}
+
+// TableName 旁路配置表名
+func (BypassConfig) TableName() string {
+	return "bypass_configs"
 }
 
 // BypassAttempt 旁路尝试记录
 type BypassAttempt struct {
 	ID        uint64     `json:"id" gorm:"primaryKey"`
-	IP        string     `json:"ip"`        // 来源IP
-	URL       string     `json:"url"`       // 请求URL
-	Headers   string     `json:"headers"`   // 请求头
-	Mode      BypassMode `json:"mode"`      // 尝试的旁路模式
-	Timestamp int64      `json:"timestamp"` // 尝试时间
-	Success   bool       `json:"success"`   // 是否成功
-	Reason    string     `json:"reason"`    // 原因说明
+	BypassID  int64      `json:"bypass_id"`  // 旁路配置ID，令牌旁路时为0
+	TokenID   string     `json:"token_id"`   // 旁路令牌ID，仅令牌旁路时记录
+	RequestID string     `json:"request_id"` // 请求ID
+	IP        string     `json:"ip"`         // 来源IP
+	URL       string     `json:"url"`        // 请求URL
+	Headers   string     `json:"headers"`    // 请求头
+	Mode      BypassMode `json:"mode"`       // 尝试的旁路模式
+	Timestamp int64      `json:"timestamp"`  // 尝试时间
+	Success   bool       `json:"success"`    // 是否成功
+	Reason    string     `json:"reason"`     // 原因说明
+}
+
+// TableName 旁路尝试记录表名
+func (BypassAttempt) TableName() string {
+	return "bypass_attempts"
+}
+
+// BypassAttemptQuery 旁路尝试记录查询条件
+type BypassAttemptQuery struct {
+	BypassID  int64  // 旁路配置ID
+	TokenID   string // 旁路令牌ID
+	IP        string // 来源IP
+	Success   *bool  // 是否成功
+	StartTime int64  // 开始时间(Unix秒)
+	EndTime   int64  // 结束时间(Unix秒)
 }
 
 // ValidateBypassConfig 验证旁路配置
@@ -64,10 +103,13 @@ func ValidateBypassConfig(config *BypassConfig) error {
 		return errors.NewError(errors.ErrValidation, "旁路开始时间必须早于结束时间")
 	}
 
-	// 验证IP列表
+	// 验证IP列表，部分旁路和监控模式必须配置，避免仅凭可伪造的URL或Header绕过检查
+	if (config.Mode == BypassModePartial || config.Mode == BypassModeMonitor) && len(config.IPs) == 0 {
+		return errors.NewError(errors.ErrValidation, "部分旁路和监控模式必须配置IP或CIDR条件，其他调用方请使用旁路令牌")
+	}
 	for _, ip := range config.IPs {
-		if net.ParseIP(ip) == nil {
-			return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的IP地址: %s", ip))
+		if _, err := parseBypassIP(ip); err != nil {
+			return err
 		}
 	}
 
@@ -80,7 +122,7 @@ func ValidateBypassConfig(config *BypassConfig) error {
 
 	// 验证Header列表
 	for _, header := range config.Headers {
-		if !regexp.MustCompile(`^[a-zA-Z0-9-]+$`).MatchString(header) {
+		if !headerNamePattern.MatchString(header) {
 			return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的Header名称: %s", header))
 		}
 	}
@@ -88,58 +130,154 @@ func ValidateBypassConfig(config *BypassConfig) error {
 	return nil
 }
 
-// IsBypassAllowed 检查是否允许旁路
-func IsBypassAllowed(config *BypassConfig, req *CheckRequest) (bool, error) {
-	if config == nil {
-		return false, errors.NewError(errors.ErrValidation, "旁路配置不能为空")
-	}
+// CompiledBypass 预编译的旁路配置，URL正则只在加载时编译一次
+type CompiledBypass struct {
+	Config *BypassConfig
+	ips    []*net.IPNet
+	urls   []*regexp.Regexp
+}
 
-	if req == nil {
-		return false, errors.NewError(errors.ErrValidation, "请求参数不能为空")
+// parseBypassIP 解析旁路IP条件，单个IP按/32或/128处理
+func parseBypassIP(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的CIDR: %s", value))
+		}
+		return ipNet, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的IP地址: %s", value))
 	}
+	bits := 128
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
 
-	// 检查旁路模式
-	if config.Mode == BypassModeNone {
-		return false, nil
+// CompileBypass 编译旁路配置
+func CompileBypass(config *BypassConfig) (*CompiledBypass, error) {
+	if err := ValidateBypassConfig(config); err != nil {
+		return nil, err
 	}
+	c := &CompiledBypass{
+		Config: config,
+		ips:    make([]*net.IPNet, 0, len(config.IPs)),
+		urls:   make([]*regexp.Regexp, 0, len(config.URLs)),
+	}
+	for _, ip := range config.IPs {
+		ipNet, _ := parseBypassIP(ip)
+		c.ips = append(c.ips, ipNet)
+	}
+	for _, url := range config.URLs {
+		c.urls = append(c.urls, regexp.MustCompile(url))
+	}
+	return c, nil
+}
 
-	// 检查时间范围
-	now := time.Now().Unix()
-	if config.StartTime > 0 && config.EndTime > 0 {
-		if now < config.StartTime || now > config.EndTime {
-			return false, nil
-		}
+// ActiveAt 判断旁路配置在指定时间是否生效
+func (c *CompiledBypass) ActiveAt(now int64) bool {
+	cfg := c.Config
+	if cfg.Mode == BypassModeNone {
+		return false
 	}
+	if cfg.StartTime > 0 && now < cfg.StartTime {
+		return false
+	}
+	return cfg.EndTime <= 0 || now < cfg.EndTime
+}
 
-	// 完全旁路模式
-	if config.Mode == BypassModeComplete {
-		return true, nil
+// Match 检查请求是否满足旁路条件
+// matched表示请求至少满足一类条件（即一次旁路尝试），allowed表示全部条件满足、允许旁路
+func (c *CompiledBypass) Match(req *CheckRequest) (matched, allowed bool) {
+	if c.Config.Mode == BypassModeComplete {
+		return true, true
 	}
 
-	// 检查IP
-	for _, ip := range config.IPs {
-		if req.ClientIP == ip {
-			return true, nil
+	allowed = true
+	check := func(configured, ok bool) {
+		if !configured {
+			return
+		}
+		if ok {
+			matched = true
+		} else {
+			allowed = false
 		}
 	}
+	check(len(c.ips) > 0, c.matchIP(req.ClientIP))
+	check(len(c.urls) > 0, c.matchURL(req.URI))
+	check(len(c.Config.Headers) > 0, c.matchHeader(req.Headers))
+	return matched, matched && allowed
+}
 
-	// 检查URL
-	for _, urlPattern := range config.URLs {
-		matched, err := regexp.MatchString(urlPattern, req.URI)
-		if err != nil {
-			return false, errors.NewError(errors.ErrValidation, fmt.Sprintf("URL模式匹配失败: %v", err))
+func (c *CompiledBypass) matchIP(clientIP string) bool {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range c.ips {
+		if ipNet.Contains(ip) {
+			return true
 		}
-		if matched {
-			return true, nil
+	}
+	return false
+}
+
+func (c *CompiledBypass) matchURL(uri string) bool {
+	for _, re := range c.urls {
+		if re.MatchString(uri) {
+			return true
 		}
 	}
+	return false
+}
 
-	// 检查Headers
-	for _, header := range config.Headers {
-		if _, exists := req.Headers[header]; exists {
-			return true, nil
+func (c *CompiledBypass) matchHeader(headers Fields) bool {
+	for _, name := range c.Config.Headers {
+		for _, header := range headers {
+			if strings.EqualFold(header.Name, name) {
+				return true
+			}
 		}
 	}
+	return false
+}
+
+// BypassDecision 旁路判定结果
+type BypassDecision struct {
+	Config  *BypassConfig      // 命中的旁路配置，令牌旁路时为nil
+	Token   *BypassTokenClaims // 验证通过的旁路令牌
+	Allowed bool               // 是否满足全部旁路条件，监控模式下规则照常检查但命中后只记录
+}
+
+// Monitor 是否为监控模式，规则照常检查但命中后只记录
+func (d *BypassDecision) Monitor() bool {
+	return d.Config != nil && d.Config.Mode == BypassModeMonitor
+}
+
+// String 旁路来源说明
+func (d *BypassDecision) String() string {
+	if d.Token != nil {
+		return fmt.Sprintf("旁路令牌%s(%s)", d.Token.ID, d.Token.Subject)
+	}
+	return fmt.Sprintf("旁路配置%d", d.Config.ID)
+}
 
-	return false, nil
+// IsBypassAllowed 检查是否允许旁路
+func IsBypassAllowed(config *BypassConfig, req *CheckRequest) (bool, error) {
+	if req == nil {
+		return false, errors.NewError(errors.ErrValidation, "请求参数不能为空")
+	}
+	c, err := CompileBypass(config)
+	if err != nil {
+		return false, err
+	}
+	if !c.ActiveAt(time.Now().Unix()) {
+		return false, nil
+	}
+	_, allowed := c.Match(req)
+	return allowed, nil
 }
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// BypassKeyStatus 旁路令牌签名密钥状态
+type BypassKeyStatus string
+
+const (
+	BypassKeyActive  BypassKeyStatus = "active"  // 生效：用于签发和验证令牌
+	BypassKeyRetired BypassKeyStatus = "retired" // 已轮换：只用于验证尚未过期的令牌
+	BypassKeyRevoked BypassKeyStatus = "revoked" // 已吊销：签发的令牌立即失效
+)
+
+// bypassTokenVersion 令牌格式版本
+const bypassTokenVersion = "v1"
+
+// BypassKey 旁路令牌签名密钥
+type BypassKey struct {
+	ID        int64           `json:"id" gorm:"primaryKey"`
+	KeyID     string          `json:"key_id"`     // 密钥标识，写入令牌用于选择验证密钥
+	Secret    []byte          `json:"-"`          // HMAC-SHA256密钥，不通过接口返回
+	Status    BypassKeyStatus `json:"status"`     // 状态
+	CreatedBy int64           `json:"created_by"` // 创建者
+	CreatedAt time.Time       `json:"created_at"` // 创建时间
+	RetiredAt *time.Time      `json:"retired_at"` // 轮换时间
+	RevokedAt *time.Time      `json:"revoked_at"` // 吊销时间
+}
+
+// TableName 旁路令牌签名密钥表名
+func (BypassKey) TableName() string {
+	return "bypass_keys"
+}
+
+// BypassTokenClaims 旁路令牌内容
+//
+// 令牌只对列出的路径和方法生效，Paths以*结尾时按前缀匹配，否则要求路径完全一致（不含查询参数）；
+// 请求路径必须是规范形式，含百分号编码、.或..段、重复斜杠、反斜杠或分号的请求不允许使用令牌
+type BypassTokenClaims struct {
+	ID        string   `json:"jti"`     // 令牌ID
+	Subject   string   `json:"sub"`     // 令牌使用方，如扫描器或健康检查名称
+	Methods   []string `json:"methods"` // 允许的请求方法，为空时不限制
+	Paths     []string `json:"paths"`   // 允许的路径
+	IssuedBy  int64    `json:"iby"`     // 签发者
+	IssuedAt  int64    `json:"iat"`     // 签发时间(Unix秒)
+	ExpiresAt int64    `json:"exp"`     // 过期时间(Unix秒)
+}
+
+// Validate 验证令牌范围
+func (c *BypassTokenClaims) Validate() error {
+	if c.Subject == "" {
+		return errors.NewError(errors.ErrValidation, "令牌使用方不能为空")
+	}
+	if len(c.Paths) == 0 {
+		return errors.NewError(errors.ErrValidation, "令牌至少需要限定一个路径")
+	}
+	for _, p := range c.Paths {
+		scope := strings.TrimSuffix(p, "*")
+		if _, ok := canonicalPath(scope); !ok || !strings.HasPrefix(p, "/") || strings.Contains(scope, "*") {
+			return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的令牌路径: %s", p))
+		}
+	}
+	for _, method := range c.Methods {
+		if method == "" || method != strings.ToUpper(method) {
+			return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的请求方法: %s", method))
+		}
+	}
+	if c.ExpiresAt <= c.IssuedAt {
+		return errors.NewError(errors.ErrValidation, "令牌过期时间必须晚于签发时间")
+	}
+	return nil
+}
+
+// Allows 判断令牌是否允许该请求方法和路径
+func (c *BypassTokenClaims) Allows(method, uri string) bool {
+	if len(c.Methods) > 0 {
+		allowed := false
+		for _, m := range c.Methods {
+			if strings.EqualFold(m, method) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	raw := uri
+	if i := strings.IndexAny(raw, "?#"); i >= 0 {
+		raw = raw[:i]
+	}
+	// 后端解码和规范化后的路径可能与原始路径不同，如 /api/..%2fadmin，此类请求不允许使用令牌
+	path, ok := canonicalPath(raw)
+	if !ok {
+		return false
+	}
+	for _, p := range c.Paths {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalPath 对路径做URL解码和规范化，ok表示原始路径已是规范形式
+// 规范化保留末尾的斜杠；反斜杠和分号在部分后端中作为路径分隔符或路径参数，视为非规范形式
+func canonicalPath(raw string) (string, bool) {
+	if strings.ContainsAny(raw, `\;`) {
+		return "", false
+	}
+	decoded, err := url.PathUnescape(raw)
+	if err != nil {
+		return "", false
+	}
+	cleaned := path.Clean(decoded)
+	if strings.HasSuffix(decoded, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned, cleaned == raw
+}
+
+// SignBypassToken 使用密钥签发令牌，格式为 v1.<密钥标识>.<内容>.<签名>，内容和签名使用base64url编码
+func SignBypassToken(key *BypassKey, claims *BypassTokenClaims) (string, error) {
+	if key == nil || key.Status != BypassKeyActive {
+		return "", errors.NewError(errors.ErrValidation, "只能使用生效的密钥签发令牌")
+	}
+	if err := claims.Validate(); err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.NewError(errors.ErrSystem, fmt.Sprintf("序列化令牌失败: %v", err))
+	}
+	signed := bypassTokenVersion + "." + key.KeyID + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(bypassTokenMAC(key.Secret, signed)), nil
+}
+
+// VerifyBypassToken 验证令牌签名和有效期，keys按密钥标识索引；不检查路径和方法范围
+func VerifyBypassToken(token string, keys map[string]*BypassKey, now int64) (*BypassTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] != bypassTokenVersion {
+		return nil, errors.NewError(errors.ErrValidation, "令牌格式无效")
+	}
+	key, ok := keys[parts[1]]
+	if !ok {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("令牌密钥不存在: %s", parts[1]))
+	}
+	if key.Status == BypassKeyRevoked {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("令牌密钥已吊销: %s", key.KeyID))
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil || !hmac.Equal(sig, bypassTokenMAC(key.Secret, strings.Join(parts[:3], "."))) {
+		return nil, errors.NewError(errors.ErrValidation, "令牌签名无效")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.NewError(errors.ErrValidation, "令牌格式无效")
+	}
+	var claims BypassTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.NewError(errors.ErrValidation, "令牌格式无效")
+	}
+	if now >= claims.ExpiresAt {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("令牌已过期: %s", claims.ID))
+	}
+	if claims.IssuedAt > now+60 {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("令牌签发时间无效: %s", claims.ID))
+	}
+	return &claims, nil
+}
+
+// bypassTokenMAC 计算令牌签名
+func bypassTokenMAC(secret []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
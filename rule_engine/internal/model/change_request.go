@@ -0,0 +1,306 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// RoleApprover 审批人角色
+const RoleApprover = "approver"
+
+// ChangeRequestStatus 变更请求状态
+type ChangeRequestStatus string
+
+const (
+	ChangeRequestDraft     ChangeRequestStatus = "draft"     // 草稿，作者可修改
+	ChangeRequestPending   ChangeRequestStatus = "pending"   // 已提交，等待审批
+	ChangeRequestApproved  ChangeRequestStatus = "approved"  // 已批准，等待发布
+	ChangeRequestRejected  ChangeRequestStatus = "rejected"  // 已驳回，作者可修改后重新提交
+	ChangeRequestPublished ChangeRequestStatus = "published" // 已发布
+	ChangeRequestCancelled ChangeRequestStatus = "cancelled" // 已取消
+)
+
+// ChangeTarget 变更对象
+type ChangeTarget string
+
+const (
+	ChangeTargetRule ChangeTarget = "rule" // 规则
+	ChangeTargetIP   ChangeTarget = "ip"   // IP/地理位置/指纹名单
+	ChangeTargetCC   ChangeTarget = "cc"   // CC防护规则
+
+	ChangeTargetRelease ChangeTarget = "release" // 规则集发布，只能回滚
+)
+
+// ChangeOperation 变更操作
+type ChangeOperation string
+
+const (
+	ChangeOperationCreate ChangeOperation = "create"
+	ChangeOperationUpdate ChangeOperation = "update"
+	ChangeOperationDelete ChangeOperation = "delete"
+
+	ChangeOperationRollback ChangeOperation = "rollback" // 回滚到TargetID指定的发布
+)
+
+// ChangeEventAction 变更请求事件
+type ChangeEventAction string
+
+const (
+	ChangeEventCreate  ChangeEventAction = "create"  // 创建
+	ChangeEventUpdate  ChangeEventAction = "update"  // 修改草稿
+	ChangeEventSubmit  ChangeEventAction = "submit"  // 提交审批
+	ChangeEventComment ChangeEventAction = "comment" // 评论
+	ChangeEventApprove ChangeEventAction = "approve" // 批准
+	ChangeEventReject  ChangeEventAction = "reject"  // 驳回
+	ChangeEventPublish ChangeEventAction = "publish" // 发布
+	ChangeEventCancel  ChangeEventAction = "cancel"  // 取消
+)
+
+// ChangeItem 变更请求中的一项变更
+type ChangeItem struct {
+	ID              int64           `json:"id" gorm:"primaryKey"`
+	ChangeRequestID int64           `json:"change_request_id"`
+	Target          ChangeTarget    `json:"target"`                             // 变更对象
+	Operation       ChangeOperation `json:"operation"`                          // 变更操作
+	TargetID        int64           `json:"target_id,omitempty"`                // 修改、删除时的对象ID
+	Payload         json.RawMessage `json:"payload,omitempty" gorm:"type:text"` // 新建、修改时的对象内容
+	TestCases       []*RuleTestCase `json:"test_cases,omitempty" gorm:"-"`      // 规则测试用例，提交时执行
+	TestCasesData   string          `json:"-" gorm:"column:test_cases"`         // 测试用例(JSON)
+	AppliedAt       *time.Time      `json:"applied_at,omitempty"`               // 发布时已生效的时间，重试发布时跳过
+}
+
+// TableName 变更项表名
+func (ChangeItem) TableName() string {
+	return "change_request_items"
+}
+
+// Validate 验证变更项
+func (i *ChangeItem) Validate() error {
+	switch i.Target {
+	case ChangeTargetRule, ChangeTargetIP, ChangeTargetCC:
+	case ChangeTargetRelease:
+		if i.Operation != ChangeOperationRollback || i.TargetID <= 0 {
+			return errors.NewError(errors.ErrValidation, "发布变更只能回滚，且必须指定发布ID")
+		}
+		if len(i.Payload) > 0 || len(i.TestCases) > 0 {
+			return errors.NewError(errors.ErrValidation, "回滚变更不能包含内容和测试用例")
+		}
+		return nil
+	default:
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的变更对象: %s", i.Target))
+	}
+	switch i.Operation {
+	case ChangeOperationCreate:
+		if len(i.Payload) == 0 {
+			return errors.NewError(errors.ErrValidation, "新建变更的内容不能为空")
+		}
+	case ChangeOperationUpdate:
+		if i.TargetID <= 0 || len(i.Payload) == 0 {
+			return errors.NewError(errors.ErrValidation, "修改变更必须指定对象ID和内容")
+		}
+	case ChangeOperationDelete:
+		if i.TargetID <= 0 {
+			return errors.NewError(errors.ErrValidation, "删除变更必须指定对象ID")
+		}
+	case ChangeOperationRollback:
+		return errors.NewError(errors.ErrValidation, "只有发布变更可以回滚")
+	default:
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的变更操作: %s", i.Operation))
+	}
+	if len(i.TestCases) > 0 && i.Target != ChangeTargetRule {
+		return errors.NewError(errors.ErrValidation, "只有规则变更可以附带测试用例")
+	}
+
+	// 内容必须能解析为对应对象并通过验证
+	if i.Operation == ChangeOperationDelete {
+		return nil
+	}
+	switch i.Target {
+	case ChangeTargetRule:
+		rule, err := i.Rule()
+		if err != nil {
+			return err
+		}
+		return rule.Validate()
+	case ChangeTargetIP:
+		rule, err := i.IPRule()
+		if err != nil {
+			return err
+		}
+		return rule.Validate()
+	default:
+		rule, err := i.CCRule()
+		if err != nil {
+			return err
+		}
+		return rule.Validate()
+	}
+}
+
+// Rule 解析规则变更内容，修改时ID取TargetID
+func (i *ChangeItem) Rule() (*Rule, error) {
+	var rule Rule
+	if err := json.Unmarshal(i.Payload, &rule); err != nil {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("解析规则变更内容失败: %v", err))
+	}
+	rule.ID = i.TargetID
+	return &rule, nil
+}
+
+// IPRule 解析IP规则变更内容
+func (i *ChangeItem) IPRule() (*IPRule, error) {
+	var rule IPRule
+	if err := json.Unmarshal(i.Payload, &rule); err != nil {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("解析IP规则变更内容失败: %v", err))
+	}
+	rule.ID = i.TargetID
+	return &rule, nil
+}
+
+// CCRule 解析CC规则变更内容
+func (i *ChangeItem) CCRule() (*CCRule, error) {
+	var rule CCRule
+	if err := json.Unmarshal(i.Payload, &rule); err != nil {
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("解析CC规则变更内容失败: %v", err))
+	}
+	rule.ID = i.TargetID
+	return &rule, nil
+}
+
+// ChangeChecks 提交时自动执行的检查结果
+type ChangeChecks struct {
+	Lint      *LintReport       `json:"lint"`       // 规则静态检查
+	Tests     []*RuleTestResult `json:"tests"`      // 测试用例执行结果
+	Failed    int               `json:"failed"`     // 未通过的测试用例数
+	Passed    bool              `json:"passed"`     // 静态检查无错误且测试用例全部通过
+	CheckedAt time.Time         `json:"checked_at"` // 检查时间
+}
+
+// ChangeRequest 变更请求，批量包含规则、IP、CC规则的变更
+type ChangeRequest struct {
+	ID          int64               `json:"id" gorm:"primaryKey"`
+	Title       string              `json:"title"`                     // 标题
+	Description string              `json:"description"`               // 说明
+	Status      ChangeRequestStatus `json:"status"`                    // 状态
+	Author      int64               `json:"author"`                    // 作者
+	Approver    int64               `json:"approver,omitempty"`        // 审批人
+	ReleaseID   int64               `json:"release_id,omitempty"`      // 发布后生成的规则集发布ID
+	Items       []*ChangeItem       `json:"items" gorm:"-"`            // 变更项
+	Checks      *ChangeChecks       `json:"checks,omitempty" gorm:"-"` // 检查结果
+	ChecksData  string              `json:"-" gorm:"column:checks"`    // 检查结果(JSON)
+	Events      []*ChangeEvent      `json:"events,omitempty" gorm:"-"` // 历史记录
+	SubmittedAt *time.Time          `json:"submitted_at,omitempty"`    // 提交时间
+	ApprovedAt  *time.Time          `json:"approved_at,omitempty"`     // 批准时间
+	PublishedAt *time.Time          `json:"published_at,omitempty"`    // 发布时间
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// TableName 变更请求表名
+func (ChangeRequest) TableName() string {
+	return "change_requests"
+}
+
+// Validate 验证变更请求
+func (r *ChangeRequest) Validate() error {
+	if r.Title == "" {
+		return errors.NewError(errors.ErrValidation, "变更请求标题不能为空")
+	}
+	if len(r.Items) == 0 {
+		return errors.NewError(errors.ErrValidation, "变更请求至少包含一项变更")
+	}
+	seen := make(map[string]bool, len(r.Items))
+	for n, item := range r.Items {
+		if err := item.Validate(); err != nil {
+			return errors.NewError(errors.ErrValidation, fmt.Sprintf("第%d项变更无效: %v", n+1, err))
+		}
+		if item.TargetID > 0 {
+			key := fmt.Sprintf("%s:%d", item.Target, item.TargetID)
+			if seen[key] {
+				return errors.NewError(errors.ErrValidation, fmt.Sprintf("同一对象只能有一项变更: %s", key))
+			}
+			seen[key] = true
+		}
+	}
+	if r.Rollback() != nil && len(r.Items) > 1 {
+		return errors.NewError(errors.ErrValidation, "回滚变更请求只能包含一项变更")
+	}
+	return nil
+}
+
+// Rollback 回滚变更项，不是回滚变更请求时为nil
+func (r *ChangeRequest) Rollback() *ChangeItem {
+	for _, item := range r.Items {
+		if item.Operation == ChangeOperationRollback {
+			return item
+		}
+	}
+	return nil
+}
+
+// Editable 作者是否可以修改
+func (r *ChangeRequest) Editable() bool {
+	return r.Status == ChangeRequestDraft || r.Status == ChangeRequestRejected
+}
+
+// Encode 序列化检查结果和测试用例，保存前调用
+func (r *ChangeRequest) Encode() error {
+	r.ChecksData = ""
+	if r.Checks != nil {
+		b, err := json.Marshal(r.Checks)
+		if err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("序列化检查结果失败: %v", err))
+		}
+		r.ChecksData = string(b)
+	}
+	for _, item := range r.Items {
+		item.TestCasesData = ""
+		if len(item.TestCases) > 0 {
+			b, err := json.Marshal(item.TestCases)
+			if err != nil {
+				return errors.NewError(errors.ErrSystem, fmt.Sprintf("序列化测试用例失败: %v", err))
+			}
+			item.TestCasesData = string(b)
+		}
+	}
+	return nil
+}
+
+// Decode 解析检查结果和测试用例，读取后调用
+func (r *ChangeRequest) Decode() error {
+	if r.ChecksData != "" {
+		var checks ChangeChecks
+		if err := json.Unmarshal([]byte(r.ChecksData), &checks); err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("解析检查结果失败: %v", err))
+		}
+		r.Checks = &checks
+	}
+	for _, item := range r.Items {
+		if item.TestCasesData != "" {
+			if err := json.Unmarshal([]byte(item.TestCasesData), &item.TestCases); err != nil {
+				return errors.NewError(errors.ErrSystem, fmt.Sprintf("解析测试用例失败: %v", err))
+			}
+		}
+	}
+	return nil
+}
+
+// ChangeEvent 变更请求历史记录
+type ChangeEvent struct {
+	ID              int64               `json:"id" gorm:"primaryKey"`
+	ChangeRequestID int64               `json:"change_request_id"`
+	Action          ChangeEventAction   `json:"action"`      // 事件
+	FromStatus      ChangeRequestStatus `json:"from_status"` // 变更前状态
+	ToStatus        ChangeRequestStatus `json:"to_status"`   // 变更后状态
+	Operator        int64               `json:"operator"`    // 操作人
+	Comment         string              `json:"comment"`     // 评论或审批意见
+	CreatedAt       time.Time           `json:"created_at"`
+}
+
+// TableName 变更请求历史表名
+func (ChangeEvent) TableName() string {
+	return "change_request_events"
+}
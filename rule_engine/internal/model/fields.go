@@ -0,0 +1,255 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Field 有序多值集合中的一项
+type Field struct {
+	Name  string `json:"name"`          // 名称
+	Value string `json:"value"`         // 解码后的值
+	Raw   string `json:"raw,omitempty"` // 原始值(URL解码前)，与Value相同时为空
+}
+
+// RawValue 原始值，未记录时为解码后的值
+func (f Field) RawValue() string {
+	if f.Raw != "" {
+		return f.Raw
+	}
+	return f.Value
+}
+
+// Fields 保留顺序和同名项的集合，用于请求头和请求参数
+// JSON可以是对象 {"id": "1"}、值为数组的对象 {"id": ["1", "2"]}，
+// 或按顺序排列的数组 [{"name": "id", "value": "1"}, ["id", "2"]]
+type Fields []Field
+
+// FieldsFromMap 由映射创建集合，按名称排序
+func FieldsFromMap(m map[string]string) Fields {
+	if m == nil {
+		return nil
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make(Fields, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, Field{Name: name, Value: m[name]})
+	}
+	return fields
+}
+
+// ParseQuery 按顺序解析查询字符串，保留同名参数和原始值，编码错误的参数保留原始内容
+func ParseQuery(query string) Fields {
+	var fields Fields
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+		rawName, rawValue, _ := strings.Cut(pair, "=")
+		field := Field{Name: unescapeQuery(rawName), Value: unescapeQuery(rawValue)}
+		if field.Value != rawValue {
+			field.Raw = rawValue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// unescapeQuery URL解码，失败时返回原始内容
+func unescapeQuery(s string) string {
+	if v, err := url.QueryUnescape(s); err == nil {
+		return v
+	}
+	return s
+}
+
+// Get 获取第一个同名项的值，名称区分大小写
+func (f Fields) Get(name string) string {
+	for _, field := range f {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// GetFold 获取第一个同名项的值，名称不区分大小写，用于请求头
+func (f Fields) GetFold(name string) string {
+	for _, field := range f {
+		if strings.EqualFold(field.Name, name) {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Add 添加一项
+func (f *Fields) Add(name, value string) {
+	*f = append(*f, Field{Name: name, Value: value})
+}
+
+// Names 按出现顺序返回名称，同名项只返回一次；foldCase为true时名称不区分大小写
+func (f Fields) Names(foldCase bool) []string {
+	seen := make(map[string]bool, len(f))
+	names := make([]string, 0, len(f))
+	for _, field := range f {
+		key := field.Name
+		if foldCase {
+			key = strings.ToLower(key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+// Duplicates 按第一次出现的顺序返回出现多次的名称；foldCase为true时名称不区分大小写
+func (f Fields) Duplicates(foldCase bool) []string {
+	counts := make(map[string]int, len(f))
+	for _, field := range f {
+		key := field.Name
+		if foldCase {
+			key = strings.ToLower(key)
+		}
+		counts[key]++
+	}
+	var names []string
+	for _, name := range f.Names(foldCase) {
+		key := name
+		if foldCase {
+			key = strings.ToLower(key)
+		}
+		if counts[key] > 1 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Map 转换为映射，同名项只保留第一个
+func (f Fields) Map() map[string]string {
+	if f == nil {
+		return nil
+	}
+	m := make(map[string]string, len(f))
+	for _, field := range f {
+		if _, ok := m[field.Name]; !ok {
+			m[field.Name] = field.Value
+		}
+	}
+	return m
+}
+
+// MarshalJSON 没有同名项和原始值时编码为对象，与原来的格式相同，否则编码为数组
+func (f Fields) MarshalJSON() ([]byte, error) {
+	if f == nil {
+		return []byte("null"), nil
+	}
+	seen := make(map[string]bool, len(f))
+	for _, field := range f {
+		if seen[field.Name] || field.Raw != "" {
+			return json.Marshal([]Field(f))
+		}
+		seen[field.Name] = true
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range f {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(field.Name)
+		value, _ := json.Marshal(field.Value)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON 按出现顺序解码对象或数组
+func (f *Fields) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*f = nil
+		return nil
+	case len(data) > 0 && data[0] == '[':
+		return f.unmarshalArray(data)
+	case len(data) > 0 && data[0] == '{':
+		return f.unmarshalObject(data)
+	}
+	return fmt.Errorf("字段集合必须是对象或数组")
+}
+
+// unmarshalArray 解码 [{"name": "a", "value": "1"}, ["a", "2"]]
+func (f *Fields) unmarshalArray(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	fields := make(Fields, 0, len(items))
+	for _, item := range items {
+		var pair []string
+		if err := json.Unmarshal(item, &pair); err == nil {
+			if len(pair) != 2 {
+				return fmt.Errorf("字段数组项必须是[名称, 值]")
+			}
+			fields = append(fields, Field{Name: pair[0], Value: pair[1]})
+			continue
+		}
+		var field Field
+		if err := json.Unmarshal(item, &field); err != nil {
+			return fmt.Errorf("字段数组项必须是{name, value}对象或[名称, 值]: %v", err)
+		}
+		fields = append(fields, field)
+	}
+	*f = fields
+	return nil
+}
+
+// unmarshalObject 按键的出现顺序解码 {"a": "1", "b": ["2", "3"]}
+func (f *Fields) unmarshalObject(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	fields := Fields{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			fields = append(fields, Field{Name: name, Value: value})
+			continue
+		}
+		var values []string
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return fmt.Errorf("字段%s的值必须是字符串或字符串数组", name)
+		}
+		for _, v := range values {
+			fields = append(fields, Field{Name: name, Value: v})
+		}
+	}
+	*f = fields
+	return nil
+}
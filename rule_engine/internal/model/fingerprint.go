@@ -0,0 +1,9 @@
+package model
+
+// FingerprintInfo 客户端TLS/HTTP指纹
+type FingerprintInfo struct {
+	JA3    string `json:"ja3"`     // JA3指纹(MD5)
+	JA3Raw string `json:"ja3_raw"` // JA3原始字符串
+	JA4    string `json:"ja4"`     // JA4指纹
+	HTTP2  string `json:"http2"`   // HTTP/2 SETTINGS等帧组成的指纹(Akamai格式)
+}
@@ -0,0 +1,10 @@
+package model
+
+// GeoInfo 请求来源的地理位置与自治系统信息
+type GeoInfo struct {
+	Country string `json:"country"` // 国家ISO代码，如 CN、US
+	Region  string `json:"region"`  // 省/州ISO代码，如 BJ、CA
+	City    string `json:"city"`    // 城市名称（英文）
+	ASN     uint64 `json:"asn"`     // 自治系统号
+	ASOrg   string `json:"as_org"`  // 自治系统组织名称
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// HealthStatus 健康状态
+type HealthStatus string
+
+const (
+	HealthUp       HealthStatus = "up"       // 正常
+	HealthDegraded HealthStatus = "degraded" // 可用但响应慢、数据过期或依赖异常
+	HealthDown     HealthStatus = "down"     // 不可用
+)
+
+// ComponentHealth 单个组件的检查结果
+type ComponentHealth struct {
+	Name      string                 `json:"name"`
+	Status    HealthStatus           `json:"status"`
+	Critical  bool                   `json:"critical"`          // 关键组件不可用时服务未就绪
+	LatencyMs int64                  `json:"latency_ms"`        // 检查耗时(毫秒)
+	Message   string                 `json:"message,omitempty"` // 异常原因
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport 服务健康报告
+type HealthReport struct {
+	Status     HealthStatus       `json:"status"`
+	Ready      bool               `json:"ready"`
+	NodeID     string             `json:"node_id,omitempty"`
+	Uptime     int64              `json:"uptime"` // 运行时间(秒)
+	CheckedAt  time.Time          `json:"checked_at"`
+	Components []*ComponentHealth `json:"components,omitempty"`
+}
+
+// RuleSnapshotStatus 规则快照状态
+type RuleSnapshotStatus struct {
+	Loaded        bool      `json:"loaded"`         // 是否已加载过快照
+	Version       int64     `json:"version"`        // 快照对应的规则版本
+	LatestVersion int64     `json:"latest_version"` // 最近一次检查时数据库中的规则版本
+	RuleCount     int       `json:"rule_count"`     // 快照中的启用规则数
+	LoadedAt      time.Time `json:"loaded_at"`      // 快照加载时间
+	CheckedAt     time.Time `json:"checked_at"`     // 最近一次版本检查时间
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// VersionLag 快照落后数据库的版本数
+func (s *RuleSnapshotStatus) VersionLag() int64 {
+	if s.LatestVersion <= s.Version {
+		return 0
+	}
+	return s.LatestVersion - s.Version
+}
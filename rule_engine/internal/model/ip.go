@@ -3,6 +3,9 @@ package model
 import (
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/xwaf/rule_engine/internal/errors"
@@ -16,6 +19,24 @@ const (
 	IPListTypeBlack IPListType = "black" // 黑名单
 )
 
+// IPEntryType 名单条目类型
+type IPEntryType string
+
+const (
+	IPEntryTypeIP      IPEntryType = "ip"      // IP地址
+	IPEntryTypeCountry IPEntryType = "country" // 国家ISO代码
+	IPEntryTypeASN     IPEntryType = "asn"     // 自治系统号
+	IPEntryTypeJA3     IPEntryType = "ja3"     // TLS JA3指纹
+	IPEntryTypeJA4     IPEntryType = "ja4"     // TLS JA4指纹
+	IPEntryTypeHTTP2   IPEntryType = "http2"   // HTTP/2指纹
+)
+
+// 指纹格式
+var (
+	ja3Pattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	ja4Pattern = regexp.MustCompile(`^[tqd][0-9s][0-9][di][0-9]{4}[0-9a-zA-Z]{2}_[0-9a-f]{12}_[0-9a-f]{12}$`)
+)
+
 // BlockType 封禁类型
 type BlockType string
 
@@ -26,35 +47,71 @@ const (
 
 // IPRule IP 规则
 type IPRule struct {
-	ID          int64      `json:"id" db:"id"`                   // 规则ID
-	IP          string     `json:"ip" db:"ip"`                   // IP地址
-	IPType      IPListType `json:"ip_type" db:"ip_type"`         // IP类型（黑/白名单）
-	BlockType   BlockType  `json:"block_type" db:"block_type"`   // 封禁类型
-	ExpireTime  time.Time  `json:"expire_time" db:"expire_time"` // 过期时间（临时封禁用）
-	Description string     `json:"description" db:"description"` // 规则描述
-	CreatedBy   int64      `json:"created_by" db:"created_by"`   // 创建者
-	UpdatedBy   int64      `json:"updated_by" db:"updated_by"`   // 更新者
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`   // 创建时间
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`   // 更新时间
+	ID          int64       `json:"id" db:"id"`                   // 规则ID
+	EntryType   IPEntryType `json:"entry_type" db:"entry_type"`   // 条目类型（IP/国家/ASN）
+	IP          string      `json:"ip" db:"ip"`                   // IP地址，或国家代码、ASN
+	IPType      IPListType  `json:"ip_type" db:"ip_type"`         // IP类型（黑/白名单）
+	BlockType   BlockType   `json:"block_type" db:"block_type"`   // 封禁类型
+	ExpireTime  time.Time   `json:"expire_time" db:"expire_time"` // 过期时间（临时封禁用）
+	Description string      `json:"description" db:"description"` // 规则描述
+	CreatedBy   int64       `json:"created_by" db:"created_by"`   // 创建者
+	UpdatedBy   int64       `json:"updated_by" db:"updated_by"`   // 更新者
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`   // 创建时间
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`   // 更新时间
 }
 
 // IPRuleQuery IP 规则查询参数
 type IPRuleQuery struct {
-	Page      int        `form:"page"`       // 页码
-	Size      int        `form:"size"`       // 每页大小
-	Keyword   string     `form:"keyword"`    // 关键词
-	IPType    IPListType `form:"ip_type"`    // IP类型
-	BlockType BlockType  `form:"block_type"` // 封禁类型
+	Page      int         `form:"page"`       // 页码
+	Size      int         `form:"size"`       // 每页大小
+	Keyword   string      `form:"keyword"`    // 关键词
+	EntryType IPEntryType `form:"entry_type"` // 条目类型
+	IPType    IPListType  `form:"ip_type"`    // IP类型
+	BlockType BlockType   `form:"block_type"` // 封禁类型
 }
 
 // Validate 验证 IP 规则
 func (r *IPRule) Validate() error {
-	// 验证IP地址
+	// 验证条目内容
 	if r.IP == "" {
 		return errors.NewError(errors.ErrRuleValidation, "IP地址不能为空")
 	}
-	if ip := net.ParseIP(r.IP); ip == nil {
-		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的IP地址: %s", r.IP))
+	if r.EntryType == "" {
+		r.EntryType = IPEntryTypeIP
+	}
+	switch r.EntryType {
+	case IPEntryTypeIP:
+		if ip := net.ParseIP(r.IP); ip == nil {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的IP地址: %s", r.IP))
+		}
+	case IPEntryTypeCountry:
+		r.IP = strings.ToUpper(strings.TrimSpace(r.IP))
+		if len(r.IP) != 2 || r.IP[0] < 'A' || r.IP[0] > 'Z' || r.IP[1] < 'A' || r.IP[1] > 'Z' {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的国家代码: %s", r.IP))
+		}
+	case IPEntryTypeASN:
+		asn, err := ParseASN(r.IP)
+		if err != nil {
+			return err
+		}
+		r.IP = strconv.FormatUint(asn, 10)
+	case IPEntryTypeJA3:
+		r.IP = strings.ToLower(strings.TrimSpace(r.IP))
+		if !ja3Pattern.MatchString(r.IP) {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的JA3指纹: %s", r.IP))
+		}
+	case IPEntryTypeJA4:
+		r.IP = strings.TrimSpace(r.IP)
+		if !ja4Pattern.MatchString(r.IP) {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的JA4指纹: %s", r.IP))
+		}
+	case IPEntryTypeHTTP2:
+		r.IP = strings.TrimSpace(r.IP)
+		if len(r.IP) > 255 || strings.Count(r.IP, "|") != 3 {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的HTTP/2指纹: %s", r.IP))
+		}
+	default:
+		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的条目类型: %s", r.EntryType))
 	}
 
 	// 验证IP类型
@@ -85,3 +142,16 @@ func (r *IPRule) Validate() error {
 
 	return nil
 }
+
+// ParseASN 解析自治系统号，支持 "13335" 和 "AS13335" 两种写法
+func ParseASN(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > 2 && strings.EqualFold(value[:2], "AS") {
+		value = value[2:]
+	}
+	asn, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || asn == 0 {
+		return 0, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的ASN: %s", value))
+	}
+	return asn, nil
+}
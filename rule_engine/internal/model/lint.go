@@ -0,0 +1,82 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintLevel 检查结果级别
+type LintLevel string
+
+const (
+	LintError   LintLevel = "error"   // 错误，阻止保存规则
+	LintWarning LintLevel = "warning" // 警告，仅提示
+)
+
+// LintCode 检查项
+type LintCode string
+
+const (
+	LintInvalidPattern   LintCode = "invalid_pattern"     // 正则表达式无法编译
+	LintPatternTooLong   LintCode = "pattern_too_long"    // 超过pattern字段长度
+	LintMatchesEmpty     LintCode = "matches_empty"       // 可匹配空字符串
+	LintMatchesAll       LintCode = "matches_all"         // 可匹配任意输入
+	LintRegexCost        LintCode = "regex_cost"          // 正则匹配代价过高
+	LintNestedQuantifier LintCode = "nested_quantifier"   // 嵌套的无界量词
+	LintShadowed         LintCode = "shadowed"            // 被更高优先级的规则覆盖
+	LintOverlap          LintCode = "allow_block_overlap" // 允许和阻止规则匹配范围重叠
+)
+
+// LintFinding 规则检查结果
+type LintFinding struct {
+	Level         LintLevel `json:"level"`                     // 级别
+	Code          LintCode  `json:"code"`                      // 检查项
+	RuleID        int64     `json:"rule_id"`                   // 规则ID，新建规则为0
+	RuleName      string    `json:"rule_name"`                 // 规则名称
+	RelatedRuleID int64     `json:"related_rule_id,omitempty"` // 相关规则ID
+	RelatedName   string    `json:"related_rule_name,omitempty"`
+	Message       string    `json:"message"` // 说明
+}
+
+// LintReport 规则检查报告
+type LintReport struct {
+	Findings []*LintFinding `json:"findings"` // 检查结果
+	Errors   int            `json:"errors"`   // 错误数
+	Warnings int            `json:"warnings"` // 警告数
+	// SkippedTypes 匹配模式不是正则的规则类型，只检查了模式长度以及uri_pattern和规则变量中的正则
+	SkippedTypes []RuleType `json:"skipped_types,omitempty"`
+}
+
+// Skip 记录未做正则检查的规则类型，同一类型只记录一次
+func (r *LintReport) Skip(t RuleType) {
+	for _, skipped := range r.SkippedTypes {
+		if skipped == t {
+			return
+		}
+	}
+	r.SkippedTypes = append(r.SkippedTypes, t)
+}
+
+// Add 添加检查结果
+func (r *LintReport) Add(f *LintFinding) {
+	r.Findings = append(r.Findings, f)
+	if f.Level == LintError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+}
+
+// HasErrors 是否存在错误
+func (r *LintReport) HasErrors() bool {
+	return r.Errors > 0
+}
+
+// String 用于日志和错误信息
+func (r *LintReport) String() string {
+	parts := make([]string, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		parts = append(parts, fmt.Sprintf("%s[%s] %s: %s", f.Level, f.Code, f.RuleName, f.Message))
+	}
+	return fmt.Sprintf("错误%d个, 警告%d个; %s", r.Errors, r.Warnings, strings.Join(parts, "; "))
+}
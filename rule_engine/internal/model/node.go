@@ -0,0 +1,156 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// NodeStatus WAF节点状态
+type NodeStatus string
+
+const (
+	NodeOnline NodeStatus = "online" // 按时上报心跳
+	NodeStale  NodeStatus = "stale"  // 错过心跳，尚未标记为下线
+	NodeDown   NodeStatus = "down"   // 连续错过心跳超过阈值，已标记为下线，重新上报心跳后恢复
+)
+
+const (
+	nodeMaxCounters    = 64  // 单次心跳最多上报的计数项
+	nodeMaxCounterName = 64  // 计数项名称最大长度
+	nodeMaxMessage     = 512 // 健康说明和同步错误最大长度
+	nodeMinTokenLength = 16  // 节点令牌最小长度
+)
+
+// nodeIDPattern 节点标识格式
+var nodeIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+// Node WAF节点(OpenResty)，记录注册信息和最近一次心跳上报的状态
+type Node struct {
+	ID              int64            `json:"id" gorm:"primaryKey"`
+	NodeID          string           `json:"node_id"`                         // 节点标识，注册时由节点提供
+	TokenHash       string           `json:"-"`                               // 节点令牌的SHA-256，不通过接口返回
+	Hostname        string           `json:"hostname"`                        // 主机名
+	Address         string           `json:"address"`                         // 节点地址，未提供时为注册请求的来源IP
+	AgentVersion    string           `json:"agent_version"`                   // 节点程序版本
+	Status          NodeStatus       `json:"status"`                          // 节点状态，stale按心跳时间计算，不保存
+	AppliedVersion  int64            `json:"applied_version"`                 // 节点已应用的规则版本
+	Mode            WAFMode          `json:"mode"`                            // 节点当前运行模式
+	Health          HealthStatus     `json:"health"`                          // 节点上报的健康状态
+	HealthMessage   string           `json:"health_message"`                  // 健康状态说明
+	Counters        map[string]int64 `json:"counters" gorm:"serializer:json"` // 节点本地计数，如请求数、拦截数
+	SyncError       string           `json:"sync_error"`                      // 当前同步错误，同步成功后清空
+	LastSyncError   string           `json:"last_sync_error"`                 // 最近一次同步错误，同步成功后保留
+	LastSyncErrorAt *time.Time       `json:"last_sync_error_at"`              // 最近一次同步错误的上报时间
+	LastHeartbeatAt *time.Time       `json:"last_heartbeat_at"`               // 最近一次心跳时间
+	RegisteredAt    time.Time        `json:"registered_at"`                   // 首次注册时间
+	UpdatedAt       time.Time        `json:"updated_at"`                      // 更新时间
+
+	VersionLag       int64 `json:"version_lag" gorm:"-"`       // 落后引擎规则版本的版本数
+	MissedHeartbeats int   `json:"missed_heartbeats" gorm:"-"` // 已错过的心跳次数
+}
+
+// TableName WAF节点表名
+func (Node) TableName() string {
+	return "waf_nodes"
+}
+
+// NodeRegistration 节点注册请求
+type NodeRegistration struct {
+	NodeID        string `json:"node_id"`       // 节点标识
+	Token         string `json:"token"`         // 节点令牌，首次注册时保存，之后的注册和心跳需提供相同的令牌
+	Hostname      string `json:"hostname"`      // 主机名
+	Address       string `json:"address"`       // 节点地址
+	AgentVersion  string `json:"agent_version"` // 节点程序版本
+	RegisterToken string `json:"-"`             // 注册令牌，引擎配置了注册令牌时必须一致
+}
+
+// Validate 验证注册请求
+func (r *NodeRegistration) Validate() error {
+	if err := ValidateNodeID(r.NodeID); err != nil {
+		return err
+	}
+	if len(r.Token) < nodeMinTokenLength {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("节点令牌长度不能小于%d", nodeMinTokenLength))
+	}
+	if len(r.Hostname) > 255 || len(r.Address) > 255 || len(r.AgentVersion) > 64 {
+		return errors.NewError(errors.ErrValidation, "主机名、地址或程序版本过长")
+	}
+	return nil
+}
+
+// ValidateNodeID 验证节点标识
+func ValidateNodeID(nodeID string) error {
+	if !nodeIDPattern.MatchString(nodeID) {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的节点标识: %q", nodeID))
+	}
+	return nil
+}
+
+// NodeHeartbeat 节点心跳
+type NodeHeartbeat struct {
+	AppliedVersion int64            `json:"applied_version"` // 已应用的规则版本
+	Mode           WAFMode          `json:"mode"`            // 当前运行模式
+	Health         HealthStatus     `json:"health"`          // 健康状态，为空时为up
+	HealthMessage  string           `json:"health_message"`  // 健康状态说明
+	Counters       map[string]int64 `json:"counters"`        // 本地计数
+	SyncError      string           `json:"sync_error"`      // 最近一次规则同步的错误，同步成功时为空
+}
+
+// Validate 验证心跳内容
+func (h *NodeHeartbeat) Validate() error {
+	if h.AppliedVersion < 0 {
+		return errors.NewError(errors.ErrValidation, "规则版本不能为负数")
+	}
+	if h.Mode != "" {
+		if err := (&WAFConfig{Mode: h.Mode}).Validate(); err != nil {
+			return err
+		}
+	}
+	switch h.Health {
+	case "", HealthUp, HealthDegraded, HealthDown:
+	default:
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的健康状态: %s", h.Health))
+	}
+	if len(h.HealthMessage) > nodeMaxMessage || len(h.SyncError) > nodeMaxMessage {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("健康说明和同步错误不能超过%d字节", nodeMaxMessage))
+	}
+	if len(h.Counters) > nodeMaxCounters {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("计数项不能超过%d个", nodeMaxCounters))
+	}
+	for name := range h.Counters {
+		if name == "" || len(name) > nodeMaxCounterName {
+			return errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的计数项名称: %q", name))
+		}
+	}
+	return nil
+}
+
+// NodeHeartbeatAck 注册和心跳的响应，节点按目标版本判断是否需要同步规则
+type NodeHeartbeatAck struct {
+	NodeID            string     `json:"node_id"`            // 节点标识
+	Status            NodeStatus `json:"status"`             // 节点状态
+	TargetVersion     int64      `json:"target_version"`     // 引擎当前的规则版本
+	VersionLag        int64      `json:"version_lag"`        // 落后引擎规则版本的版本数
+	HeartbeatInterval int64      `json:"heartbeat_interval"` // 心跳间隔(秒)
+}
+
+// FleetStatus 节点总体状态
+// 版本差异和落后版本数只统计未下线的节点
+type FleetStatus struct {
+	TargetVersion int64     `json:"target_version"` // 引擎当前的规则版本
+	Total         int       `json:"total"`          // 节点总数
+	Online        int       `json:"online"`         // 在线节点数
+	Stale         int       `json:"stale"`          // 错过心跳的节点数
+	Down          int       `json:"down"`           // 已下线节点数
+	MinVersion    int64     `json:"min_version"`    // 已应用的最低规则版本
+	MaxVersion    int64     `json:"max_version"`    // 已应用的最高规则版本
+	VersionSkew   int64     `json:"version_skew"`   // 节点之间的最大版本差
+	MaxLag        int64     `json:"max_lag"`        // 落后引擎规则版本的最大版本数
+	StaleNodes    []*Node   `json:"stale_nodes"`    // 错过心跳或已下线的节点
+	LaggingNodes  []*Node   `json:"lagging_nodes"`  // 规则版本落后的节点
+	SyncErrors    []*Node   `json:"sync_errors"`    // 当前同步失败的节点
+	CheckedAt     time.Time `json:"checked_at"`     // 统计时间
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// ReleaseAction 发布类型
+type ReleaseAction string
+
+const (
+	ReleaseActionPublish  ReleaseAction = "publish"  // 发布当前规则集
+	ReleaseActionRollback ReleaseAction = "rollback" // 重新发布历史快照
+)
+
+// ReleaseSnapshot 规则集快照
+type ReleaseSnapshot struct {
+	Rules   []*Rule      `json:"rules"`    // 规则
+	Groups  []*RuleGroup `json:"groups"`   // 规则组
+	IPRules []*IPRule    `json:"ip_rules"` // IP/地理位置/指纹名单
+	CCRules []*CCRule    `json:"cc_rules"` // CC防护规则
+	Config  *WAFConfig   `json:"config"`   // WAF配置
+}
+
+// RulesetRelease 规则集发布，发布后不可修改
+type RulesetRelease struct {
+	ID          int64            `json:"id" gorm:"primaryKey"`
+	Action      ReleaseAction    `json:"action"`                      // 发布类型
+	SourceID    int64            `json:"source_id,omitempty"`         // 回滚时的来源发布ID
+	Hash        string           `json:"hash"`                        // 快照内容哈希(SHA-256)
+	RuleCount   int              `json:"rule_count"`                  // 规则数
+	Description string           `json:"description"`                 // 发布说明
+	Content     string           `json:"-"`                           // 快照内容(JSON)
+	Snapshot    *ReleaseSnapshot `json:"snapshot,omitempty" gorm:"-"` // 快照，列表接口不返回
+	CreatedBy   int64            `json:"created_by"`                  // 发布人
+	CreatedAt   time.Time        `json:"created_at"`                  // 发布时间
+}
+
+// TableName 发布表名
+func (RulesetRelease) TableName() string {
+	return "ruleset_releases"
+}
+
+// Seal 按ID排序快照内容并计算哈希，保存发布前调用
+func (r *RulesetRelease) Seal() error {
+	if r.Snapshot == nil {
+		return errors.NewError(errors.ErrValidation, "发布快照不能为空")
+	}
+	s := r.Snapshot
+	sort.Slice(s.Rules, func(i, j int) bool { return s.Rules[i].ID < s.Rules[j].ID })
+	sort.Slice(s.Groups, func(i, j int) bool { return s.Groups[i].ID < s.Groups[j].ID })
+	sort.Slice(s.IPRules, func(i, j int) bool { return s.IPRules[i].ID < s.IPRules[j].ID })
+	sort.Slice(s.CCRules, func(i, j int) bool { return s.CCRules[i].ID < s.CCRules[j].ID })
+
+	content, err := json.Marshal(s)
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("序列化发布快照失败: %v", err))
+	}
+	sum := sha256.Sum256(content)
+	r.Content = string(content)
+	r.Hash = hex.EncodeToString(sum[:])
+	r.RuleCount = len(s.Rules)
+	return nil
+}
+
+// Load 解析快照内容并校验哈希
+func (r *RulesetRelease) Load() error {
+	sum := sha256.Sum256([]byte(r.Content))
+	if hex.EncodeToString(sum[:]) != r.Hash {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("发布快照哈希校验失败: release_id=%d", r.ID))
+	}
+	var s ReleaseSnapshot
+	if err := json.Unmarshal([]byte(r.Content), &s); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("解析发布快照失败: %v", err))
+	}
+	r.Snapshot = &s
+	return nil
+}
+
+// ReleaseItemDiff 规则组、名单、CC规则的变更
+type ReleaseItemDiff struct {
+	ID         int64          `json:"id"`          // 条目ID
+	Name       string         `json:"name"`        // 名称、IP或URI
+	UpdateType RuleUpdateType `json:"update_type"` // 变更类型
+}
+
+// ReleaseDiff 两次发布之间的差异
+type ReleaseDiff struct {
+	FromID        int64              `json:"from_id"`        // 起始发布ID
+	ToID          int64              `json:"to_id"`          // 目标发布ID，0表示当前未发布的规则集
+	Rules         []*RuleDiff        `json:"rules"`          // 规则变更
+	Groups        []*ReleaseItemDiff `json:"groups"`         // 规则组变更
+	IPRules       []*ReleaseItemDiff `json:"ip_rules"`       // 名单变更
+	CCRules       []*ReleaseItemDiff `json:"cc_rules"`       // CC规则变更
+	ConfigChanged bool               `json:"config_changed"` // WAF配置是否变化
+}
+
+// DiffSnapshots 比较两个快照，条目按ID对应，忽略创建和更新时间
+func DiffSnapshots(from, to *ReleaseSnapshot) *ReleaseDiff {
+	diff := &ReleaseDiff{
+		Rules:   make([]*RuleDiff, 0),
+		Groups:  make([]*ReleaseItemDiff, 0),
+		IPRules: make([]*ReleaseItemDiff, 0),
+		CCRules: make([]*ReleaseItemDiff, 0),
+	}
+
+	// 规则
+	oldRules := make(map[int64]*Rule, len(from.Rules))
+	for _, r := range from.Rules {
+		oldRules[r.ID] = r
+	}
+	now := time.Now()
+	for _, r := range to.Rules {
+		old, ok := oldRules[r.ID]
+		delete(oldRules, r.ID)
+		switch {
+		case !ok:
+			diff.Rules = append(diff.Rules, newRuleDiff(r, RuleUpdateTypeCreate, now))
+		case !sameRule(old, r):
+			diff.Rules = append(diff.Rules, newRuleDiff(r, RuleUpdateTypeUpdate, now))
+		}
+	}
+	for _, r := range from.Rules {
+		if _, ok := oldRules[r.ID]; ok {
+			diff.Rules = append(diff.Rules, newRuleDiff(r, RuleUpdateTypeDelete, now))
+		}
+	}
+
+	// 规则组、名单、CC规则
+	diff.Groups = diffItems(groupItems(from.Groups), groupItems(to.Groups))
+	diff.IPRules = diffItems(ipItems(from.IPRules), ipItems(to.IPRules))
+	diff.CCRules = diffItems(ccItems(from.CCRules), ccItems(to.CCRules))
+
+	// 配置只比较运行模式和描述
+	switch {
+	case from.Config == nil || to.Config == nil:
+		diff.ConfigChanged = from.Config != to.Config
+	default:
+		diff.ConfigChanged = from.Config.Mode != to.Config.Mode || from.Config.Description != to.Config.Description
+	}
+	return diff
+}
+
+func newRuleDiff(r *Rule, updateType RuleUpdateType, now time.Time) *RuleDiff {
+	return &RuleDiff{
+		RuleID:     r.ID,
+		Name:       r.Name,
+		Pattern:    r.Pattern,
+		Action:     r.Action,
+		Status:     r.Status,
+		Version:    r.Version,
+		UpdateType: updateType,
+		UpdateTime: now,
+	}
+}
+
+// sameRule 比较规则内容，忽略时间和操作人
+func sameRule(a, b *Rule) bool {
+	x, y := *a, *b
+	x.CreatedAt, y.CreatedAt = time.Time{}, time.Time{}
+	x.UpdatedAt, y.UpdatedAt = time.Time{}, time.Time{}
+	x.CreatedBy, y.CreatedBy = 0, 0
+	x.UpdatedBy, y.UpdatedBy = 0, 0
+	return x == y
+}
+
+// releaseItem 用于比较的条目，content 为去掉时间字段后的内容
+type releaseItem struct {
+	id      int64
+	name    string
+	content interface{}
+}
+
+func groupItems(groups []*RuleGroup) []releaseItem {
+	items := make([]releaseItem, 0, len(groups))
+	for _, g := range groups {
+		c := *g
+		c.CreatedAt, c.UpdatedAt = time.Time{}, time.Time{}
+		items = append(items, releaseItem{id: g.ID, name: g.Name, content: c})
+	}
+	return items
+}
+
+func ipItems(rules []*IPRule) []releaseItem {
+	items := make([]releaseItem, 0, len(rules))
+	for _, r := range rules {
+		c := *r
+		c.CreatedAt, c.UpdatedAt = time.Time{}, time.Time{}
+		items = append(items, releaseItem{id: r.ID, name: fmt.Sprintf("%s:%s", r.EntryType, r.IP), content: c})
+	}
+	return items
+}
+
+func ccItems(rules []*CCRule) []releaseItem {
+	items := make([]releaseItem, 0, len(rules))
+	for _, r := range rules {
+		c := *r
+		c.CreatedAt, c.UpdatedAt = time.Time{}, time.Time{}
+		items = append(items, releaseItem{id: r.ID, name: r.URI, content: c})
+	}
+	return items
+}
+
+func diffItems(from, to []releaseItem) []*ReleaseItemDiff {
+	diffs := make([]*ReleaseItemDiff, 0)
+	old := make(map[int64]releaseItem, len(from))
+	for _, item := range from {
+		old[item.id] = item
+	}
+	for _, item := range to {
+		prev, ok := old[item.id]
+		delete(old, item.id)
+		switch {
+		case !ok:
+			diffs = append(diffs, &ReleaseItemDiff{ID: item.id, Name: item.name, UpdateType: RuleUpdateTypeCreate})
+		case !reflect.DeepEqual(prev.content, item.content):
+			diffs = append(diffs, &ReleaseItemDiff{ID: item.id, Name: item.name, UpdateType: RuleUpdateTypeUpdate})
+		}
+	}
+	for _, item := range from {
+		if _, ok := old[item.id]; ok {
+			diffs = append(diffs, &ReleaseItemDiff{ID: item.id, Name: item.name, UpdateType: RuleUpdateTypeDelete})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,49 @@
+package model
+
+// BodyEncodingBase64 请求体为base64编码，用于通过JSON接口提交压缩或二进制请求体
+const BodyEncodingBase64 = "base64"
+
+// 请求体处理器
+const (
+	BodyProcessorURLEncoded = "urlencoded" // application/x-www-form-urlencoded
+	BodyProcessorMultipart  = "multipart"  // multipart/form-data
+	BodyProcessorJSON       = "json"       // application/json 及 +json 类型
+	BodyProcessorXML        = "xml"        // application/xml、text/xml 及 +xml 类型
+)
+
+// RequestBody 按Content-Type解析后的请求体，由引擎在规则匹配前填充
+type RequestBody struct {
+	Processor string        `json:"processor,omitempty"` // 使用的处理器，未识别的类型为空
+	Form      Fields        `json:"form,omitempty"`      // 表单字段和multipart非文件字段，表单字段保留URL解码前的原始值
+	JSON      Fields        `json:"json,omitempty"`      // JSON叶子节点，名称如 user.tags[0]
+	XML       Fields        `json:"xml,omitempty"`       // XML元素文本和属性，名称如 /order/item/@id
+	Files     []*UploadFile `json:"files,omitempty"`     // multipart请求中上传的文件
+	Error     string        `json:"error,omitempty"`     // 解压、解析失败或超出限制的原因，已解析的字段仍然保留
+
+	BlockReason string `json:"block_reason,omitempty"` // 按配置需要拦截的原因，如请求体无法完整解码，为空时不拦截
+}
+
+// Fields 规则变量对应的字段，非请求体字段变量时返回nil
+func (b *RequestBody) Fields(variable RuleVariable) Fields {
+	if b == nil {
+		return nil
+	}
+	switch variable {
+	case RuleVarRequestArgsPost:
+		return b.Form
+	case RuleVarRequestBodyJSON:
+		return b.JSON
+	case RuleVarRequestBodyXML:
+		return b.XML
+	}
+	return nil
+}
+
+// IsBodyFieldVariable 判断规则变量是否为请求体解析出的字段
+func IsBodyFieldVariable(v RuleVariable) bool {
+	switch v {
+	case RuleVarRequestArgsPost, RuleVarRequestBodyJSON, RuleVarRequestBodyXML:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"unicode/utf8"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// ResponseData 响应阶段检查的响应数据
+type ResponseData struct {
+	StatusCode int               `json:"status_code"` // 响应状态码
+	Headers    map[string]string `json:"headers"`     // 响应头
+	Body       string            `json:"body"`        // 响应体片段
+}
+
+// ResponseCheckRequest 响应检查请求，由前端或代理在收到上游响应后提交
+type ResponseCheckRequest struct {
+	RequestID  string            `json:"request_id"`  // 请求阶段检查时使用的请求ID
+	StatusCode int               `json:"status_code"` // 响应状态码
+	Headers    map[string]string `json:"headers"`     // 响应头
+	Body       string            `json:"body"`        // 响应体片段（可只提交开头部分）
+	RuleTypes  []RuleType        `json:"rule_types"`  // 检查的规则类型，为空时检查所有类型
+}
+
+// Validate 验证请求参数
+func (r *ResponseCheckRequest) Validate() error {
+	if r.RequestID == "" {
+		return errors.NewError(errors.ErrValidation, "request_id不能为空")
+	}
+	if r.StatusCode < 100 || r.StatusCode > 599 {
+		return errors.NewError(errors.ErrValidation, "status_code必须在100-599之间")
+	}
+	return nil
+}
+
+// TruncateBody 将响应体截断到最大长度，保证不截断多字节字符，返回是否发生截断
+func (r *ResponseCheckRequest) TruncateBody(maxSize int) bool {
+	if maxSize <= 0 || len(r.Body) <= maxSize {
+		return false
+	}
+	n := maxSize
+	for n > 0 && !utf8.RuneStart(r.Body[n]) {
+		n--
+	}
+	r.Body = r.Body[:n]
+	return true
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/xwaf/rule_engine/internal/errors"
@@ -12,24 +13,44 @@ import (
 type RuleVariable string
 
 const (
-	RuleVarRequestURI     RuleVariable = "request_uri"
-	RuleVarRequestHeaders RuleVariable = "request_headers"
-	RuleVarRequestArgs    RuleVariable = "request_args"
-	RuleVarRequestBody    RuleVariable = "request_body"
-	RuleVarRequestMethod  RuleVariable = "request_method"
-	RuleVarResponse       RuleVariable = "response"
+	RuleVarRequestURI      RuleVariable = "request_uri"
+	RuleVarRequestHeaders  RuleVariable = "request_headers"
+	RuleVarRequestArgs     RuleVariable = "request_args"
+	RuleVarRequestBody     RuleVariable = "request_body"
+	RuleVarRequestMethod   RuleVariable = "request_method"
+	RuleVarRequestArgsPost RuleVariable = "request_args_post"         // 表单和multipart字段
+	RuleVarRequestBodyJSON RuleVariable = "request_body_json"         // JSON请求体叶子节点
+	RuleVarRequestBodyXML  RuleVariable = "request_body_xml"          // XML请求体元素文本和属性
+	RuleVarRequestBodyErr  RuleVariable = "request_body_error"        // 请求体解压、解析失败或超出限制的原因
+	RuleVarResponse        RuleVariable = "response"                  // 响应头和响应体
+	RuleVarResponseStatus  RuleVariable = "response_status"           // 响应状态码
+	RuleVarResponseHeaders RuleVariable = "response_headers"          // 响应头
+	RuleVarResponseBody    RuleVariable = "response_body"             // 响应体
+	RuleVarGeoCountry      RuleVariable = "request_geo_country"       // 来源国家ISO代码
+	RuleVarGeoRegion       RuleVariable = "request_geo_region"        // 来源省/州ISO代码
+	RuleVarGeoASN          RuleVariable = "request_geo_asn"           // 来源自治系统号
+	RuleVarBotCategory     RuleVariable = "request_bot_category"      // 客户端分类
+	RuleVarBotName         RuleVariable = "request_bot_name"          // 命中的客户端签名
+	RuleVarBotScore        RuleVariable = "request_bot_score"         // 机器人评分
+	RuleVarJA3             RuleVariable = "request_ja3"               // TLS JA3指纹
+	RuleVarJA4             RuleVariable = "request_ja4"               // TLS JA4指纹
+	RuleVarHTTP2           RuleVariable = "request_http2_fingerprint" // HTTP/2指纹
 )
 
 // RuleType 规则类型
 type RuleType string
 
 const (
-	RuleTypeIP     RuleType = "ip"     // IP规则
-	RuleTypeCC     RuleType = "cc"     // CC规则
-	RuleTypeRegex  RuleType = "regex"  // 正则规则
-	RuleTypeSQLi   RuleType = "sqli"   // SQL注入规则
-	RuleTypeXSS    RuleType = "xss"    // XSS规则
-	RuleTypeCustom RuleType = "custom" // 自定义规则
+	RuleTypeIP          RuleType = "ip"          // IP规则
+	RuleTypeCC          RuleType = "cc"          // CC规则
+	RuleTypeRegex       RuleType = "regex"       // 正则规则
+	RuleTypeSQLi        RuleType = "sqli"        // SQL注入规则
+	RuleTypeXSS         RuleType = "xss"         // XSS规则
+	RuleTypeGeo         RuleType = "geo"         // 地理位置/ASN规则
+	RuleTypeBot         RuleType = "bot"         // 机器人规则
+	RuleTypeFingerprint RuleType = "fingerprint" // 客户端指纹规则
+	RuleTypeLeakage     RuleType = "leakage"     // 响应信息泄露规则
+	RuleTypeCustom      RuleType = "custom"      // 自定义规则
 )
 
 // ActionType 动作类型
@@ -41,6 +62,15 @@ const (
 	ActionLog      ActionType = "log"      // 记录日志
 	ActionRedirect ActionType = "redirect" // 重定向
 	ActionCaptcha  ActionType = "captcha"  // 验证码
+	ActionMask     ActionType = "mask"     // 响应脱敏
+)
+
+// RulePhase 规则检查阶段
+type RulePhase string
+
+const (
+	RulePhaseRequest  RulePhase = "request"  // 请求阶段（默认）
+	RulePhaseResponse RulePhase = "response" // 响应阶段
 )
 
 // StatusType 状态类型
@@ -140,25 +170,31 @@ const (
 
 // Rule 规则定义
 type Rule struct {
-	ID             int64        `json:"id" db:"id"`
-	GroupID        int64        `json:"group_id" db:"group_id"`
-	Name           string       `json:"name" db:"name"`
-	Description    string       `json:"description" db:"description"`
-	Pattern        string       `json:"pattern" db:"pattern"`
-	Params         string       `json:"params" db:"params"`
-	Type           RuleType     `json:"type" db:"type"`
-	RuleVariable   RuleVariable `json:"rule_variable" db:"rule_variable"`
-	Action         ActionType   `json:"action" db:"action"`
-	Priority       int          `json:"priority" db:"priority"`
-	Status         StatusType   `json:"status" db:"status"`
-	Severity       SeverityType `json:"severity" db:"severity"`
-	RulesOperation string       `json:"rules_operation" db:"rules_operation"`
-	Version        int64        `json:"version" db:"version"`
-	Hash           string       `json:"hash" db:"hash"`
-	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`
-	CreatedBy      int64        `json:"created_by" db:"created_by"`
-	UpdatedBy      int64        `json:"updated_by" db:"updated_by"`
+	ID              int64        `json:"id" db:"id"`
+	GroupID         int64        `json:"group_id" db:"group_id"`
+	Name            string       `json:"name" db:"name"`
+	Description     string       `json:"description" db:"description"`
+	Pattern         string       `json:"pattern" db:"pattern"`
+	Params          string       `json:"params" db:"params"`
+	Type            RuleType     `json:"type" db:"type"`
+	RuleVariable    RuleVariable `json:"rule_variable" db:"rule_variable"`
+	Phase           RulePhase    `json:"phase" db:"phase"`
+	Action          ActionType   `json:"action" db:"action"`
+	Priority        int          `json:"priority" db:"priority"`
+	Status          StatusType   `json:"status" db:"status"`
+	Severity        SeverityType `json:"severity" db:"severity"`
+	RulesOperation  string       `json:"rules_operation" db:"rules_operation"`
+	Version         int64        `json:"version" db:"version"`
+	Hash            string       `json:"hash" db:"hash"`
+	TemplateID      string       `json:"template_id,omitempty" db:"template_id"`           // 创建规则的模板ID
+	TemplateVersion int          `json:"template_version,omitempty" db:"template_version"` // 创建规则时的模板版本
+	StartTime       int64        `json:"start_time,omitempty" db:"start_time"`             // 生效时间(Unix秒)，0表示立即生效
+	EndTime         int64        `json:"end_time,omitempty" db:"end_time"`                 // 过期时间(Unix秒)，0表示不过期
+	Schedule        string       `json:"schedule,omitempty" db:"schedule"`                 // 周期生效计划，例如"mon-fri 02:00-04:00"，为空表示全天生效
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`
+	CreatedBy       int64        `json:"created_by" db:"created_by"`
+	UpdatedBy       int64        `json:"updated_by" db:"updated_by"`
 }
 
 // ValidateXSSRule 验证XSS规则
@@ -192,7 +228,7 @@ func (r *Rule) Validate() error {
 
 	// 验证规则类型的合法性
 	switch r.Type {
-	case RuleTypeIP, RuleTypeCC, RuleTypeRegex, RuleTypeSQLi, RuleTypeXSS, RuleTypeCustom:
+	case RuleTypeIP, RuleTypeCC, RuleTypeRegex, RuleTypeSQLi, RuleTypeXSS, RuleTypeGeo, RuleTypeBot, RuleTypeFingerprint, RuleTypeLeakage, RuleTypeCustom:
 		// 合法的规则类型
 	default:
 		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的规则类型: %s", r.Type))
@@ -200,12 +236,84 @@ func (r *Rule) Validate() error {
 
 	// 验证动作类型的合法性
 	switch r.Action {
-	case ActionBlock, ActionAllow, ActionLog, ActionRedirect, ActionCaptcha:
+	case ActionBlock, ActionAllow, ActionLog, ActionRedirect, ActionCaptcha, ActionMask:
 		// 合法的动作类型
 	default:
 		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的动作类型: %s", r.Action))
 	}
 
+	if err := r.ValidateSchedule(); err != nil {
+		return err
+	}
+	if err := r.validateVariable(); err != nil {
+		return err
+	}
+	return r.validatePhase()
+}
+
+// maxRuleVariableLength 规则变量最大长度，与数据库字段长度相同
+const maxRuleVariableLength = 512
+
+// validateVariable 验证请求字段选择器的语法，大写或包含:|!的规则变量均按选择器解析
+func (r *Rule) validateVariable() error {
+	v := string(r.RuleVariable)
+	if len(v) > maxRuleVariableLength {
+		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("规则变量不能超过%d个字符", maxRuleVariableLength))
+	}
+	if v == "" || !IsTargetVariable(r.RuleVariable) && v != strings.ToUpper(v) && !strings.ContainsAny(v, ":|!") {
+		return nil
+	}
+	_, err := ParseTargetSelector(r.RuleVariable)
+	return err
+}
+
+// GetPhase 获取规则检查阶段，未设置时为请求阶段
+func (r *Rule) GetPhase() RulePhase {
+	if r.Phase == "" {
+		return RulePhaseRequest
+	}
+	return r.Phase
+}
+
+// IsResponseVariable 判断规则变量是否属于响应阶段
+func IsResponseVariable(v RuleVariable) bool {
+	switch v {
+	case RuleVarResponse, RuleVarResponseStatus, RuleVarResponseHeaders, RuleVarResponseBody:
+		return true
+	}
+	return false
+}
+
+// validatePhase 验证规则阶段与规则类型、变量和动作是否匹配
+func (r *Rule) validatePhase() error {
+	switch r.GetPhase() {
+	case RulePhaseRequest:
+		if IsResponseVariable(r.RuleVariable) || r.Type == RuleTypeLeakage {
+			return errors.NewError(errors.ErrRuleValidation, "响应变量和信息泄露规则只能用于响应阶段")
+		}
+		if r.Action == ActionMask {
+			return errors.NewError(errors.ErrRuleValidation, "脱敏动作只能用于响应阶段")
+		}
+	case RulePhaseResponse:
+		if r.RuleVariable != "" && !IsResponseVariable(r.RuleVariable) {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("响应阶段不支持的规则变量: %s", r.RuleVariable))
+		}
+		switch r.Action {
+		case ActionBlock, ActionAllow, ActionLog, ActionMask:
+		default:
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("响应阶段不支持的动作类型: %s", r.Action))
+		}
+		if r.Action == ActionMask {
+			if r.Type != RuleTypeLeakage && r.Type != RuleTypeRegex {
+				return errors.NewError(errors.ErrRuleValidation, "脱敏动作只能用于信息泄露规则和正则规则")
+			}
+			if r.RuleVariable != RuleVarResponseBody && r.RuleVariable != RuleVarResponse && r.RuleVariable != "" {
+				return errors.NewError(errors.ErrRuleValidation, "脱敏动作只能用于响应体")
+			}
+		}
+	default:
+		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的规则阶段: %s", r.Phase))
+	}
 	return nil
 }
 
@@ -0,0 +1,225 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// weekdayNames 星期名称
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ScheduleWindow 周期生效时间窗口，结束时间不大于开始时间时跨越午夜
+type ScheduleWindow struct {
+	Days  [7]bool // 按 time.Weekday 索引，窗口开始的星期
+	Start int     // 开始时间(当天分钟数)
+	End   int     // 结束时间(当天分钟数，1440表示24:00)
+}
+
+// Schedule 规则周期生效计划
+//
+// 格式为 "[TZ=时区] 星期 开始-结束[; 星期 开始-结束...]"，例如：
+//
+//	mon-fri 02:00-04:00
+//	TZ=Asia/Shanghai sat,sun 00:00-24:00; * 22:00-02:00
+//
+// 星期支持 mon..sun、区间(mon-fri)、列表(sat,sun) 和 *(每天)；未指定时区时使用服务器本地时区
+type Schedule struct {
+	Location *time.Location
+	Windows  []ScheduleWindow
+}
+
+// scheduleCache 已解析的计划，规则检查时按表达式复用
+var scheduleCache sync.Map
+
+// ParseSchedule 解析周期生效计划
+func ParseSchedule(expr string) (*Schedule, error) {
+	if v, ok := scheduleCache.Load(expr); ok {
+		return v.(*Schedule), nil
+	}
+
+	s := &Schedule{Location: time.Local}
+	body := strings.TrimSpace(expr)
+	if strings.HasPrefix(body, "TZ=") {
+		fields := strings.SplitN(body, " ", 2)
+		loc, err := time.LoadLocation(strings.TrimPrefix(fields[0], "TZ="))
+		if err != nil {
+			return nil, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的时区: %s", fields[0]))
+		}
+		s.Location = loc
+		body = ""
+		if len(fields) == 2 {
+			body = fields[1]
+		}
+	}
+
+	for _, part := range strings.Split(body, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := parseScheduleWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		s.Windows = append(s.Windows, w)
+	}
+	if len(s.Windows) == 0 {
+		return nil, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("生效计划至少包含一个时间窗口: %q", expr))
+	}
+
+	scheduleCache.Store(expr, s)
+	return s, nil
+}
+
+// parseScheduleWindow 解析 "星期 开始-结束"
+func parseScheduleWindow(part string) (ScheduleWindow, error) {
+	var w ScheduleWindow
+	fields := strings.Fields(part)
+	if len(fields) != 2 {
+		return w, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的时间窗口: %q", part))
+	}
+
+	if err := parseScheduleDays(fields[0], &w.Days); err != nil {
+		return w, err
+	}
+
+	span := strings.SplitN(fields[1], "-", 2)
+	if len(span) != 2 {
+		return w, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的时间范围: %q", fields[1]))
+	}
+	var err error
+	if w.Start, err = parseClock(span[0]); err != nil {
+		return w, err
+	}
+	if w.End, err = parseClock(span[1]); err != nil {
+		return w, err
+	}
+	if w.Start == 24*60 {
+		return w, errors.NewError(errors.ErrRuleValidation, "时间窗口不能从24:00开始")
+	}
+	if w.Start == w.End {
+		return w, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("时间窗口的开始和结束不能相同: %q", fields[1]))
+	}
+	return w, nil
+}
+
+// parseScheduleDays 解析星期
+func parseScheduleDays(expr string, days *[7]bool) error {
+	if expr == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return nil
+	}
+	for _, item := range strings.Split(expr, ",") {
+		bounds := strings.SplitN(strings.ToLower(item), "-", 2)
+		from, ok := weekdayNames[bounds[0]]
+		if !ok {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的星期: %q", item))
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, ok = weekdayNames[bounds[1]]; !ok {
+				return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的星期: %q", item))
+			}
+		}
+		// 区间可以跨周，例如 fri-mon
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM，返回当天分钟数
+func parseClock(s string) (int, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) == 2 {
+		h, herr := strconv.Atoi(parts[0])
+		m, merr := strconv.Atoi(parts[1])
+		if herr == nil && merr == nil && h >= 0 && m >= 0 && m < 60 && (h < 24 || h == 24 && m == 0) {
+			return h*60 + m, nil
+		}
+	}
+	return 0, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的时间: %q", s))
+}
+
+// Active 判断时间是否在任一窗口内
+func (s *Schedule) Active(t time.Time) bool {
+	t = t.In(s.Location)
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	for _, w := range s.Windows {
+		if w.Start < w.End {
+			if w.Days[today] && minute >= w.Start && minute < w.End {
+				return true
+			}
+			continue
+		}
+		// 跨越午夜：开始当天的后半段，或开始次日的前半段
+		if w.Days[today] && minute >= w.Start || w.Days[yesterday] && minute < w.End {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSchedule 验证规则的生效时间和周期计划
+func (r *Rule) ValidateSchedule() error {
+	if r.StartTime < 0 || r.EndTime < 0 {
+		return errors.NewError(errors.ErrRuleValidation, "规则生效时间和过期时间不能为负数")
+	}
+	if r.StartTime > 0 && r.EndTime > 0 && r.StartTime >= r.EndTime {
+		return errors.NewError(errors.ErrRuleValidation, "规则生效时间必须早于过期时间")
+	}
+	if r.Schedule != "" {
+		if _, err := ParseSchedule(r.Schedule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ActiveAt 判断规则在指定时间是否处于生效时间范围和周期窗口内，不检查规则状态
+func (r *Rule) ActiveAt(t time.Time) bool {
+	now := t.Unix()
+	if r.StartTime > 0 && now < r.StartTime {
+		return false
+	}
+	if r.EndTime > 0 && now >= r.EndTime {
+		return false
+	}
+	if r.Schedule == "" {
+		return true
+	}
+	s, err := ParseSchedule(r.Schedule)
+	if err != nil {
+		// 保存时已验证，解析失败说明数据被直接修改，按不生效处理
+		return false
+	}
+	return s.Active(t)
+}
+
+// RuleExpiration 即将过期的规则
+type RuleExpiration struct {
+	Rule      *Rule     `json:"rule"`       // 规则
+	ExpiresAt time.Time `json:"expires_at"` // 过期时间
+	Active    bool      `json:"active"`     // 当前是否生效
+}
@@ -0,0 +1,418 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// 目标选择器集合
+const (
+	TargetRequestURI          = "REQUEST_URI"                // 请求URI
+	TargetRequestMethod       = "REQUEST_METHOD"             // 请求方法
+	TargetRequestHost         = "REQUEST_HOST"               // Host请求头
+	TargetQueryString         = "QUERY_STRING"               // URI中?之后的原始查询字符串
+	TargetRequestBody         = "REQUEST_BODY"               // 解压后的请求体
+	TargetRequestHeaders      = "REQUEST_HEADERS"            // 请求头
+	TargetRequestHeadersNames = "REQUEST_HEADERS_NAMES"      // 请求头名称
+	TargetRequestHeadersOrder = "REQUEST_HEADERS_ORDER"      // 按出现顺序以,连接的小写请求头名称，用于请求头顺序指纹
+	TargetRequestHeadersDups  = "REQUEST_HEADERS_DUPLICATES" // 出现多次的小写请求头名称，如重复的host、content-length
+	TargetRequestCookies      = "REQUEST_COOKIES"            // Cookie
+	TargetRequestCookiesNames = "REQUEST_COOKIES_NAMES"      // Cookie名称
+	TargetArgs                = "ARGS"                       // 查询参数和表单字段
+	TargetArgsNames           = "ARGS_NAMES"                 // 查询参数和表单字段名称
+	TargetArgsRaw             = "ARGS_RAW"                   // 查询参数和表单字段URL解码前的原始值
+	TargetArgsDups            = "ARGS_DUPLICATES"            // 在查询参数和表单字段中出现多次的名称，用于检测HTTP参数污染
+	TargetArgsGet             = "ARGS_GET"                   // 查询参数
+	TargetArgsGetNames        = "ARGS_GET_NAMES"             // 查询参数名称
+	TargetArgsPost            = "ARGS_POST"                  // 表单和multipart字段
+	TargetArgsPostNames       = "ARGS_POST_NAMES"            // 表单和multipart字段名称
+	TargetJSON                = "JSON"                       // JSON请求体叶子节点，键为路径，如 $.user.name
+	TargetXML                 = "XML"                        // XML请求体元素和属性，键为路径，如 /order/item/@id
+	TargetFiles               = "FILES"                      // 上传文件的原始文件名，键为表单字段名
+	TargetFilesNames          = "FILES_NAMES"                // 上传文件的表单字段名
+	TargetFilesSizes          = "FILES_SIZES"                // 上传文件大小(字节)，键为表单字段名
+	TargetFilesViolations     = "FILES_VIOLATIONS"           // 上传文件的违规类型，键为表单字段名，如 double_extension
+)
+
+// targetCollection 集合定义
+type targetCollection struct {
+	keyed      bool // 是否支持按键选择
+	ignoreCase bool // 键是否不区分大小写
+}
+
+// targetCollections 支持的集合
+var targetCollections = map[string]targetCollection{
+	TargetRequestURI:          {},
+	TargetRequestMethod:       {},
+	TargetRequestHost:         {},
+	TargetQueryString:         {},
+	TargetRequestBody:         {},
+	TargetRequestHeaders:      {keyed: true, ignoreCase: true},
+	TargetRequestHeadersNames: {keyed: true, ignoreCase: true},
+	TargetRequestHeadersOrder: {},
+	TargetRequestHeadersDups:  {keyed: true, ignoreCase: true},
+	TargetRequestCookies:      {keyed: true},
+	TargetRequestCookiesNames: {keyed: true},
+	TargetArgs:                {keyed: true},
+	TargetArgsNames:           {keyed: true},
+	TargetArgsRaw:             {keyed: true},
+	TargetArgsDups:            {keyed: true},
+	TargetArgsGet:             {keyed: true},
+	TargetArgsGetNames:        {keyed: true},
+	TargetArgsPost:            {keyed: true},
+	TargetArgsPostNames:       {keyed: true},
+	TargetJSON:                {keyed: true},
+	TargetXML:                 {keyed: true},
+	TargetFiles:               {keyed: true},
+	TargetFilesNames:          {keyed: true},
+	TargetFilesSizes:          {keyed: true},
+	TargetFilesViolations:     {keyed: true},
+}
+
+// legacyTargets 原有规则变量对应的集合，检查范围与原来相同
+var legacyTargets = map[RuleVariable]string{
+	RuleVarRequestURI:      TargetRequestURI,
+	RuleVarRequestHeaders:  TargetRequestHeaders,
+	RuleVarRequestArgs:     TargetArgsGet,
+	RuleVarRequestBody:     TargetRequestBody,
+	RuleVarRequestMethod:   TargetRequestMethod,
+	RuleVarRequestArgsPost: TargetArgsPost,
+	RuleVarRequestBodyJSON: TargetJSON,
+	RuleVarRequestBodyXML:  TargetXML,
+}
+
+// TargetValue 目标选择器从请求中取出的值
+type TargetValue struct {
+	Collection string // 值所在的集合，ARGS取出的值为ARGS_GET或ARGS_POST
+	Name       string // 键，不带键的集合为空
+	Value      string // 值，名称集合的值为键本身
+}
+
+// targetItem 选择器中的一项
+type targetItem struct {
+	collection string
+	key        string         // 按键选择，为空时选择整个集合
+	keyRegex   *regexp.Regexp // 按正则选择键
+	userRegex  bool           // 正则由规则变量中的 /正则/ 指定，而非由JSON路径生成
+	exclude    bool           // 以!开头，从其他项的结果中排除
+}
+
+// TargetSelector 规则检查的请求字段
+// 语法为以|分隔的多项，每项为 集合、集合:键 或 集合:/正则/，以!开头的项从结果中排除，
+// 如 "ARGS|REQUEST_HEADERS:User-Agent|!ARGS:password"；也可使用 request_args 等原有规则变量
+type TargetSelector struct {
+	items []targetItem
+}
+
+// IsTargetVariable 判断规则变量是否为请求字段选择器（含原有的请求字段变量）
+// 地理位置、客户端分类、指纹和响应变量由对应的处理器单独处理
+func IsTargetVariable(v RuleVariable) bool {
+	if _, ok := legacyTargets[v]; ok {
+		return true
+	}
+	name := strings.TrimPrefix(strings.TrimSpace(string(v)), "!")
+	if i := strings.IndexAny(name, ":|"); i >= 0 {
+		name = name[:i]
+	}
+	_, ok := targetCollections[strings.ToUpper(strings.TrimSpace(name))]
+	return ok
+}
+
+// ParseTargetSelector 解析规则变量中的选择器
+func ParseTargetSelector(v RuleVariable) (*TargetSelector, error) {
+	if collection, ok := legacyTargets[v]; ok {
+		return &TargetSelector{items: []targetItem{{collection: collection}}}, nil
+	}
+
+	expr := string(v)
+	selector := &TargetSelector{}
+	included := false
+	for pos := 0; pos <= len(expr); {
+		item, next, err := parseTargetItem(expr, pos)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的规则变量 %q: %v", expr, err))
+		}
+		selector.items = append(selector.items, item)
+		included = included || !item.exclude
+		pos = next + 1
+	}
+	if !included {
+		return nil, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的规则变量 %q: 至少需要一个不以!开头的项", expr))
+	}
+	return selector, nil
+}
+
+// parseTargetItem 从pos开始解析一项，返回该项和结束位置(|或字符串末尾)
+func parseTargetItem(expr string, pos int) (targetItem, int, error) {
+	var item targetItem
+	end := pos
+	for end < len(expr) && expr[end] != '|' && expr[end] != ':' {
+		end++
+	}
+	name := strings.TrimSpace(expr[pos:end])
+	if strings.HasPrefix(name, "!") {
+		item.exclude = true
+		name = strings.TrimSpace(name[1:])
+	}
+	item.collection = strings.ToUpper(name)
+	collection, ok := targetCollections[item.collection]
+	if !ok {
+		if name == "" {
+			return item, end, fmt.Errorf("集合名称不能为空")
+		}
+		return item, end, fmt.Errorf("不支持的集合: %s", name)
+	}
+	if end == len(expr) || expr[end] == '|' {
+		return item, end, nil
+	}
+	if !collection.keyed {
+		return item, end, fmt.Errorf("%s不支持按键选择", item.collection)
+	}
+
+	// 键为 /正则/ 时读取到未转义的/为止，正则中可以包含|和:，请求头的键不区分大小写
+	start := end + 1
+	for start < len(expr) && expr[start] == ' ' {
+		start++
+	}
+	if start < len(expr) && expr[start] == '/' {
+		end = start + 1
+		for end < len(expr) && expr[end] != '/' {
+			if expr[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(expr) {
+			return item, end, fmt.Errorf("%s的正则缺少结束的/", item.collection)
+		}
+		pattern := strings.ReplaceAll(expr[start+1:end], `\/`, "/")
+		if collection.ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return item, end, fmt.Errorf("%s的正则无效: %v", item.collection, err)
+		}
+		item.keyRegex = re
+		item.userRegex = true
+		end++
+		for end < len(expr) && expr[end] == ' ' {
+			end++
+		}
+		if end < len(expr) && expr[end] != '|' {
+			return item, end, fmt.Errorf("%s的正则之后只能是|", item.collection)
+		}
+		return item, end, nil
+	}
+
+	end = start
+	for end < len(expr) && expr[end] != '|' {
+		end++
+	}
+	key := strings.TrimSpace(expr[start:end])
+	if key == "" {
+		return item, end, fmt.Errorf("%s的键不能为空", item.collection)
+	}
+	if item.collection == TargetJSON {
+		return jsonPathItem(item, key, end)
+	}
+	item.key = key
+	return item, end, nil
+}
+
+// jsonPathItem JSON路径可以带$.前缀，[*]匹配任意数组下标
+func jsonPathItem(item targetItem, key string, end int) (targetItem, int, error) {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "$"), ".")
+	if key == "" {
+		return item, end, fmt.Errorf("JSON路径不能为空")
+	}
+	if !strings.Contains(key, "[*]") {
+		item.key = key
+		return item, end, nil
+	}
+	pattern := strings.ReplaceAll(regexp.QuoteMeta(key), `\[\*\]`, `\[\d+\]`)
+	item.keyRegex = regexp.MustCompile("^" + pattern + "$")
+	return item, end, nil
+}
+
+// KeyPatterns 规则变量中以 /正则/ 指定的键正则，用于静态检查
+func (s *TargetSelector) KeyPatterns() []string {
+	var patterns []string
+	for _, item := range s.items {
+		if item.userRegex {
+			patterns = append(patterns, item.keyRegex.String())
+		}
+	}
+	return patterns
+}
+
+// Values 从请求中取出选择器对应的值，同一集合中的值按出现顺序，同名字段全部取出
+func (s *TargetSelector) Values(req *CheckRequest) []TargetValue {
+	var values []TargetValue
+	excluded := make(map[[2]string]bool)
+	for _, item := range s.items {
+		for _, value := range collectTarget(req, item.collection) {
+			if !item.matchKey(value.Name) {
+				continue
+			}
+			if item.exclude {
+				excluded[[2]string{value.Collection, value.Name}] = true
+			} else {
+				values = append(values, value)
+			}
+		}
+	}
+	if len(excluded) == 0 {
+		return values
+	}
+	kept := values[:0]
+	for _, value := range values {
+		if !excluded[[2]string{value.Collection, value.Name}] {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// matchKey 判断键是否被该项选择
+func (t *targetItem) matchKey(name string) bool {
+	switch {
+	case t.keyRegex != nil:
+		return t.keyRegex.MatchString(name)
+	case t.key == "":
+		return true
+	case targetCollections[t.collection].ignoreCase:
+		return strings.EqualFold(t.key, name)
+	default:
+		return t.key == name
+	}
+}
+
+// collectTarget 取出集合中的全部值
+func collectTarget(req *CheckRequest, collection string) []TargetValue {
+	scalar := func(value string) []TargetValue {
+		return []TargetValue{{Collection: collection, Value: value}}
+	}
+	switch collection {
+	case TargetRequestURI:
+		return scalar(req.URI)
+	case TargetRequestMethod:
+		return scalar(req.Method)
+	case TargetRequestHost:
+		return scalar(req.Headers.GetFold("Host"))
+	case TargetQueryString:
+		_, query, _ := strings.Cut(req.URI, "?")
+		return scalar(query)
+	case TargetRequestBody:
+		return scalar(req.Body)
+	case TargetRequestHeaders, TargetRequestHeadersNames:
+		return fieldValues(collection, req.Headers)
+	case TargetRequestHeadersOrder:
+		names := make([]string, 0, len(req.Headers))
+		for _, header := range req.Headers {
+			names = append(names, strings.ToLower(header.Name))
+		}
+		return scalar(strings.Join(names, ","))
+	case TargetRequestHeadersDups:
+		names := req.Headers.Duplicates(true)
+		for i := range names {
+			names[i] = strings.ToLower(names[i])
+		}
+		return nameValues(collection, names)
+	case TargetRequestCookies, TargetRequestCookiesNames:
+		return fieldValues(collection, parseCookies(req.Headers.GetFold("Cookie")))
+	case TargetArgsGet, TargetArgsGetNames:
+		return fieldValues(collection, req.QueryArgs())
+	case TargetArgsPost, TargetArgsPostNames:
+		return fieldValues(collection, req.RequestBody.Fields(RuleVarRequestArgsPost))
+	case TargetArgs:
+		return append(collectTarget(req, TargetArgsGet), collectTarget(req, TargetArgsPost)...)
+	case TargetArgsNames:
+		return append(collectTarget(req, TargetArgsGetNames), collectTarget(req, TargetArgsPostNames)...)
+	case TargetArgsRaw:
+		var values []TargetValue
+		for _, fields := range []Fields{req.QueryArgs(), req.RequestBody.Fields(RuleVarRequestArgsPost)} {
+			for _, field := range fields {
+				values = append(values, TargetValue{Collection: collection, Name: field.Name, Value: field.RawValue()})
+			}
+		}
+		return values
+	case TargetArgsDups:
+		args := append(append(Fields{}, req.QueryArgs()...), req.RequestBody.Fields(RuleVarRequestArgsPost)...)
+		return nameValues(collection, args.Duplicates(false))
+	case TargetJSON:
+		return fieldValues(collection, req.RequestBody.Fields(RuleVarRequestBodyJSON))
+	case TargetXML:
+		return fieldValues(collection, req.RequestBody.Fields(RuleVarRequestBodyXML))
+	case TargetFiles, TargetFilesNames, TargetFilesSizes, TargetFilesViolations:
+		return fileValues(collection, req.RequestBody)
+	}
+	return nil
+}
+
+// nameValues 名称列表作为集合中的值
+func nameValues(collection string, names []string) []TargetValue {
+	values := make([]TargetValue, 0, len(names))
+	for _, name := range names {
+		values = append(values, TargetValue{Collection: collection, Name: name, Value: name})
+	}
+	return values
+}
+
+// fieldValues 按出现顺序取出字段的值，名称集合的值为字段名
+func fieldValues(collection string, fields Fields) []TargetValue {
+	values := make([]TargetValue, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, targetValue(collection, field.Name, field.Value))
+	}
+	return values
+}
+
+// fileValues 按出现顺序取出上传文件的信息
+func fileValues(collection string, body *RequestBody) []TargetValue {
+	if body == nil {
+		return nil
+	}
+	var values []TargetValue
+	for _, file := range body.Files {
+		switch collection {
+		case TargetFiles:
+			values = append(values, TargetValue{Collection: collection, Name: file.Field, Value: file.Filename})
+		case TargetFilesSizes:
+			values = append(values, TargetValue{Collection: collection, Name: file.Field, Value: strconv.FormatInt(file.Size, 10)})
+		case TargetFilesViolations:
+			for _, violation := range file.Violations {
+				values = append(values, TargetValue{Collection: collection, Name: file.Field, Value: violation})
+			}
+		default:
+			values = append(values, targetValue(collection, file.Field, ""))
+		}
+	}
+	return values
+}
+
+// targetValue 创建集合中的值
+func targetValue(collection, name, value string) TargetValue {
+	if strings.HasSuffix(collection, "_NAMES") {
+		value = name
+	}
+	return TargetValue{Collection: collection, Name: name, Value: value}
+}
+
+// parseCookies 解析Cookie请求头，保留无法解析的原始内容
+func parseCookies(header string) Fields {
+	var cookies Fields
+	for _, part := range strings.Split(header, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		cookies = append(cookies, Field{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	return cookies
+}
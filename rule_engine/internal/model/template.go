@@ -0,0 +1,229 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// TemplateParamType 模板参数类型
+type TemplateParamType string
+
+const (
+	TemplateParamString  TemplateParamType = "string"  // 原样替换（默认）
+	TemplateParamLiteral TemplateParamType = "literal" // 按正则字面量转义后替换，如路径前缀
+	TemplateParamInt     TemplateParamType = "int"     // 整数，如阈值
+)
+
+// templatePlaceholder 模板占位符，形如 ${path_prefix}
+var templatePlaceholder = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+// TemplateParam 模板参数定义
+type TemplateParam struct {
+	Name        string            `yaml:"name" json:"name"`               // 参数名
+	Type        TemplateParamType `yaml:"type" json:"type"`               // 参数类型
+	Description string            `yaml:"description" json:"description"` // 参数说明
+	Default     string            `yaml:"default" json:"default"`         // 默认值
+	Required    bool              `yaml:"required" json:"required"`       // 是否必填
+}
+
+// RuleTemplate 规则模板
+type RuleTemplate struct {
+	ID             string          `yaml:"id" json:"id"`                           // 模板ID，未配置时为 分组_序号
+	Version        int             `yaml:"version" json:"version"`                 // 模板版本，升级模板时递增
+	Group          string          `yaml:"-" json:"group"`                         // 模板分组（YAML顶层键）
+	Source         string          `yaml:"-" json:"source"`                        // 模板文件
+	Name           string          `yaml:"name" json:"name"`                       // 规则名称
+	Type           RuleType        `yaml:"type" json:"type"`                       // 规则类型
+	RuleVariable   RuleVariable    `yaml:"rule_variable" json:"rule_variable"`     // 规则变量
+	Phase          RulePhase       `yaml:"phase" json:"phase,omitempty"`           // 检查阶段
+	Patterns       []string        `yaml:"patterns" json:"patterns"`               // 匹配模式，每个模式生成一条规则
+	Params         string          `yaml:"rule_params" json:"rule_params"`         // 规则参数(JSON)
+	Description    string          `yaml:"description" json:"description"`         // 规则描述
+	Action         ActionType      `yaml:"action" json:"action"`                   // 动作
+	Status         StatusType      `yaml:"status" json:"status"`                   // 状态
+	Priority       int             `yaml:"priority" json:"priority"`               // 优先级
+	Severity       SeverityType    `yaml:"severity" json:"severity"`               // 风险级别
+	RulesOperation string          `yaml:"rules_operation" json:"rules_operation"` // 规则组合操作
+	Message        string          `yaml:"message" json:"message"`                 // 命中提示
+	Variables      []TemplateParam `yaml:"params" json:"params"`                   // 模板参数定义
+}
+
+// TemplateInstantiateRequest 模板实例化请求
+type TemplateInstantiateRequest struct {
+	Params   map[string]string `json:"params"`   // 模板参数取值
+	Name     string            `json:"name"`     // 覆盖规则名称
+	GroupID  int64             `json:"group_id"` // 规则组ID
+	Action   ActionType        `json:"action"`   // 覆盖动作
+	Priority *int              `json:"priority"` // 覆盖优先级
+	Status   StatusType        `json:"status"`   // 覆盖状态
+	Severity SeverityType      `json:"severity"` // 覆盖风险级别
+	DryRun   bool              `json:"dry_run"`  // 只生成规则不保存
+	Operator int64             `json:"-"`        // 操作人
+}
+
+// TemplateUpgrade 可升级的规则
+type TemplateUpgrade struct {
+	Rule           *Rule `json:"rule"`            // 由旧版本模板创建的规则
+	CurrentVersion int   `json:"current_version"` // 规则创建时的模板版本
+	LatestVersion  int   `json:"latest_version"`  // 模板最新版本
+}
+
+// Validate 验证模板定义
+func (t *RuleTemplate) Validate() error {
+	if t.ID == "" {
+		return errors.NewError(errors.ErrRuleValidation, "模板ID不能为空")
+	}
+	if t.Name == "" {
+		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("模板名称不能为空: %s", t.ID))
+	}
+	if len(t.Patterns) == 0 {
+		return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("模板匹配模式不能为空: %s", t.ID))
+	}
+
+	defined := make(map[string]bool, len(t.Variables))
+	for _, p := range t.Variables {
+		if p.Name == "" {
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("模板参数名不能为空: %s", t.ID))
+		}
+		switch p.Type {
+		case "", TemplateParamString, TemplateParamLiteral, TemplateParamInt:
+		default:
+			return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("无效的模板参数类型: %s.%s=%s", t.ID, p.Name, p.Type))
+		}
+		defined[p.Name] = true
+	}
+
+	// 占位符必须有对应的参数定义
+	for _, field := range t.textFields() {
+		for _, m := range templatePlaceholder.FindAllStringSubmatch(field, -1) {
+			if !defined[m[1]] {
+				return errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("模板占位符未定义参数: %s.%s", t.ID, m[1]))
+			}
+		}
+	}
+	return nil
+}
+
+// textFields 支持占位符的字段
+func (t *RuleTemplate) textFields() []string {
+	fields := []string{t.Name, t.Description, t.Params, string(t.Action)}
+	return append(fields, t.Patterns...)
+}
+
+// Instantiate 使用参数生成规则，每个匹配模式生成一条规则
+func (t *RuleTemplate) Instantiate(req *TemplateInstantiateRequest) ([]*Rule, error) {
+	values, err := t.resolveParams(req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	name := t.Name
+	if req.Name != "" {
+		name = req.Name
+	}
+	action := t.Action
+	if req.Action != "" {
+		action = req.Action
+	}
+	priority := t.Priority
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	status := t.Status
+	if req.Status != "" {
+		status = req.Status
+	}
+	severity := t.Severity
+	if req.Severity != "" {
+		severity = req.Severity
+	}
+	rulesOperation := t.RulesOperation
+	if rulesOperation == "" {
+		rulesOperation = "and"
+	}
+
+	rules := make([]*Rule, 0, len(t.Patterns))
+	for i, pattern := range t.Patterns {
+		rule := &Rule{
+			GroupID:         req.GroupID,
+			Name:            substitute(name, values),
+			Description:     substitute(t.Description, values),
+			Pattern:         substitute(pattern, values),
+			Params:          substituteJSON(t.Params, values),
+			Type:            t.Type,
+			RuleVariable:    t.RuleVariable,
+			Phase:           t.Phase,
+			Action:          ActionType(substitute(string(action), values)),
+			Priority:        priority,
+			Status:          status,
+			Severity:        severity,
+			RulesOperation:  rulesOperation,
+			TemplateID:      t.ID,
+			TemplateVersion: t.Version,
+			CreatedBy:       req.Operator,
+			UpdatedBy:       req.Operator,
+		}
+		if len(t.Patterns) > 1 {
+			rule.Name = fmt.Sprintf("%s #%d", rule.Name, i+1)
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// resolveParams 校验参数并填充默认值，literal类型的参数按正则转义
+func (t *RuleTemplate) resolveParams(input map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(t.Variables))
+	for _, p := range t.Variables {
+		value, ok := input[p.Name]
+		if !ok || value == "" {
+			if p.Required {
+				return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("缺少模板参数: %s", p.Name))
+			}
+			value = p.Default
+		}
+		switch p.Type {
+		case TemplateParamInt:
+			if _, err := strconv.Atoi(value); err != nil {
+				return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("模板参数必须是整数: %s=%s", p.Name, value))
+			}
+		case TemplateParamLiteral:
+			value = regexp.QuoteMeta(value)
+		}
+		values[p.Name] = value
+	}
+	for name := range input {
+		if _, ok := values[name]; !ok {
+			return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("未知的模板参数: %s", name))
+		}
+	}
+	return values, nil
+}
+
+// substitute 替换占位符
+func substitute(s string, values map[string]string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return templatePlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		return values[m[2:len(m)-1]]
+	})
+}
+
+// substituteJSON 在JSON文本中替换占位符，参数值按JSON字符串转义
+func substituteJSON(s string, values map[string]string) string {
+	escaped := make(map[string]string, len(values))
+	for k, v := range values {
+		b, _ := json.Marshal(v)
+		escaped[k] = string(b[1 : len(b)-1])
+	}
+	return substitute(s, escaped)
+}
@@ -0,0 +1,78 @@
+package model
+
+// 上传文件违规类型
+const (
+	UploadViolationDangerousExtension = "dangerous_extension" // 扩展名为禁止上传的类型，如 shell.php
+	UploadViolationDoubleExtension    = "double_extension"    // 中间的扩展名为禁止上传的类型，如 shell.php.jpg
+	UploadViolationTypeMismatch       = "type_mismatch"       // 声明的Content-Type与文件头识别的类型不符
+	UploadViolationFileTooLarge       = "file_too_large"      // 单个文件超过大小限制
+	UploadViolationTotalTooLarge      = "total_too_large"     // 文件总大小超过限制，记录在超出限制的文件及之后的文件上
+)
+
+// IsUploadViolation 判断是否为已知的上传文件违规类型
+func IsUploadViolation(v string) bool {
+	switch v {
+	case UploadViolationDangerousExtension, UploadViolationDoubleExtension, UploadViolationTypeMismatch,
+		UploadViolationFileTooLarge, UploadViolationTotalTooLarge:
+		return true
+	}
+	return false
+}
+
+// UploadFile multipart请求中上传的文件
+type UploadFile struct {
+	Field        string   `json:"field"`                   // 表单字段名
+	Filename     string   `json:"filename"`                // 客户端提交的文件名
+	ContentType  string   `json:"content_type,omitempty"`  // 声明的Content-Type
+	DetectedType string   `json:"detected_type,omitempty"` // 按文件头识别的类型，空文件为空
+	Size         int64    `json:"size"`                    // 文件大小(字节)
+	Violations   []string `json:"violations,omitempty"`    // 违规类型
+}
+
+// UploadViolations 请求中全部上传文件的违规类型，按出现顺序去重
+func (b *RequestBody) UploadViolations() []string {
+	if b == nil {
+		return nil
+	}
+	var violations []string
+	seen := make(map[string]bool)
+	for _, file := range b.Files {
+		for _, v := range file.Violations {
+			if !seen[v] {
+				seen[v] = true
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations
+}
+
+// UploadEvent 上传文件违规事件，每个有违规的文件一条
+type UploadEvent struct {
+	ID           uint64 `json:"id" gorm:"primaryKey"`
+	RequestID    string `json:"request_id"`    // 请求ID
+	IP           string `json:"ip"`            // 来源IP
+	URL          string `json:"url"`           // 请求URL
+	Field        string `json:"field"`         // 表单字段名
+	Filename     string `json:"filename"`      // 客户端提交的文件名
+	ContentType  string `json:"content_type"`  // 声明的Content-Type
+	DetectedType string `json:"detected_type"` // 按文件头识别的类型
+	Size         int64  `json:"size"`          // 文件大小(字节)
+	Violations   string `json:"violations"`    // 违规类型，多个以逗号分隔
+	Blocked      bool   `json:"blocked"`       // 是否按上传违规处理配置拦截
+	Timestamp    int64  `json:"timestamp"`     // 检查时间(Unix秒)
+}
+
+// TableName 上传违规事件表名
+func (UploadEvent) TableName() string {
+	return "upload_events"
+}
+
+// UploadEventQuery 上传违规事件查询条件
+type UploadEventQuery struct {
+	IP        string // 来源IP
+	RequestID string // 请求ID
+	Violation string // 包含的违规类型
+	StartTime int64  // 开始时间(Unix秒)
+	EndTime   int64  // 结束时间(Unix秒)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -185,13 +186,32 @@ type RuleMatchPoint struct {
 
 // CheckRequest 检查请求
 type CheckRequest struct {
-	ClientIP  string            `json:"client_ip"`
-	URI       string            `json:"uri"`
-	Headers   map[string]string `json:"headers"`
-	Args      map[string]string `json:"args"`
-	Body      string            `json:"body"`
-	Method    string            `json:"method"`
-	RuleTypes []RuleType        `json:"rule_types"`
+	ClientIP     string           `json:"client_ip"`
+	URI          string           `json:"uri"`
+	Headers      Fields           `json:"headers"` // 请求头，按原始顺序保留同名请求头
+	Args         Fields           `json:"args"`    // 查询参数，按原始顺序保留同名参数；为空时由引擎从uri解析
+	Body         string           `json:"body"`
+	BodyEncoding string           `json:"body_encoding,omitempty"` // 请求体编码，base64表示body为base64编码的原始字节
+	Method       string           `json:"method"`
+	RuleTypes    []RuleType       `json:"rule_types"`
+	Geo          *GeoInfo         `json:"geo,omitempty"`         // 地理位置信息，未传入时由引擎根据ClientIP查询
+	Bot          *BotInfo         `json:"bot,omitempty"`         // 客户端分类，未传入时由引擎根据请求头识别
+	Fingerprint  *FingerprintInfo `json:"fingerprint,omitempty"` // 客户端TLS/HTTP指纹，由前端传入，代理模式下由引擎计算
+	RequestID    string           `json:"request_id,omitempty"`  // 请求ID，用于关联响应阶段检查
+	Response     *ResponseData    `json:"response,omitempty"`    // 响应数据，仅响应阶段检查时存在
+	RequestBody  *RequestBody     `json:"-"`                     // 按Content-Type解析后的请求体，由引擎填充
+}
+
+// QueryArgs 查询参数，未传入args时从uri的查询字符串按顺序解析，保留同名参数和原始值
+func (r *CheckRequest) QueryArgs() Fields {
+	if len(r.Args) > 0 {
+		return r.Args
+	}
+	_, query, ok := strings.Cut(r.URI, "?")
+	if !ok {
+		return nil
+	}
+	return ParseQuery(query)
 }
 
 // Validate 验证请求参数
@@ -212,6 +232,10 @@ func (r *CheckRequest) Validate() error {
 		return errors.NewError(errors.ErrValidation, "rule_types不能为空")
 	}
 
+	if r.BodyEncoding != "" && r.BodyEncoding != BodyEncodingBase64 {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("不支持的body_encoding: %s", r.BodyEncoding))
+	}
+
 	return nil
 }
 
@@ -285,12 +309,23 @@ type RuleTestResult struct {
 	Error       string        `json:"error"`
 }
 
+// 检查结果的判定来源
+const (
+	CheckSourceRule = "rule" // 规则，MatchedRule为命中的规则
+	CheckSourceList = "list" // IP、地理位置或指纹名单，MatchedRule为空
+
+	CheckSourceRequestBody = "request_body" // 请求体处理，如请求体无法完整解码，MatchedRule为空
+)
+
 // CheckResult 检查结果
 type CheckResult struct {
-	Matched     bool       `json:"matched"`      // 是否匹配
-	Action      ActionType `json:"action"`       // 动作
-	MatchedRule *Rule      `json:"matched_rule"` // 匹配的规则
-	Message     string     `json:"message"`      // 消息
+	Matched     bool          `json:"matched"`               // 是否匹配
+	Action      ActionType    `json:"action"`                // 动作
+	MatchedRule *Rule         `json:"matched_rule"`          // 匹配的规则，非规则判定时为空
+	Source      string        `json:"source,omitempty"`      // 判定来源，未匹配时为空
+	Message     string        `json:"message"`               // 消息
+	MaskedBody  string        `json:"masked_body,omitempty"` // 脱敏后的响应体，仅响应阶段脱敏动作时返回
+	Uploads     []*UploadFile `json:"uploads,omitempty"`     // 上传文件检查结果，仅multipart请求包含文件时返回
 }
 
 // NextToken 获取下一个Token
@@ -0,0 +1,206 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/fingerprint"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+const (
+	defaultMaxBodySize = 1 << 20
+	requestIDHeader    = "X-Request-ID"
+)
+
+// requestRuleTypes 代理模式下检查的规则类型，包含全部请求阶段规则
+var requestRuleTypes = []model.RuleType{
+	model.RuleTypeIP,
+	model.RuleTypeCC,
+	model.RuleTypeRegex,
+	model.RuleTypeSQLi,
+	model.RuleTypeXSS,
+	model.RuleTypeGeo,
+	model.RuleTypeBot,
+	model.RuleTypeFingerprint,
+	model.RuleTypeCustom,
+}
+
+// Options 代理模式配置，配置项为0时使用默认值
+type Options struct {
+	Upstream     string        // 上游地址，如 http://127.0.0.1:8081
+	MaxBodySize  int64         // 参与检查的请求体最大字节数，超出部分不检查但照常转发
+	ReadTimeout  time.Duration // 读取请求超时时间
+	WriteTimeout time.Duration // 写入响应超时时间
+}
+
+// Server 代理模式服务：终止客户端TLS连接，在引擎内计算JA3/JA4指纹并检查请求，放行的请求转发到上游
+type Server struct {
+	ruleService service.RuleService
+	upstream    *httputil.ReverseProxy
+	maxBodySize int64
+	server      *http.Server
+}
+
+// NewServer 创建代理模式服务
+func NewServer(ruleService service.RuleService, opts Options) (*Server, error) {
+	if ruleService == nil {
+		return nil, errors.NewError(errors.ErrConfig, "规则服务不能为空")
+	}
+	target, err := url.Parse(opts.Upstream)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的上游地址: %q", opts.Upstream))
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+
+	s := &Server{
+		ruleService: ruleService,
+		maxBodySize: opts.MaxBodySize,
+		upstream: &httputil.ReverseProxy{
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(target)
+				r.SetXForwarded()
+				r.Out.Host = r.In.Host
+			},
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				logger.Errorf("转发请求失败: RequestID=%s, Error=%v", r.Header.Get(requestIDHeader), err)
+				w.WriteHeader(http.StatusBadGateway)
+			},
+		},
+	}
+	s.server = &http.Server{
+		Handler:      s,
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		// 将连接的ClientHello记录关联到请求上下文，用于计算TLS指纹
+		ConnContext: fingerprint.ConnContext,
+	}
+	return s, nil
+}
+
+// ServeTLS 在监听器上提供HTTPS服务，直到Stop
+// ClientHello记录监听器位于TLS层之下，读取到的是未解密的握手数据
+func (s *Server) ServeTLS(lis net.Listener, certFile, keyFile string) error {
+	logger.Infof("启动代理模式服务: %s", lis.Addr())
+	if err := s.server.ServeTLS(fingerprint.NewListener(lis), certFile, keyFile); err != nil && err != http.ErrServerClosed {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("代理模式服务异常退出: %v", err))
+	}
+	return nil
+}
+
+// Stop 停止接收新连接并等待进行中的请求完成
+func (s *Server) Stop(ctx context.Context) error {
+	if err := s.server.Shutdown(ctx); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("关闭代理模式服务失败: %v", err))
+	}
+	return nil
+}
+
+// ServeHTTP 检查请求，拦截的请求返回403，其余请求转发到上游
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = uuid.New().String()
+		r.Header.Set(requestIDHeader, requestID)
+	}
+
+	req, err := s.checkRequest(r, requestID)
+	if err != nil {
+		logger.Errorf("读取请求失败: RequestID=%s, Error=%v", requestID, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	result, err := s.ruleService.CheckRequest(r.Context(), req)
+	if err != nil {
+		logger.Errorf("代理模式检查失败: RequestID=%s, Error=%v", requestID, err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if blocked(result) {
+		logger.Infof("代理模式拦截请求: RequestID=%s, ClientIP=%s, Source=%s, Action=%s, Message=%s",
+			requestID, req.ClientIP, result.Source, result.Action, result.Message)
+		w.Header().Set(requestIDHeader, requestID)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.upstream.ServeHTTP(w, r)
+}
+
+// checkRequest 由客户端请求构造检查请求，读取的请求体放回原请求以便转发
+func (s *Server) checkRequest(r *http.Request, requestID string) (*model.CheckRequest, error) {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+
+	// 按名称排序，同名请求头保持原始顺序
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := model.Fields{{Name: "Host", Value: r.Host}}
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			headers = append(headers, model.Field{Name: name, Value: value})
+		}
+	}
+
+	req := &model.CheckRequest{
+		ClientIP:    clientIP,
+		URI:         r.RequestURI,
+		Headers:     headers,
+		Method:      r.Method,
+		RuleTypes:   requestRuleTypes,
+		Fingerprint: fingerprint.FromContext(r.Context()),
+		RequestID:   requestID,
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return req, nil
+	}
+
+	// 请求体可能是压缩或二进制内容，按原始字节base64编码后检查
+	body, err := io.ReadAll(io.LimitReader(r.Body, s.maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	if len(body) > 0 {
+		req.Body = base64.StdEncoding.EncodeToString(body)
+		req.BodyEncoding = model.BodyEncodingBase64
+	}
+	return req, nil
+}
+
+// blocked 判定是否拦截，代理模式不提供验证码和重定向页面，均按拦截处理
+func blocked(result *model.CheckResult) bool {
+	if !result.Matched {
+		return false
+	}
+	switch result.Action {
+	case model.ActionBlock, model.ActionCaptcha, model.ActionRedirect:
+		return true
+	}
+	return false
+}
+
+// readCloser 已读取部分与剩余请求体拼接后的请求体
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+)
+
+// recordingRules 记录检查请求，URI包含blocked时拦截
+type recordingRules struct {
+	service.RuleService
+	requests chan *model.CheckRequest
+}
+
+func (r *recordingRules) CheckRequest(_ context.Context, req *model.CheckRequest) (*model.CheckResult, error) {
+	r.requests <- req
+	if strings.Contains(req.URI, "blocked") {
+		return &model.CheckResult{Matched: true, Action: model.ActionBlock, Source: model.CheckSourceRule}, nil
+	}
+	return &model.CheckResult{Action: model.ActionAllow}, nil
+}
+
+// writeKeyPair 将测试证书写入临时文件
+func writeKeyPair(t *testing.T, cert tls.Certificate) (string, string) {
+	t.Helper()
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestProxyFingerprint(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer upstream.Close()
+
+	// 使用httptest内置的自签名证书
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	certFile, keyFile := writeKeyPair(t, tlsServer.TLS.Certificates[0])
+	tlsServer.Close()
+
+	rules := &recordingRules{requests: make(chan *model.CheckRequest, 2)}
+	srv, err := NewServer(rules, Options{Upstream: upstream.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = srv.ServeTLS(lis, certFile, keyFile) }()
+	defer srv.Stop(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	base := "https://" + lis.Addr().String()
+
+	resp, err := client.Post(base+"/orders", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Fatalf("放行的请求未转发到上游: %d %q", resp.StatusCode, body)
+	}
+	req := <-rules.requests
+	if req.Fingerprint == nil || req.Fingerprint.JA3 == "" || req.Fingerprint.JA3Raw == "" || req.Fingerprint.JA4 == "" {
+		t.Fatalf("未计算TLS指纹: %+v", req.Fingerprint)
+	}
+	if !strings.HasPrefix(req.Fingerprint.JA4, "t13") {
+		t.Fatalf("JA4指纹错误: %s", req.Fingerprint.JA4)
+	}
+	if req.ClientIP != "127.0.0.1" || req.BodyEncoding != model.BodyEncodingBase64 || req.Body != base64.StdEncoding.EncodeToString([]byte("hello")) {
+		t.Fatalf("检查请求错误: %+v", req)
+	}
+
+	resp, err = client.Get(base + "/blocked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("拦截的请求状态码为%d，期望403", resp.StatusCode)
+	}
+	if req := <-rules.requests; req.Fingerprint == nil {
+		t.Fatal("复用连接的请求未关联TLS指纹")
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// BypassRepository 旁路配置仓储接口
+type BypassRepository interface {
+	// CreateBypass 创建旁路配置
+	CreateBypass(ctx context.Context, config *model.BypassConfig) error
+
+	// UpdateBypass 更新旁路配置，不检查配置是否存在
+	UpdateBypass(ctx context.Context, config *model.BypassConfig) error
+
+	// DeleteBypass 删除旁路配置
+	// 返回错误:
+	// - ErrRuleNotFound: 旁路配置不存在
+	DeleteBypass(ctx context.Context, id int64) error
+
+	// GetBypass 获取旁路配置
+	// 返回错误:
+	// - ErrRuleNotFound: 旁路配置不存在
+	GetBypass(ctx context.Context, id int64) (*model.BypassConfig, error)
+
+	// ListBypasses 获取旁路配置列表，activeAt大于0时只返回该时间未过期的配置
+	ListBypasses(ctx context.Context, activeAt int64, offset, limit int) ([]*model.BypassConfig, int64, error)
+
+	// CreateAttempts 批量记录旁路尝试
+	CreateAttempts(ctx context.Context, attempts []*model.BypassAttempt) error
+
+	// ListAttempts 获取旁路尝试记录，按时间倒序
+	ListAttempts(ctx context.Context, query *model.BypassAttemptQuery, offset, limit int) ([]*model.BypassAttempt, int64, error)
+
+	// ListKeys 获取全部旁路令牌签名密钥
+	ListKeys(ctx context.Context) ([]*model.BypassKey, error)
+
+	// RotateKey 在同一事务中将生效的密钥标记为已轮换并创建新的生效密钥
+	RotateKey(ctx context.Context, key *model.BypassKey) error
+
+	// RevokeKey 吊销密钥
+	// 返回错误:
+	// - ErrRuleNotFound: 密钥不存在或已吊销
+	RevokeKey(ctx context.Context, keyID string, revokedAt time.Time) error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// ChangeRequestRepository 变更请求仓储接口
+type ChangeRequestRepository interface {
+	// CreateChangeRequest 创建变更请求及其变更项
+	CreateChangeRequest(ctx context.Context, cr *model.ChangeRequest) error
+
+	// UpdateChangeRequest 更新变更请求，变更项整体替换
+	// 返回错误:
+	// - ErrRuleNotFound: 变更请求不存在
+	UpdateChangeRequest(ctx context.Context, cr *model.ChangeRequest) error
+
+	// GetChangeRequest 获取变更请求及其变更项
+	// 返回错误:
+	// - ErrRuleNotFound: 变更请求不存在
+	GetChangeRequest(ctx context.Context, id int64) (*model.ChangeRequest, error)
+
+	// ListChangeRequests 获取变更请求列表（不含变更项），status为空时返回全部
+	ListChangeRequests(ctx context.Context, status model.ChangeRequestStatus, offset, limit int) ([]*model.ChangeRequest, int64, error)
+
+	// CreateEvent 记录变更请求历史
+	CreateEvent(ctx context.Context, event *model.ChangeEvent) error
+
+	// ListEvents 获取变更请求历史，按时间正序
+	ListEvents(ctx context.Context, changeRequestID int64) ([]*model.ChangeEvent, error)
+}
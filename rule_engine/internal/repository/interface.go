@@ -59,6 +59,9 @@ type RuleCache interface {
 	// 返回错误:
 	// - ErrCache: 缓存操作失败
 	ClearRules(ctx context.Context) error
+
+	// SetRuleTTL 设置规则缓存过期时间，只影响之后写入的缓存，ttl<=0时使用默认值
+	SetRuleTTL(ttl time.Duration)
 }
 
 // CacheRepository 缓存仓库接口
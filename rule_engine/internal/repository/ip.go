@@ -26,6 +26,9 @@ type IPRuleRepository interface {
 	// GetIPRuleByIP 根据IP获取规则
 	GetIPRuleByIP(ctx context.Context, ip string) (*model.IPRule, error)
 
+	// GetIPRuleByEntry 根据条目类型（IP/国家/ASN）和值获取规则
+	GetIPRuleByEntry(ctx context.Context, entryType model.IPEntryType, value string) (*model.IPRule, error)
+
 	// ListIPRules 获取IP规则列表
 	ListIPRules(ctx context.Context, query *model.IPRuleQuery, offset, limit int) ([]*model.IPRule, int64, error)
 
@@ -101,6 +104,19 @@ func (r *IPRepository) GetIPRuleByIP(ctx context.Context, ip string) (*model.IPR
 	return &rule, nil
 }
 
+// GetIPRuleByEntry 根据条目类型和值获取规则
+func (r *IPRepository) GetIPRuleByEntry(ctx context.Context, entryType model.IPEntryType, value string) (*model.IPRule, error) {
+	var rule model.IPRule
+	err := r.db.WithContext(ctx).Where("entry_type = ? AND ip = ?", entryType, value).First(&rule).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %s=%s", entryType, value))
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取IP规则失败: %v", err))
+	}
+	return &rule, nil
+}
+
 // ListIPRules 获取IP规则列表
 func (r *IPRepository) ListIPRules(ctx context.Context, query *model.IPRuleQuery, offset, limit int) ([]*model.IPRule, int64, error) {
 	var rules []*model.IPRule
@@ -114,6 +130,9 @@ func (r *IPRepository) ListIPRules(ctx context.Context, query *model.IPRuleQuery
 			db = db.Where("ip LIKE ? OR description LIKE ?",
 				"%"+query.Keyword+"%", "%"+query.Keyword+"%")
 		}
+		if query.EntryType != "" {
+			db = db.Where("entry_type = ?", query.EntryType)
+		}
 		if query.IPType != "" {
 			db = db.Where("ip_type = ?", query.IPType)
 		}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// bypassRepository 旁路配置内存仓储实现
+type bypassRepository struct {
+	s *Store
+}
+
+// NewBypassRepository 创建旁路配置仓储
+func NewBypassRepository(s *Store) repository.BypassRepository {
+	return &bypassRepository{s: mustStore(s)}
+}
+
+// CreateBypass 创建旁路配置
+func (r *bypassRepository) CreateBypass(ctx context.Context, config *model.BypassConfig) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	config.ID = r.s.nextID("bypass_configs")
+	stamp(&config.CreatedAt, &config.UpdatedAt)
+	r.s.bypasses[config.ID] = copyBypass(config)
+	return nil
+}
+
+// UpdateBypass 更新旁路配置，配置不存在时不做任何修改
+func (r *bypassRepository) UpdateBypass(ctx context.Context, config *model.BypassConfig) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.bypasses[config.ID]
+	if !ok {
+		return nil
+	}
+	config.UpdatedAt = time.Now()
+	c := copyBypass(config)
+	c.CreatedBy = existing.CreatedBy
+	c.CreatedAt = existing.CreatedAt
+	r.s.bypasses[config.ID] = c
+	return nil
+}
+
+// DeleteBypass 删除旁路配置，尝试记录保留
+func (r *bypassRepository) DeleteBypass(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.bypasses[id]; !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("旁路配置不存在: %d", id))
+	}
+	delete(r.s.bypasses, id)
+	return nil
+}
+
+// GetBypass 获取旁路配置
+func (r *bypassRepository) GetBypass(ctx context.Context, id int64) (*model.BypassConfig, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	config, ok := r.s.bypasses[id]
+	if !ok {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("旁路配置不存在: %d", id))
+	}
+	return copyBypass(config), nil
+}
+
+// ListBypasses 获取旁路配置列表
+func (r *bypassRepository) ListBypasses(ctx context.Context, activeAt int64, offset, limit int) ([]*model.BypassConfig, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	ids := sortedIDs(r.s.bypasses)
+	configs := make([]*model.BypassConfig, 0)
+	for i := len(ids) - 1; i >= 0; i-- {
+		config := r.s.bypasses[ids[i]]
+		if activeAt > 0 && config.EndTime != 0 && config.EndTime <= activeAt {
+			continue
+		}
+		configs = append(configs, copyBypass(config))
+	}
+	return page(configs, offset, limit), int64(len(configs)), nil
+}
+
+// CreateAttempts 批量记录旁路尝试
+func (r *bypassRepository) CreateAttempts(ctx context.Context, attempts []*model.BypassAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, attempt := range attempts {
+		attempt.ID = uint64(r.s.nextID("bypass_attempts"))
+		c := *attempt
+		r.s.attempts = append(r.s.attempts, &c)
+	}
+	return nil
+}
+
+// ListAttempts 获取旁路尝试记录
+func (r *bypassRepository) ListAttempts(ctx context.Context, query *model.BypassAttemptQuery, offset, limit int) ([]*model.BypassAttempt, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	attempts := make([]*model.BypassAttempt, 0)
+	for i := len(r.s.attempts) - 1; i >= 0; i-- {
+		attempt := r.s.attempts[i]
+		if query.BypassID > 0 && attempt.BypassID != query.BypassID {
+			continue
+		}
+		if query.TokenID != "" && attempt.TokenID != query.TokenID {
+			continue
+		}
+		if query.IP != "" && attempt.IP != query.IP {
+			continue
+		}
+		if query.Success != nil && attempt.Success != *query.Success {
+			continue
+		}
+		if query.StartTime > 0 && attempt.Timestamp < query.StartTime {
+			continue
+		}
+		if query.EndTime > 0 && attempt.Timestamp >= query.EndTime {
+			continue
+		}
+		c := *attempt
+		attempts = append(attempts, &c)
+	}
+	return page(attempts, offset, limit), int64(len(attempts)), nil
+}
+
+// ListKeys 获取旁路令牌签名密钥
+func (r *bypassRepository) ListKeys(ctx context.Context) ([]*model.BypassKey, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	keys := make([]*model.BypassKey, 0, len(r.s.keys))
+	for i := len(r.s.keys) - 1; i >= 0; i-- {
+		keys = append(keys, copyBypassKey(r.s.keys[i]))
+	}
+	return keys, nil
+}
+
+// RotateKey 将生效的密钥标记为已轮换并创建新密钥
+func (r *bypassRepository) RotateKey(ctx context.Context, key *model.BypassKey) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.keys {
+		if existing.Status == model.BypassKeyActive {
+			existing.Status = model.BypassKeyRetired
+			existing.RetiredAt = copyTime(&key.CreatedAt)
+		}
+	}
+	key.ID = r.s.nextID("bypass_keys")
+	stamp(&key.CreatedAt, nil)
+	r.s.keys = append(r.s.keys, copyBypassKey(key))
+	return nil
+}
+
+// RevokeKey 吊销密钥
+func (r *bypassRepository) RevokeKey(ctx context.Context, keyID string, revokedAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	revoked := false
+	for _, existing := range r.s.keys {
+		if existing.KeyID == keyID && existing.Status != model.BypassKeyRevoked {
+			existing.Status = model.BypassKeyRevoked
+			existing.RevokedAt = copyTime(&revokedAt)
+			revoked = true
+		}
+	}
+	if !revoked {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("旁路令牌密钥不存在或已吊销: %s", keyID))
+	}
+	return nil
+}
+
+// copyBypass 复制旁路配置
+func copyBypass(config *model.BypassConfig) *model.BypassConfig {
+	c := *config
+	c.IPs = copyStrings(config.IPs)
+	c.URLs = copyStrings(config.URLs)
+	c.Headers = copyStrings(config.Headers)
+	return &c
+}
+
+// copyBypassKey 复制旁路令牌签名密钥
+func copyBypassKey(key *model.BypassKey) *model.BypassKey {
+	c := *key
+	c.Secret = append([]byte(nil), key.Secret...)
+	c.RetiredAt = copyTime(key.RetiredAt)
+	c.RevokedAt = copyTime(key.RevokedAt)
+	return &c
+}
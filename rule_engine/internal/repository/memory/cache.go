@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+const (
+	ruleKeyPrefix     = "waf:rule:"
+	defaultRuleExpire = 24 * time.Hour
+)
+
+// cacheEntry 缓存条目，expiresAt为0时不过期
+type cacheEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// Cache 内存缓存实现，值以JSON保存，与Redis实现的序列化行为相同
+// 过期条目在读取时删除
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	ttl     atomic.Int64
+}
+
+// NewCache 创建缓存
+func NewCache() *Cache {
+	return &Cache{entries: make(map[string]*cacheEntry)}
+}
+
+// Set 设置缓存，expiration<=0时不过期
+func (c *Cache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	entry := &cacheEntry{data: data}
+	if expiration > 0 {
+		entry.expiresAt = time.Now().Add(expiration)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	return nil
+}
+
+// Get 获取缓存，不存在或已过期时返回ErrCacheMiss
+func (c *Cache) Get(ctx context.Context, key string, value interface{}) error {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		return errors.NewError(errors.ErrCacheMiss, fmt.Sprintf("缓存不存在: %s", key))
+	}
+	return json.Unmarshal(entry.data, value)
+}
+
+// Delete 删除缓存
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+// SetRuleTTL 设置规则缓存过期时间，ttl<=0时使用默认值
+func (c *Cache) SetRuleTTL(ttl time.Duration) {
+	c.ttl.Store(int64(ttl))
+}
+
+// ruleExpire 当前规则缓存过期时间
+func (c *Cache) ruleExpire() time.Duration {
+	if ttl := time.Duration(c.ttl.Load()); ttl > 0 {
+		return ttl
+	}
+	return defaultRuleExpire
+}
+
+// SetRule 设置规则缓存
+func (c *Cache) SetRule(ctx context.Context, rule *model.Rule) error {
+	key := fmt.Sprintf("%s%d", ruleKeyPrefix, rule.ID)
+	return c.Set(ctx, key, rule, c.ruleExpire())
+}
+
+// GetRule 获取规则缓存
+func (c *Cache) GetRule(ctx context.Context, id int64) (*model.Rule, error) {
+	key := fmt.Sprintf("%s%d", ruleKeyPrefix, id)
+	var rule model.Rule
+	if err := c.Get(ctx, key, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRule 删除规则缓存
+func (c *Cache) DeleteRule(ctx context.Context, id int64) error {
+	key := fmt.Sprintf("%s%d", ruleKeyPrefix, id)
+	return c.Delete(ctx, key)
+}
+
+// ClearRules 清空规则缓存
+func (c *Cache) ClearRules(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, ruleKeyPrefix) {
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
+var (
+	_ repository.CacheRepository = (*Cache)(nil)
+	_ repository.RuleCache       = (*Cache)(nil)
+)
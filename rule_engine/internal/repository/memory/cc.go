@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// ccRuleRepository CC规则内存仓储实现
+type ccRuleRepository struct {
+	s *Store
+}
+
+// NewCCRuleRepository 创建CC规则仓储
+func NewCCRuleRepository(s *Store) repository.CCRuleRepository {
+	return &ccRuleRepository{s: mustStore(s)}
+}
+
+// validateCCRule 验证CC规则，与MySQL实现的检查一致
+func validateCCRule(rule *model.CCRule, create bool) error {
+	if rule == nil {
+		return errors.NewError(errors.ErrValidation, "CC规则不能为空")
+	}
+	if create && rule.URI == "" {
+		return errors.NewError(errors.ErrValidation, "URI不能为空")
+	}
+	if !create && rule.ID <= 0 {
+		return errors.NewError(errors.ErrValidation, "规则ID必须大于0")
+	}
+	if rule.LimitRate <= 0 {
+		return errors.NewError(errors.ErrValidation, "限制速率必须大于0")
+	}
+	if rule.TimeWindow <= 0 {
+		return errors.NewError(errors.ErrValidation, "时间窗口必须大于0")
+	}
+	if rule.LimitUnit == "" {
+		return errors.NewError(errors.ErrValidation, "限制单位不能为空")
+	}
+	return nil
+}
+
+// CreateCCRule 创建CC规则，URI重复时返回ErrRuleConflict
+func (r *ccRuleRepository) CreateCCRule(ctx context.Context, rule *model.CCRule) error {
+	if err := validateCCRule(rule, true); err != nil {
+		return err
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.ccRules {
+		if existing.URI == rule.URI {
+			return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("CC规则已存在: URI=%s", rule.URI))
+		}
+	}
+	rule.ID = r.s.nextID("cc_rules")
+	stamp(&rule.CreatedAt, &rule.UpdatedAt)
+	c := *rule
+	r.s.ccRules[rule.ID] = &c
+	return nil
+}
+
+// UpdateCCRule 更新CC规则，URI不可修改
+func (r *ccRuleRepository) UpdateCCRule(ctx context.Context, rule *model.CCRule) error {
+	if err := validateCCRule(rule, false); err != nil {
+		return err
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.ccRules[rule.ID]
+	if !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("CC规则不存在: ID=%d", rule.ID))
+	}
+	rule.UpdatedAt = time.Now()
+	existing.LimitRate = rule.LimitRate
+	existing.TimeWindow = rule.TimeWindow
+	existing.LimitUnit = rule.LimitUnit
+	existing.Status = rule.Status
+	existing.UpdatedAt = rule.UpdatedAt
+	return nil
+}
+
+// DeleteCCRule 删除CC规则
+func (r *ccRuleRepository) DeleteCCRule(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return errors.NewError(errors.ErrValidation, "规则ID必须大于0")
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.ccRules[id]; !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("CC规则不存在: ID=%d", id))
+	}
+	delete(r.s.ccRules, id)
+	return nil
+}
+
+// GetCCRule 获取CC规则
+func (r *ccRuleRepository) GetCCRule(ctx context.Context, id int64) (*model.CCRule, error) {
+	if id <= 0 {
+		return nil, errors.NewError(errors.ErrValidation, "规则ID必须大于0")
+	}
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	rule, ok := r.s.ccRules[id]
+	if !ok {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("CC规则不存在: ID=%d", id))
+	}
+	c := *rule
+	return &c, nil
+}
+
+// ListCCRules 获取CC规则列表，按ID倒序
+func (r *ccRuleRepository) ListCCRules(ctx context.Context, offset, limit int) ([]*model.CCRule, error) {
+	if offset < 0 {
+		return nil, errors.NewError(errors.ErrValidation, "偏移量不能为负数")
+	}
+	if limit <= 0 {
+		return nil, errors.NewError(errors.ErrValidation, "每页大小必须大于0")
+	}
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	ids := sortedIDs(r.s.ccRules)
+	rules := make([]*model.CCRule, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		c := *r.s.ccRules[ids[i]]
+		rules = append(rules, &c)
+	}
+	return page(rules, offset, limit), nil
+}
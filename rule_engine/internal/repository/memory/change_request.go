@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// changeRequestRepository 变更请求内存仓储实现
+type changeRequestRepository struct {
+	s *Store
+}
+
+// NewChangeRequestRepository 创建变更请求仓储
+func NewChangeRequestRepository(s *Store) repository.ChangeRequestRepository {
+	return &changeRequestRepository{s: mustStore(s)}
+}
+
+// CreateChangeRequest 创建变更请求
+func (r *changeRequestRepository) CreateChangeRequest(ctx context.Context, cr *model.ChangeRequest) error {
+	if err := cr.Encode(); err != nil {
+		return err
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	cr.ID = r.s.nextID("change_requests")
+	stamp(&cr.CreatedAt, &cr.UpdatedAt)
+	r.s.changes[cr.ID] = copyChangeRequest(cr)
+	r.s.putChangeItems(cr)
+	return nil
+}
+
+// UpdateChangeRequest 更新变更请求
+func (r *changeRequestRepository) UpdateChangeRequest(ctx context.Context, cr *model.ChangeRequest) error {
+	if err := cr.Encode(); err != nil {
+		return err
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.changes[cr.ID]
+	if !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("变更请求不存在: %d", cr.ID))
+	}
+	cr.UpdatedAt = time.Now()
+	c := copyChangeRequest(cr)
+	c.CreatedAt = existing.CreatedAt
+	r.s.changes[cr.ID] = c
+	r.s.putChangeItems(cr)
+	return nil
+}
+
+// GetChangeRequest 获取变更请求
+func (r *changeRequestRepository) GetChangeRequest(ctx context.Context, id int64) (*model.ChangeRequest, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	existing, ok := r.s.changes[id]
+	if !ok {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("变更请求不存在: %d", id))
+	}
+	cr := copyChangeRequest(existing)
+	cr.Items = make([]*model.ChangeItem, 0, len(r.s.changeItems[id]))
+	for _, item := range r.s.changeItems[id] {
+		cr.Items = append(cr.Items, copyChangeItem(item))
+	}
+	if err := cr.Decode(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// ListChangeRequests 获取变更请求列表
+func (r *changeRequestRepository) ListChangeRequests(ctx context.Context, status model.ChangeRequestStatus, offset, limit int) ([]*model.ChangeRequest, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	ids := sortedIDs(r.s.changes)
+	crs := make([]*model.ChangeRequest, 0)
+	for i := len(ids) - 1; i >= 0; i-- {
+		existing := r.s.changes[ids[i]]
+		if status != "" && existing.Status != status {
+			continue
+		}
+		crs = append(crs, copyChangeRequest(existing))
+	}
+	total := int64(len(crs))
+	crs = page(crs, offset, limit)
+	for _, cr := range crs {
+		if err := cr.Decode(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return crs, total, nil
+}
+
+// CreateEvent 记录变更请求历史
+func (r *changeRequestRepository) CreateEvent(ctx context.Context, event *model.ChangeEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	event.ID = r.s.nextID("change_request_events")
+	stamp(&event.CreatedAt, nil)
+	c := *event
+	r.s.changeEvents = append(r.s.changeEvents, &c)
+	return nil
+}
+
+// ListEvents 获取变更请求历史
+func (r *changeRequestRepository) ListEvents(ctx context.Context, changeRequestID int64) ([]*model.ChangeEvent, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	events := make([]*model.ChangeEvent, 0)
+	for _, event := range r.s.changeEvents {
+		if event.ChangeRequestID == changeRequestID {
+			c := *event
+			events = append(events, &c)
+		}
+	}
+	return events, nil
+}
+
+// putChangeItems 替换变更请求的全部变更项，调用方需持有写锁
+func (s *Store) putChangeItems(cr *model.ChangeRequest) {
+	items := make([]*model.ChangeItem, 0, len(cr.Items))
+	for _, item := range cr.Items {
+		item.ID = s.nextID("change_request_items")
+		item.ChangeRequestID = cr.ID
+		items = append(items, copyChangeItem(item))
+	}
+	s.changeItems[cr.ID] = items
+}
+
+// copyChangeRequest 复制变更请求的存储字段，不含变更项、检查结果和历史记录
+func copyChangeRequest(cr *model.ChangeRequest) *model.ChangeRequest {
+	c := *cr
+	c.Items = nil
+	c.Checks = nil
+	c.Events = nil
+	c.SubmittedAt = copyTime(cr.SubmittedAt)
+	c.ApprovedAt = copyTime(cr.ApprovedAt)
+	c.PublishedAt = copyTime(cr.PublishedAt)
+	return &c
+}
+
+// copyChangeItem 复制变更项的存储字段，测试用例以序列化内容保存
+func copyChangeItem(item *model.ChangeItem) *model.ChangeItem {
+	c := *item
+	c.TestCases = nil
+	if item.Payload != nil {
+		c.Payload = append(json.RawMessage(nil), item.Payload...)
+	}
+	c.AppliedAt = copyTime(item.AppliedAt)
+	return &c
+}
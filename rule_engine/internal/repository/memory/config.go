@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// wafConfigRepository WAF配置内存仓储实现
+type wafConfigRepository struct {
+	s *Store
+}
+
+// NewWAFConfigRepository 创建WAF配置仓储
+func NewWAFConfigRepository(s *Store) repository.WAFConfigRepository {
+	return &wafConfigRepository{s: mustStore(s)}
+}
+
+// GetConfig 获取最新的WAF配置
+func (r *wafConfigRepository) GetConfig(ctx context.Context) (*model.WAFConfig, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	if len(r.s.configs) == 0 {
+		return nil, errors.NewError(errors.ErrConfig, "WAF配置不存在，请先创建配置")
+	}
+	c := *r.s.configs[len(r.s.configs)-1]
+	return &c, nil
+}
+
+// UpdateConfig 更新WAF配置，ID为0时创建新配置
+func (r *wafConfigRepository) UpdateConfig(ctx context.Context, config *model.WAFConfig) error {
+	if config == nil {
+		return errors.NewError(errors.ErrValidation, "配置不能为空")
+	}
+	if config.Mode == "" {
+		return errors.NewError(errors.ErrValidation, "WAF模式不能为空")
+	}
+	if config.CreatedBy == "" {
+		return errors.NewError(errors.ErrValidation, "创建者不能为空")
+	}
+	if config.UpdatedBy == "" {
+		return errors.NewError(errors.ErrValidation, "更新者不能为空")
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now().Unix()
+	if config.ID == 0 {
+		config.ID = r.s.nextID("waf_configs")
+		config.CreatedAt = now
+		config.UpdatedAt = now
+		c := *config
+		r.s.configs = append(r.s.configs, &c)
+		return nil
+	}
+	for _, existing := range r.s.configs {
+		if existing.ID == config.ID {
+			config.UpdatedAt = now
+			existing.Mode = config.Mode
+			existing.Description = config.Description
+			existing.UpdatedBy = config.UpdatedBy
+			existing.UpdatedAt = now
+			return nil
+		}
+	}
+	return errors.NewError(errors.ErrConfig, fmt.Sprintf("WAF配置不存在: ID=%d", config.ID))
+}
+
+// LogModeChange 记录模式变更日志
+func (r *wafConfigRepository) LogModeChange(ctx context.Context, log *model.WAFModeChangeLog) error {
+	if log == nil {
+		return errors.NewError(errors.ErrValidation, "日志不能为空")
+	}
+	if log.OldMode == "" {
+		return errors.NewError(errors.ErrValidation, "原WAF模式不能为空")
+	}
+	if log.NewMode == "" {
+		return errors.NewError(errors.ErrValidation, "新WAF模式不能为空")
+	}
+	if log.Operator == "" {
+		return errors.NewError(errors.ErrValidation, "操作者不能为空")
+	}
+	if log.Reason == "" {
+		return errors.NewError(errors.ErrValidation, "变更原因不能为空")
+	}
+	if log.CreatedAt == 0 {
+		return errors.NewError(errors.ErrValidation, "创建时间不能为空")
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	log.ID = r.s.nextID("waf_mode_change_logs")
+	c := *log
+	r.s.modeLogs = append(r.s.modeLogs, &c)
+	return nil
+}
+
+// GetModeChangeLogs 获取时间范围内的模式变更日志，按时间倒序
+func (r *wafConfigRepository) GetModeChangeLogs(ctx context.Context, startTime, endTime int64, pageNum, pageSize int) ([]*model.WAFModeChangeLog, int64, error) {
+	if startTime < 0 {
+		return nil, 0, errors.NewError(errors.ErrValidation, "开始时间不能为负数")
+	}
+	if endTime < startTime {
+		return nil, 0, errors.NewError(errors.ErrValidation, "结束时间不能小于开始时间")
+	}
+	if pageNum <= 0 {
+		return nil, 0, errors.NewError(errors.ErrValidation, "页码必须大于0")
+	}
+	if pageSize <= 0 {
+		return nil, 0, errors.NewError(errors.ErrValidation, "每页大小必须大于0")
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	logs := make([]*model.WAFModeChangeLog, 0)
+	for _, log := range r.s.modeLogs {
+		if log.CreatedAt >= startTime && log.CreatedAt <= endTime {
+			c := *log
+			logs = append(logs, &c)
+		}
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].CreatedAt != logs[j].CreatedAt {
+			return logs[i].CreatedAt > logs[j].CreatedAt
+		}
+		return logs[i].ID > logs[j].ID
+	})
+	return page(logs, (pageNum-1)*pageSize, pageSize), int64(len(logs)), nil
+}
@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/repository/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repotest.Repositories {
+		s := NewStore()
+		cache := NewCache()
+		return &repotest.Repositories{
+			Rules:     NewRuleRepository(s),
+			Versions:  NewRuleVersionRepository(s),
+			IPs:       NewIPRuleRepository(s),
+			CC:        NewCCRuleRepository(s),
+			Configs:   NewWAFConfigRepository(s),
+			Releases:  NewReleaseRepository(s),
+			Changes:   NewChangeRequestRepository(s),
+			Bypasses:  NewBypassRepository(s),
+			Nodes:     NewNodeRepository(s),
+			Uploads:   NewUploadEventRepository(s),
+			Cache:     cache,
+			RuleCache: cache,
+		}
+	})
+}
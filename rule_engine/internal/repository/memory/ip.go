@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// ipRuleRepository IP规则内存仓储实现
+type ipRuleRepository struct {
+	s *Store
+}
+
+// NewIPRuleRepository 创建IP规则仓储
+func NewIPRuleRepository(s *Store) repository.IPRuleRepository {
+	return &ipRuleRepository{s: mustStore(s)}
+}
+
+// CreateIPRule 创建IP规则，同类型条目重复时返回ErrRuleConflict
+func (r *ipRuleRepository) CreateIPRule(ctx context.Context, rule *model.IPRule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if r.s.ipRuleByEntry(rule.EntryType, rule.IP) != nil {
+		return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("IP规则已存在: %s=%s", rule.EntryType, rule.IP))
+	}
+	rule.ID = r.s.nextID("ip_rules")
+	stamp(&rule.CreatedAt, &rule.UpdatedAt)
+	c := *rule
+	r.s.ipRules[rule.ID] = &c
+	return nil
+}
+
+// UpdateIPRule 更新IP规则，条目类型和值不可修改
+func (r *ipRuleRepository) UpdateIPRule(ctx context.Context, rule *model.IPRule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.ipRules[rule.ID]
+	if !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %d", rule.ID))
+	}
+	rule.UpdatedAt = time.Now()
+	existing.IPType = rule.IPType
+	existing.BlockType = rule.BlockType
+	existing.ExpireTime = rule.ExpireTime
+	existing.Description = rule.Description
+	existing.UpdatedBy = rule.UpdatedBy
+	existing.UpdatedAt = rule.UpdatedAt
+	return nil
+}
+
+// DeleteIPRule 删除IP规则
+func (r *ipRuleRepository) DeleteIPRule(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.ipRules[id]; !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %d", id))
+	}
+	delete(r.s.ipRules, id)
+	return nil
+}
+
+// GetIPRule 获取IP规则
+func (r *ipRuleRepository) GetIPRule(ctx context.Context, id int64) (*model.IPRule, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	rule, ok := r.s.ipRules[id]
+	if !ok {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %d", id))
+	}
+	c := *rule
+	return &c, nil
+}
+
+// GetIPRuleByIP 根据IP获取规则
+func (r *ipRuleRepository) GetIPRuleByIP(ctx context.Context, ip string) (*model.IPRule, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, id := range sortedIDs(r.s.ipRules) {
+		if rule := r.s.ipRules[id]; rule.IP == ip {
+			c := *rule
+			return &c, nil
+		}
+	}
+	return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %s", ip))
+}
+
+// GetIPRuleByEntry 根据条目类型和值获取规则
+func (r *ipRuleRepository) GetIPRuleByEntry(ctx context.Context, entryType model.IPEntryType, value string) (*model.IPRule, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	if rule := r.s.ipRuleByEntry(entryType, value); rule != nil {
+		c := *rule
+		return &c, nil
+	}
+	return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %s=%s", entryType, value))
+}
+
+// ListIPRules 获取IP规则列表，按创建时间倒序，limit<=0时返回全部
+func (r *ipRuleRepository) ListIPRules(ctx context.Context, query *model.IPRuleQuery, offset, limit int) ([]*model.IPRule, int64, error) {
+	if query == nil {
+		query = &model.IPRuleQuery{}
+	}
+	keyword := strings.ToLower(query.Keyword)
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	rules := make([]*model.IPRule, 0)
+	for _, rule := range r.s.ipRules {
+		if keyword != "" && !strings.Contains(strings.ToLower(rule.IP), keyword) &&
+			!strings.Contains(strings.ToLower(rule.Description), keyword) {
+			continue
+		}
+		if (query.EntryType != "" && rule.EntryType != query.EntryType) ||
+			(query.IPType != "" && rule.IPType != query.IPType) ||
+			(query.BlockType != "" && rule.BlockType != query.BlockType) {
+			continue
+		}
+		c := *rule
+		rules = append(rules, &c)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if !rules[i].CreatedAt.Equal(rules[j].CreatedAt) {
+			return rules[i].CreatedAt.After(rules[j].CreatedAt)
+		}
+		return rules[i].ID > rules[j].ID
+	})
+	return page(rules, offset, limit), int64(len(rules)), nil
+}
+
+// ExistsByIP 检查IP是否存在规则
+func (r *ipRuleRepository) ExistsByIP(ctx context.Context, ip string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, rule := range r.s.ipRules {
+		if rule.IP == ip {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ipRuleByEntry 按条目类型和值查找规则，调用方需持有锁
+func (s *Store) ipRuleByEntry(entryType model.IPEntryType, value string) *model.IPRule {
+	for _, rule := range s.ipRules {
+		if rule.EntryType == entryType && rule.IP == value {
+			return rule
+		}
+	}
+	return nil
+}
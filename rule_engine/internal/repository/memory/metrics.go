@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// metricsRepository 监控指标内存仓储实现，统计进程启动以来的累计值
+type metricsRepository struct {
+	mu    sync.Mutex
+	rules map[string]*model.RuleMatchMetrics
+	apis  map[apiKey]*apiStats
+}
+
+// apiKey API统计的键
+type apiKey struct {
+	path   string
+	method string
+}
+
+// apiStats API累计统计
+type apiStats struct {
+	requests  int64
+	errors    int64
+	responses int64
+	total     time.Duration
+	max       time.Duration
+	min       time.Duration
+}
+
+// NewMetricsRepository 创建监控指标仓储
+func NewMetricsRepository() repository.MetricsRepository {
+	return &metricsRepository{
+		rules: make(map[string]*model.RuleMatchMetrics),
+		apis:  make(map[apiKey]*apiStats),
+	}
+}
+
+// RecordRuleMatch 记录规则匹配
+func (r *metricsRepository) RecordRuleMatch(ctx context.Context, ruleID, ruleName string, action model.ActionType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.rules[ruleID]
+	if !ok {
+		m = &model.RuleMatchMetrics{RuleID: ruleID}
+		r.rules[ruleID] = m
+	}
+	m.RuleName = ruleName
+	m.TotalHits++
+	switch action {
+	case model.ActionBlock:
+		m.BlockCount++
+	case model.ActionAllow:
+		m.AllowCount++
+	}
+	m.LastHitTime = time.Now().Format(time.RFC3339)
+}
+
+// RecordAPIRequest 记录API请求，状态码大于等于400时记为错误
+func (r *metricsRepository) RecordAPIRequest(ctx context.Context, path, method, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.api(path, method)
+	s.requests++
+	if code, err := strconv.Atoi(status); err != nil || code >= 400 {
+		s.errors++
+	}
+}
+
+// RecordAPIResponseTime 记录API响应时间
+func (r *metricsRepository) RecordAPIResponseTime(ctx context.Context, path, method string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.api(path, method)
+	if s.responses == 0 || duration < s.min {
+		s.min = duration
+	}
+	if duration > s.max {
+		s.max = duration
+	}
+	s.responses++
+	s.total += duration
+}
+
+// api 获取API统计，不存在时创建，调用方需持有锁
+func (r *metricsRepository) api(path, method string) *apiStats {
+	key := apiKey{path: path, method: method}
+	s, ok := r.apis[key]
+	if !ok {
+		s = &apiStats{}
+		r.apis[key] = s
+	}
+	return s
+}
+
+// GetRuleMatchMetrics 获取规则匹配统计，按规则ID排序
+func (r *metricsRepository) GetRuleMatchMetrics(ctx context.Context) ([]*model.RuleMatchMetrics, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	metrics := make([]*model.RuleMatchMetrics, 0, len(r.rules))
+	for _, m := range r.rules {
+		c := *m
+		metrics = append(metrics, &c)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].RuleID < metrics[j].RuleID })
+	return metrics, nil
+}
+
+// GetAPIMetrics 获取API性能统计，响应时间单位为秒，按路径和方法排序
+func (r *metricsRepository) GetAPIMetrics(ctx context.Context) ([]*model.APIMetrics, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	metrics := make([]*model.APIMetrics, 0, len(r.apis))
+	for key, s := range r.apis {
+		m := &model.APIMetrics{
+			Path:            key.path,
+			Method:          key.method,
+			TotalRequests:   s.requests,
+			MaxResponseTime: s.max.Seconds(),
+			MinResponseTime: s.min.Seconds(),
+			ErrorCount:      s.errors,
+		}
+		if s.responses > 0 {
+			m.AvgResponseTime = (s.total / time.Duration(s.responses)).Seconds()
+		}
+		if s.requests > 0 {
+			m.ErrorRate = float64(s.errors) / float64(s.requests)
+		}
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Path != metrics[j].Path {
+			return metrics[i].Path < metrics[j].Path
+		}
+		return metrics[i].Method < metrics[j].Method
+	})
+	return metrics, nil
+}
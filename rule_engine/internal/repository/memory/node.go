@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// nodeRepository WAF节点内存仓储实现
+type nodeRepository struct {
+	s *Store
+}
+
+// NewNodeRepository 创建WAF节点仓储
+func NewNodeRepository(s *Store) repository.NodeRepository {
+	return &nodeRepository{s: mustStore(s)}
+}
+
+// CreateNode 注册节点，节点标识已存在时返回ErrRuleConflict
+func (r *nodeRepository) CreateNode(ctx context.Context, node *model.Node) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.nodes[node.NodeID]; ok {
+		return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("节点已存在: %s", node.NodeID))
+	}
+	node.ID = r.s.nextID("waf_nodes")
+	stamp(&node.RegisteredAt, &node.UpdatedAt)
+	r.s.nodes[node.NodeID] = copyNode(node)
+	return nil
+}
+
+// UpdateNode 更新节点，节点不存在时不做任何修改
+func (r *nodeRepository) UpdateNode(ctx context.Context, node *model.Node) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.nodes[node.NodeID]
+	if !ok {
+		return nil
+	}
+	node.UpdatedAt = time.Now()
+	c := copyNode(node)
+	c.ID = existing.ID
+	c.TokenHash = existing.TokenHash
+	c.RegisteredAt = existing.RegisteredAt
+	r.s.nodes[node.NodeID] = c
+	return nil
+}
+
+// GetNode 获取节点
+func (r *nodeRepository) GetNode(ctx context.Context, nodeID string) (*model.Node, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	node, ok := r.s.nodes[nodeID]
+	if !ok {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("节点不存在: %s", nodeID))
+	}
+	return copyNode(node), nil
+}
+
+// ListNodes 获取全部节点
+func (r *nodeRepository) ListNodes(ctx context.Context) ([]*model.Node, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	nodes := make([]*model.Node, 0, len(r.s.nodes))
+	for _, node := range r.s.nodes {
+		nodes = append(nodes, copyNode(node))
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+	return nodes, nil
+}
+
+// DeleteNode 删除节点
+func (r *nodeRepository) DeleteNode(ctx context.Context, nodeID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.nodes[nodeID]; !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("节点不存在: %s", nodeID))
+	}
+	delete(r.s.nodes, nodeID)
+	return nil
+}
+
+// MarkDown 将心跳超时的节点标记为下线
+func (r *nodeRepository) MarkDown(ctx context.Context, before time.Time) ([]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	marked := make([]string, 0)
+	now := time.Now()
+	for nodeID, node := range r.s.nodes {
+		if node.Status == model.NodeDown || node.LastHeartbeatAt == nil || !node.LastHeartbeatAt.Before(before) {
+			continue
+		}
+		node.Status = model.NodeDown
+		node.UpdatedAt = now
+		marked = append(marked, nodeID)
+	}
+	sort.Strings(marked)
+	return marked, nil
+}
+
+// copyNode 复制节点
+func copyNode(node *model.Node) *model.Node {
+	c := *node
+	if node.Counters != nil {
+		c.Counters = make(map[string]int64, len(node.Counters))
+		for k, v := range node.Counters {
+			c.Counters[k] = v
+		}
+	}
+	c.LastSyncErrorAt = copyTime(node.LastSyncErrorAt)
+	c.LastHeartbeatAt = copyTime(node.LastHeartbeatAt)
+	return &c
+}
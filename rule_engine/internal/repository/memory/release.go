@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// releaseRepository 规则集发布内存仓储实现
+type releaseRepository struct {
+	s *Store
+}
+
+// NewReleaseRepository 创建规则集发布仓储
+func NewReleaseRepository(s *Store) repository.ReleaseRepository {
+	return &releaseRepository{s: mustStore(s)}
+}
+
+// Snapshot 读取当前规则集
+func (r *releaseRepository) Snapshot(ctx context.Context) (*model.ReleaseSnapshot, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.snapshot(), nil
+}
+
+// CreateRelease 发布当前规则集
+func (r *releaseRepository) CreateRelease(ctx context.Context, release *model.RulesetRelease) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	release.Snapshot = r.s.snapshot()
+	if err := release.Seal(); err != nil {
+		return err
+	}
+	r.s.insertRelease(release)
+	return nil
+}
+
+// RestoreRelease 用快照替换当前规则集
+func (r *releaseRepository) RestoreRelease(ctx context.Context, release *model.RulesetRelease) error {
+	if err := release.Seal(); err != nil {
+		return err
+	}
+	snapshot := release.Snapshot
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	// 保留原ID，使回滚后的规则与历史发布一一对应
+	r.s.rules = make(map[int64]*model.Rule, len(snapshot.Rules))
+	for _, rule := range snapshot.Rules {
+		c := *rule
+		r.s.rules[c.ID] = &c
+		r.s.useID("rules", c.ID)
+	}
+	r.s.groups = make(map[int64]*model.RuleGroup, len(snapshot.Groups))
+	for _, group := range snapshot.Groups {
+		c := *group
+		r.s.groups[c.ID] = &c
+		r.s.useID("rule_groups", c.ID)
+	}
+	r.s.ipRules = make(map[int64]*model.IPRule, len(snapshot.IPRules))
+	for _, rule := range snapshot.IPRules {
+		c := *rule
+		r.s.ipRules[c.ID] = &c
+		r.s.useID("ip_rules", c.ID)
+	}
+	r.s.ccRules = make(map[int64]*model.CCRule, len(snapshot.CCRules))
+	for _, rule := range snapshot.CCRules {
+		c := *rule
+		r.s.ccRules[c.ID] = &c
+		r.s.useID("cc_rules", c.ID)
+	}
+	// 配置按最新一条生效，追加一条记录而不修改历史配置
+	if snapshot.Config != nil {
+		now := time.Now().Unix()
+		operator := strconv.FormatInt(release.CreatedBy, 10)
+		r.s.configs = append(r.s.configs, &model.WAFConfig{
+			ID:          r.s.nextID("waf_configs"),
+			Mode:        snapshot.Config.Mode,
+			Description: snapshot.Config.Description,
+			CreatedBy:   operator,
+			UpdatedBy:   operator,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+	r.s.insertRelease(release)
+	return nil
+}
+
+// GetRelease 获取发布
+func (r *releaseRepository) GetRelease(ctx context.Context, id int64) (*model.RulesetRelease, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, release := range r.s.releases {
+		if release.ID == id {
+			c := *release
+			return &c, nil
+		}
+	}
+	return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("发布不存在: %d", id))
+}
+
+// GetLatestRelease 获取最新发布
+func (r *releaseRepository) GetLatestRelease(ctx context.Context) (*model.RulesetRelease, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	if len(r.s.releases) == 0 {
+		return nil, nil
+	}
+	c := *r.s.releases[len(r.s.releases)-1]
+	return &c, nil
+}
+
+// ListReleases 获取发布列表
+func (r *releaseRepository) ListReleases(ctx context.Context, offset, limit int) ([]*model.RulesetRelease, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	releases := make([]*model.RulesetRelease, 0, len(r.s.releases))
+	for i := len(r.s.releases) - 1; i >= 0; i-- {
+		c := *r.s.releases[i]
+		c.Content = ""
+		releases = append(releases, &c)
+	}
+	return page(releases, offset, limit), int64(len(r.s.releases)), nil
+}
+
+// snapshot 复制当前规则集，调用方需持有锁
+func (s *Store) snapshot() *model.ReleaseSnapshot {
+	snapshot := &model.ReleaseSnapshot{
+		Rules:   make([]*model.Rule, 0, len(s.rules)),
+		Groups:  make([]*model.RuleGroup, 0, len(s.groups)),
+		IPRules: make([]*model.IPRule, 0, len(s.ipRules)),
+		CCRules: make([]*model.CCRule, 0, len(s.ccRules)),
+	}
+	for _, id := range sortedIDs(s.rules) {
+		c := *s.rules[id]
+		snapshot.Rules = append(snapshot.Rules, &c)
+	}
+	for _, id := range sortedIDs(s.groups) {
+		c := *s.groups[id]
+		snapshot.Groups = append(snapshot.Groups, &c)
+	}
+	for _, id := range sortedIDs(s.ipRules) {
+		c := *s.ipRules[id]
+		snapshot.IPRules = append(snapshot.IPRules, &c)
+	}
+	for _, id := range sortedIDs(s.ccRules) {
+		c := *s.ccRules[id]
+		snapshot.CCRules = append(snapshot.CCRules, &c)
+	}
+	if n := len(s.configs); n > 0 {
+		latest := s.configs[n-1]
+		snapshot.Config = &model.WAFConfig{
+			ID:          latest.ID,
+			Mode:        latest.Mode,
+			Description: latest.Description,
+		}
+	}
+	return snapshot
+}
+
+// insertRelease 保存发布，快照只保存序列化内容，调用方需持有写锁
+func (s *Store) insertRelease(release *model.RulesetRelease) {
+	release.ID = s.nextID("ruleset_releases")
+	stamp(&release.CreatedAt, nil)
+	c := *release
+	c.Snapshot = nil
+	s.releases = append(s.releases, &c)
+}
@@ -0,0 +1,377 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// ruleRepository 规则内存仓储实现，同时实现规则审计和规则统计仓储
+type ruleRepository struct {
+	s *Store
+}
+
+// NewRuleRepository 创建规则仓储
+func NewRuleRepository(s *Store) repository.RuleRepository {
+	return &ruleRepository{s: mustStore(s)}
+}
+
+// NewRuleAuditRepository 创建规则审计仓储，与规则仓储共享审计日志
+func NewRuleAuditRepository(s *Store) repository.RuleAuditRepository {
+	return &ruleRepository{s: mustStore(s)}
+}
+
+// NewRuleStatsRepository 创建规则统计仓储
+func NewRuleStatsRepository(s *Store) repository.RuleStatsRepository {
+	return &ruleRepository{s: mustStore(s)}
+}
+
+// CreateRule 创建规则
+func (r *ruleRepository) CreateRule(ctx context.Context, rule *model.Rule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.checkRuleCreate(rule, nil); err != nil {
+		return err
+	}
+	r.s.insertRule(rule)
+	return nil
+}
+
+// BatchCreateRules 批量创建规则，任一规则失败时全部不创建
+func (r *ruleRepository) BatchCreateRules(ctx context.Context, rules []*model.Rule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if err := r.s.checkRuleCreate(rule, names); err != nil {
+			return err
+		}
+		names[rule.Name] = true
+	}
+	for _, rule := range rules {
+		r.s.insertRule(rule)
+	}
+	return nil
+}
+
+// UpdateRule 更新规则，创建时间保持不变
+func (r *ruleRepository) UpdateRule(ctx context.Context, rule *model.Rule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.checkRuleUpdate(rule, nil); err != nil {
+		return err
+	}
+	r.s.replaceRule(rule)
+	return nil
+}
+
+// BatchUpdateRules 批量更新规则，任一规则失败时全部不更新
+func (r *ruleRepository) BatchUpdateRules(ctx context.Context, rules []*model.Rule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.s.updateRules(rules)
+}
+
+// DeleteRule 删除规则
+func (r *ruleRepository) DeleteRule(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.rules[id]; !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %d", id))
+	}
+	delete(r.s.rules, id)
+	return nil
+}
+
+// BatchDeleteRules 批量删除规则，存在任一规则时删除存在的规则
+func (r *ruleRepository) BatchDeleteRules(ctx context.Context, ids []int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	deleted := 0
+	for _, id := range ids {
+		if _, ok := r.s.rules[id]; ok {
+			delete(r.s.rules, id)
+			deleted++
+		}
+	}
+	if deleted == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, "未找到要删除的规则")
+	}
+	return nil
+}
+
+// GetRule 获取规则
+func (r *ruleRepository) GetRule(ctx context.Context, id int64) (*model.Rule, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	rule, ok := r.s.rules[id]
+	if !ok {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %d", id))
+	}
+	c := *rule
+	return &c, nil
+}
+
+// GetRuleByName 根据名称获取规则
+func (r *ruleRepository) GetRuleByName(ctx context.Context, name string) (*model.Rule, error) {
+	if name == "" {
+		return nil, errors.NewError(errors.ErrRuleValidation, "规则名称不能为空")
+	}
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	if rule := r.s.ruleByName(name); rule != nil {
+		c := *rule
+		return &c, nil
+	}
+	return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %s", name))
+}
+
+// ListRules 获取规则列表，按ID排序
+func (r *ruleRepository) ListRules(ctx context.Context, query *repository.RuleQuery) ([]*model.Rule, int64, error) {
+	if query == nil {
+		query = &repository.RuleQuery{}
+	}
+	keyword := strings.ToLower(query.Keyword)
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	matched := make([]*model.Rule, 0)
+	for _, id := range sortedIDs(r.s.rules) {
+		rule := r.s.rules[id]
+		if keyword != "" && !strings.Contains(strings.ToLower(rule.Name), keyword) &&
+			!strings.Contains(strings.ToLower(rule.Description), keyword) {
+			continue
+		}
+		if (query.Status != "" && rule.Status != query.Status) ||
+			(query.RuleType != "" && rule.Type != query.RuleType) ||
+			(query.RuleVariable != "" && rule.RuleVariable != query.RuleVariable) ||
+			(query.Severity != "" && rule.Severity != query.Severity) ||
+			(query.RulesOperation != "" && rule.RulesOperation != query.RulesOperation) ||
+			(query.TemplateID != "" && rule.TemplateID != query.TemplateID) ||
+			(query.GroupID != 0 && rule.GroupID != query.GroupID) {
+			continue
+		}
+		c := *rule
+		matched = append(matched, &c)
+	}
+
+	// 未指定分页时返回全部规则
+	total := int64(len(matched))
+	if query.PageSize > 0 {
+		p := query.Page
+		if p < 1 {
+			p = 1
+		}
+		matched = page(matched, (p-1)*query.PageSize, query.PageSize)
+	}
+	return matched, total, nil
+}
+
+// GetLatestVersion 获取规则的最新版本号
+func (r *ruleRepository) GetLatestVersion(ctx context.Context) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var version int64
+	for _, rule := range r.s.rules {
+		if rule.Version > version {
+			version = rule.Version
+		}
+	}
+	return version, nil
+}
+
+// ImportRules 导入规则，按名称匹配，已存在的规则更新，其余创建
+func (r *ruleRepository) ImportRules(ctx context.Context, rules []*model.Rule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, rule := range rules {
+		if existing := r.s.ruleByName(rule.Name); existing != nil {
+			rule.ID = existing.ID
+			r.s.replaceRule(rule)
+			continue
+		}
+		rule.ID = 0
+		r.s.insertRule(rule)
+	}
+	return nil
+}
+
+// BeginTx 开启事务
+func (r *ruleRepository) BeginTx(ctx context.Context) (repository.Transaction, error) {
+	return &memoryTx{}, nil
+}
+
+// GetRuleStats 获取规则统计信息
+func (r *ruleRepository) GetRuleStats(ctx context.Context) (*model.RuleStats, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	stats := &model.RuleStats{TotalRules: int64(len(r.s.rules))}
+	for _, rule := range r.s.rules {
+		switch rule.Status {
+		case model.StatusEnabled:
+			stats.EnabledRules++
+		case model.StatusDisabled:
+			stats.DisabledRules++
+		}
+	}
+	return stats, nil
+}
+
+// GetRuleMatchStats 获取规则匹配统计信息，不记录时间线，总数为累计匹配次数
+func (r *ruleRepository) GetRuleMatchStats(ctx context.Context, ruleID int64, startTime, endTime time.Time) (*model.RuleMatchStat, error) {
+	total, err := r.GetRuleMatchCount(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RuleMatchStat{
+		RuleID:    ruleID,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Total:     total,
+		Timeline:  make([]*model.RuleMatchPoint, 0),
+	}, nil
+}
+
+// IncrRuleMatchCount 增加规则匹配计数
+func (r *ruleRepository) IncrRuleMatchCount(ctx context.Context, ruleID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.matchCounts[ruleID]++
+	return nil
+}
+
+// GetRuleMatchCount 获取规则匹配计数，没有匹配时为0
+func (r *ruleRepository) GetRuleMatchCount(ctx context.Context, ruleID int64) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.matchCounts[ruleID], nil
+}
+
+// GetRuleAuditLogs 获取规则审计日志，按时间倒序
+func (r *ruleRepository) GetRuleAuditLogs(ctx context.Context, ruleID int64) ([]*model.RuleAuditLog, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	logs := make([]*model.RuleAuditLog, 0)
+	for _, log := range r.s.auditLogs {
+		if log.RuleID == ruleID {
+			c := *log
+			logs = append(logs, &c)
+		}
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		if !logs[i].CreatedAt.Equal(logs[j].CreatedAt) {
+			return logs[i].CreatedAt.After(logs[j].CreatedAt)
+		}
+		return logs[i].ID > logs[j].ID
+	})
+	return logs, nil
+}
+
+// CreateRuleAuditLog 创建规则审计日志
+func (r *ruleRepository) CreateRuleAuditLog(ctx context.Context, log *model.RuleAuditLog) error {
+	if log == nil {
+		return errors.NewError(errors.ErrValidation, "审计日志不能为空")
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	log.ID = r.s.nextID("rule_audit_logs")
+	stamp(&log.CreatedAt, nil)
+	c := *log
+	r.s.auditLogs = append(r.s.auditLogs, &c)
+	return nil
+}
+
+// CreateAuditLog 创建审计日志
+func (r *ruleRepository) CreateAuditLog(ctx context.Context, log *model.RuleAuditLog) error {
+	return r.CreateRuleAuditLog(ctx, log)
+}
+
+// GetAuditLogs 获取审计日志列表
+func (r *ruleRepository) GetAuditLogs(ctx context.Context, ruleID int64) ([]*model.RuleAuditLog, error) {
+	return r.GetRuleAuditLogs(ctx, ruleID)
+}
+
+// ruleByName 按名称查找规则，调用方需持有锁
+func (s *Store) ruleByName(name string) *model.Rule {
+	for _, rule := range s.rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// checkRuleCreate 检查规则能否创建，pending为同一批次中已检查的规则名称
+func (s *Store) checkRuleCreate(rule *model.Rule, pending map[string]bool) error {
+	if rule == nil {
+		return errors.NewError(errors.ErrValidation, "规则不能为空")
+	}
+	if _, ok := s.rules[rule.ID]; ok && rule.ID > 0 {
+		return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("规则ID已存在: %d", rule.ID))
+	}
+	if pending[rule.Name] || s.ruleByName(rule.Name) != nil {
+		return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("规则名称已存在: %s", rule.Name))
+	}
+	return nil
+}
+
+// checkRuleUpdate 检查规则能否更新，pending为同一批次中规则ID对应的新名称
+func (s *Store) checkRuleUpdate(rule *model.Rule, pending map[int64]string) error {
+	if rule == nil {
+		return errors.NewError(errors.ErrValidation, "规则不能为空")
+	}
+	if _, ok := s.rules[rule.ID]; !ok {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %d", rule.ID))
+	}
+	for id, existing := range s.rules {
+		name := existing.Name
+		if n, ok := pending[id]; ok {
+			name = n
+		}
+		if id != rule.ID && name == rule.Name {
+			return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("规则名称已存在: %s", rule.Name))
+		}
+	}
+	return nil
+}
+
+// updateRules 批量更新规则，任一规则失败时全部不更新，调用方需持有写锁
+func (s *Store) updateRules(rules []*model.Rule) error {
+	pending := make(map[int64]string, len(rules))
+	for _, rule := range rules {
+		if err := s.checkRuleUpdate(rule, pending); err != nil {
+			return err
+		}
+		pending[rule.ID] = rule.Name
+	}
+	for _, rule := range rules {
+		s.replaceRule(rule)
+	}
+	return nil
+}
+
+// insertRule 保存新规则，回写ID和时间，调用方需持有写锁
+func (s *Store) insertRule(rule *model.Rule) {
+	if rule.ID > 0 {
+		s.useID("rules", rule.ID)
+	} else {
+		rule.ID = s.nextID("rules")
+	}
+	stamp(&rule.CreatedAt, &rule.UpdatedAt)
+	c := *rule
+	s.rules[rule.ID] = &c
+}
+
+// replaceRule 替换已存在的规则，保留创建时间，调用方需持有写锁
+func (s *Store) replaceRule(rule *model.Rule) {
+	rule.UpdatedAt = time.Now()
+	c := *rule
+	c.CreatedAt = s.rules[rule.ID].CreatedAt
+	s.rules[rule.ID] = &c
+}
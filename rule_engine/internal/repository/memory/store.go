@@ -0,0 +1,156 @@
+// Package memory 内存仓储实现，用于测试服务代码和不需要持久化的单机场景
+// 错误语义与MySQL、SQLite实现相同，可通过repotest包验证
+package memory
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// Store 内存存储，同一Store创建的仓储共享数据，所有方法并发安全
+// 写入和读取时复制记录，调用方修改传入或返回的记录不影响已保存的数据
+type Store struct {
+	mu  sync.RWMutex
+	seq map[string]int64 // 各表的自增ID
+
+	rules        map[int64]*model.Rule
+	groups       map[int64]*model.RuleGroup
+	matchCounts  map[int64]int64
+	auditLogs    []*model.RuleAuditLog
+	versions     []*model.RuleVersion
+	syncLogs     []*model.RuleSyncLog
+	updateEvents []*model.RuleUpdateEvent
+	ipRules      map[int64]*model.IPRule
+	ccRules      map[int64]*model.CCRule
+	configs      []*model.WAFConfig
+	modeLogs     []*model.WAFModeChangeLog
+	releases     []*model.RulesetRelease
+	changes      map[int64]*model.ChangeRequest
+	changeItems  map[int64][]*model.ChangeItem
+	changeEvents []*model.ChangeEvent
+	bypasses     map[int64]*model.BypassConfig
+	attempts     []*model.BypassAttempt
+	keys         []*model.BypassKey
+	nodes        map[string]*model.Node
+	uploadEvents []*model.UploadEvent
+}
+
+// NewStore 创建空的内存存储
+func NewStore() *Store {
+	return &Store{
+		seq:         make(map[string]int64),
+		rules:       make(map[int64]*model.Rule),
+		groups:      make(map[int64]*model.RuleGroup),
+		matchCounts: make(map[int64]int64),
+		ipRules:     make(map[int64]*model.IPRule),
+		ccRules:     make(map[int64]*model.CCRule),
+		changes:     make(map[int64]*model.ChangeRequest),
+		changeItems: make(map[int64][]*model.ChangeItem),
+		bypasses:    make(map[int64]*model.BypassConfig),
+		nodes:       make(map[string]*model.Node),
+	}
+}
+
+// nextID 分配表的下一个ID，调用方需持有写锁
+func (s *Store) nextID(table string) int64 {
+	s.seq[table]++
+	return s.seq[table]
+}
+
+// useID 记录指定的ID，保证之后分配的ID更大，调用方需持有写锁
+func (s *Store) useID(table string, id int64) {
+	if id > s.seq[table] {
+		s.seq[table] = id
+	}
+}
+
+// mustStore 仓储构造函数的参数检查
+func mustStore(s *Store) *Store {
+	if s == nil {
+		panic("内存存储不能为空")
+	}
+	return s
+}
+
+// sortedIDs 按ID升序返回map的键
+func sortedIDs[T any](m map[int64]T) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// page 按偏移量和数量截取列表，limit<=0时返回偏移量之后的全部
+func page[T any](items []T, offset, limit int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return make([]T, 0)
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// stamp 设置创建和更新时间，未指定创建时间时使用当前时间
+func stamp(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt != nil && updatedAt.IsZero() {
+		*updatedAt = now
+	}
+}
+
+// copyStrings 复制字符串切片
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
+
+// copyTime 复制时间指针
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// memoryTx 内存存储的事务，与MySQL实现相同，仓储的其他方法不在该事务中执行
+type memoryTx struct {
+	mu   sync.Mutex
+	done bool
+}
+
+// Commit 提交事务，事务已结束时返回sql.ErrTxDone
+func (t *memoryTx) Commit() error {
+	return t.finish()
+}
+
+// Rollback 回滚事务，事务已结束时返回sql.ErrTxDone
+func (t *memoryTx) Rollback() error {
+	return t.finish()
+}
+
+func (t *memoryTx) finish() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	return nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// uploadEventRepository 上传违规事件内存仓储实现
+type uploadEventRepository struct {
+	s *Store
+}
+
+// NewUploadEventRepository 创建上传违规事件仓储
+func NewUploadEventRepository(s *Store) repository.UploadEventRepository {
+	return &uploadEventRepository{s: mustStore(s)}
+}
+
+// CreateUploadEvents 批量记录上传违规事件
+func (r *uploadEventRepository) CreateUploadEvents(ctx context.Context, events []*model.UploadEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, event := range events {
+		event.ID = uint64(r.s.nextID("upload_events"))
+		c := *event
+		r.s.uploadEvents = append(r.s.uploadEvents, &c)
+	}
+	return nil
+}
+
+// ListUploadEvents 获取上传违规事件
+func (r *uploadEventRepository) ListUploadEvents(ctx context.Context, query *model.UploadEventQuery, offset, limit int) ([]*model.UploadEvent, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	events := make([]*model.UploadEvent, 0)
+	for i := len(r.s.uploadEvents) - 1; i >= 0; i-- {
+		event := r.s.uploadEvents[i]
+		if query.IP != "" && event.IP != query.IP {
+			continue
+		}
+		if query.RequestID != "" && event.RequestID != query.RequestID {
+			continue
+		}
+		if query.Violation != "" && !strings.Contains(","+event.Violations+",", ","+query.Violation+",") {
+			continue
+		}
+		if query.StartTime > 0 && event.Timestamp < query.StartTime {
+			continue
+		}
+		if query.EndTime > 0 && event.Timestamp >= query.EndTime {
+			continue
+		}
+		c := *event
+		events = append(events, &c)
+	}
+	return page(events, offset, limit), int64(len(events)), nil
+}
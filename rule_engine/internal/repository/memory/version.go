@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// ruleVersionRepository 规则版本内存仓储实现
+type ruleVersionRepository struct {
+	s *Store
+}
+
+// NewRuleVersionRepository 创建规则版本仓储
+func NewRuleVersionRepository(s *Store) repository.RuleVersionRepository {
+	return &ruleVersionRepository{s: mustStore(s)}
+}
+
+// CreateVersion 创建规则版本
+func (r *ruleVersionRepository) CreateVersion(ctx context.Context, version *model.RuleVersion) error {
+	if version == nil {
+		return errors.NewError(errors.ErrValidation, "规则版本不能为空")
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	version.ID = r.s.nextID("rule_versions")
+	stamp(&version.CreatedAt, nil)
+	c := *version
+	r.s.versions = append(r.s.versions, &c)
+	return nil
+}
+
+// GetVersion 获取规则版本
+func (r *ruleVersionRepository) GetVersion(ctx context.Context, ruleID, version int64) (*model.RuleVersion, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for i := len(r.s.versions) - 1; i >= 0; i-- {
+		if v := r.s.versions[i]; v.RuleID == ruleID && v.Version == version {
+			c := *v
+			return &c, nil
+		}
+	}
+	return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则版本不存在: rule_id=%d, version=%d", ruleID, version))
+}
+
+// ListVersions 获取规则版本列表，按版本号倒序
+func (r *ruleVersionRepository) ListVersions(ctx context.Context, ruleID int64) ([]*model.RuleVersion, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	versions := make([]*model.RuleVersion, 0)
+	for _, v := range r.s.versions {
+		if v.RuleID == ruleID {
+			c := *v
+			versions = append(versions, &c)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Version != versions[j].Version {
+			return versions[i].Version > versions[j].Version
+		}
+		return versions[i].ID > versions[j].ID
+	})
+	return versions, nil
+}
+
+// GetRulesByVersion 获取在指定版本有版本记录的规则
+func (r *ruleVersionRepository) GetRulesByVersion(ctx context.Context, version int64) ([]*model.Rule, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	ids := make(map[int64]bool)
+	for _, v := range r.s.versions {
+		if v.Version == version {
+			ids[v.RuleID] = true
+		}
+	}
+	rules := make([]*model.Rule, 0, len(ids))
+	for _, id := range sortedIDs(r.s.rules) {
+		if ids[id] {
+			c := *r.s.rules[id]
+			rules = append(rules, &c)
+		}
+	}
+	return rules, nil
+}
+
+// GetLatestVersion 获取最新版本号
+func (r *ruleVersionRepository) GetLatestVersion(ctx context.Context) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var latest int64
+	for _, v := range r.s.versions {
+		if v.Version > latest {
+			latest = v.Version
+		}
+	}
+	return latest, nil
+}
+
+// RollbackRules 更新规则并记录更新事件，任一规则不存在时全部不更新
+func (r *ruleVersionRepository) RollbackRules(ctx context.Context, rules []*model.Rule, event *model.RuleUpdateEvent) error {
+	if event == nil {
+		return errors.NewError(errors.ErrValidation, "更新事件不能为空")
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.updateRules(rules); err != nil {
+		return err
+	}
+	event.ID = r.s.nextID("rule_update_events")
+	stamp(&event.CreatedAt, nil)
+	c := *event
+	c.RuleDiffs = make([]*model.RuleDiff, 0, len(event.RuleDiffs))
+	for _, diff := range event.RuleDiffs {
+		if diff == nil {
+			continue
+		}
+		d := *diff
+		c.RuleDiffs = append(c.RuleDiffs, &d)
+	}
+	r.s.updateEvents = append(r.s.updateEvents, &c)
+	return nil
+}
+
+// RefreshRules 刷新规则更新时间
+func (r *ruleVersionRepository) RefreshRules(ctx context.Context) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	for _, rule := range r.s.rules {
+		rule.UpdatedAt = now
+	}
+	return nil
+}
+
+// CreateSyncLog 创建同步日志
+func (r *ruleVersionRepository) CreateSyncLog(ctx context.Context, log *model.RuleSyncLog) error {
+	if log == nil {
+		return errors.NewError(errors.ErrValidation, "同步日志不能为空")
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	log.ID = r.s.nextID("rule_sync_logs")
+	stamp(&log.CreatedAt, nil)
+	c := *log
+	r.s.syncLogs = append(r.s.syncLogs, &c)
+	return nil
+}
+
+// ListSyncLogs 获取同步日志列表，按时间倒序
+func (r *ruleVersionRepository) ListSyncLogs(ctx context.Context, ruleID int64) ([]*model.RuleSyncLog, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	logs := make([]*model.RuleSyncLog, 0)
+	for _, log := range r.s.syncLogs {
+		if log.RuleID == ruleID {
+			c := *log
+			logs = append(logs, &c)
+		}
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		if !logs[i].CreatedAt.Equal(logs[j].CreatedAt) {
+			return logs[i].CreatedAt.After(logs[j].CreatedAt)
+		}
+		return logs[i].ID > logs[j].ID
+	})
+	return logs, nil
+}
@@ -0,0 +1,175 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// bypassRepository 旁路配置MySQL仓储实现
+type bypassRepository struct {
+	db *gorm.DB
+}
+
+// NewBypassRepository 创建旁路配置仓储
+func NewBypassRepository(db *gorm.DB) repository.BypassRepository {
+	return &bypassRepository{db: db}
+}
+
+// CreateBypass 创建旁路配置
+func (r *bypassRepository) CreateBypass(ctx context.Context, config *model.BypassConfig) error {
+	if err := r.db.WithContext(ctx).Create(config).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建旁路配置失败: %v", err))
+	}
+	return nil
+}
+
+// UpdateBypass 更新旁路配置
+func (r *bypassRepository) UpdateBypass(ctx context.Context, config *model.BypassConfig) error {
+	// 内容未变化时MySQL返回的影响行数为0，是否存在由调用方检查
+	err := r.db.WithContext(ctx).Model(config).Select("*").Omit("id", "created_by", "created_at").Updates(config).Error
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新旁路配置失败: %v", err))
+	}
+	return nil
+}
+
+// DeleteBypass 删除旁路配置，尝试记录保留
+func (r *bypassRepository) DeleteBypass(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).Delete(&model.BypassConfig{}, id)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("删除旁路配置失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("旁路配置不存在: %d", id))
+	}
+	return nil
+}
+
+// GetBypass 获取旁路配置
+func (r *bypassRepository) GetBypass(ctx context.Context, id int64) (*model.BypassConfig, error) {
+	var config model.BypassConfig
+	if err := r.db.WithContext(ctx).First(&config, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("旁路配置不存在: %d", id))
+		}
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取旁路配置失败: %v", err))
+	}
+	return &config, nil
+}
+
+// ListBypasses 获取旁路配置列表
+func (r *bypassRepository) ListBypasses(ctx context.Context, activeAt int64, offset, limit int) ([]*model.BypassConfig, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.BypassConfig{})
+	if activeAt > 0 {
+		db = db.Where("(end_time = 0 OR end_time > ?)", activeAt)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取旁路配置总数失败: %v", err))
+	}
+
+	configs := make([]*model.BypassConfig, 0)
+	query := db.Order("id DESC").Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&configs).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取旁路配置列表失败: %v", err))
+	}
+	return configs, total, nil
+}
+
+// CreateAttempts 批量记录旁路尝试
+func (r *bypassRepository) CreateAttempts(ctx context.Context, attempts []*model.BypassAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(attempts, 100).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("记录旁路尝试失败: %v", err))
+	}
+	return nil
+}
+
+// ListAttempts 获取旁路尝试记录
+func (r *bypassRepository) ListAttempts(ctx context.Context, query *model.BypassAttemptQuery, offset, limit int) ([]*model.BypassAttempt, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.BypassAttempt{})
+	if query.BypassID > 0 {
+		db = db.Where("bypass_id = ?", query.BypassID)
+	}
+	if query.TokenID != "" {
+		db = db.Where("token_id = ?", query.TokenID)
+	}
+	if query.IP != "" {
+		db = db.Where("ip = ?", query.IP)
+	}
+	if query.Success != nil {
+		db = db.Where("success = ?", *query.Success)
+	}
+	if query.StartTime > 0 {
+		db = db.Where("timestamp >= ?", query.StartTime)
+	}
+	if query.EndTime > 0 {
+		db = db.Where("timestamp < ?", query.EndTime)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取旁路尝试记录总数失败: %v", err))
+	}
+
+	attempts := make([]*model.BypassAttempt, 0)
+	q := db.Order("id DESC").Offset(offset)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&attempts).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取旁路尝试记录失败: %v", err))
+	}
+	return attempts, total, nil
+}
+
+// ListKeys 获取旁路令牌签名密钥
+func (r *bypassRepository) ListKeys(ctx context.Context) ([]*model.BypassKey, error) {
+	keys := make([]*model.BypassKey, 0)
+	if err := r.db.WithContext(ctx).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取旁路令牌密钥失败: %v", err))
+	}
+	return keys, nil
+}
+
+// RotateKey 将生效的密钥标记为已轮换并创建新密钥
+func (r *bypassRepository) RotateKey(ctx context.Context, key *model.BypassKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.BypassKey{}).
+			Where("status = ?", model.BypassKeyActive).
+			Updates(map[string]interface{}{"status": model.BypassKeyRetired, "retired_at": key.CreatedAt}).Error
+		if err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("轮换旁路令牌密钥失败: %v", err))
+		}
+		if err := tx.Create(key).Error; err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建旁路令牌密钥失败: %v", err))
+		}
+		return nil
+	})
+}
+
+// RevokeKey 吊销密钥
+func (r *bypassRepository) RevokeKey(ctx context.Context, keyID string, revokedAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.BypassKey{}).
+		Where("key_id = ? AND status <> ?", keyID, model.BypassKeyRevoked).
+		Updates(map[string]interface{}{"status": model.BypassKeyRevoked, "revoked_at": revokedAt})
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("吊销旁路令牌密钥失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("旁路令牌密钥不存在或已吊销: %s", keyID))
+	}
+	return nil
+}
@@ -25,6 +25,9 @@ func NewCCRuleRepository(db *sql.DB) repository.CCRuleRepository {
 
 // CreateCCRule 创建CC规则
 func (r *ccRuleRepository) CreateCCRule(ctx context.Context, rule *model.CCRule) error {
+	ctx, span := startSpan(ctx, "cc_rules", "CreateCCRule")
+	defer span.End()
+
 	if ctx == nil {
 		return errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -66,6 +69,9 @@ func (r *ccRuleRepository) CreateCCRule(ctx context.Context, rule *model.CCRule)
 
 // UpdateCCRule 更新CC规则
 func (r *ccRuleRepository) UpdateCCRule(ctx context.Context, rule *model.CCRule) error {
+	ctx, span := startSpan(ctx, "cc_rules", "UpdateCCRule")
+	defer span.End()
+
 	if ctx == nil {
 		return errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -110,6 +116,9 @@ func (r *ccRuleRepository) UpdateCCRule(ctx context.Context, rule *model.CCRule)
 
 // DeleteCCRule 删除CC规则
 func (r *ccRuleRepository) DeleteCCRule(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "cc_rules", "DeleteCCRule")
+	defer span.End()
+
 	if ctx == nil {
 		return errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -136,6 +145,9 @@ func (r *ccRuleRepository) DeleteCCRule(ctx context.Context, id int64) error {
 
 // GetCCRule 获取CC规则
 func (r *ccRuleRepository) GetCCRule(ctx context.Context, id int64) (*model.CCRule, error) {
+	ctx, span := startSpan(ctx, "cc_rules", "GetCCRule")
+	defer span.End()
+
 	if ctx == nil {
 		return nil, errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -165,6 +177,9 @@ func (r *ccRuleRepository) GetCCRule(ctx context.Context, id int64) (*model.CCRu
 
 // ListCCRules 获取CC规则列表
 func (r *ccRuleRepository) ListCCRules(ctx context.Context, offset, limit int) ([]*model.CCRule, error) {
+	ctx, span := startSpan(ctx, "cc_rules", "ListCCRules")
+	defer span.End()
+
 	if ctx == nil {
 		return nil, errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -25,6 +25,9 @@ func NewWAFConfigRepository(db *sql.DB) repository.WAFConfigRepository {
 
 // GetConfig 获取WAF配置
 func (r *wafConfigRepository) GetConfig(ctx context.Context) (*model.WAFConfig, error) {
+	ctx, span := startSpan(ctx, "waf_configs", "GetConfig")
+	defer span.End()
+
 	if ctx == nil {
 		return nil, errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -51,6 +54,9 @@ func (r *wafConfigRepository) GetConfig(ctx context.Context) (*model.WAFConfig,
 
 // UpdateConfig 更新WAF配置
 func (r *wafConfigRepository) UpdateConfig(ctx context.Context, config *model.WAFConfig) error {
+	ctx, span := startSpan(ctx, "waf_configs", "UpdateConfig")
+	defer span.End()
+
 	if ctx == nil {
 		return errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -91,7 +97,7 @@ func (r *wafConfigRepository) UpdateConfig(ctx context.Context, config *model.WA
 		query := `
 			UPDATE waf_configs SET
 				mode = ?, description = ?, updated_by = ?,
-				updated_at = CURRENT_TIMESTAMP
+				updated_at = UNIX_TIMESTAMP()
 			WHERE id = ?
 		`
 		result, err := r.db.ExecContext(ctx, query,
@@ -116,6 +122,9 @@ func (r *wafConfigRepository) UpdateConfig(ctx context.Context, config *model.WA
 
 // LogModeChange 记录模式变更日志
 func (r *wafConfigRepository) LogModeChange(ctx context.Context, log *model.WAFModeChangeLog) error {
+	ctx, span := startSpan(ctx, "waf_mode_change_logs", "LogModeChange")
+	defer span.End()
+
 	if ctx == nil {
 		return errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
@@ -154,6 +163,9 @@ func (r *wafConfigRepository) LogModeChange(ctx context.Context, log *model.WAFM
 
 // GetModeChangeLogs 获取模式变更日志
 func (r *wafConfigRepository) GetModeChangeLogs(ctx context.Context, startTime, endTime int64, page, pageSize int) ([]*model.WAFModeChangeLog, int64, error) {
+	ctx, span := startSpan(ctx, "waf_mode_change_logs", "GetModeChangeLogs")
+	defer span.End()
+
 	if ctx == nil {
 		return nil, 0, errors.NewError(errors.ErrValidation, "上下文不能为空")
 	}
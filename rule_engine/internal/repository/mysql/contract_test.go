@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/xwaf/rule_engine/internal/migrate"
+	"github.com/xwaf/rule_engine/internal/repository"
+	redisrepo "github.com/xwaf/rule_engine/internal/repository/redis"
+	"github.com/xwaf/rule_engine/internal/repository/repotest"
+	"github.com/xwaf/rule_engine/scripts/migrations"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 契约测试需要可用的MySQL和Redis，未设置 XWAF_TEST_MYSQL_DSN 时跳过:
+//
+//	XWAF_TEST_MYSQL_DSN="root:password@tcp(127.0.0.1:3306)/" \
+//	XWAF_TEST_REDIS_ADDR="127.0.0.1:6379" XWAF_TEST_REDIS_DB=15 go test ./internal/repository/mysql/
+//
+// 每个子测试创建一个临时数据库并执行全部迁移，结束后删除；Redis使用的库在每个子测试前清空
+func TestContract(t *testing.T) {
+	dsn := os.Getenv("XWAF_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("未设置XWAF_TEST_MYSQL_DSN，跳过MySQL契约测试")
+	}
+	redisAddr := os.Getenv("XWAF_TEST_REDIS_ADDR")
+	if redisAddr == "" {
+		redisAddr = "127.0.0.1:6379"
+	}
+	redisDB := 15
+	if v := os.Getenv("XWAF_TEST_REDIS_DB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			t.Fatalf("无效的XWAF_TEST_REDIS_DB: %v", err)
+		}
+		redisDB = n
+	}
+
+	server, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("连接MySQL失败: %v", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: redisAddr, DB: redisDB})
+	t.Cleanup(func() { rdb.Close() })
+
+	repotest.Run(t, func(t *testing.T) *repotest.Repositories {
+		ctx := context.Background()
+		name := fmt.Sprintf("xwaf_contract_%d", time.Now().UnixNano())
+		if err := server.Exec("CREATE DATABASE " + name + " CHARACTER SET utf8mb4").Error; err != nil {
+			t.Fatalf("创建测试数据库失败: %v", err)
+		}
+		t.Cleanup(func() { server.Exec("DROP DATABASE IF EXISTS " + name) })
+
+		db, err := gorm.Open(mysql.Open(dsn+name+"?charset=utf8mb4&parseTime=True&loc=Local"), &gorm.Config{
+			Logger:                 logger.Discard,
+			SkipDefaultTransaction: true,
+		})
+		if err != nil {
+			t.Fatalf("连接测试数据库失败: %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatalf("获取原始数据库连接失败: %v", err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+
+		migrator, err := migrate.New(sqlDB, migrations.FS, migrate.Options{})
+		if err != nil {
+			t.Fatalf("创建迁移失败: %v", err)
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			t.Fatalf("执行迁移失败: %v", err)
+		}
+		if err := rdb.FlushDB(ctx).Err(); err != nil {
+			t.Fatalf("清空Redis失败: %v", err)
+		}
+
+		cache := redisrepo.NewCacheRepository(rdb)
+		return &repotest.Repositories{
+			Rules:     NewRuleRepository(db, rdb),
+			Versions:  NewRuleVersionRepository(sqlDB),
+			IPs:       NewIPRuleRepository(sqlDB),
+			CC:        NewCCRuleRepository(sqlDB),
+			Configs:   NewWAFConfigRepository(sqlDB),
+			Releases:  NewReleaseRepository(db),
+			Changes:   NewChangeRequestRepository(db),
+			Bypasses:  NewBypassRepository(db),
+			Nodes:     NewNodeRepository(db),
+			Uploads:   NewUploadEventRepository(db),
+			Cache:     cache,
+			RuleCache: cache.(repository.RuleCache),
+		}
+	})
+}
@@ -22,14 +22,17 @@ func NewIPRuleRepository(db *sql.DB) repository.IPRuleRepository {
 
 // CreateIPRule 创建IP规则
 func (r *ipRuleRepository) CreateIPRule(ctx context.Context, rule *model.IPRule) error {
+	ctx, span := startSpan(ctx, "ip_rules", "CreateIPRule")
+	defer span.End()
+
 	query := `
 		INSERT INTO ip_rules (
-			ip, ip_type, block_type, expire_time, description,
+			entry_type, ip, ip_type, block_type, expire_time, description,
 			created_by, updated_by
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	result, err := r.db.ExecContext(ctx, query,
-		rule.IP, rule.IPType, rule.BlockType, rule.ExpireTime, rule.Description,
+		rule.EntryType, rule.IP, rule.IPType, rule.BlockType, rule.ExpireTime, rule.Description,
 		rule.CreatedBy, rule.UpdatedBy,
 	)
 	if err != nil {
@@ -47,6 +50,9 @@ func (r *ipRuleRepository) CreateIPRule(ctx context.Context, rule *model.IPRule)
 
 // UpdateIPRule 更新IP规则
 func (r *ipRuleRepository) UpdateIPRule(ctx context.Context, rule *model.IPRule) error {
+	ctx, span := startSpan(ctx, "ip_rules", "UpdateIPRule")
+	defer span.End()
+
 	query := `
 		UPDATE ip_rules SET
 			ip_type = ?, block_type = ?, expire_time = ?, description = ?,
@@ -74,6 +80,9 @@ func (r *ipRuleRepository) UpdateIPRule(ctx context.Context, rule *model.IPRule)
 
 // DeleteIPRule 删除IP规则
 func (r *ipRuleRepository) DeleteIPRule(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "ip_rules", "DeleteIPRule")
+	defer span.End()
+
 	query := "DELETE FROM ip_rules WHERE id = ?"
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -93,14 +102,17 @@ func (r *ipRuleRepository) DeleteIPRule(ctx context.Context, id int64) error {
 
 // GetIPRule 获取IP规则
 func (r *ipRuleRepository) GetIPRule(ctx context.Context, id int64) (*model.IPRule, error) {
+	ctx, span := startSpan(ctx, "ip_rules", "GetIPRule")
+	defer span.End()
+
 	query := `
-		SELECT id, ip, ip_type, block_type, expire_time, description,
+		SELECT id, entry_type, ip, ip_type, block_type, expire_time, description,
 			created_by, updated_by, created_at, updated_at
 		FROM ip_rules WHERE id = ?
 	`
 	var rule model.IPRule
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&rule.ID, &rule.IP, &rule.IPType, &rule.BlockType, &rule.ExpireTime,
+		&rule.ID, &rule.EntryType, &rule.IP, &rule.IPType, &rule.BlockType, &rule.ExpireTime,
 		&rule.Description, &rule.CreatedBy, &rule.UpdatedBy,
 		&rule.CreatedAt, &rule.UpdatedAt,
 	)
@@ -116,14 +128,17 @@ func (r *ipRuleRepository) GetIPRule(ctx context.Context, id int64) (*model.IPRu
 
 // GetIPRuleByIP 根据IP获取规则
 func (r *ipRuleRepository) GetIPRuleByIP(ctx context.Context, ip string) (*model.IPRule, error) {
+	ctx, span := startSpan(ctx, "ip_rules", "GetIPRuleByIP")
+	defer span.End()
+
 	query := `
-		SELECT id, ip, ip_type, block_type, expire_time, description,
+		SELECT id, entry_type, ip, ip_type, block_type, expire_time, description,
 			created_by, updated_by, created_at, updated_at
 		FROM ip_rules WHERE ip = ?
 	`
 	var rule model.IPRule
 	err := r.db.QueryRowContext(ctx, query, ip).Scan(
-		&rule.ID, &rule.IP, &rule.IPType, &rule.BlockType, &rule.ExpireTime,
+		&rule.ID, &rule.EntryType, &rule.IP, &rule.IPType, &rule.BlockType, &rule.ExpireTime,
 		&rule.Description, &rule.CreatedBy, &rule.UpdatedBy,
 		&rule.CreatedAt, &rule.UpdatedAt,
 	)
@@ -137,8 +152,37 @@ func (r *ipRuleRepository) GetIPRuleByIP(ctx context.Context, ip string) (*model
 	return &rule, nil
 }
 
+// GetIPRuleByEntry 根据条目类型和值获取规则
+func (r *ipRuleRepository) GetIPRuleByEntry(ctx context.Context, entryType model.IPEntryType, value string) (*model.IPRule, error) {
+	ctx, span := startSpan(ctx, "ip_rules", "GetIPRuleByEntry")
+	defer span.End()
+
+	query := `
+		SELECT id, entry_type, ip, ip_type, block_type, expire_time, description,
+			created_by, updated_by, created_at, updated_at
+		FROM ip_rules WHERE entry_type = ? AND ip = ?
+	`
+	var rule model.IPRule
+	err := r.db.QueryRowContext(ctx, query, entryType, value).Scan(
+		&rule.ID, &rule.EntryType, &rule.IP, &rule.IPType, &rule.BlockType, &rule.ExpireTime,
+		&rule.Description, &rule.CreatedBy, &rule.UpdatedBy,
+		&rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %s=%s", entryType, value))
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取IP规则失败: %v", err))
+	}
+
+	return &rule, nil
+}
+
 // ListIPRules 获取IP规则列表
 func (r *ipRuleRepository) ListIPRules(ctx context.Context, query *model.IPRuleQuery, offset, limit int) ([]*model.IPRule, int64, error) {
+	ctx, span := startSpan(ctx, "ip_rules", "ListIPRules")
+	defer span.End()
+
 	// 构建查询条件
 	conditions := []string{"1 = 1"}
 	args := []interface{}{}
@@ -148,6 +192,10 @@ func (r *ipRuleRepository) ListIPRules(ctx context.Context, query *model.IPRuleQ
 		keyword := "%" + query.Keyword + "%"
 		args = append(args, keyword, keyword)
 	}
+	if query.EntryType != "" {
+		conditions = append(conditions, "entry_type = ?")
+		args = append(args, query.EntryType)
+	}
 	if query.IPType != "" {
 		conditions = append(conditions, "ip_type = ?")
 		args = append(args, query.IPType)
@@ -169,7 +217,7 @@ func (r *ipRuleRepository) ListIPRules(ctx context.Context, query *model.IPRuleQ
 
 	// 查询列表
 	listQuery := fmt.Sprintf(`
-		SELECT id, ip, ip_type, block_type, expire_time, description,
+		SELECT id, entry_type, ip, ip_type, block_type, expire_time, description,
 			created_by, updated_by, created_at, updated_at
 		FROM ip_rules WHERE %s
 		ORDER BY created_at DESC LIMIT ? OFFSET ?
@@ -186,7 +234,7 @@ func (r *ipRuleRepository) ListIPRules(ctx context.Context, query *model.IPRuleQ
 	for rows.Next() {
 		var rule model.IPRule
 		err := rows.Scan(
-			&rule.ID, &rule.IP, &rule.IPType, &rule.BlockType, &rule.ExpireTime,
+			&rule.ID, &rule.EntryType, &rule.IP, &rule.IPType, &rule.BlockType, &rule.ExpireTime,
 			&rule.Description, &rule.CreatedBy, &rule.UpdatedBy,
 			&rule.CreatedAt, &rule.UpdatedAt,
 		)
@@ -205,6 +253,9 @@ func (r *ipRuleRepository) ListIPRules(ctx context.Context, query *model.IPRuleQ
 
 // ExistsByIP 检查IP是否存在规则
 func (r *ipRuleRepository) ExistsByIP(ctx context.Context, ip string) (bool, error) {
+	ctx, span := startSpan(ctx, "ip_rules", "ExistsByIP")
+	defer span.End()
+
 	query := "SELECT COUNT(*) FROM ip_rules WHERE ip = ?"
 	var count int
 	err := r.db.QueryRowContext(ctx, query, ip).Scan(&count)
@@ -1,12 +1,16 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
 	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -81,3 +85,13 @@ func InitMySQL(config *Config) error {
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// startSpan 为直接使用database/sql的仓储方法创建Span，基于gorm的仓储由回调记录
+// 没有父Span时返回的Span不记录，避免后台任务的查询各自产生新链路
+func startSpan(ctx context.Context, table, method string) (context.Context, trace.Span) {
+	return tracing.StartChild(ctx, "mysql "+method, trace.SpanKindClient,
+		attribute.String("db.system", "mysql"),
+		attribute.String("db.sql.table", table),
+		attribute.String("db.operation", method),
+	)
+}
@@ -79,7 +79,7 @@ func (r *RuleRepository) ListRules(ctx context.Context, query *repository.RuleQu
 		db = db.Where("status = ?", query.Status)
 	}
 	if query.RuleType != "" {
-		db = db.Where("rule_type = ?", query.RuleType)
+		db = db.Where("type = ?", query.RuleType)
 	}
 	if query.RuleVariable != "" {
 		db = db.Where("rule_variable = ?", query.RuleVariable)
@@ -90,13 +90,19 @@ func (r *RuleRepository) ListRules(ctx context.Context, query *repository.RuleQu
 	if query.RulesOperation != "" {
 		db = db.Where("rules_operation = ?", query.RulesOperation)
 	}
+	if query.TemplateID != "" {
+		db = db.Where("template_id = ?", query.TemplateID)
+	}
 
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取规则总数失败: %v", err))
 	}
 
-	offset := (query.Page - 1) * query.PageSize
-	if err := db.Offset(offset).Limit(query.PageSize).Find(&rules).Error; err != nil {
+	// 未指定分页时返回全部规则
+	if query.PageSize > 0 {
+		db = db.Offset((query.Page - 1) * query.PageSize).Limit(query.PageSize)
+	}
+	if err := db.Find(&rules).Error; err != nil {
 		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询规则列表失败: %v", err))
 	}
 
@@ -490,7 +496,7 @@ func (r *RuleRepository) ExportRules(ctx context.Context, query *repository.Rule
 	db := r.db.WithContext(ctx)
 
 	if query.RuleType != "" {
-		db = db.Where("rule_type = ?", query.RuleType)
+		db = db.Where("type = ?", query.RuleType)
 	}
 	if query.Status != "" {
 		db = db.Where("status = ?", query.Status)
@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// uploadEventRepository 上传违规事件MySQL仓储实现
+type uploadEventRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadEventRepository 创建上传违规事件仓储
+func NewUploadEventRepository(db *gorm.DB) repository.UploadEventRepository {
+	return &uploadEventRepository{db: db}
+}
+
+// CreateUploadEvents 批量记录上传违规事件
+func (r *uploadEventRepository) CreateUploadEvents(ctx context.Context, events []*model.UploadEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(events, 100).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("记录上传违规事件失败: %v", err))
+	}
+	return nil
+}
+
+// ListUploadEvents 获取上传违规事件
+func (r *uploadEventRepository) ListUploadEvents(ctx context.Context, query *model.UploadEventQuery, offset, limit int) ([]*model.UploadEvent, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.UploadEvent{})
+	if query.IP != "" {
+		db = db.Where("ip = ?", query.IP)
+	}
+	if query.RequestID != "" {
+		db = db.Where("request_id = ?", query.RequestID)
+	}
+	if query.Violation != "" {
+		db = db.Where("CONCAT(',', violations, ',') LIKE ?", "%,"+query.Violation+",%")
+	}
+	if query.StartTime > 0 {
+		db = db.Where("timestamp >= ?", query.StartTime)
+	}
+	if query.EndTime > 0 {
+		db = db.Where("timestamp < ?", query.EndTime)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取上传违规事件总数失败: %v", err))
+	}
+
+	events := make([]*model.UploadEvent, 0)
+	q := db.Order("id DESC").Offset(offset)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&events).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取上传违规事件失败: %v", err))
+	}
+	return events, total, nil
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/xwaf/rule_engine/internal/errors"
@@ -22,6 +23,9 @@ func NewRuleVersionRepository(db *sql.DB) repository.RuleVersionRepository {
 
 // CreateVersion 创建规则版本
 func (r *ruleVersionRepository) CreateVersion(ctx context.Context, version *model.RuleVersion) error {
+	ctx, span := startSpan(ctx, "rule_versions", "CreateVersion")
+	defer span.End()
+
 	query := `
 		INSERT INTO rule_versions (rule_id, version, hash, content, change_type, status, created_by)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
@@ -45,6 +49,9 @@ func (r *ruleVersionRepository) CreateVersion(ctx context.Context, version *mode
 
 // GetVersion 获取规则版本
 func (r *ruleVersionRepository) GetVersion(ctx context.Context, ruleID, version int64) (*model.RuleVersion, error) {
+	ctx, span := startSpan(ctx, "rule_versions", "GetVersion")
+	defer span.End()
+
 	query := `
 		SELECT id, rule_id, version, hash, content, change_type, status, created_by, created_at
 		FROM rule_versions WHERE rule_id = ? AND version = ?
@@ -65,6 +72,9 @@ func (r *ruleVersionRepository) GetVersion(ctx context.Context, ruleID, version
 
 // ListVersions 获取规则版本列表
 func (r *ruleVersionRepository) ListVersions(ctx context.Context, ruleID int64) ([]*model.RuleVersion, error) {
+	ctx, span := startSpan(ctx, "rule_versions", "ListVersions")
+	defer span.End()
+
 	query := `
 		SELECT id, rule_id, version, hash, content, change_type, status, created_by, created_at
 		FROM rule_versions WHERE rule_id = ? ORDER BY version DESC
@@ -97,6 +107,9 @@ func (r *ruleVersionRepository) ListVersions(ctx context.Context, ruleID int64)
 
 // GetLatestVersion 获取最新版本号
 func (r *ruleVersionRepository) GetLatestVersion(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "rule_versions", "GetLatestVersion")
+	defer span.End()
+
 	query := "SELECT COALESCE(MAX(version), 0) FROM rule_versions"
 	var version int64
 	err := r.db.QueryRowContext(ctx, query).Scan(&version)
@@ -108,6 +121,9 @@ func (r *ruleVersionRepository) GetLatestVersion(ctx context.Context) (int64, er
 
 // CreateSyncLog 创建同步日志
 func (r *ruleVersionRepository) CreateSyncLog(ctx context.Context, log *model.RuleSyncLog) error {
+	ctx, span := startSpan(ctx, "rule_sync_logs", "CreateSyncLog")
+	defer span.End()
+
 	query := `
 		INSERT INTO rule_sync_logs (rule_id, version, status, message, created_by)
 		VALUES (?, ?, ?, ?, ?)
@@ -130,6 +146,9 @@ func (r *ruleVersionRepository) CreateSyncLog(ctx context.Context, log *model.Ru
 
 // ListSyncLogs 获取同步日志列表
 func (r *ruleVersionRepository) ListSyncLogs(ctx context.Context, ruleID int64) ([]*model.RuleSyncLog, error) {
+	ctx, span := startSpan(ctx, "rule_sync_logs", "ListSyncLogs")
+	defer span.End()
+
 	query := `
 		SELECT id, rule_id, version, status, message, created_by, created_at
 		FROM rule_sync_logs WHERE rule_id = ? ORDER BY created_at DESC
@@ -162,6 +181,9 @@ func (r *ruleVersionRepository) ListSyncLogs(ctx context.Context, ruleID int64)
 
 // GetRulesByVersion 获取指定版本的规则列表
 func (r *ruleVersionRepository) GetRulesByVersion(ctx context.Context, version int64) ([]*model.Rule, error) {
+	ctx, span := startSpan(ctx, "rules", "GetRulesByVersion")
+	defer span.End()
+
 	query := `
 		SELECT r.* FROM rules r
 		INNER JOIN rule_versions rv ON r.id = rv.rule_id
@@ -196,6 +218,9 @@ func (r *ruleVersionRepository) GetRulesByVersion(ctx context.Context, version i
 
 // RefreshRules 刷新规则
 func (r *ruleVersionRepository) RefreshRules(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "rules", "RefreshRules")
+	defer span.End()
+
 	query := `UPDATE rules SET updated_at = CURRENT_TIMESTAMP`
 	_, err := r.db.ExecContext(ctx, query)
 	if err != nil {
@@ -206,6 +231,9 @@ func (r *ruleVersionRepository) RefreshRules(ctx context.Context) error {
 
 // RollbackRules 回滚规则
 func (r *ruleVersionRepository) RollbackRules(ctx context.Context, rules []*model.Rule, event *model.RuleUpdateEvent) error {
+	ctx, span := startSpan(ctx, "rules", "RollbackRules")
+	defer span.End()
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("开始事务失败: %v", err)
@@ -229,13 +257,17 @@ func (r *ruleVersionRepository) RollbackRules(ctx context.Context, rules []*mode
 		}
 	}
 
-	// 记录更新事件
+	// 记录更新事件，规则差异保存为JSON
+	diffs, err := json.Marshal(event.RuleDiffs)
+	if err != nil {
+		return fmt.Errorf("序列化规则差异失败: %v", err)
+	}
 	query := `
 		INSERT INTO rule_update_events (version, action, rule_diffs)
 		VALUES (?, ?, ?)
 	`
 	_, err = tx.ExecContext(ctx, query,
-		event.Version, event.Action, event.RuleDiffs,
+		event.Version, event.Action, string(diffs),
 	)
 	if err != nil {
 		return fmt.Errorf("记录更新事件失败: %v", err)
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// NodeRepository WAF节点仓储接口
+type NodeRepository interface {
+	// CreateNode 注册节点
+	// 返回错误:
+	// - ErrRuleConflict: 节点标识已存在
+	CreateNode(ctx context.Context, node *model.Node) error
+
+	// UpdateNode 按节点标识更新节点，不修改令牌和注册时间，不检查节点是否存在
+	UpdateNode(ctx context.Context, node *model.Node) error
+
+	// GetNode 获取节点
+	// 返回错误:
+	// - ErrRuleNotFound: 节点不存在
+	GetNode(ctx context.Context, nodeID string) (*model.Node, error)
+
+	// ListNodes 获取全部节点，按节点标识升序
+	ListNodes(ctx context.Context) ([]*model.Node, error)
+
+	// DeleteNode 删除节点
+	// 返回错误:
+	// - ErrRuleNotFound: 节点不存在
+	DeleteNode(ctx context.Context, nodeID string) error
+
+	// MarkDown 将最近一次心跳早于before且未下线的节点标记为下线，返回被标记的节点标识
+	MarkDown(ctx context.Context, before time.Time) ([]string, error)
+}
@@ -4,20 +4,42 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/xwaf/rule_engine/internal/errors"
 	"github.com/xwaf/rule_engine/internal/model"
 	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/metrics"
 )
 
 const (
-	ruleKeyPrefix = "waf:rule:"
-	ruleExpire    = 24 * time.Hour
+	ruleKeyPrefix     = "waf:rule:"
+	defaultRuleExpire = 24 * time.Hour
 )
 
+// ruleTTL 规则缓存过期时间，可在运行时修改
+type ruleTTL struct {
+	ttl atomic.Int64
+}
+
+// SetRuleTTL 设置规则缓存过期时间，ttl<=0时使用默认值
+func (t *ruleTTL) SetRuleTTL(ttl time.Duration) {
+	t.ttl.Store(int64(ttl))
+}
+
+// ruleExpire 当前规则缓存过期时间
+func (t *ruleTTL) ruleExpire() time.Duration {
+	if ttl := time.Duration(t.ttl.Load()); ttl > 0 {
+		return ttl
+	}
+	return defaultRuleExpire
+}
+
 // redisCache Redis缓存实现
 type redisCache struct {
+	ruleTTL
 	client *redis.Client
 }
 
@@ -37,9 +59,14 @@ func (c *redisCache) Set(ctx context.Context, key string, value interface{}, exp
 	return c.client.Set(ctx, key, data, expiration).Err()
 }
 
-// Get 获取缓存
+// Get 获取缓存，不存在时返回ErrCacheMiss
 func (c *redisCache) Get(ctx context.Context, key string, value interface{}) error {
+	start := time.Now()
 	data, err := c.client.Get(ctx, key).Bytes()
+	metrics.RecordCacheOperation("get", err == nil, time.Since(start))
+	if err == redis.Nil {
+		return errors.NewError(errors.ErrCacheMiss, fmt.Sprintf("缓存不存在: %s", key))
+	}
 	if err != nil {
 		return err
 	}
@@ -100,7 +127,7 @@ func (c *redisCache) Publish(ctx context.Context, channel string, message interf
 // SetRule 设置规则缓存
 func (c *redisCache) SetRule(ctx context.Context, rule *model.Rule) error {
 	key := fmt.Sprintf("%s%d", ruleKeyPrefix, rule.ID)
-	return c.Set(ctx, key, rule, ruleExpire)
+	return c.Set(ctx, key, rule, c.ruleExpire())
 }
 
 // GetRule 获取规则缓存
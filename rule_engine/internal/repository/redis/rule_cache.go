@@ -4,14 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/xwaf/rule_engine/internal/model"
 	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/metrics"
 )
 
 // redisRuleCache Redis规则缓存实现
 type redisRuleCache struct {
+	ruleTTL
 	client *redis.Client
 }
 
@@ -30,13 +33,15 @@ func (c *redisRuleCache) SetRule(ctx context.Context, rule *model.Rule) error {
 	}
 
 	key := fmt.Sprintf("%s%d", ruleKeyPrefix, rule.ID)
-	return c.client.Set(ctx, key, data, ruleExpire).Err()
+	return c.client.Set(ctx, key, data, c.ruleExpire()).Err()
 }
 
 // GetRule 获取规则缓存
 func (c *redisRuleCache) GetRule(ctx context.Context, id int64) (*model.Rule, error) {
 	key := fmt.Sprintf("%s%d", ruleKeyPrefix, id)
+	start := time.Now()
 	data, err := c.client.Get(ctx, key).Bytes()
+	metrics.RecordCacheOperation("get_rule", err == nil, time.Since(start))
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil
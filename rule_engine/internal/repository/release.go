@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// ReleaseRepository 规则集发布仓储接口
+type ReleaseRepository interface {
+	// Snapshot 在同一事务中读取当前规则集
+	Snapshot(ctx context.Context) (*model.ReleaseSnapshot, error)
+
+	// CreateRelease 读取当前规则集快照并保存发布，快照读取和保存在同一事务中完成
+	CreateRelease(ctx context.Context, release *model.RulesetRelease) error
+
+	// RestoreRelease 用发布中的快照替换当前规则集并保存为新发布
+	// 返回错误:
+	// - ErrSystem: 系统错误，如数据库操作失败，此时当前规则集保持不变
+	RestoreRelease(ctx context.Context, release *model.RulesetRelease) error
+
+	// GetRelease 获取发布（含快照内容）
+	// 返回错误:
+	// - ErrRuleNotFound: 发布不存在
+	GetRelease(ctx context.Context, id int64) (*model.RulesetRelease, error)
+
+	// GetLatestRelease 获取最新发布，没有发布时返回nil
+	GetLatestRelease(ctx context.Context) (*model.RulesetRelease, error)
+
+	// ListReleases 获取发布列表（不含快照内容），按ID倒序
+	ListReleases(ctx context.Context, offset, limit int) ([]*model.RulesetRelease, int64, error)
+}
@@ -0,0 +1,181 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testBypasses 旁路配置仓储契约
+func testBypasses(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Bypasses != nil }, []subtest{
+		{"CRUD", testBypassCRUD},
+		{"ListActive", testBypassListActive},
+		{"Attempts", testBypassAttempts},
+		{"Keys", testBypassKeys},
+	})
+}
+
+// newBypass 生成可以保存的旁路配置
+func newBypass(endTime int64) *model.BypassConfig {
+	return &model.BypassConfig{
+		Mode:      model.BypassModePartial,
+		IPs:       []string{"10.0.0.1"},
+		URLs:      []string{"^/health"},
+		Headers:   []string{},
+		EndTime:   endTime,
+		Reason:    "契约测试",
+		CreatedBy: 1,
+		UpdatedBy: 1,
+	}
+}
+
+func testBypassCRUD(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	config := newBypass(time.Now().Add(time.Hour).Unix())
+	must(t, r.Bypasses.CreateBypass(ctx, config))
+	if config.ID <= 0 {
+		t.Fatalf("创建后未设置旁路配置ID: %d", config.ID)
+	}
+
+	config.IPs = append(config.IPs, "10.0.0.2")
+	config.Reason = "已更新"
+	config.UpdatedBy = 2
+	must(t, r.Bypasses.UpdateBypass(ctx, config))
+	got, err := r.Bypasses.GetBypass(ctx, config.ID)
+	must(t, err)
+	if len(got.IPs) != 2 || got.Reason != "已更新" || got.CreatedBy != 1 || got.UpdatedBy != 2 {
+		t.Fatalf("旁路配置未更新: %+v", got)
+	}
+
+	must(t, r.Bypasses.DeleteBypass(ctx, config.ID))
+	_, err = r.Bypasses.GetBypass(ctx, config.ID)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	wantCode(t, r.Bypasses.DeleteBypass(ctx, config.ID), errors.ErrRuleNotFound)
+}
+
+func testBypassListActive(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	_, beforeAll, err := r.Bypasses.ListBypasses(ctx, 0, 0, 0)
+	must(t, err)
+	_, beforeActive, err := r.Bypasses.ListBypasses(ctx, now, 0, 0)
+	must(t, err)
+
+	for _, end := range []int64{now - 60, now + 3600, 0} {
+		must(t, r.Bypasses.CreateBypass(ctx, newBypass(end)))
+	}
+
+	_, total, err := r.Bypasses.ListBypasses(ctx, 0, 0, 0)
+	must(t, err)
+	if total-beforeAll != 3 {
+		t.Fatalf("全部旁路配置新增%d条，期望3条", total-beforeAll)
+	}
+	configs, total, err := r.Bypasses.ListBypasses(ctx, now, 0, 1)
+	must(t, err)
+	if total-beforeActive != 2 || len(configs) != 1 {
+		t.Fatalf("未过期的旁路配置新增%d条，返回%d条，期望新增2条，返回1条", total-beforeActive, len(configs))
+	}
+	if configs[0].EndTime != 0 {
+		t.Fatalf("旁路配置列表未按ID倒序，第一条结束时间为%d", configs[0].EndTime)
+	}
+}
+
+func testBypassAttempts(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	must(t, r.Bypasses.CreateAttempts(ctx, nil))
+
+	ip := uniqueIP()
+	base := time.Now().Unix()
+	attempts := make([]*model.BypassAttempt, 0, 4)
+	for i := int64(0); i < 4; i++ {
+		attempts = append(attempts, &model.BypassAttempt{
+			BypassID:  7,
+			IP:        ip,
+			URL:       "/health",
+			Mode:      model.BypassModePartial,
+			Timestamp: base + i,
+			Success:   i%2 == 0,
+		})
+	}
+	must(t, r.Bypasses.CreateAttempts(ctx, attempts))
+
+	got, total, err := r.Bypasses.ListAttempts(ctx, &model.BypassAttemptQuery{IP: ip}, 0, 3)
+	must(t, err)
+	if total != 4 || len(got) != 3 {
+		t.Fatalf("返回%d条，总数%d，期望3条，总数4", len(got), total)
+	}
+	if got[0].Timestamp != base+3 {
+		t.Fatalf("旁路尝试记录未按时间倒序，第一条时间为%d", got[0].Timestamp)
+	}
+
+	success := true
+	_, total, err = r.Bypasses.ListAttempts(ctx, &model.BypassAttemptQuery{IP: ip, Success: &success}, 0, 0)
+	must(t, err)
+	if total != 2 {
+		t.Fatalf("成功的尝试记录总数为%d，期望2", total)
+	}
+	_, total, err = r.Bypasses.ListAttempts(ctx, &model.BypassAttemptQuery{IP: ip, StartTime: base + 1, EndTime: base + 3}, 0, 0)
+	must(t, err)
+	if total != 2 {
+		t.Fatalf("时间范围内的尝试记录总数为%d，期望2", total)
+	}
+}
+
+func testBypassKeys(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	first := &model.BypassKey{
+		KeyID:     uniqueName("key"),
+		Secret:    []byte("0123456789abcdef0123456789abcdef"),
+		Status:    model.BypassKeyActive,
+		CreatedBy: 1,
+		CreatedAt: time.Now().Add(-time.Hour).Truncate(time.Second),
+	}
+	must(t, r.Bypasses.RotateKey(ctx, first))
+	second := &model.BypassKey{
+		KeyID:     uniqueName("key"),
+		Secret:    []byte("fedcba9876543210fedcba9876543210"),
+		Status:    model.BypassKeyActive,
+		CreatedBy: 1,
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+	must(t, r.Bypasses.RotateKey(ctx, second))
+
+	keys := findKeys(t, r, first.KeyID, second.KeyID)
+	if keys[0].Status != model.BypassKeyRetired || keys[0].RetiredAt == nil {
+		t.Fatalf("轮换后原密钥未标记为已轮换: %s", keys[0].Status)
+	}
+	if keys[1].Status != model.BypassKeyActive || string(keys[1].Secret) != string(second.Secret) {
+		t.Fatalf("新密钥状态或内容不正确: %s", keys[1].Status)
+	}
+
+	must(t, r.Bypasses.RevokeKey(ctx, first.KeyID, time.Now()))
+	keys = findKeys(t, r, first.KeyID, second.KeyID)
+	if keys[0].Status != model.BypassKeyRevoked || keys[0].RevokedAt == nil {
+		t.Fatalf("密钥未吊销: %s", keys[0].Status)
+	}
+	wantCode(t, r.Bypasses.RevokeKey(ctx, first.KeyID, time.Now()), errors.ErrRuleNotFound)
+	wantCode(t, r.Bypasses.RevokeKey(ctx, uniqueName("missing-key"), time.Now()), errors.ErrRuleNotFound)
+}
+
+// findKeys 按密钥标识查找密钥，不存在时测试失败
+func findKeys(t *testing.T, r *Repositories, keyIDs ...string) []*model.BypassKey {
+	t.Helper()
+	keys, err := r.Bypasses.ListKeys(context.Background())
+	must(t, err)
+	found := make([]*model.BypassKey, len(keyIDs))
+	for i, keyID := range keyIDs {
+		for _, key := range keys {
+			if key.KeyID == keyID {
+				found[i] = key
+			}
+		}
+		if found[i] == nil {
+			t.Fatalf("密钥不存在: %s", keyID)
+		}
+	}
+	return found
+}
@@ -0,0 +1,86 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+)
+
+// testCache 缓存契约
+func testCache(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Cache != nil }, []subtest{
+		{"GetSetDelete", testCacheGetSetDelete},
+		{"Expire", testCacheExpire},
+	})
+	runAll(t, newRepos, func(r *Repositories) bool { return r.RuleCache != nil }, []subtest{
+		{"Rules", testRuleCache},
+	})
+}
+
+// cacheValue 缓存测试的值
+type cacheValue struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+func testCacheGetSetDelete(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	key := uniqueName("repotest:cache")
+	var got cacheValue
+	wantCode(t, r.Cache.Get(ctx, key, &got), errors.ErrCacheMiss)
+
+	want := cacheValue{Name: "a", Count: 2, Tags: []string{"x", "y"}}
+	must(t, r.Cache.Set(ctx, key, want, time.Minute))
+	must(t, r.Cache.Get(ctx, key, &got))
+	if got.Name != want.Name || got.Count != want.Count || len(got.Tags) != 2 {
+		t.Fatalf("缓存的值不正确: %+v", got)
+	}
+
+	must(t, r.Cache.Delete(ctx, key))
+	wantCode(t, r.Cache.Get(ctx, key, &got), errors.ErrCacheMiss)
+	must(t, r.Cache.Delete(ctx, key))
+}
+
+func testCacheExpire(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	key := uniqueName("repotest:expire")
+	must(t, r.Cache.Set(ctx, key, cacheValue{Name: "a"}, 50*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	var got cacheValue
+	wantCode(t, r.Cache.Get(ctx, key, &got), errors.ErrCacheMiss)
+}
+
+func testRuleCache(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("cached"))
+	rule.ID = seq.Add(1) + 1000000
+	_, err := r.RuleCache.GetRule(ctx, rule.ID)
+	wantCode(t, err, errors.ErrCacheMiss)
+
+	must(t, r.RuleCache.SetRule(ctx, rule))
+	got, err := r.RuleCache.GetRule(ctx, rule.ID)
+	must(t, err)
+	if got.Name != rule.Name || got.Pattern != rule.Pattern {
+		t.Fatalf("缓存的规则不正确: %+v", got)
+	}
+
+	must(t, r.RuleCache.DeleteRule(ctx, rule.ID))
+	_, err = r.RuleCache.GetRule(ctx, rule.ID)
+	wantCode(t, err, errors.ErrCacheMiss)
+
+	must(t, r.RuleCache.SetRule(ctx, rule))
+	must(t, r.RuleCache.ClearRules(ctx))
+	_, err = r.RuleCache.GetRule(ctx, rule.ID)
+	wantCode(t, err, errors.ErrCacheMiss)
+
+	// 过期时间只影响之后写入的缓存
+	r.RuleCache.SetRuleTTL(50 * time.Millisecond)
+	defer r.RuleCache.SetRuleTTL(0)
+	must(t, r.RuleCache.SetRule(ctx, rule))
+	time.Sleep(100 * time.Millisecond)
+	_, err = r.RuleCache.GetRule(ctx, rule.ID)
+	wantCode(t, err, errors.ErrCacheMiss)
+}
@@ -0,0 +1,94 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testCCRules CC规则仓储契约
+func testCCRules(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.CC != nil }, []subtest{
+		{"CRUD", testCCRuleCRUD},
+		{"Validation", testCCRuleValidation},
+		{"List", testCCRuleList},
+	})
+}
+
+// newCCRule 生成可以保存的CC规则
+func newCCRule(uri string) *model.CCRule {
+	return &model.CCRule{
+		URI:        uri,
+		LimitRate:  100,
+		TimeWindow: 1,
+		LimitUnit:  model.LimitUnitMinute,
+		Status:     model.CCStatusEnabled,
+	}
+}
+
+func testCCRuleCRUD(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newCCRule("/" + uniqueName("cc"))
+	must(t, r.CC.CreateCCRule(ctx, rule))
+	if rule.ID <= 0 {
+		t.Fatalf("创建后未设置CC规则ID: %d", rule.ID)
+	}
+	wantCode(t, r.CC.CreateCCRule(ctx, newCCRule(rule.URI)), errors.ErrRuleConflict)
+
+	rule.LimitRate = 10
+	rule.Status = model.CCStatusDisabled
+	must(t, r.CC.UpdateCCRule(ctx, rule))
+	got, err := r.CC.GetCCRule(ctx, rule.ID)
+	must(t, err)
+	if got.URI != rule.URI || got.LimitRate != 10 || got.Status != model.CCStatusDisabled {
+		t.Fatalf("CC规则未更新: %+v", got)
+	}
+
+	must(t, r.CC.DeleteCCRule(ctx, rule.ID))
+	_, err = r.CC.GetCCRule(ctx, rule.ID)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	wantCode(t, r.CC.DeleteCCRule(ctx, rule.ID), errors.ErrRuleNotFound)
+	wantCode(t, r.CC.UpdateCCRule(ctx, rule), errors.ErrRuleNotFound)
+}
+
+func testCCRuleValidation(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	wantValidation(t, r.CC.CreateCCRule(ctx, nil))
+	wantValidation(t, r.CC.CreateCCRule(ctx, newCCRule("")))
+	invalid := newCCRule("/" + uniqueName("cc-invalid"))
+	invalid.LimitRate = 0
+	wantValidation(t, r.CC.CreateCCRule(ctx, invalid))
+	_, err := r.CC.GetCCRule(ctx, 0)
+	wantValidation(t, err)
+	_, err = r.CC.ListCCRules(ctx, -1, 10)
+	wantValidation(t, err)
+	_, err = r.CC.ListCCRules(ctx, 0, 0)
+	wantValidation(t, err)
+}
+
+func testCCRuleList(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		rule := newCCRule("/" + uniqueName("cclist"))
+		must(t, r.CC.CreateCCRule(ctx, rule))
+		ids = append(ids, rule.ID)
+	}
+
+	rules, err := r.CC.ListCCRules(ctx, 0, 2)
+	must(t, err)
+	if len(rules) != 2 {
+		t.Fatalf("返回%d条，期望2条", len(rules))
+	}
+	// 按ID倒序，最后创建的在前
+	if rules[0].ID != ids[2] || rules[1].ID != ids[1] {
+		t.Fatalf("CC规则列表未按ID倒序: %d, %d", rules[0].ID, rules[1].ID)
+	}
+	rules, err = r.CC.ListCCRules(ctx, 2, 2)
+	must(t, err)
+	if len(rules) != 1 || rules[0].ID != ids[0] {
+		t.Fatalf("偏移2应只返回第一条CC规则，实际返回%d条", len(rules))
+	}
+}
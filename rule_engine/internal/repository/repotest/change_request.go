@@ -0,0 +1,142 @@
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testChangeRequests 变更请求仓储契约
+func testChangeRequests(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Changes != nil }, []subtest{
+		{"CreateAndGet", testChangeRequestCreateAndGet},
+		{"Update", testChangeRequestUpdate},
+		{"List", testChangeRequestList},
+		{"Events", testChangeRequestEvents},
+	})
+}
+
+// newChangeRequest 生成包含一个新建规则变更项的草稿
+func newChangeRequest(title string) *model.ChangeRequest {
+	payload, _ := json.Marshal(newRule(uniqueName("change-rule")))
+	return &model.ChangeRequest{
+		Title:  title,
+		Status: model.ChangeRequestDraft,
+		Author: 1,
+		Items: []*model.ChangeItem{{
+			Target:    model.ChangeTargetRule,
+			Operation: model.ChangeOperationCreate,
+			Payload:   payload,
+			TestCases: []*model.RuleTestCase{{Input: "1 union select 1", Expected: true}},
+		}},
+	}
+}
+
+func testChangeRequestCreateAndGet(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	cr := newChangeRequest(uniqueName("change"))
+	cr.Checks = &model.ChangeChecks{Passed: true}
+	must(t, r.Changes.CreateChangeRequest(ctx, cr))
+	if cr.ID <= 0 || cr.Items[0].ID <= 0 || cr.Items[0].ChangeRequestID != cr.ID {
+		t.Fatalf("创建后未设置变更请求或变更项ID: %+v", cr.Items[0])
+	}
+
+	got, err := r.Changes.GetChangeRequest(ctx, cr.ID)
+	must(t, err)
+	if got.Title != cr.Title || got.Status != model.ChangeRequestDraft {
+		t.Fatalf("获取的变更请求不正确: %+v", got)
+	}
+	if got.Checks == nil || !got.Checks.Passed {
+		t.Fatal("检查结果未保存")
+	}
+	if len(got.Items) != 1 || len(got.Items[0].TestCases) != 1 || !got.Items[0].TestCases[0].Expected {
+		t.Fatal("变更项或测试用例未保存")
+	}
+	if !json.Valid(got.Items[0].Payload) {
+		t.Fatalf("变更项内容不是合法的JSON: %s", got.Items[0].Payload)
+	}
+
+	_, err = r.Changes.GetChangeRequest(ctx, cr.ID+1000)
+	wantCode(t, err, errors.ErrRuleNotFound)
+}
+
+func testChangeRequestUpdate(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	cr := newChangeRequest(uniqueName("change-update"))
+	must(t, r.Changes.CreateChangeRequest(ctx, cr))
+
+	// 变更项整体替换
+	submitted := time.Now().Truncate(time.Second)
+	cr.Status = model.ChangeRequestPending
+	cr.SubmittedAt = &submitted
+	cr.Items = append(cr.Items, &model.ChangeItem{
+		Target:    model.ChangeTargetCC,
+		Operation: model.ChangeOperationDelete,
+		TargetID:  42,
+	})
+	must(t, r.Changes.UpdateChangeRequest(ctx, cr))
+
+	got, err := r.Changes.GetChangeRequest(ctx, cr.ID)
+	must(t, err)
+	if got.Status != model.ChangeRequestPending || got.SubmittedAt == nil || !got.SubmittedAt.Equal(submitted) {
+		t.Fatalf("变更请求未更新: status=%s submitted_at=%v", got.Status, got.SubmittedAt)
+	}
+	if len(got.Items) != 2 || got.Items[1].TargetID != 42 {
+		t.Fatalf("变更项未替换，返回%d条", len(got.Items))
+	}
+
+	missing := newChangeRequest(uniqueName("change-missing"))
+	missing.ID = cr.ID + 1000
+	wantCode(t, r.Changes.UpdateChangeRequest(ctx, missing), errors.ErrRuleNotFound)
+}
+
+func testChangeRequestList(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	_, before, err := r.Changes.ListChangeRequests(ctx, model.ChangeRequestApproved, 0, 0)
+	must(t, err)
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		cr := newChangeRequest(uniqueName("change-list"))
+		cr.Status = model.ChangeRequestApproved
+		must(t, r.Changes.CreateChangeRequest(ctx, cr))
+		ids = append(ids, cr.ID)
+	}
+	must(t, r.Changes.CreateChangeRequest(ctx, newChangeRequest(uniqueName("change-list"))))
+
+	crs, total, err := r.Changes.ListChangeRequests(ctx, model.ChangeRequestApproved, 0, 2)
+	must(t, err)
+	if total-before != 3 || len(crs) != 2 {
+		t.Fatalf("按状态过滤返回%d条，新增%d条，期望2条，新增3条", len(crs), total-before)
+	}
+	if crs[0].ID != ids[2] || crs[1].ID != ids[1] {
+		t.Fatalf("变更请求列表未按ID倒序: %d, %d", crs[0].ID, crs[1].ID)
+	}
+}
+
+func testChangeRequestEvents(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	cr := newChangeRequest(uniqueName("change-events"))
+	must(t, r.Changes.CreateChangeRequest(ctx, cr))
+	for _, action := range []model.ChangeEventAction{model.ChangeEventCreate, model.ChangeEventSubmit, model.ChangeEventApprove} {
+		must(t, r.Changes.CreateEvent(ctx, &model.ChangeEvent{
+			ChangeRequestID: cr.ID,
+			Action:          action,
+			Operator:        1,
+		}))
+	}
+
+	events, err := r.Changes.ListEvents(ctx, cr.ID)
+	must(t, err)
+	if len(events) != 3 || events[0].Action != model.ChangeEventCreate || events[2].Action != model.ChangeEventApprove {
+		t.Fatalf("变更请求历史未按记录顺序返回，返回%d条", len(events))
+	}
+	events, err = r.Changes.ListEvents(ctx, cr.ID+1000)
+	must(t, err)
+	if len(events) != 0 {
+		t.Fatalf("其他变更请求返回了%d条历史", len(events))
+	}
+}
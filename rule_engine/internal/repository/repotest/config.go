@@ -0,0 +1,91 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testConfigs WAF配置仓储契约
+func testConfigs(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Configs != nil }, []subtest{
+		{"CreateAndUpdate", testConfigCreateAndUpdate},
+		{"Validation", testConfigValidation},
+		{"ModeChangeLogs", testConfigModeChangeLogs},
+	})
+}
+
+func testConfigCreateAndUpdate(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	config := &model.WAFConfig{
+		Mode:        model.WAFModeBlock,
+		Description: "契约测试",
+		CreatedBy:   "repotest",
+		UpdatedBy:   "repotest",
+	}
+	must(t, r.Configs.UpdateConfig(ctx, config))
+	if config.ID <= 0 {
+		t.Fatalf("创建后未设置配置ID: %d", config.ID)
+	}
+
+	got, err := r.Configs.GetConfig(ctx)
+	must(t, err)
+	if got.ID != config.ID || got.Mode != model.WAFModeBlock {
+		t.Fatalf("获取的配置不是最新配置: %+v", got)
+	}
+
+	config.Mode = model.WAFModeLog
+	must(t, r.Configs.UpdateConfig(ctx, config))
+	got, err = r.Configs.GetConfig(ctx)
+	must(t, err)
+	if got.ID != config.ID || got.Mode != model.WAFModeLog {
+		t.Fatalf("配置未更新: %+v", got)
+	}
+
+	missing := *config
+	missing.ID = config.ID + 1000
+	wantCode(t, r.Configs.UpdateConfig(ctx, &missing), errors.ErrConfig)
+}
+
+func testConfigValidation(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	wantValidation(t, r.Configs.UpdateConfig(ctx, nil))
+	wantValidation(t, r.Configs.UpdateConfig(ctx, &model.WAFConfig{CreatedBy: "repotest", UpdatedBy: "repotest"}))
+	wantValidation(t, r.Configs.UpdateConfig(ctx, &model.WAFConfig{Mode: model.WAFModeBlock, UpdatedBy: "repotest"}))
+	wantValidation(t, r.Configs.LogModeChange(ctx, &model.WAFModeChangeLog{NewMode: model.WAFModeLog}))
+	_, _, err := r.Configs.GetModeChangeLogs(ctx, 10, 5, 1, 10)
+	wantValidation(t, err)
+	_, _, err = r.Configs.GetModeChangeLogs(ctx, 0, 10, 0, 10)
+	wantValidation(t, err)
+}
+
+func testConfigModeChangeLogs(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	base := time.Now().Unix()
+	for i := int64(0); i < 5; i++ {
+		must(t, r.Configs.LogModeChange(ctx, &model.WAFModeChangeLog{
+			OldMode:   model.WAFModeBlock,
+			NewMode:   model.WAFModeLog,
+			Operator:  "repotest",
+			Reason:    "契约测试",
+			CreatedAt: base + i,
+		}))
+	}
+
+	logs, total, err := r.Configs.GetModeChangeLogs(ctx, base+1, base+3, 1, 2)
+	must(t, err)
+	if total != 3 || len(logs) != 2 {
+		t.Fatalf("第1页返回%d条，总数%d，期望2条，总数3", len(logs), total)
+	}
+	if logs[0].CreatedAt != base+3 || logs[1].CreatedAt != base+2 {
+		t.Fatalf("模式变更日志未按时间倒序: %d, %d", logs[0].CreatedAt, logs[1].CreatedAt)
+	}
+	logs, _, err = r.Configs.GetModeChangeLogs(ctx, base+1, base+3, 2, 2)
+	must(t, err)
+	if len(logs) != 1 || logs[0].CreatedAt != base+1 {
+		t.Fatalf("第2页应只返回最早的日志，实际返回%d条", len(logs))
+	}
+}
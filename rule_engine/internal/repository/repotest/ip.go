@@ -0,0 +1,128 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testIPRules IP规则仓储契约
+func testIPRules(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.IPs != nil }, []subtest{
+		{"CRUD", testIPRuleCRUD},
+		{"Conflict", testIPRuleConflict},
+		{"List", testIPRuleList},
+	})
+}
+
+// uniqueIP 生成不重复的测试IP
+func uniqueIP() string {
+	n := seq.Add(1)
+	return fmt.Sprintf("10.%d.%d.%d", (n>>16)&0xff, (n>>8)&0xff, n&0xff)
+}
+
+// newIPRule 生成可以保存的IP规则
+func newIPRule(ip string) *model.IPRule {
+	return &model.IPRule{
+		EntryType:   model.IPEntryTypeIP,
+		IP:          ip,
+		IPType:      model.IPListTypeBlack,
+		BlockType:   model.BlockTypePermanent,
+		Description: "契约测试",
+		CreatedBy:   1,
+		UpdatedBy:   1,
+	}
+}
+
+func testIPRuleCRUD(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newIPRule(uniqueIP())
+	must(t, r.IPs.CreateIPRule(ctx, rule))
+	if rule.ID <= 0 {
+		t.Fatalf("创建后未设置IP规则ID: %d", rule.ID)
+	}
+
+	got, err := r.IPs.GetIPRule(ctx, rule.ID)
+	must(t, err)
+	if got.IP != rule.IP || got.IPType != model.IPListTypeBlack {
+		t.Fatalf("获取的IP规则不正确: %+v", got)
+	}
+	got, err = r.IPs.GetIPRuleByIP(ctx, rule.IP)
+	must(t, err)
+	if got.ID != rule.ID {
+		t.Fatalf("按IP获取的规则ID为%d，期望%d", got.ID, rule.ID)
+	}
+	got, err = r.IPs.GetIPRuleByEntry(ctx, model.IPEntryTypeIP, rule.IP)
+	must(t, err)
+	if got.ID != rule.ID {
+		t.Fatalf("按条目获取的规则ID为%d，期望%d", got.ID, rule.ID)
+	}
+	_, err = r.IPs.GetIPRuleByEntry(ctx, model.IPEntryTypeCountry, rule.IP)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	exists, err := r.IPs.ExistsByIP(ctx, rule.IP)
+	must(t, err)
+	if !exists {
+		t.Fatal("ExistsByIP未找到已创建的规则")
+	}
+
+	rule.IPType = model.IPListTypeWhite
+	rule.Description = "已更新"
+	must(t, r.IPs.UpdateIPRule(ctx, rule))
+	got, err = r.IPs.GetIPRule(ctx, rule.ID)
+	must(t, err)
+	if got.IPType != model.IPListTypeWhite || got.Description != "已更新" {
+		t.Fatalf("IP规则未更新: %+v", got)
+	}
+
+	must(t, r.IPs.DeleteIPRule(ctx, rule.ID))
+	_, err = r.IPs.GetIPRule(ctx, rule.ID)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	_, err = r.IPs.GetIPRuleByIP(ctx, rule.IP)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	wantCode(t, r.IPs.DeleteIPRule(ctx, rule.ID), errors.ErrRuleNotFound)
+	wantCode(t, r.IPs.UpdateIPRule(ctx, rule), errors.ErrRuleNotFound)
+	exists, err = r.IPs.ExistsByIP(ctx, rule.IP)
+	must(t, err)
+	if exists {
+		t.Fatal("删除后ExistsByIP仍返回true")
+	}
+}
+
+func testIPRuleConflict(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	ip := uniqueIP()
+	must(t, r.IPs.CreateIPRule(ctx, newIPRule(ip)))
+	wantCode(t, r.IPs.CreateIPRule(ctx, newIPRule(ip)), errors.ErrRuleConflict)
+}
+
+func testIPRuleList(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	desc := uniqueName("iplist")
+	for i := 0; i < 5; i++ {
+		rule := newIPRule(uniqueIP())
+		rule.Description = desc
+		if i%2 == 1 {
+			rule.IPType = model.IPListTypeWhite
+		}
+		must(t, r.IPs.CreateIPRule(ctx, rule))
+	}
+
+	rules, total, err := r.IPs.ListIPRules(ctx, &model.IPRuleQuery{Keyword: desc}, 0, 2)
+	must(t, err)
+	if total != 5 || len(rules) != 2 {
+		t.Fatalf("第1页返回%d条，总数%d，期望2条，总数5", len(rules), total)
+	}
+	rules, _, err = r.IPs.ListIPRules(ctx, &model.IPRuleQuery{Keyword: desc}, 4, 2)
+	must(t, err)
+	if len(rules) != 1 {
+		t.Fatalf("偏移4返回%d条，期望1条", len(rules))
+	}
+	rules, total, err = r.IPs.ListIPRules(ctx, &model.IPRuleQuery{Keyword: desc, IPType: model.IPListTypeWhite}, 0, 10)
+	must(t, err)
+	if total != 2 || len(rules) != 2 {
+		t.Fatalf("按名单类型过滤返回%d条，总数%d，期望2条", len(rules), total)
+	}
+}
@@ -0,0 +1,119 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testNodes WAF节点仓储契约
+func testNodes(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Nodes != nil }, []subtest{
+		{"CRUD", testNodeCRUD},
+		{"Conflict", testNodeConflict},
+		{"MarkDown", testNodeMarkDown},
+	})
+}
+
+// newNode 生成可以保存的节点，lastHeartbeat为最近一次心跳时间
+func newNode(nodeID string, lastHeartbeat time.Time) *model.Node {
+	now := time.Now()
+	return &model.Node{
+		NodeID:          nodeID,
+		TokenHash:       "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		Hostname:        "waf-01",
+		Status:          model.NodeOnline,
+		Mode:            model.WAFModeBlock,
+		Health:          model.HealthUp,
+		Counters:        map[string]int64{"requests": 10},
+		LastHeartbeatAt: &lastHeartbeat,
+		RegisteredAt:    now,
+	}
+}
+
+func testNodeCRUD(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	node := newNode(uniqueName("node"), time.Now())
+	must(t, r.Nodes.CreateNode(ctx, node))
+	if node.ID <= 0 {
+		t.Fatalf("创建后未设置节点ID: %d", node.ID)
+	}
+
+	node.AppliedVersion = 7
+	node.Counters = map[string]int64{"requests": 20, "blocked": 2}
+	node.SyncError = "下载规则超时"
+	node.TokenHash = "changed"
+	must(t, r.Nodes.UpdateNode(ctx, node))
+	got, err := r.Nodes.GetNode(ctx, node.NodeID)
+	must(t, err)
+	if got.AppliedVersion != 7 || got.Counters["blocked"] != 2 || got.SyncError != "下载规则超时" || got.Hostname != "waf-01" {
+		t.Fatalf("节点未更新: %+v", got)
+	}
+	if got.TokenHash == "changed" {
+		t.Fatalf("更新节点不应修改令牌")
+	}
+
+	nodes, err := r.Nodes.ListNodes(ctx)
+	must(t, err)
+	found := false
+	for i, n := range nodes {
+		if i > 0 && nodes[i-1].NodeID >= n.NodeID {
+			t.Fatalf("节点列表未按节点标识升序: %s, %s", nodes[i-1].NodeID, n.NodeID)
+		}
+		found = found || n.NodeID == node.NodeID
+	}
+	if !found {
+		t.Fatalf("节点列表中没有节点%s", node.NodeID)
+	}
+
+	must(t, r.Nodes.DeleteNode(ctx, node.NodeID))
+	_, err = r.Nodes.GetNode(ctx, node.NodeID)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	wantCode(t, r.Nodes.DeleteNode(ctx, node.NodeID), errors.ErrRuleNotFound)
+}
+
+func testNodeConflict(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	nodeID := uniqueName("node")
+	must(t, r.Nodes.CreateNode(ctx, newNode(nodeID, time.Now())))
+	wantCode(t, r.Nodes.CreateNode(ctx, newNode(nodeID, time.Now())), errors.ErrRuleConflict)
+}
+
+func testNodeMarkDown(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	now := time.Now()
+	old, fresh := newNode(uniqueName("node"), now.Add(-time.Hour)), newNode(uniqueName("node"), now)
+	must(t, r.Nodes.CreateNode(ctx, old))
+	must(t, r.Nodes.CreateNode(ctx, fresh))
+
+	marked, err := r.Nodes.MarkDown(ctx, now.Add(-time.Minute))
+	must(t, err)
+	if !containsString(marked, old.NodeID) || containsString(marked, fresh.NodeID) {
+		t.Fatalf("标记下线的节点为%v，期望包含%s，不包含%s", marked, old.NodeID, fresh.NodeID)
+	}
+	got, err := r.Nodes.GetNode(ctx, old.NodeID)
+	must(t, err)
+	if got.Status != model.NodeDown {
+		t.Fatalf("节点状态为%s，期望%s", got.Status, model.NodeDown)
+	}
+
+	// 已下线的节点不重复标记
+	marked, err = r.Nodes.MarkDown(ctx, now.Add(-time.Minute))
+	must(t, err)
+	if containsString(marked, old.NodeID) {
+		t.Fatalf("已下线的节点被重复标记: %v", marked)
+	}
+}
+
+// containsString 检查切片中是否包含字符串
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
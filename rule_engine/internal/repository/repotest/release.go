@@ -0,0 +1,121 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testReleases 规则集发布仓储契约
+func testReleases(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Rules != nil && r.Releases != nil }, []subtest{
+		{"CreateAndGet", testReleaseCreateAndGet},
+		{"Restore", testReleaseRestore},
+		{"List", testReleaseList},
+	})
+}
+
+func testReleaseCreateAndGet(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	latest, err := r.Releases.GetLatestRelease(ctx)
+	must(t, err)
+	if latest != nil {
+		t.Fatalf("没有发布时最新发布应为nil，实际为%d", latest.ID)
+	}
+
+	must(t, r.Rules.BatchCreateRules(ctx, []*model.Rule{newRule(uniqueName("release")), newRule(uniqueName("release"))}))
+	snapshot, err := r.Releases.Snapshot(ctx)
+	must(t, err)
+
+	release := &model.RulesetRelease{Action: model.ReleaseActionPublish, Description: "契约测试", CreatedBy: 1}
+	must(t, r.Releases.CreateRelease(ctx, release))
+	if release.ID <= 0 || release.Hash == "" || release.RuleCount != len(snapshot.Rules) {
+		t.Fatalf("发布未设置ID、哈希或规则数: %+v", release)
+	}
+
+	got, err := r.Releases.GetRelease(ctx, release.ID)
+	must(t, err)
+	must(t, got.Load())
+	if got.Hash != release.Hash || len(got.Snapshot.Rules) != len(snapshot.Rules) {
+		t.Fatalf("获取的发布与保存的不一致: hash=%s rules=%d", got.Hash, len(got.Snapshot.Rules))
+	}
+
+	latest, err = r.Releases.GetLatestRelease(ctx)
+	must(t, err)
+	if latest == nil || latest.ID != release.ID {
+		t.Fatal("最新发布不是刚创建的发布")
+	}
+
+	_, err = r.Releases.GetRelease(ctx, release.ID+1000)
+	wantCode(t, err, errors.ErrRuleNotFound)
+}
+
+func testReleaseRestore(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	kept := newRule(uniqueName("restore"))
+	must(t, r.Rules.CreateRule(ctx, kept))
+	published := &model.RulesetRelease{Action: model.ReleaseActionPublish, CreatedBy: 1}
+	must(t, r.Releases.CreateRelease(ctx, published))
+
+	// 发布后修改规则集
+	pattern := kept.Pattern
+	kept.Pattern = "(?i)load_file\\("
+	must(t, r.Rules.UpdateRule(ctx, kept))
+	added := newRule(uniqueName("restore-added"))
+	must(t, r.Rules.CreateRule(ctx, added))
+
+	source, err := r.Releases.GetRelease(ctx, published.ID)
+	must(t, err)
+	must(t, source.Load())
+	restored := &model.RulesetRelease{
+		Action:    model.ReleaseActionRollback,
+		SourceID:  source.ID,
+		Snapshot:  source.Snapshot,
+		CreatedBy: 1,
+	}
+	must(t, r.Releases.RestoreRelease(ctx, restored))
+	if restored.ID <= published.ID || restored.Hash != published.Hash {
+		t.Fatalf("回滚的发布ID或哈希不正确: id=%d hash=%s", restored.ID, restored.Hash)
+	}
+
+	got, err := r.Rules.GetRule(ctx, kept.ID)
+	must(t, err)
+	if got.Pattern != pattern {
+		t.Fatalf("回滚后规则未恢复: %s", got.Pattern)
+	}
+	_, err = r.Rules.GetRule(ctx, added.ID)
+	wantCode(t, err, errors.ErrRuleNotFound)
+
+	// 回滚后新建的规则不能与快照中的ID冲突
+	after := newRule(uniqueName("restore-after"))
+	must(t, r.Rules.CreateRule(ctx, after))
+	if after.ID == kept.ID {
+		t.Fatalf("回滚后新建规则的ID与已恢复的规则冲突: %d", after.ID)
+	}
+}
+
+func testReleaseList(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	_, before, err := r.Releases.ListReleases(ctx, 0, 0)
+	must(t, err)
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		release := &model.RulesetRelease{Action: model.ReleaseActionPublish, CreatedBy: 1}
+		must(t, r.Releases.CreateRelease(ctx, release))
+		ids = append(ids, release.ID)
+	}
+
+	releases, total, err := r.Releases.ListReleases(ctx, 0, 2)
+	must(t, err)
+	if total-before != 3 || len(releases) != 2 {
+		t.Fatalf("返回%d条，新增%d条，期望2条，新增3条", len(releases), total-before)
+	}
+	if releases[0].ID != ids[2] || releases[1].ID != ids[1] {
+		t.Fatalf("发布列表未按ID倒序: %d, %d", releases[0].ID, releases[1].ID)
+	}
+	if releases[0].Content != "" || releases[0].Snapshot != nil {
+		t.Fatal("发布列表不应包含快照内容")
+	}
+}
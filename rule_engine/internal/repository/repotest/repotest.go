@@ -0,0 +1,134 @@
+// Package repotest 仓储契约测试，MySQL、SQLite、内存等存储实现都应通过
+// 存储实现在自己的测试中调用Run，每个子测试通过newRepos获取一套数据为空的仓储:
+//
+//	func TestContract(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) *repotest.Repositories {
+//			s := memory.NewStore()
+//			return &repotest.Repositories{Rules: memory.NewRuleRepository(s), ...}
+//		})
+//	}
+//
+// 未提供的仓储对应的子测试跳过
+package repotest
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// Repositories 被测试的仓储，同一实例中的仓储共享数据
+type Repositories struct {
+	Rules     repository.RuleRepository
+	Versions  repository.RuleVersionRepository
+	IPs       repository.IPRuleRepository
+	CC        repository.CCRuleRepository
+	Configs   repository.WAFConfigRepository
+	Releases  repository.ReleaseRepository
+	Changes   repository.ChangeRequestRepository
+	Bypasses  repository.BypassRepository
+	Nodes     repository.NodeRepository
+	Uploads   repository.UploadEventRepository
+	Cache     repository.CacheRepository
+	RuleCache repository.RuleCache
+}
+
+// Run 运行全部契约测试，newRepos每次调用都应返回数据为空的仓储
+func Run(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	t.Run("Rule", func(t *testing.T) { testRules(t, newRepos) })
+	t.Run("Version", func(t *testing.T) { testVersions(t, newRepos) })
+	t.Run("IPRule", func(t *testing.T) { testIPRules(t, newRepos) })
+	t.Run("CCRule", func(t *testing.T) { testCCRules(t, newRepos) })
+	t.Run("WAFConfig", func(t *testing.T) { testConfigs(t, newRepos) })
+	t.Run("Release", func(t *testing.T) { testReleases(t, newRepos) })
+	t.Run("ChangeRequest", func(t *testing.T) { testChangeRequests(t, newRepos) })
+	t.Run("Bypass", func(t *testing.T) { testBypasses(t, newRepos) })
+	t.Run("Node", func(t *testing.T) { testNodes(t, newRepos) })
+	t.Run("UploadEvent", func(t *testing.T) { testUploadEvents(t, newRepos) })
+	t.Run("Cache", func(t *testing.T) { testCache(t, newRepos) })
+}
+
+// subtest 仓储的一组用例，用例按名称运行，每个用例使用新的仓储
+type subtest struct {
+	name string
+	fn   func(t *testing.T, r *Repositories)
+}
+
+// runAll 依次运行用例，need返回false时跳过
+func runAll(t *testing.T, newRepos func(t *testing.T) *Repositories, need func(r *Repositories) bool, cases []subtest) {
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			r := newRepos(t)
+			if !need(r) {
+				t.Skip("未提供被测试的仓储")
+			}
+			c.fn(t, r)
+		})
+	}
+}
+
+// wantCode 检查错误码
+func wantCode(t *testing.T, err error, code errors.ErrorCode) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("期望错误码%d，实际没有错误", code)
+	}
+	var e *errors.Error
+	if !stderrors.As(err, &e) {
+		t.Fatalf("期望错误码%d，实际错误类型为%T: %v", code, err, err)
+	}
+	if e.Code != code {
+		t.Fatalf("期望错误码%d，实际为%d: %v", code, e.Code, err)
+	}
+}
+
+// wantValidation 检查错误为验证错误，ErrValidation和ErrRuleValidation都可以
+func wantValidation(t *testing.T, err error) {
+	t.Helper()
+	var e *errors.Error
+	if err == nil || !stderrors.As(err, &e) || !e.IsValidationError() {
+		t.Fatalf("期望验证错误，实际为: %v", err)
+	}
+}
+
+// must 检查操作成功
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("操作失败: %v", err)
+	}
+}
+
+// seq 生成用例中不重复的名称
+var seq atomic.Int64
+
+// uniqueName 生成带前缀的唯一名称，共享数据库的实现也不会冲突
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, seq.Add(1))
+}
+
+// newRule 生成可以保存的规则
+func newRule(name string) *model.Rule {
+	return &model.Rule{
+		Name:           name,
+		Description:    "契约测试规则",
+		Pattern:        "(?i)union\\s+select",
+		Type:           model.RuleTypeSQLi,
+		RuleVariable:   model.RuleVarRequestArgs,
+		Phase:          model.RulePhaseRequest,
+		Action:         model.ActionBlock,
+		Priority:       10,
+		Status:         model.StatusEnabled,
+		Severity:       model.SeverityHigh,
+		RulesOperation: "and",
+		Version:        1,
+		CreatedBy:      1,
+		UpdatedBy:      1,
+	}
+}
@@ -0,0 +1,299 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+)
+
+// testRules 规则仓储契约
+func testRules(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Rules != nil }, []subtest{
+		{"CreateAndGet", testRuleCreateAndGet},
+		{"CreateConflict", testRuleCreateConflict},
+		{"Update", testRuleUpdate},
+		{"Delete", testRuleDelete},
+		{"BatchCreate", testRuleBatchCreate},
+		{"BatchUpdate", testRuleBatchUpdate},
+		{"List", testRuleList},
+		{"Import", testRuleImport},
+		{"BeginTx", testRuleBeginTx},
+		{"MatchCount", testRuleMatchCount},
+		{"Stats", testRuleStats},
+		{"Concurrent", testRuleConcurrent},
+	})
+}
+
+func testRuleCreateAndGet(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("create"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+	if rule.ID <= 0 {
+		t.Fatalf("创建后未设置规则ID: %d", rule.ID)
+	}
+
+	got, err := r.Rules.GetRule(ctx, rule.ID)
+	must(t, err)
+	if got.Name != rule.Name || got.Pattern != rule.Pattern || got.Status != rule.Status {
+		t.Fatalf("获取的规则与创建的不一致: %+v", got)
+	}
+	if got.CreatedAt.IsZero() {
+		t.Fatal("创建时间未设置")
+	}
+
+	byName, err := r.Rules.GetRuleByName(ctx, rule.Name)
+	must(t, err)
+	if byName.ID != rule.ID {
+		t.Fatalf("按名称获取的规则ID为%d，期望%d", byName.ID, rule.ID)
+	}
+
+	_, err = r.Rules.GetRule(ctx, rule.ID+1000)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	_, err = r.Rules.GetRuleByName(ctx, uniqueName("missing"))
+	wantCode(t, err, errors.ErrRuleNotFound)
+	_, err = r.Rules.GetRuleByName(ctx, "")
+	wantValidation(t, err)
+}
+
+func testRuleCreateConflict(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	name := uniqueName("conflict")
+	must(t, r.Rules.CreateRule(ctx, newRule(name)))
+	wantCode(t, r.Rules.CreateRule(ctx, newRule(name)), errors.ErrRuleConflict)
+}
+
+func testRuleUpdate(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("update"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+	other := newRule(uniqueName("update-other"))
+	must(t, r.Rules.CreateRule(ctx, other))
+
+	rule.Pattern = "(?i)sleep\\("
+	rule.Status = model.StatusDisabled
+	must(t, r.Rules.UpdateRule(ctx, rule))
+	got, err := r.Rules.GetRule(ctx, rule.ID)
+	must(t, err)
+	if got.Pattern != rule.Pattern || got.Status != model.StatusDisabled {
+		t.Fatalf("规则未更新: %+v", got)
+	}
+
+	missing := newRule(uniqueName("update-missing"))
+	missing.ID = other.ID + 1000
+	wantCode(t, r.Rules.UpdateRule(ctx, missing), errors.ErrRuleNotFound)
+
+	rule.Name = other.Name
+	wantCode(t, r.Rules.UpdateRule(ctx, rule), errors.ErrRuleConflict)
+}
+
+func testRuleDelete(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("delete"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+	must(t, r.Rules.DeleteRule(ctx, rule.ID))
+	_, err := r.Rules.GetRule(ctx, rule.ID)
+	wantCode(t, err, errors.ErrRuleNotFound)
+	wantCode(t, r.Rules.DeleteRule(ctx, rule.ID), errors.ErrRuleNotFound)
+
+	a, b := newRule(uniqueName("delete-a")), newRule(uniqueName("delete-b"))
+	must(t, r.Rules.BatchCreateRules(ctx, []*model.Rule{a, b}))
+	must(t, r.Rules.BatchDeleteRules(ctx, []int64{a.ID, b.ID}))
+	wantCode(t, r.Rules.BatchDeleteRules(ctx, []int64{a.ID, b.ID}), errors.ErrRuleNotFound)
+}
+
+func testRuleBatchCreate(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rules := []*model.Rule{newRule(uniqueName("batch")), newRule(uniqueName("batch")), newRule(uniqueName("batch"))}
+	must(t, r.Rules.BatchCreateRules(ctx, rules))
+	for _, rule := range rules {
+		if rule.ID <= 0 {
+			t.Fatalf("批量创建后未设置规则ID: %s", rule.Name)
+		}
+		if _, err := r.Rules.GetRule(ctx, rule.ID); err != nil {
+			t.Fatalf("获取批量创建的规则失败: %v", err)
+		}
+	}
+
+	// 批次中有冲突时全部不创建
+	fresh := newRule(uniqueName("batch-fresh"))
+	err := r.Rules.BatchCreateRules(ctx, []*model.Rule{fresh, newRule(rules[0].Name)})
+	wantCode(t, err, errors.ErrRuleConflict)
+	_, err = r.Rules.GetRuleByName(ctx, fresh.Name)
+	wantCode(t, err, errors.ErrRuleNotFound)
+}
+
+func testRuleBatchUpdate(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	a, b := newRule(uniqueName("bupdate-a")), newRule(uniqueName("bupdate-b"))
+	must(t, r.Rules.BatchCreateRules(ctx, []*model.Rule{a, b}))
+
+	a.Priority, b.Priority = 1, 2
+	must(t, r.Rules.BatchUpdateRules(ctx, []*model.Rule{a, b}))
+	got, err := r.Rules.GetRule(ctx, b.ID)
+	must(t, err)
+	if got.Priority != 2 {
+		t.Fatalf("批量更新后优先级为%d，期望2", got.Priority)
+	}
+
+	// 批次中有不存在的规则时全部不更新
+	a.Priority = 99
+	missing := newRule(uniqueName("bupdate-missing"))
+	missing.ID = b.ID + 1000
+	wantCode(t, r.Rules.BatchUpdateRules(ctx, []*model.Rule{a, missing}), errors.ErrRuleNotFound)
+	got, err = r.Rules.GetRule(ctx, a.ID)
+	must(t, err)
+	if got.Priority != 1 {
+		t.Fatalf("批量更新失败后规则被修改，优先级为%d", got.Priority)
+	}
+}
+
+func testRuleList(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	prefix := uniqueName("list")
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		rule := newRule(uniqueName(prefix))
+		if i%2 == 1 {
+			rule.Status = model.StatusDisabled
+		}
+		must(t, r.Rules.CreateRule(ctx, rule))
+		ids = append(ids, rule.ID)
+	}
+
+	rules, total, err := r.Rules.ListRules(ctx, &repository.RuleQuery{Keyword: prefix, Page: 1, PageSize: 2})
+	must(t, err)
+	if total != 5 || len(rules) != 2 {
+		t.Fatalf("第1页返回%d条，总数%d，期望2条，总数5", len(rules), total)
+	}
+	if rules[0].ID != ids[0] || rules[1].ID != ids[1] {
+		t.Fatalf("规则列表未按ID排序: %d, %d", rules[0].ID, rules[1].ID)
+	}
+
+	rules, _, err = r.Rules.ListRules(ctx, &repository.RuleQuery{Keyword: prefix, Page: 3, PageSize: 2})
+	must(t, err)
+	if len(rules) != 1 || rules[0].ID != ids[4] {
+		t.Fatalf("第3页应只返回最后一条规则，实际返回%d条", len(rules))
+	}
+
+	rules, total, err = r.Rules.ListRules(ctx, &repository.RuleQuery{Keyword: prefix, Status: model.StatusDisabled, Page: 1, PageSize: 10})
+	must(t, err)
+	if total != 2 || len(rules) != 2 {
+		t.Fatalf("按状态过滤返回%d条，总数%d，期望2条", len(rules), total)
+	}
+}
+
+func testRuleImport(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	existing := newRule(uniqueName("import"))
+	must(t, r.Rules.CreateRule(ctx, existing))
+
+	// 同名规则更新，其余规则创建
+	replaced := newRule(existing.Name)
+	replaced.Pattern = "(?i)benchmark\\("
+	added := newRule(uniqueName("import-new"))
+	must(t, r.Rules.ImportRules(ctx, []*model.Rule{replaced, added}))
+
+	got, err := r.Rules.GetRuleByName(ctx, existing.Name)
+	must(t, err)
+	if got.ID != existing.ID || got.Pattern != replaced.Pattern {
+		t.Fatalf("导入未更新同名规则: id=%d pattern=%s", got.ID, got.Pattern)
+	}
+	if _, err := r.Rules.GetRuleByName(ctx, added.Name); err != nil {
+		t.Fatalf("导入未创建新规则: %v", err)
+	}
+}
+
+func testRuleBeginTx(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	tx, err := r.Rules.BeginTx(ctx)
+	must(t, err)
+	must(t, tx.Commit())
+	if err := tx.Commit(); err == nil {
+		t.Fatal("已提交的事务再次提交应返回错误")
+	}
+
+	tx, err = r.Rules.BeginTx(ctx)
+	must(t, err)
+	must(t, tx.Rollback())
+	if err := tx.Rollback(); err == nil {
+		t.Fatal("已回滚的事务再次回滚应返回错误")
+	}
+}
+
+func testRuleMatchCount(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("match"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+
+	count, err := r.Rules.GetRuleMatchCount(ctx, rule.ID)
+	must(t, err)
+	if count != 0 {
+		t.Fatalf("新规则匹配计数为%d", count)
+	}
+	for i := 0; i < 3; i++ {
+		must(t, r.Rules.IncrRuleMatchCount(ctx, rule.ID))
+	}
+	count, err = r.Rules.GetRuleMatchCount(ctx, rule.ID)
+	must(t, err)
+	if count != 3 {
+		t.Fatalf("匹配计数为%d，期望3", count)
+	}
+}
+
+func testRuleStats(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	before, err := r.Rules.GetRuleStats(ctx)
+	must(t, err)
+
+	enabled, disabled := newRule(uniqueName("stats")), newRule(uniqueName("stats"))
+	disabled.Status = model.StatusDisabled
+	must(t, r.Rules.BatchCreateRules(ctx, []*model.Rule{enabled, disabled}))
+
+	after, err := r.Rules.GetRuleStats(ctx)
+	must(t, err)
+	if after.TotalRules-before.TotalRules != 2 ||
+		after.EnabledRules-before.EnabledRules != 1 ||
+		after.DisabledRules-before.DisabledRules != 1 {
+		t.Fatalf("规则统计不正确: 之前%+v，之后%+v", before, after)
+	}
+}
+
+func testRuleConcurrent(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	prefix := uniqueName("concurrent")
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rule := newRule(uniqueName(prefix))
+			if err := r.Rules.CreateRule(ctx, rule); err != nil {
+				errs <- err
+				return
+			}
+			if err := r.Rules.IncrRuleMatchCount(ctx, rule.ID); err != nil {
+				errs <- err
+			}
+			if _, _, err := r.Rules.ListRules(ctx, &repository.RuleQuery{Keyword: prefix}); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("并发操作失败: %v", err)
+	}
+
+	_, total, err := r.Rules.ListRules(ctx, &repository.RuleQuery{Keyword: prefix})
+	must(t, err)
+	if total != workers {
+		t.Fatalf("并发创建后规则总数为%d，期望%d", total, workers)
+	}
+}
@@ -0,0 +1,76 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testUploadEvents 上传违规事件仓储契约
+func testUploadEvents(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Uploads != nil }, []subtest{
+		{"CreateAndList", testUploadEventList},
+	})
+}
+
+func testUploadEventList(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	must(t, r.Uploads.CreateUploadEvents(ctx, nil))
+
+	ip := uniqueIP()
+	base := time.Now().Unix()
+	violations := []string{
+		model.UploadViolationDangerousExtension,
+		model.UploadViolationDoubleExtension + "," + model.UploadViolationTypeMismatch,
+		model.UploadViolationTypeMismatch,
+		model.UploadViolationFileTooLarge,
+	}
+	events := make([]*model.UploadEvent, 0, len(violations))
+	for i, v := range violations {
+		events = append(events, &model.UploadEvent{
+			RequestID:  uniqueName("req"),
+			IP:         ip,
+			URL:        "/upload",
+			Field:      "file",
+			Filename:   "shell.php.jpg",
+			Size:       int64(i),
+			Violations: v,
+			Blocked:    i%2 == 0,
+			Timestamp:  base + int64(i),
+		})
+	}
+	must(t, r.Uploads.CreateUploadEvents(ctx, events))
+	if events[0].ID == 0 {
+		t.Fatal("记录后未设置事件ID")
+	}
+
+	got, total, err := r.Uploads.ListUploadEvents(ctx, &model.UploadEventQuery{IP: ip}, 0, 3)
+	must(t, err)
+	if total != 4 || len(got) != 3 {
+		t.Fatalf("返回%d条，总数%d，期望3条，总数4", len(got), total)
+	}
+	if got[0].Timestamp != base+3 || got[0].Violations != model.UploadViolationFileTooLarge {
+		t.Fatalf("上传违规事件未按时间倒序: %+v", got[0])
+	}
+	if !got[1].Blocked || got[2].Blocked {
+		t.Fatalf("拦截状态未保存: %+v, %+v", got[1], got[2])
+	}
+
+	_, total, err = r.Uploads.ListUploadEvents(ctx, &model.UploadEventQuery{IP: ip, Violation: model.UploadViolationTypeMismatch}, 0, 0)
+	must(t, err)
+	if total != 2 {
+		t.Fatalf("包含type_mismatch的事件总数为%d，期望2", total)
+	}
+	_, total, err = r.Uploads.ListUploadEvents(ctx, &model.UploadEventQuery{RequestID: events[0].RequestID}, 0, 0)
+	must(t, err)
+	if total != 1 {
+		t.Fatalf("按请求ID查询的事件总数为%d，期望1", total)
+	}
+	_, total, err = r.Uploads.ListUploadEvents(ctx, &model.UploadEventQuery{IP: ip, StartTime: base + 1, EndTime: base + 3}, 0, 0)
+	must(t, err)
+	if total != 2 {
+		t.Fatalf("时间范围内的事件总数为%d，期望2", total)
+	}
+}
@@ -0,0 +1,174 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// testVersions 规则版本仓储契约
+func testVersions(t *testing.T, newRepos func(t *testing.T) *Repositories) {
+	runAll(t, newRepos, func(r *Repositories) bool { return r.Rules != nil && r.Versions != nil }, []subtest{
+		{"CreateAndList", testVersionCreateAndList},
+		{"RulesByVersion", testVersionRulesByVersion},
+		{"Rollback", testVersionRollback},
+		{"RollbackAtomic", testVersionRollbackAtomic},
+		{"SyncLogs", testVersionSyncLogs},
+	})
+}
+
+// createVersions 为规则创建指定的版本记录
+func createVersions(t *testing.T, r *Repositories, ruleID int64, versions ...int64) {
+	t.Helper()
+	for _, v := range versions {
+		must(t, r.Versions.CreateVersion(context.Background(), &model.RuleVersion{
+			RuleID:     ruleID,
+			Version:    v,
+			Hash:       "hash",
+			Content:    "{}",
+			ChangeType: string(model.RuleUpdateTypeUpdate),
+			Status:     string(model.StatusEnabled),
+			CreatedBy:  1,
+		}))
+	}
+}
+
+func testVersionCreateAndList(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("version"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+	base, err := r.Versions.GetLatestVersion(ctx)
+	must(t, err)
+	createVersions(t, r, rule.ID, base+1, base+3, base+2)
+
+	versions, err := r.Versions.ListVersions(ctx, rule.ID)
+	must(t, err)
+	if len(versions) != 3 {
+		t.Fatalf("版本列表返回%d条，期望3条", len(versions))
+	}
+	for i, want := range []int64{base + 3, base + 2, base + 1} {
+		if versions[i].Version != want {
+			t.Fatalf("版本列表未按版本号倒序: 第%d条为%d，期望%d", i, versions[i].Version, want)
+		}
+	}
+
+	v, err := r.Versions.GetVersion(ctx, rule.ID, base+2)
+	must(t, err)
+	if v.RuleID != rule.ID || v.Version != base+2 || v.ID <= 0 {
+		t.Fatalf("获取的版本不正确: %+v", v)
+	}
+	_, err = r.Versions.GetVersion(ctx, rule.ID, base+100)
+	wantCode(t, err, errors.ErrRuleNotFound)
+
+	latest, err := r.Versions.GetLatestVersion(ctx)
+	must(t, err)
+	if latest != base+3 {
+		t.Fatalf("最新版本号为%d，期望%d", latest, base+3)
+	}
+}
+
+func testVersionRulesByVersion(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	a, b := newRule(uniqueName("byversion")), newRule(uniqueName("byversion"))
+	must(t, r.Rules.BatchCreateRules(ctx, []*model.Rule{a, b}))
+	base, err := r.Versions.GetLatestVersion(ctx)
+	must(t, err)
+	createVersions(t, r, a.ID, base+1)
+	createVersions(t, r, b.ID, base+2)
+
+	rules, err := r.Versions.GetRulesByVersion(ctx, base+1)
+	must(t, err)
+	if len(rules) != 1 || rules[0].ID != a.ID {
+		t.Fatalf("版本%d的规则不正确，返回%d条", base+1, len(rules))
+	}
+	rules, err = r.Versions.GetRulesByVersion(ctx, base+100)
+	must(t, err)
+	if len(rules) != 0 {
+		t.Fatalf("不存在的版本返回了%d条规则", len(rules))
+	}
+}
+
+func testVersionRollback(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("rollback"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+
+	rule.Pattern = "(?i)drop\\s+table"
+	rule.Version = 2
+	event := &model.RuleUpdateEvent{
+		Version: 2,
+		Action:  model.RuleUpdateTypeRollback,
+		RuleDiffs: []*model.RuleDiff{{
+			RuleID:     rule.ID,
+			Name:       rule.Name,
+			Pattern:    rule.Pattern,
+			Version:    2,
+			UpdateType: model.RuleUpdateTypeRollback,
+		}},
+	}
+	must(t, r.Versions.RollbackRules(ctx, []*model.Rule{rule}, event))
+	if event.ID <= 0 {
+		t.Fatal("回滚后未设置更新事件ID")
+	}
+	got, err := r.Rules.GetRule(ctx, rule.ID)
+	must(t, err)
+	if got.Pattern != rule.Pattern || got.Version != 2 {
+		t.Fatalf("回滚未更新规则: %+v", got)
+	}
+
+	wantValidation(t, r.Versions.RollbackRules(ctx, []*model.Rule{rule}, nil))
+}
+
+func testVersionRollbackAtomic(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("rollback-atomic"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+	pattern := rule.Pattern
+
+	changed := *rule
+	changed.Pattern = "(?i)xp_cmdshell"
+	missing := newRule(uniqueName("rollback-missing"))
+	missing.ID = rule.ID + 1000
+	event := &model.RuleUpdateEvent{Version: 2, Action: model.RuleUpdateTypeRollback}
+	err := r.Versions.RollbackRules(ctx, []*model.Rule{&changed, missing}, event)
+	wantCode(t, err, errors.ErrRuleNotFound)
+
+	got, err := r.Rules.GetRule(ctx, rule.ID)
+	must(t, err)
+	if got.Pattern != pattern {
+		t.Fatalf("回滚失败后规则被修改: %s", got.Pattern)
+	}
+}
+
+func testVersionSyncLogs(t *testing.T, r *Repositories) {
+	ctx := context.Background()
+	rule := newRule(uniqueName("sync"))
+	must(t, r.Rules.CreateRule(ctx, rule))
+	for v := int64(1); v <= 3; v++ {
+		must(t, r.Versions.CreateSyncLog(ctx, &model.RuleSyncLog{
+			RuleID:    rule.ID,
+			Version:   v,
+			Status:    "success",
+			SyncType:  "full",
+			CreatedBy: "repotest",
+		}))
+	}
+
+	logs, err := r.Versions.ListSyncLogs(ctx, rule.ID)
+	must(t, err)
+	if len(logs) != 3 {
+		t.Fatalf("同步日志返回%d条，期望3条", len(logs))
+	}
+	for i := 1; i < len(logs); i++ {
+		if logs[i].CreatedAt.After(logs[i-1].CreatedAt) {
+			t.Fatal("同步日志未按时间倒序")
+		}
+	}
+	logs, err = r.Versions.ListSyncLogs(ctx, rule.ID+1000)
+	must(t, err)
+	if len(logs) != 0 {
+		t.Fatalf("其他规则返回了%d条同步日志", len(logs))
+	}
+}
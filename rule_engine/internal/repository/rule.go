@@ -16,6 +16,7 @@ type RuleQuery struct {
 	RuleVariable   model.RuleVariable `form:"rule_variable"`   // 规则变量
 	Severity       model.SeverityType `form:"severity"`        // 风险级别
 	RulesOperation string             `form:"rules_operation"` // 规则组合操作
+	TemplateID     string             `form:"template_id"`     // 创建规则的模板ID
 	GroupID        int64              `form:"group_id"`        // 规则组ID
 	CreatedBy      int64              `form:"created_by"`      // 创建者ID
 	UpdatedBy      int64              `form:"updated_by"`      // 更新者ID
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+	"gorm.io/gorm"
+)
+
+const (
+	ruleKeyPrefix     = "waf:rule:"
+	defaultRuleExpire = 24 * time.Hour
+)
+
+// ruleTTL 规则缓存过期时间，可在运行时修改
+type ruleTTL struct {
+	ttl atomic.Int64
+}
+
+// SetRuleTTL 设置规则缓存过期时间，ttl<=0时使用默认值
+func (t *ruleTTL) SetRuleTTL(ttl time.Duration) {
+	t.ttl.Store(int64(ttl))
+}
+
+// ruleExpire 当前规则缓存过期时间
+func (t *ruleTTL) ruleExpire() time.Duration {
+	if ttl := time.Duration(t.ttl.Load()); ttl > 0 {
+		return ttl
+	}
+	return defaultRuleExpire
+}
+
+// Cache SQLite缓存实现，替代单机部署中的Redis
+// 过期条目读取时忽略，由Run定期清理
+type Cache struct {
+	ruleTTL
+	db *gorm.DB
+}
+
+// NewCache 创建缓存
+func NewCache(db *gorm.DB) *Cache {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &Cache{db: db}
+}
+
+// Set 设置缓存，expiration<=0时不过期
+func (c *Cache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var expiresAt int64
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration).UnixNano()
+	}
+	err = c.db.WithContext(ctx).Exec(
+		"INSERT INTO cache_entries (key, value, expires_at) VALUES (?, ?, ?) "+
+			"ON CONFLICT(key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at",
+		key, data, expiresAt).Error
+	if err != nil {
+		return errors.NewError(errors.ErrCache, fmt.Sprintf("设置缓存失败: %v", err))
+	}
+	return nil
+}
+
+// Get 获取缓存，不存在或已过期时返回ErrCacheMiss
+func (c *Cache) Get(ctx context.Context, key string, value interface{}) error {
+	start := time.Now()
+	var data []byte
+	err := c.db.WithContext(ctx).
+		Raw("SELECT value FROM cache_entries WHERE key = ? AND (expires_at = 0 OR expires_at > ?)", key, time.Now().UnixNano()).
+		Row().Scan(&data)
+	metrics.RecordCacheOperation("get", err == nil, time.Since(start))
+	if err == sql.ErrNoRows {
+		return errors.NewError(errors.ErrCacheMiss, fmt.Sprintf("缓存不存在: %s", key))
+	}
+	if err != nil {
+		return errors.NewError(errors.ErrCache, fmt.Sprintf("获取缓存失败: %v", err))
+	}
+	return json.Unmarshal(data, value)
+}
+
+// Delete 删除缓存
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if err := c.db.WithContext(ctx).Exec("DELETE FROM cache_entries WHERE key = ?", key).Error; err != nil {
+		return errors.NewError(errors.ErrCache, fmt.Sprintf("删除缓存失败: %v", err))
+	}
+	return nil
+}
+
+// SetRule 设置规则缓存
+func (c *Cache) SetRule(ctx context.Context, rule *model.Rule) error {
+	key := fmt.Sprintf("%s%d", ruleKeyPrefix, rule.ID)
+	return c.Set(ctx, key, rule, c.ruleExpire())
+}
+
+// GetRule 获取规则缓存
+func (c *Cache) GetRule(ctx context.Context, id int64) (*model.Rule, error) {
+	key := fmt.Sprintf("%s%d", ruleKeyPrefix, id)
+	var rule model.Rule
+	if err := c.Get(ctx, key, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRule 删除规则缓存
+func (c *Cache) DeleteRule(ctx context.Context, id int64) error {
+	key := fmt.Sprintf("%s%d", ruleKeyPrefix, id)
+	return c.Delete(ctx, key)
+}
+
+// ClearRules 清空规则缓存
+func (c *Cache) ClearRules(ctx context.Context) error {
+	err := c.db.WithContext(ctx).Exec("DELETE FROM cache_entries WHERE key LIKE ?", ruleKeyPrefix+"%").Error
+	if err != nil {
+		return errors.NewError(errors.ErrCache, fmt.Sprintf("清空规则缓存失败: %v", err))
+	}
+	return nil
+}
+
+// Purge 删除已过期的缓存，返回删除的条目数
+func (c *Cache) Purge(ctx context.Context) (int64, error) {
+	result := c.db.WithContext(ctx).Exec("DELETE FROM cache_entries WHERE expires_at > 0 AND expires_at <= ?", time.Now().UnixNano())
+	if result.Error != nil {
+		return 0, errors.NewError(errors.ErrCache, fmt.Sprintf("清理过期缓存失败: %v", result.Error))
+	}
+	return result.RowsAffected, nil
+}
+
+// Run 按间隔清理过期缓存，直到ctx取消
+func (c *Cache) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Purge(ctx); err != nil && ctx.Err() == nil {
+				logger.Warnf("清理过期缓存失败: %v", err)
+			}
+		}
+	}
+}
+
+var (
+	_ repository.CacheRepository = (*Cache)(nil)
+	_ repository.RuleCache       = (*Cache)(nil)
+)
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ccRuleRepository CC规则SQLite仓储实现
+type ccRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewCCRuleRepository 创建CC规则仓储
+func NewCCRuleRepository(db *gorm.DB) repository.CCRuleRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &ccRuleRepository{db: db}
+}
+
+// validateCCRule 验证CC规则，与MySQL实现的检查一致
+func validateCCRule(rule *model.CCRule, create bool) error {
+	if rule == nil {
+		return errors.NewError(errors.ErrValidation, "CC规则不能为空")
+	}
+	if create && rule.URI == "" {
+		return errors.NewError(errors.ErrValidation, "URI不能为空")
+	}
+	if !create && rule.ID <= 0 {
+		return errors.NewError(errors.ErrValidation, "规则ID必须大于0")
+	}
+	if rule.LimitRate <= 0 {
+		return errors.NewError(errors.ErrValidation, "限制速率必须大于0")
+	}
+	if rule.TimeWindow <= 0 {
+		return errors.NewError(errors.ErrValidation, "时间窗口必须大于0")
+	}
+	if rule.LimitUnit == "" {
+		return errors.NewError(errors.ErrValidation, "限制单位不能为空")
+	}
+	return nil
+}
+
+// CreateCCRule 创建CC规则，URI重复时返回ErrRuleConflict
+func (r *ccRuleRepository) CreateCCRule(ctx context.Context, rule *model.CCRule) error {
+	if err := validateCCRule(rule, true); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		if err == gorm.ErrDuplicatedKey {
+			return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("CC规则已存在: URI=%s", rule.URI))
+		}
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建CC规则失败: %v", err))
+	}
+	return nil
+}
+
+// UpdateCCRule 更新CC规则，URI不可修改
+func (r *ccRuleRepository) UpdateCCRule(ctx context.Context, rule *model.CCRule) error {
+	if err := validateCCRule(rule, false); err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(rule).
+		Select("limit_rate", "time_window", "limit_unit", "status", "updated_at").
+		Updates(rule)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新CC规则失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("CC规则不存在: ID=%d", rule.ID))
+	}
+	return nil
+}
+
+// DeleteCCRule 删除CC规则
+func (r *ccRuleRepository) DeleteCCRule(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return errors.NewError(errors.ErrValidation, "规则ID必须大于0")
+	}
+	result := r.db.WithContext(ctx).Delete(&model.CCRule{}, id)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("删除CC规则失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("CC规则不存在: ID=%d", id))
+	}
+	return nil
+}
+
+// GetCCRule 获取CC规则
+func (r *ccRuleRepository) GetCCRule(ctx context.Context, id int64) (*model.CCRule, error) {
+	if id <= 0 {
+		return nil, errors.NewError(errors.ErrValidation, "规则ID必须大于0")
+	}
+	var rule model.CCRule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("CC规则不存在: ID=%d", id))
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取CC规则失败: %v", err))
+	}
+	return &rule, nil
+}
+
+// ListCCRules 获取CC规则列表，按ID倒序
+func (r *ccRuleRepository) ListCCRules(ctx context.Context, offset, limit int) ([]*model.CCRule, error) {
+	if offset < 0 {
+		return nil, errors.NewError(errors.ErrValidation, "偏移量不能为负数")
+	}
+	if limit <= 0 {
+		return nil, errors.NewError(errors.ErrValidation, "每页大小必须大于0")
+	}
+	rules := make([]*model.CCRule, 0)
+	if err := r.db.WithContext(ctx).Order("id DESC").Offset(offset).Limit(limit).Find(&rules).Error; err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询CC规则列表失败: %v", err))
+	}
+	return rules, nil
+}
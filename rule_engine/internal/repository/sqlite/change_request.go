@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// changeRequestRepository 变更请求SQLite仓储实现
+type changeRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewChangeRequestRepository 创建变更请求仓储
+func NewChangeRequestRepository(db *gorm.DB) repository.ChangeRequestRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &changeRequestRepository{db: db}
+}
+
+// CreateChangeRequest 创建变更请求
+func (r *changeRequestRepository) CreateChangeRequest(ctx context.Context, cr *model.ChangeRequest) error {
+	if err := cr.Encode(); err != nil {
+		return err
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(cr).Error; err != nil {
+			return err
+		}
+		return createChangeItems(tx, cr)
+	})
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建变更请求失败: %v", err))
+	}
+	return nil
+}
+
+// UpdateChangeRequest 更新变更请求
+func (r *changeRequestRepository) UpdateChangeRequest(ctx context.Context, cr *model.ChangeRequest) error {
+	if err := cr.Encode(); err != nil {
+		return err
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(cr).Select("*").Omit("id", "created_at").Updates(cr)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("change_request_id = ?", cr.ID).Delete(&model.ChangeItem{}).Error; err != nil {
+			return err
+		}
+		return createChangeItems(tx, cr)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("变更请求不存在: %d", cr.ID))
+		}
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新变更请求失败: %v", err))
+	}
+	return nil
+}
+
+// createChangeItems 写入变更项，调用方负责开启事务
+func createChangeItems(tx *gorm.DB, cr *model.ChangeRequest) error {
+	if len(cr.Items) == 0 {
+		return nil
+	}
+	for _, item := range cr.Items {
+		item.ID = 0
+		item.ChangeRequestID = cr.ID
+	}
+	return tx.Create(&cr.Items).Error
+}
+
+// GetChangeRequest 获取变更请求
+func (r *changeRequestRepository) GetChangeRequest(ctx context.Context, id int64) (*model.ChangeRequest, error) {
+	var cr model.ChangeRequest
+	db := r.db.WithContext(ctx)
+	if err := db.First(&cr, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("变更请求不存在: %d", id))
+		}
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取变更请求失败: %v", err))
+	}
+
+	cr.Items = make([]*model.ChangeItem, 0)
+	if err := db.Where("change_request_id = ?", id).Order("id").Find(&cr.Items).Error; err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取变更项失败: %v", err))
+	}
+	if err := cr.Decode(); err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// ListChangeRequests 获取变更请求列表
+func (r *changeRequestRepository) ListChangeRequests(ctx context.Context, status model.ChangeRequestStatus, offset, limit int) ([]*model.ChangeRequest, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.ChangeRequest{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取变更请求总数失败: %v", err))
+	}
+
+	crs := make([]*model.ChangeRequest, 0)
+	query := db.Order("id DESC").Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&crs).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取变更请求列表失败: %v", err))
+	}
+	for _, cr := range crs {
+		if err := cr.Decode(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return crs, total, nil
+}
+
+// CreateEvent 记录变更请求历史
+func (r *changeRequestRepository) CreateEvent(ctx context.Context, event *model.ChangeEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("记录变更请求历史失败: %v", err))
+	}
+	return nil
+}
+
+// ListEvents 获取变更请求历史
+func (r *changeRequestRepository) ListEvents(ctx context.Context, changeRequestID int64) ([]*model.ChangeEvent, error) {
+	events := make([]*model.ChangeEvent, 0)
+	err := r.db.WithContext(ctx).Where("change_request_id = ?", changeRequestID).Order("id").Find(&events).Error
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取变更请求历史失败: %v", err))
+	}
+	return events, nil
+}
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// wafConfigRepository WAF配置SQLite仓储实现
+type wafConfigRepository struct {
+	db *gorm.DB
+}
+
+// NewWAFConfigRepository 创建WAF配置仓储
+func NewWAFConfigRepository(db *gorm.DB) repository.WAFConfigRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &wafConfigRepository{db: db}
+}
+
+// GetConfig 获取最新的WAF配置
+func (r *wafConfigRepository) GetConfig(ctx context.Context) (*model.WAFConfig, error) {
+	var config model.WAFConfig
+	err := r.db.WithContext(ctx).Order("id DESC").First(&config).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewError(errors.ErrConfig, "WAF配置不存在，请先创建配置")
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取WAF配置失败: %v", err))
+	}
+	return &config, nil
+}
+
+// UpdateConfig 更新WAF配置，ID为0时创建新配置
+func (r *wafConfigRepository) UpdateConfig(ctx context.Context, config *model.WAFConfig) error {
+	if config == nil {
+		return errors.NewError(errors.ErrValidation, "配置不能为空")
+	}
+	if config.Mode == "" {
+		return errors.NewError(errors.ErrValidation, "WAF模式不能为空")
+	}
+	if config.CreatedBy == "" {
+		return errors.NewError(errors.ErrValidation, "创建者不能为空")
+	}
+	if config.UpdatedBy == "" {
+		return errors.NewError(errors.ErrValidation, "更新者不能为空")
+	}
+
+	now := time.Now().Unix()
+	config.UpdatedAt = now
+	if config.ID == 0 {
+		config.CreatedAt = now
+		if err := r.db.WithContext(ctx).Create(config).Error; err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建WAF配置失败: %v", err))
+		}
+		return nil
+	}
+
+	result := r.db.WithContext(ctx).Model(config).
+		Select("mode", "description", "updated_by", "updated_at").
+		Updates(config)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新WAF配置失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrConfig, fmt.Sprintf("WAF配置不存在: ID=%d", config.ID))
+	}
+	return nil
+}
+
+// LogModeChange 记录模式变更日志
+func (r *wafConfigRepository) LogModeChange(ctx context.Context, log *model.WAFModeChangeLog) error {
+	if log == nil {
+		return errors.NewError(errors.ErrValidation, "日志不能为空")
+	}
+	if log.OldMode == "" {
+		return errors.NewError(errors.ErrValidation, "原WAF模式不能为空")
+	}
+	if log.NewMode == "" {
+		return errors.NewError(errors.ErrValidation, "新WAF模式不能为空")
+	}
+	if log.Operator == "" {
+		return errors.NewError(errors.ErrValidation, "操作者不能为空")
+	}
+	if log.Reason == "" {
+		return errors.NewError(errors.ErrValidation, "变更原因不能为空")
+	}
+	if log.CreatedAt == 0 {
+		return errors.NewError(errors.ErrValidation, "创建时间不能为空")
+	}
+	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("记录模式变更日志失败: %v", err))
+	}
+	return nil
+}
+
+// GetModeChangeLogs 获取时间范围内的模式变更日志，按时间倒序
+func (r *wafConfigRepository) GetModeChangeLogs(ctx context.Context, startTime, endTime int64, page, pageSize int) ([]*model.WAFModeChangeLog, int64, error) {
+	if startTime < 0 {
+		return nil, 0, errors.NewError(errors.ErrValidation, "开始时间不能为负数")
+	}
+	if endTime < startTime {
+		return nil, 0, errors.NewError(errors.ErrValidation, "结束时间不能小于开始时间")
+	}
+	if page <= 0 {
+		return nil, 0, errors.NewError(errors.ErrValidation, "页码必须大于0")
+	}
+	if pageSize <= 0 {
+		return nil, 0, errors.NewError(errors.ErrValidation, "每页大小必须大于0")
+	}
+
+	db := r.db.WithContext(ctx).Model(&model.WAFModeChangeLog{}).Where("created_at BETWEEN ? AND ?", startTime, endTime)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取模式变更日志总数失败: %v", err))
+	}
+
+	logs := make([]*model.WAFModeChangeLog, 0)
+	err := db.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error
+	if err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取模式变更日志失败: %v", err))
+	}
+	return logs, total, nil
+}
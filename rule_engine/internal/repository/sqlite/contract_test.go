@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xwaf/rule_engine/internal/repository/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repotest.Repositories {
+		db, err := Open(&Config{Path: filepath.Join(t.TempDir(), "waf.db")})
+		if err != nil {
+			t.Fatalf("打开SQLite失败: %v", err)
+		}
+		t.Cleanup(func() {
+			if sqlDB, err := db.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+		cache := NewCache(db)
+		return &repotest.Repositories{
+			Rules:     NewRuleRepository(db),
+			Versions:  NewRuleVersionRepository(db),
+			IPs:       NewIPRuleRepository(db),
+			CC:        NewCCRuleRepository(db),
+			Configs:   NewWAFConfigRepository(db),
+			Releases:  NewReleaseRepository(db),
+			Changes:   NewChangeRequestRepository(db),
+			Bypasses:  NewBypassRepository(db),
+			Nodes:     NewNodeRepository(db),
+			Uploads:   NewUploadEventRepository(db),
+			Cache:     cache,
+			RuleCache: cache,
+		}
+	})
+}
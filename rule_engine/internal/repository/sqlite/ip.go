@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ipRuleRepository IP规则SQLite仓储实现
+type ipRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewIPRuleRepository 创建IP规则仓储
+func NewIPRuleRepository(db *gorm.DB) repository.IPRuleRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &ipRuleRepository{db: db}
+}
+
+// CreateIPRule 创建IP规则，同类型条目重复时返回ErrRuleConflict
+func (r *ipRuleRepository) CreateIPRule(ctx context.Context, rule *model.IPRule) error {
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		if err == gorm.ErrDuplicatedKey {
+			return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("IP规则已存在: %s=%s", rule.EntryType, rule.IP))
+		}
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建IP规则失败: %v", err))
+	}
+	return nil
+}
+
+// UpdateIPRule 更新IP规则，条目类型和值不可修改
+func (r *ipRuleRepository) UpdateIPRule(ctx context.Context, rule *model.IPRule) error {
+	result := r.db.WithContext(ctx).Model(rule).
+		Select("ip_type", "block_type", "expire_time", "description", "updated_by", "updated_at").
+		Updates(rule)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新IP规则失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %d", rule.ID))
+	}
+	return nil
+}
+
+// DeleteIPRule 删除IP规则
+func (r *ipRuleRepository) DeleteIPRule(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).Delete(&model.IPRule{}, id)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("删除IP规则失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %d", id))
+	}
+	return nil
+}
+
+// GetIPRule 获取IP规则
+func (r *ipRuleRepository) GetIPRule(ctx context.Context, id int64) (*model.IPRule, error) {
+	var rule model.IPRule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %d", id))
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取IP规则失败: %v", err))
+	}
+	return &rule, nil
+}
+
+// GetIPRuleByIP 根据IP获取规则
+func (r *ipRuleRepository) GetIPRuleByIP(ctx context.Context, ip string) (*model.IPRule, error) {
+	var rule model.IPRule
+	err := r.db.WithContext(ctx).Where("ip = ?", ip).Order("id").First(&rule).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %s", ip))
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取IP规则失败: %v", err))
+	}
+	return &rule, nil
+}
+
+// GetIPRuleByEntry 根据条目类型和值获取规则
+func (r *ipRuleRepository) GetIPRuleByEntry(ctx context.Context, entryType model.IPEntryType, value string) (*model.IPRule, error) {
+	var rule model.IPRule
+	err := r.db.WithContext(ctx).Where("entry_type = ? AND ip = ?", entryType, value).First(&rule).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("IP规则不存在: %s=%s", entryType, value))
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取IP规则失败: %v", err))
+	}
+	return &rule, nil
+}
+
+// ListIPRules 获取IP规则列表，按创建时间倒序，limit<=0时返回全部
+func (r *ipRuleRepository) ListIPRules(ctx context.Context, query *model.IPRuleQuery, offset, limit int) ([]*model.IPRule, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.IPRule{})
+	if query != nil {
+		if query.Keyword != "" {
+			db = db.Where("(ip LIKE ? OR description LIKE ?)", "%"+query.Keyword+"%", "%"+query.Keyword+"%")
+		}
+		if query.EntryType != "" {
+			db = db.Where("entry_type = ?", query.EntryType)
+		}
+		if query.IPType != "" {
+			db = db.Where("ip_type = ?", query.IPType)
+		}
+		if query.BlockType != "" {
+			db = db.Where("block_type = ?", query.BlockType)
+		}
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取IP规则总数失败: %v", err))
+	}
+
+	rules := make([]*model.IPRule, 0)
+	q := db.Order("created_at DESC, id DESC").Offset(offset)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&rules).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询IP规则列表失败: %v", err))
+	}
+	return rules, total, nil
+}
+
+// ExistsByIP 检查IP是否存在规则
+func (r *ipRuleRepository) ExistsByIP(ctx context.Context, ip string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.IPRule{}).Where("ip = ?", ip).Count(&count).Error; err != nil {
+		return false, errors.NewError(errors.ErrSystem, fmt.Sprintf("检查IP规则是否存在失败: %v", err))
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// nodeRepository WAF节点SQLite仓储实现
+type nodeRepository struct {
+	db *gorm.DB
+}
+
+// NewNodeRepository 创建WAF节点仓储
+func NewNodeRepository(db *gorm.DB) repository.NodeRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &nodeRepository{db: db}
+}
+
+// CreateNode 注册节点，节点标识已存在时返回ErrRuleConflict
+func (r *nodeRepository) CreateNode(ctx context.Context, node *model.Node) error {
+	if err := r.db.WithContext(ctx).Create(node).Error; err != nil {
+		if err == gorm.ErrDuplicatedKey {
+			return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("节点已存在: %s", node.NodeID))
+		}
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("注册节点失败: %v", err))
+	}
+	return nil
+}
+
+// UpdateNode 更新节点
+func (r *nodeRepository) UpdateNode(ctx context.Context, node *model.Node) error {
+	err := r.db.WithContext(ctx).Model(&model.Node{}).Where("node_id = ?", node.NodeID).
+		Select("*").Omit("id", "node_id", "token_hash", "registered_at").Updates(node).Error
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("更新节点失败: %v", err))
+	}
+	return nil
+}
+
+// GetNode 获取节点
+func (r *nodeRepository) GetNode(ctx context.Context, nodeID string) (*model.Node, error) {
+	var node model.Node
+	if err := r.db.WithContext(ctx).Where("node_id = ?", nodeID).First(&node).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("节点不存在: %s", nodeID))
+		}
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取节点失败: %v", err))
+	}
+	return &node, nil
+}
+
+// ListNodes 获取全部节点
+func (r *nodeRepository) ListNodes(ctx context.Context) ([]*model.Node, error) {
+	nodes := make([]*model.Node, 0)
+	if err := r.db.WithContext(ctx).Order("node_id").Find(&nodes).Error; err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取节点列表失败: %v", err))
+	}
+	return nodes, nil
+}
+
+// DeleteNode 删除节点
+func (r *nodeRepository) DeleteNode(ctx context.Context, nodeID string) error {
+	result := r.db.WithContext(ctx).Where("node_id = ?", nodeID).Delete(&model.Node{})
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("删除节点失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("节点不存在: %s", nodeID))
+	}
+	return nil
+}
+
+// MarkDown 将心跳超时的节点标记为下线
+func (r *nodeRepository) MarkDown(ctx context.Context, before time.Time) ([]string, error) {
+	marked := make([]string, 0)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stale := tx.Model(&model.Node{}).Where("status <> ? AND last_heartbeat_at < ?", model.NodeDown, before)
+		if err := stale.Order("node_id").Pluck("node_id", &marked).Error; err != nil {
+			return err
+		}
+		if len(marked) == 0 {
+			return nil
+		}
+		return tx.Model(&model.Node{}).Where("node_id IN ?", marked).
+			Updates(map[string]interface{}{"status": model.NodeDown, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("标记下线节点失败: %v", err))
+	}
+	return marked, nil
+}
@@ -0,0 +1,186 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// releaseColumns 发布列表查询的字段，不含快照内容
+const releaseColumns = "id, action, source_id, hash, rule_count, description, created_by, created_at"
+
+// releaseRepository 规则集发布SQLite仓储实现
+type releaseRepository struct {
+	db *gorm.DB
+}
+
+// NewReleaseRepository 创建规则集发布仓储
+func NewReleaseRepository(db *gorm.DB) repository.ReleaseRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &releaseRepository{db: db}
+}
+
+// Snapshot 读取当前规则集，SQLite事务本身即为快照隔离
+func (r *releaseRepository) Snapshot(ctx context.Context) (*model.ReleaseSnapshot, error) {
+	var snapshot *model.ReleaseSnapshot
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		snapshot, err = readSnapshot(tx)
+		return err
+	})
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("读取规则集快照失败: %v", err))
+	}
+	return snapshot, nil
+}
+
+// CreateRelease 发布当前规则集
+func (r *releaseRepository) CreateRelease(ctx context.Context, release *model.RulesetRelease) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		snapshot, err := readSnapshot(tx)
+		if err != nil {
+			return err
+		}
+		release.Snapshot = snapshot
+		if err := release.Seal(); err != nil {
+			return err
+		}
+		return tx.Create(release).Error
+	})
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("发布规则集失败: %v", err))
+	}
+	return nil
+}
+
+// RestoreRelease 用快照替换当前规则集
+func (r *releaseRepository) RestoreRelease(ctx context.Context, release *model.RulesetRelease) error {
+	if err := release.Seal(); err != nil {
+		return err
+	}
+	s := release.Snapshot
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"rules", "rule_groups", "ip_rules", "cc_rules"} {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				return fmt.Errorf("清空%s失败: %v", table, err)
+			}
+		}
+		// 保留原ID，使回滚后的规则与历史发布一一对应
+		if len(s.Rules) > 0 {
+			if err := tx.Create(&s.Rules).Error; err != nil {
+				return fmt.Errorf("恢复规则失败: %v", err)
+			}
+		}
+		if len(s.Groups) > 0 {
+			if err := tx.Create(&s.Groups).Error; err != nil {
+				return fmt.Errorf("恢复规则组失败: %v", err)
+			}
+		}
+		if len(s.IPRules) > 0 {
+			if err := tx.Create(&s.IPRules).Error; err != nil {
+				return fmt.Errorf("恢复IP规则失败: %v", err)
+			}
+		}
+		if len(s.CCRules) > 0 {
+			if err := tx.Create(&s.CCRules).Error; err != nil {
+				return fmt.Errorf("恢复CC规则失败: %v", err)
+			}
+		}
+		// 配置按最新一条生效，追加一条记录而不修改历史配置
+		if s.Config != nil {
+			err := tx.Exec("INSERT INTO waf_configs (mode, description, created_by, updated_by) VALUES (?, ?, ?, ?)",
+				s.Config.Mode, s.Config.Description, release.CreatedBy, release.CreatedBy).Error
+			if err != nil {
+				return fmt.Errorf("恢复WAF配置失败: %v", err)
+			}
+		}
+		return tx.Create(release).Error
+	})
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("回滚规则集失败: %v", err))
+	}
+	return nil
+}
+
+// readSnapshot 读取规则集，调用方负责开启事务
+func readSnapshot(tx *gorm.DB) (*model.ReleaseSnapshot, error) {
+	s := &model.ReleaseSnapshot{
+		Rules:   make([]*model.Rule, 0),
+		Groups:  make([]*model.RuleGroup, 0),
+		IPRules: make([]*model.IPRule, 0),
+		CCRules: make([]*model.CCRule, 0),
+	}
+	if err := tx.Order("id").Find(&s.Rules).Error; err != nil {
+		return nil, fmt.Errorf("读取规则失败: %v", err)
+	}
+	if err := tx.Order("id").Find(&s.Groups).Error; err != nil {
+		return nil, fmt.Errorf("读取规则组失败: %v", err)
+	}
+	if err := tx.Order("id").Find(&s.IPRules).Error; err != nil {
+		return nil, fmt.Errorf("读取IP规则失败: %v", err)
+	}
+	if err := tx.Order("id").Find(&s.CCRules).Error; err != nil {
+		return nil, fmt.Errorf("读取CC规则失败: %v", err)
+	}
+
+	var config model.WAFConfig
+	err := tx.Raw("SELECT id, mode, description FROM waf_configs ORDER BY id DESC LIMIT 1").Scan(&config).Error
+	if err != nil {
+		return nil, fmt.Errorf("读取WAF配置失败: %v", err)
+	}
+	if config.ID > 0 {
+		s.Config = &config
+	}
+	return s, nil
+}
+
+// GetRelease 获取发布
+func (r *releaseRepository) GetRelease(ctx context.Context, id int64) (*model.RulesetRelease, error) {
+	var release model.RulesetRelease
+	if err := r.db.WithContext(ctx).First(&release, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("发布不存在: %d", id))
+		}
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取发布失败: %v", err))
+	}
+	return &release, nil
+}
+
+// GetLatestRelease 获取最新发布
+func (r *releaseRepository) GetLatestRelease(ctx context.Context) (*model.RulesetRelease, error) {
+	var releases []*model.RulesetRelease
+	if err := r.db.WithContext(ctx).Order("id DESC").Limit(1).Find(&releases).Error; err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取最新发布失败: %v", err))
+	}
+	if len(releases) == 0 {
+		return nil, nil
+	}
+	return releases[0], nil
+}
+
+// ListReleases 获取发布列表
+func (r *releaseRepository) ListReleases(ctx context.Context, offset, limit int) ([]*model.RulesetRelease, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.RulesetRelease{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取发布总数失败: %v", err))
+	}
+
+	releases := make([]*model.RulesetRelease, 0)
+	query := db.Select(releaseColumns).Order("id DESC").Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&releases).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取发布列表失败: %v", err))
+	}
+	return releases, total, nil
+}
@@ -0,0 +1,367 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ruleRepository 规则SQLite仓储实现
+type ruleRepository struct {
+	db *gorm.DB
+}
+
+// NewRuleRepository 创建规则仓储，匹配计数保存在rule_match_counts表中
+func NewRuleRepository(db *gorm.DB) repository.RuleRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &ruleRepository{db: db}
+}
+
+// CreateRule 创建规则
+func (r *ruleRepository) CreateRule(ctx context.Context, rule *model.Rule) error {
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return ruleWriteError("创建规则失败", rule.Name, err)
+	}
+	return nil
+}
+
+// BatchCreateRules 批量创建规则，任一规则失败时全部不创建
+func (r *ruleRepository) BatchCreateRules(ctx context.Context, rules []*model.Rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range rules {
+			if err := tx.Create(rule).Error; err != nil {
+				return ruleWriteError("批量创建规则失败", rule.Name, err)
+			}
+		}
+		return nil
+	})
+	return systemError("批量创建规则失败", err)
+}
+
+// UpdateRule 更新规则，创建时间保持不变
+func (r *ruleRepository) UpdateRule(ctx context.Context, rule *model.Rule) error {
+	return updateRule(r.db.WithContext(ctx), rule)
+}
+
+// BatchUpdateRules 批量更新规则，任一规则失败时全部不更新
+func (r *ruleRepository) BatchUpdateRules(ctx context.Context, rules []*model.Rule) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range rules {
+			if err := updateRule(tx, rule); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return systemError("批量更新规则失败", err)
+}
+
+// updateRule 按ID更新规则的全部字段
+// 不使用Save，Save在规则不存在时会插入新规则
+func updateRule(db *gorm.DB, rule *model.Rule) error {
+	result := db.Model(rule).Select("*").Omit("id", "created_at").Updates(rule)
+	if result.Error != nil {
+		return ruleWriteError("更新规则失败", rule.Name, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %d", rule.ID))
+	}
+	return nil
+}
+
+// DeleteRule 删除规则
+func (r *ruleRepository) DeleteRule(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).Delete(&model.Rule{}, id)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("删除规则失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %d", id))
+	}
+	return nil
+}
+
+// BatchDeleteRules 批量删除规则，存在任一规则时删除存在的规则
+func (r *ruleRepository) BatchDeleteRules(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, "未找到要删除的规则")
+	}
+	result := r.db.WithContext(ctx).Delete(&model.Rule{}, ids)
+	if result.Error != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("批量删除规则失败: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewError(errors.ErrRuleNotFound, "未找到要删除的规则")
+	}
+	return nil
+}
+
+// GetRule 获取规则
+func (r *ruleRepository) GetRule(ctx context.Context, id int64) (*model.Rule, error) {
+	var rule model.Rule
+	if err := r.db.WithContext(ctx).First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %d", id))
+		}
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取规则失败: %v", err))
+	}
+	return &rule, nil
+}
+
+// GetRuleByName 根据名称获取规则
+func (r *ruleRepository) GetRuleByName(ctx context.Context, name string) (*model.Rule, error) {
+	if name == "" {
+		return nil, errors.NewError(errors.ErrRuleValidation, "规则名称不能为空")
+	}
+
+	var rule model.Rule
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&rule).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则不存在: %s", name))
+		}
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取规则失败: %v", err))
+	}
+	return &rule, nil
+}
+
+// ListRules 获取规则列表，按ID排序
+func (r *ruleRepository) ListRules(ctx context.Context, query *repository.RuleQuery) ([]*model.Rule, int64, error) {
+	if query == nil {
+		query = &repository.RuleQuery{}
+	}
+	db := r.db.WithContext(ctx).Model(&model.Rule{})
+
+	if query.Keyword != "" {
+		db = db.Where("name LIKE ? OR description LIKE ?", "%"+query.Keyword+"%", "%"+query.Keyword+"%")
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.RuleType != "" {
+		db = db.Where("type = ?", query.RuleType)
+	}
+	if query.RuleVariable != "" {
+		db = db.Where("rule_variable = ?", query.RuleVariable)
+	}
+	if query.Severity != "" {
+		db = db.Where("severity = ?", query.Severity)
+	}
+	if query.RulesOperation != "" {
+		db = db.Where("rules_operation = ?", query.RulesOperation)
+	}
+	if query.TemplateID != "" {
+		db = db.Where("template_id = ?", query.TemplateID)
+	}
+	if query.GroupID != 0 {
+		db = db.Where("group_id = ?", query.GroupID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取规则总数失败: %v", err))
+	}
+
+	// 未指定分页时返回全部规则
+	db = db.Order("id")
+	if query.PageSize > 0 {
+		page := query.Page
+		if page < 1 {
+			page = 1
+		}
+		db = db.Offset((page - 1) * query.PageSize).Limit(query.PageSize)
+	}
+	rules := make([]*model.Rule, 0)
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询规则列表失败: %v", err))
+	}
+	return rules, total, nil
+}
+
+// GetLatestVersion 获取最新版本号
+func (r *ruleRepository) GetLatestVersion(ctx context.Context) (int64, error) {
+	var version int64
+	if err := r.db.WithContext(ctx).Model(&model.Rule{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取最新版本号失败: %v", err))
+	}
+	return version, nil
+}
+
+// ImportRules 导入规则，按名称匹配，已存在的规则更新，其余创建
+func (r *ruleRepository) ImportRules(ctx context.Context, rules []*model.Rule) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range rules {
+			var existing model.Rule
+			err := tx.Select("id", "created_at").Where("name = ?", rule.Name).First(&existing).Error
+			switch err {
+			case nil:
+				rule.ID = existing.ID
+				rule.CreatedAt = existing.CreatedAt
+				if err := updateRule(tx, rule); err != nil {
+					return err
+				}
+			case gorm.ErrRecordNotFound:
+				rule.ID = 0
+				if err := tx.Create(rule).Error; err != nil {
+					return ruleWriteError("创建规则失败", rule.Name, err)
+				}
+			default:
+				return errors.NewError(errors.ErrSystem, fmt.Sprintf("检查规则是否存在失败: %v", err))
+			}
+		}
+		return nil
+	})
+	return systemError("导入规则失败", err)
+}
+
+// BeginTx 开启事务
+// 与MySQL实现相同，仓储的其他方法不在该事务中执行。事务以DEFERRED方式开启，
+// 在执行语句前不持有写锁，调用方在提交前通过仓储写入时不会互相等待
+func (r *ruleRepository) BeginTx(ctx context.Context) (repository.Transaction, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("开启事务失败: %v", err))
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("开启事务失败: %v", err))
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN DEFERRED"); err != nil {
+		conn.Close()
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("开启事务失败: %v", err))
+	}
+	return &deferredTx{conn: conn}, nil
+}
+
+// deferredTx 在独占连接上手动开启的事务
+type deferredTx struct {
+	conn *sql.Conn
+	done bool
+}
+
+// Commit 提交事务
+func (t *deferredTx) Commit() error {
+	return t.finish("COMMIT")
+}
+
+// Rollback 回滚事务，事务已结束时返回sql.ErrTxDone
+func (t *deferredTx) Rollback() error {
+	return t.finish("ROLLBACK")
+}
+
+func (t *deferredTx) finish(stmt string) error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	defer t.conn.Close()
+	if _, err := t.conn.ExecContext(context.Background(), stmt); err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("结束事务失败: %v", err))
+	}
+	return nil
+}
+
+// GetRuleStats 获取规则统计信息
+func (r *ruleRepository) GetRuleStats(ctx context.Context) (*model.RuleStats, error) {
+	var stats model.RuleStats
+	err := r.db.WithContext(ctx).Model(&model.Rule{}).
+		Select("COUNT(*) AS total_rules, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS enabled_rules, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS disabled_rules",
+			model.RuleStatusEnabled, model.RuleStatusDisabled).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取规则统计失败: %v", err))
+	}
+	return &stats, nil
+}
+
+// GetRuleMatchStats 获取规则匹配统计信息，不记录时间线，总数为累计匹配次数
+func (r *ruleRepository) GetRuleMatchStats(ctx context.Context, ruleID int64, startTime, endTime time.Time) (*model.RuleMatchStat, error) {
+	total, err := r.GetRuleMatchCount(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RuleMatchStat{
+		RuleID:    ruleID,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Total:     total,
+		Timeline:  make([]*model.RuleMatchPoint, 0),
+	}, nil
+}
+
+// IncrRuleMatchCount 增加规则匹配计数
+func (r *ruleRepository) IncrRuleMatchCount(ctx context.Context, ruleID int64) error {
+	err := r.db.WithContext(ctx).Exec(
+		"INSERT INTO rule_match_counts (rule_id, count) VALUES (?, 1) "+
+			"ON CONFLICT (rule_id) DO UPDATE SET count = count + 1", ruleID).Error
+	if err != nil {
+		return errors.NewError(errors.ErrCache, fmt.Sprintf("增加规则匹配计数失败: %v", err))
+	}
+	return nil
+}
+
+// GetRuleMatchCount 获取规则匹配计数，没有匹配时为0
+func (r *ruleRepository) GetRuleMatchCount(ctx context.Context, ruleID int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw("SELECT COALESCE(MAX(count), 0) FROM rule_match_counts WHERE rule_id = ?", ruleID).
+		Scan(&count).Error
+	if err != nil {
+		return 0, errors.NewError(errors.ErrCache, fmt.Sprintf("获取规则匹配计数失败: %v", err))
+	}
+	return count, nil
+}
+
+// GetRuleAuditLogs 获取规则审计日志，按时间倒序
+func (r *ruleRepository) GetRuleAuditLogs(ctx context.Context, ruleID int64) ([]*model.RuleAuditLog, error) {
+	logs := make([]*model.RuleAuditLog, 0)
+	err := r.db.WithContext(ctx).Where("rule_id = ?", ruleID).Order("created_at DESC, id DESC").Find(&logs).Error
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取规则审计日志失败: %v", err))
+	}
+	return logs, nil
+}
+
+// CreateRuleAuditLog 创建规则审计日志
+func (r *ruleRepository) CreateRuleAuditLog(ctx context.Context, log *model.RuleAuditLog) error {
+	if log == nil {
+		return errors.NewError(errors.ErrValidation, "审计日志不能为空")
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建规则审计日志失败: %v", err))
+	}
+	return nil
+}
+
+// ruleWriteError 规则写入错误，名称重复时返回ErrRuleConflict
+func ruleWriteError(action, name string, err error) error {
+	if err == gorm.ErrDuplicatedKey {
+		return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("规则名称已存在: %s", name))
+	}
+	return errors.NewError(errors.ErrSystem, fmt.Sprintf("%s: %v", action, err))
+}
+
+// systemError 事务返回的错误，仓储错误原样返回，其余错误记为系统错误
+func systemError(action string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*errors.Error); ok {
+		return err
+	}
+	return errors.NewError(errors.ErrSystem, fmt.Sprintf("%s: %v", action, err))
+}
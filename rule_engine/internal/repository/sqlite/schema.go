@@ -0,0 +1,314 @@
+package sqlite
+
+// schema 表结构，与scripts/migrations迁移后的MySQL表结构对应
+// 时间字段声明为TIMESTAMP，驱动按时间类型读写；WAF配置的时间为Unix秒
+var schema = []string{
+	// 规则表，规则名称唯一
+	`CREATE TABLE IF NOT EXISTS rules (
+		id               INTEGER PRIMARY KEY AUTOINCREMENT,
+		group_id         INTEGER NOT NULL DEFAULT 0,
+		name             TEXT    NOT NULL,
+		description      TEXT    NOT NULL DEFAULT '',
+		type             TEXT    NOT NULL,
+		rule_variable    TEXT    NOT NULL DEFAULT '',
+		phase            TEXT    NOT NULL DEFAULT 'request',
+		pattern          TEXT    NOT NULL,
+		params           TEXT    NOT NULL DEFAULT '',
+		action           TEXT    NOT NULL,
+		priority         INTEGER NOT NULL DEFAULT 0,
+		status           TEXT    NOT NULL DEFAULT 'enabled',
+		severity         TEXT    NOT NULL DEFAULT 'medium',
+		rules_operation  TEXT    NOT NULL DEFAULT 'and',
+		version          INTEGER NOT NULL DEFAULT 0,
+		hash             TEXT    NOT NULL DEFAULT '',
+		template_id      TEXT    NOT NULL DEFAULT '',
+		template_version INTEGER NOT NULL DEFAULT 0,
+		start_time       INTEGER NOT NULL DEFAULT 0,
+		end_time         INTEGER NOT NULL DEFAULT 0,
+		schedule         TEXT    NOT NULL DEFAULT '',
+		created_by       INTEGER NOT NULL DEFAULT 0,
+		updated_by       INTEGER NOT NULL DEFAULT 0,
+		created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uk_rules_name ON rules (name)`,
+	`CREATE INDEX IF NOT EXISTS idx_rules_status ON rules (status)`,
+	`CREATE INDEX IF NOT EXISTS idx_rules_version ON rules (version)`,
+	`CREATE INDEX IF NOT EXISTS idx_rules_template_id ON rules (template_id)`,
+
+	// 规则组表
+	`CREATE TABLE IF NOT EXISTS rule_groups (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		name        TEXT    NOT NULL,
+		description TEXT    NOT NULL DEFAULT '',
+		status      INTEGER NOT NULL DEFAULT 1,
+		created_by  INTEGER NOT NULL DEFAULT 0,
+		updated_by  INTEGER NOT NULL DEFAULT 0,
+		created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uk_rule_groups_name ON rule_groups (name)`,
+
+	// 规则版本表
+	`CREATE TABLE IF NOT EXISTS rule_versions (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id     INTEGER NOT NULL,
+		version     INTEGER NOT NULL,
+		hash        TEXT    NOT NULL,
+		content     TEXT    NOT NULL,
+		change_type TEXT    NOT NULL,
+		status      TEXT    NOT NULL DEFAULT 'enabled',
+		created_by  INTEGER NOT NULL DEFAULT 0,
+		created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_rule_versions_rule_id ON rule_versions (rule_id, version)`,
+	`CREATE INDEX IF NOT EXISTS idx_rule_versions_version ON rule_versions (version)`,
+
+	// 规则同步日志表
+	`CREATE TABLE IF NOT EXISTS rule_sync_logs (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id    INTEGER NOT NULL,
+		version    INTEGER NOT NULL,
+		status     TEXT    NOT NULL,
+		message    TEXT    NOT NULL DEFAULT '',
+		sync_type  TEXT    NOT NULL DEFAULT '',
+		created_by TEXT    NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_rule_sync_logs_rule_id ON rule_sync_logs (rule_id)`,
+
+	// 规则审计日志表
+	`CREATE TABLE IF NOT EXISTS rule_audit_logs (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id    INTEGER NOT NULL,
+		action     TEXT    NOT NULL,
+		operator   TEXT    NOT NULL DEFAULT '',
+		old_value  TEXT    NOT NULL DEFAULT '',
+		new_value  TEXT    NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_rule_audit_logs_rule_id ON rule_audit_logs (rule_id)`,
+
+	// 规则更新事件表，变更列表为JSON
+	`CREATE TABLE IF NOT EXISTS rule_update_events (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		version    INTEGER NOT NULL,
+		action     TEXT    NOT NULL,
+		rule_diffs TEXT    NOT NULL DEFAULT '[]',
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_rule_update_events_version ON rule_update_events (version)`,
+
+	// 规则匹配计数表，对应MySQL部署中Redis的计数
+	`CREATE TABLE IF NOT EXISTS rule_match_counts (
+		rule_id INTEGER PRIMARY KEY,
+		count   INTEGER NOT NULL DEFAULT 0
+	)`,
+
+	// CC防护规则表，URI唯一
+	`CREATE TABLE IF NOT EXISTS cc_rules (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		uri         TEXT    NOT NULL,
+		limit_rate  INTEGER NOT NULL,
+		time_window INTEGER NOT NULL,
+		limit_unit  TEXT    NOT NULL,
+		status      TEXT    NOT NULL DEFAULT 'enabled',
+		created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uk_cc_rules_uri ON cc_rules (uri)`,
+
+	// IP规则表，条目类型和值唯一
+	`CREATE TABLE IF NOT EXISTS ip_rules (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		entry_type  TEXT    NOT NULL DEFAULT 'ip',
+		ip          TEXT    NOT NULL,
+		ip_type     TEXT    NOT NULL,
+		block_type  TEXT    NOT NULL,
+		expire_time TIMESTAMP NULL,
+		description TEXT    NOT NULL DEFAULT '',
+		created_by  INTEGER NOT NULL DEFAULT 0,
+		updated_by  INTEGER NOT NULL DEFAULT 0,
+		created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uk_ip_rules_entry ON ip_rules (entry_type, ip)`,
+	`CREATE INDEX IF NOT EXISTS idx_ip_rules_ip ON ip_rules (ip)`,
+
+	// WAF配置表，最新一条生效
+	`CREATE TABLE IF NOT EXISTS waf_configs (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		mode        TEXT    NOT NULL DEFAULT 'block',
+		description TEXT    NOT NULL DEFAULT '',
+		created_by  TEXT    NOT NULL DEFAULT '',
+		updated_by  TEXT    NOT NULL DEFAULT '',
+		created_at  INTEGER NOT NULL DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+		updated_at  INTEGER NOT NULL DEFAULT (CAST(strftime('%s', 'now') AS INTEGER))
+	)`,
+
+	// WAF模式变更日志表
+	`CREATE TABLE IF NOT EXISTS waf_mode_change_logs (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		old_mode    TEXT    NOT NULL,
+		new_mode    TEXT    NOT NULL,
+		operator    TEXT    NOT NULL,
+		reason      TEXT    NOT NULL,
+		description TEXT    NOT NULL DEFAULT '',
+		created_at  INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_waf_mode_change_logs_created_at ON waf_mode_change_logs (created_at)`,
+
+	// 规则集发布表
+	`CREATE TABLE IF NOT EXISTS ruleset_releases (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		action      TEXT    NOT NULL DEFAULT 'publish',
+		source_id   INTEGER NOT NULL DEFAULT 0,
+		hash        TEXT    NOT NULL,
+		rule_count  INTEGER NOT NULL DEFAULT 0,
+		description TEXT    NOT NULL DEFAULT '',
+		content     TEXT    NOT NULL,
+		created_by  INTEGER NOT NULL DEFAULT 0,
+		created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+
+	// 变更请求表
+	`CREATE TABLE IF NOT EXISTS change_requests (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		title        TEXT    NOT NULL,
+		description  TEXT    NOT NULL DEFAULT '',
+		status       TEXT    NOT NULL DEFAULT 'draft',
+		author       INTEGER NOT NULL,
+		approver     INTEGER NOT NULL DEFAULT 0,
+		release_id   INTEGER NOT NULL DEFAULT 0,
+		checks       TEXT    NOT NULL DEFAULT '',
+		submitted_at TIMESTAMP NULL,
+		approved_at  TIMESTAMP NULL,
+		published_at TIMESTAMP NULL,
+		created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_change_requests_status ON change_requests (status)`,
+
+	// 变更项表
+	`CREATE TABLE IF NOT EXISTS change_request_items (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		change_request_id INTEGER NOT NULL,
+		target            TEXT    NOT NULL,
+		operation         TEXT    NOT NULL,
+		target_id         INTEGER NOT NULL DEFAULT 0,
+		payload           TEXT,
+		test_cases        TEXT    NOT NULL DEFAULT '',
+		applied_at        TIMESTAMP NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_change_request_items_cr ON change_request_items (change_request_id)`,
+
+	// 变更请求历史表
+	`CREATE TABLE IF NOT EXISTS change_request_events (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		change_request_id INTEGER NOT NULL,
+		action            TEXT    NOT NULL,
+		from_status       TEXT    NOT NULL DEFAULT '',
+		to_status         TEXT    NOT NULL DEFAULT '',
+		operator          INTEGER NOT NULL,
+		comment           TEXT    NOT NULL DEFAULT '',
+		created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_change_request_events_cr ON change_request_events (change_request_id)`,
+
+	// 旁路配置表
+	`CREATE TABLE IF NOT EXISTS bypass_configs (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		mode       TEXT    NOT NULL,
+		ips        TEXT,
+		urls       TEXT,
+		headers    TEXT,
+		start_time INTEGER NOT NULL DEFAULT 0,
+		end_time   INTEGER NOT NULL DEFAULT 0,
+		reason     TEXT    NOT NULL DEFAULT '',
+		created_by INTEGER NOT NULL DEFAULT 0,
+		updated_by INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_bypass_configs_end_time ON bypass_configs (end_time)`,
+
+	// 旁路尝试记录表
+	`CREATE TABLE IF NOT EXISTS bypass_attempts (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		bypass_id  INTEGER NOT NULL,
+		token_id   TEXT    NOT NULL DEFAULT '',
+		request_id TEXT    NOT NULL DEFAULT '',
+		ip         TEXT    NOT NULL DEFAULT '',
+		url        TEXT    NOT NULL DEFAULT '',
+		headers    TEXT,
+		mode       TEXT    NOT NULL,
+		timestamp  INTEGER NOT NULL,
+		success    INTEGER NOT NULL DEFAULT 0,
+		reason     TEXT    NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_bypass_attempts_bypass_id ON bypass_attempts (bypass_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_bypass_attempts_timestamp ON bypass_attempts (timestamp)`,
+
+	// 旁路令牌签名密钥表，密钥标识唯一
+	`CREATE TABLE IF NOT EXISTS bypass_keys (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		key_id     TEXT    NOT NULL,
+		secret     BLOB    NOT NULL,
+		status     TEXT    NOT NULL,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		retired_at TIMESTAMP NULL,
+		revoked_at TIMESTAMP NULL
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uk_bypass_keys_key_id ON bypass_keys (key_id)`,
+
+	// WAF节点表，节点标识唯一
+	`CREATE TABLE IF NOT EXISTS waf_nodes (
+		id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+		node_id            TEXT    NOT NULL,
+		token_hash         TEXT    NOT NULL,
+		hostname           TEXT    NOT NULL DEFAULT '',
+		address            TEXT    NOT NULL DEFAULT '',
+		agent_version      TEXT    NOT NULL DEFAULT '',
+		status             TEXT    NOT NULL DEFAULT 'online',
+		applied_version    INTEGER NOT NULL DEFAULT 0,
+		mode               TEXT    NOT NULL DEFAULT '',
+		health             TEXT    NOT NULL DEFAULT '',
+		health_message     TEXT    NOT NULL DEFAULT '',
+		counters           TEXT,
+		sync_error         TEXT    NOT NULL DEFAULT '',
+		last_sync_error    TEXT    NOT NULL DEFAULT '',
+		last_sync_error_at TIMESTAMP NULL,
+		last_heartbeat_at  TIMESTAMP NULL,
+		registered_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at         TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uk_waf_nodes_node_id ON waf_nodes (node_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_waf_nodes_status_heartbeat ON waf_nodes (status, last_heartbeat_at)`,
+
+	// 上传违规事件表，违规类型以逗号分隔
+	`CREATE TABLE IF NOT EXISTS upload_events (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id    TEXT    NOT NULL DEFAULT '',
+		ip            TEXT    NOT NULL DEFAULT '',
+		url           TEXT    NOT NULL DEFAULT '',
+		field         TEXT    NOT NULL DEFAULT '',
+		filename      TEXT    NOT NULL DEFAULT '',
+		content_type  TEXT    NOT NULL DEFAULT '',
+		detected_type TEXT    NOT NULL DEFAULT '',
+		size          INTEGER NOT NULL DEFAULT 0,
+		violations    TEXT    NOT NULL DEFAULT '',
+		blocked       INTEGER NOT NULL DEFAULT 0,
+		timestamp     INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_upload_events_ip ON upload_events (ip)`,
+	`CREATE INDEX IF NOT EXISTS idx_upload_events_timestamp ON upload_events (timestamp)`,
+
+	// 缓存表，对应MySQL部署中的Redis缓存，expires_at为过期时间(Unix纳秒)，0表示不过期
+	`CREATE TABLE IF NOT EXISTS cache_entries (
+		key        TEXT    PRIMARY KEY,
+		value      BLOB    NOT NULL,
+		expires_at INTEGER NOT NULL DEFAULT 0
+	) WITHOUT ROWID`,
+	`CREATE INDEX IF NOT EXISTS idx_cache_entries_expires_at ON cache_entries (expires_at)`,
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Config SQLite配置
+type Config struct {
+	Path        string // 数据库文件路径
+	BusyTimeout int    // 等待其他连接释放写锁的时间(毫秒)
+}
+
+// Open 打开SQLite数据库，文件不存在时创建，并创建缺少的表
+// 使用WAL模式，读写互不阻塞；写事务以IMMEDIATE方式开启，在事务开始时获取写锁，
+// 避免事务先读后写时因其他连接已写入而失败
+func Open(config *Config) (*gorm.DB, error) {
+	if dir := filepath.Dir(config.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("创建数据库目录失败: %v", err))
+		}
+	}
+
+	busyTimeout := config.BusyTimeout
+	if busyTimeout <= 0 {
+		busyTimeout = 5000
+	}
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d&_journal_mode=WAL&_synchronous=NORMAL&_foreign_keys=1&_txlock=immediate",
+		config.Path, busyTimeout)
+
+	newLogger := logger.New(
+		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		logger.Config{
+			SlowThreshold:             time.Second,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		},
+	)
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: newLogger,
+		// 唯一键冲突转换为gorm.ErrDuplicatedKey
+		TranslateError: true,
+		// 禁用默认事务
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("打开数据库失败: %v", err))
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取数据库实例失败: %v", err))
+	}
+	// 连接不过期，避免WAL模式下频繁重建连接
+	sqlDB.SetMaxIdleConns(4)
+	sqlDB.SetConnMaxLifetime(0)
+
+	if err := createSchema(db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// createSchema 创建缺少的表和索引
+func createSchema(db *gorm.DB) error {
+	for _, stmt := range schema {
+		if err := db.Exec(stmt).Error; err != nil {
+			return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建数据库表失败: %v", err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ruleVersionRepository 规则版本SQLite仓储实现
+type ruleVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewRuleVersionRepository 创建规则版本仓储
+func NewRuleVersionRepository(db *gorm.DB) repository.RuleVersionRepository {
+	if db == nil {
+		panic("数据库连接不能为空")
+	}
+	return &ruleVersionRepository{db: db}
+}
+
+// ruleUpdateEvent 规则更新事件记录，变更列表保存为JSON
+type ruleUpdateEvent struct {
+	ID        int64
+	Version   int64
+	Action    model.RuleUpdateType
+	RuleDiffs string
+	CreatedAt time.Time
+}
+
+// TableName 规则更新事件表名
+func (ruleUpdateEvent) TableName() string {
+	return "rule_update_events"
+}
+
+// CreateVersion 创建规则版本
+func (r *ruleVersionRepository) CreateVersion(ctx context.Context, version *model.RuleVersion) error {
+	if version == nil {
+		return errors.NewError(errors.ErrValidation, "规则版本不能为空")
+	}
+	if err := r.db.WithContext(ctx).Create(version).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建规则版本失败: %v", err))
+	}
+	return nil
+}
+
+// GetVersion 获取规则版本
+func (r *ruleVersionRepository) GetVersion(ctx context.Context, ruleID, version int64) (*model.RuleVersion, error) {
+	var v model.RuleVersion
+	err := r.db.WithContext(ctx).Where("rule_id = ? AND version = ?", ruleID, version).Order("id DESC").First(&v).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则版本不存在: rule_id=%d, version=%d", ruleID, version))
+	}
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取规则版本失败: %v", err))
+	}
+	return &v, nil
+}
+
+// ListVersions 获取规则版本列表，按版本号倒序
+func (r *ruleVersionRepository) ListVersions(ctx context.Context, ruleID int64) ([]*model.RuleVersion, error) {
+	versions := make([]*model.RuleVersion, 0)
+	err := r.db.WithContext(ctx).Where("rule_id = ?", ruleID).Order("version DESC, id DESC").Find(&versions).Error
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询规则版本列表失败: %v", err))
+	}
+	return versions, nil
+}
+
+// GetRulesByVersion 获取在指定版本有版本记录的规则
+func (r *ruleVersionRepository) GetRulesByVersion(ctx context.Context, version int64) ([]*model.Rule, error) {
+	rules := make([]*model.Rule, 0)
+	err := r.db.WithContext(ctx).
+		Where("id IN (?)", r.db.Model(&model.RuleVersion{}).Select("rule_id").Where("version = ?", version)).
+		Order("id").Find(&rules).Error
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询规则列表失败: %v", err))
+	}
+	return rules, nil
+}
+
+// GetLatestVersion 获取最新版本号
+func (r *ruleVersionRepository) GetLatestVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := r.db.WithContext(ctx).Model(&model.RuleVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil {
+		return 0, errors.NewError(errors.ErrSystem, fmt.Sprintf("获取最新版本号失败: %v", err))
+	}
+	return version, nil
+}
+
+// RollbackRules 在同一事务中更新规则并记录更新事件，任一规则不存在时全部不更新
+func (r *ruleVersionRepository) RollbackRules(ctx context.Context, rules []*model.Rule, event *model.RuleUpdateEvent) error {
+	if event == nil {
+		return errors.NewError(errors.ErrValidation, "更新事件不能为空")
+	}
+	diffs, err := json.Marshal(event.RuleDiffs)
+	if err != nil {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("序列化规则变更失败: %v", err))
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range rules {
+			if err := updateRule(tx, rule); err != nil {
+				return err
+			}
+		}
+		record := &ruleUpdateEvent{
+			Version:   event.Version,
+			Action:    event.Action,
+			RuleDiffs: string(diffs),
+			CreatedAt: event.CreatedAt,
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("记录更新事件失败: %v", err)
+		}
+		event.ID = record.ID
+		event.CreatedAt = record.CreatedAt
+		return nil
+	})
+	return systemError("回滚规则失败", err)
+}
+
+// RefreshRules 刷新规则更新时间
+func (r *ruleVersionRepository) RefreshRules(ctx context.Context) error {
+	err := r.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).
+		Model(&model.Rule{}).UpdateColumn("updated_at", time.Now()).Error
+	if err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("刷新规则失败: %v", err))
+	}
+	return nil
+}
+
+// CreateSyncLog 创建同步日志
+func (r *ruleVersionRepository) CreateSyncLog(ctx context.Context, log *model.RuleSyncLog) error {
+	if log == nil {
+		return errors.NewError(errors.ErrValidation, "同步日志不能为空")
+	}
+	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("创建同步日志失败: %v", err))
+	}
+	return nil
+}
+
+// ListSyncLogs 获取同步日志列表，按时间倒序
+func (r *ruleVersionRepository) ListSyncLogs(ctx context.Context, ruleID int64) ([]*model.RuleSyncLog, error) {
+	logs := make([]*model.RuleSyncLog, 0)
+	err := r.db.WithContext(ctx).Where("rule_id = ?", ruleID).Order("created_at DESC, id DESC").Find(&logs).Error
+	if err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("查询同步日志列表失败: %v", err))
+	}
+	return logs, nil
+}
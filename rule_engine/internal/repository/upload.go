@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// UploadEventRepository 上传违规事件仓储接口
+type UploadEventRepository interface {
+	// CreateUploadEvents 批量记录上传违规事件
+	CreateUploadEvents(ctx context.Context, events []*model.UploadEvent) error
+
+	// ListUploadEvents 获取上传违规事件，按时间倒序
+	ListUploadEvents(ctx context.Context, query *model.UploadEventQuery, offset, limit int) ([]*model.UploadEvent, int64, error)
+}
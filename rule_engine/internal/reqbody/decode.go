@@ -0,0 +1,65 @@
+package reqbody
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decode 按Content-Encoding依次解压，多个编码按逆序解压
+// 解压后超过maxSize时只保留maxSize+1字节并返回truncated，由调用方记录超出限制
+func decode(body, contentEncoding string, maxSize int) (string, bool, error) {
+	if contentEncoding == "" {
+		return body, false, nil
+	}
+	truncated := false
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		var (
+			decoded string
+			err     error
+		)
+		switch encoding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			decoded, err = readLimited(func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }, body, maxSize)
+		case "deflate":
+			// 按规范为zlib格式，部分客户端发送不带zlib头的原始deflate数据
+			decoded, err = readLimited(func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }, body, maxSize)
+			if err != nil {
+				decoded, err = readLimited(func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }, body, maxSize)
+			}
+		case "br":
+			decoded, err = readLimited(func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }, body, maxSize)
+		default:
+			return body, false, fmt.Errorf("不支持的Content-Encoding: %s", encoding)
+		}
+		if err != nil {
+			return body, false, fmt.Errorf("请求体%s解压失败: %v", encoding, err)
+		}
+		body = decoded
+		truncated = truncated || len(body) > maxSize
+	}
+	return body, truncated, nil
+}
+
+// readLimited 解压请求体，最多读取maxSize+1字节，避免解压炸弹占用内存
+func readLimited(open func(io.Reader) (io.Reader, error), body string, maxSize int) (string, error) {
+	r, err := open(strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(r, int64(maxSize)+1)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,202 @@
+package reqbody
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// parseURLEncoded 解析表单，按出现顺序保留同名字段和URL解码前的原始值；编码错误的字段保留原始内容继续解析
+func parseURLEncoded(body string, f *fields) error {
+	var invalid error
+	for _, pair := range strings.Split(body, "&") {
+		if pair == "" {
+			continue
+		}
+		name, raw, _ := strings.Cut(pair, "=")
+		field := model.Field{Name: name, Value: raw}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			field.Name = unescaped
+		} else if invalid == nil {
+			invalid = fmt.Errorf("表单编码错误: %v", err)
+		}
+		if unescaped, err := url.QueryUnescape(raw); err == nil {
+			field.Value = unescaped
+		} else if invalid == nil {
+			invalid = fmt.Errorf("表单编码错误: %v", err)
+		}
+		if field.Value != raw {
+			field.Raw = raw
+		}
+		if err := f.addField(field); err != nil {
+			return err
+		}
+	}
+	return invalid
+}
+
+// parseMultipart 解析multipart表单，文件内容不作为字段，由upload检查后记录文件信息
+func parseMultipart(body, boundary string, f *fields, upload *uploadInspector) error {
+	if boundary == "" {
+		return fmt.Errorf("multipart请求缺少boundary")
+	}
+	reader := multipart.NewReader(strings.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("multipart格式错误: %v", err)
+		}
+		if filename := partFilename(part); filename != "" {
+			if len(upload.files) >= f.max {
+				return fmt.Errorf("上传文件数超过%d个，其余文件未检查", f.max)
+			}
+			if err := upload.inspect(part.FormName(), filename, part.Header.Get("Content-Type"), part); err != nil {
+				return fmt.Errorf("multipart格式错误: %v", err)
+			}
+			continue
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			return fmt.Errorf("multipart格式错误: %v", err)
+		}
+		if err := f.add(part.FormName(), string(value)); err != nil {
+			return err
+		}
+	}
+}
+
+// partFilename 上传文件的原始文件名，part.FileName()会去掉路径，无法检查 ../ 等路径穿越
+func partFilename(part *multipart.Part) string {
+	if _, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	return part.FileName()
+}
+
+// parseJSON 解析JSON，叶子节点名称为路径，如 user.tags[0]，顶层为标量时名称为空
+func parseJSON(body string, maxDepth int, f *fields) error {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	p := &jsonParser{dec: dec, maxDepth: maxDepth, fields: f}
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("JSON格式错误: %v", err)
+	}
+	if err := p.value(tok, "", 0); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("JSON格式错误: 存在多余内容")
+	}
+	return nil
+}
+
+// jsonParser 逐个读取JSON Token，嵌套超过限制时停止
+type jsonParser struct {
+	dec      *json.Decoder
+	maxDepth int
+	fields   *fields
+}
+
+// value 解析一个JSON值
+func (p *jsonParser) value(tok json.Token, path string, depth int) error {
+	switch v := tok.(type) {
+	case json.Delim:
+		if depth >= p.maxDepth {
+			return fmt.Errorf("JSON嵌套超过%d层", p.maxDepth)
+		}
+		for i := 0; p.dec.More(); i++ {
+			name := path + "[" + strconv.Itoa(i) + "]"
+			if v == '{' {
+				key, err := p.dec.Token()
+				if err != nil {
+					return fmt.Errorf("JSON格式错误: %v", err)
+				}
+				name = key.(string)
+				if path != "" {
+					name = path + "." + name
+				}
+			}
+			next, err := p.dec.Token()
+			if err != nil {
+				return fmt.Errorf("JSON格式错误: %v", err)
+			}
+			if err := p.value(next, name, depth+1); err != nil {
+				return err
+			}
+		}
+		// 读取结束符
+		if _, err := p.dec.Token(); err != nil {
+			return fmt.Errorf("JSON格式错误: %v", err)
+		}
+		return nil
+	case string:
+		return p.fields.add(path, v)
+	case json.Number:
+		return p.fields.add(path, v.String())
+	case bool:
+		return p.fields.add(path, strconv.FormatBool(v))
+	}
+	// null不作为字段
+	return nil
+}
+
+// parseXML 解析XML，元素文本名称为元素路径，如 /order/item，属性名称如 /order/item/@id
+// 不解析DTD和外部实体
+func parseXML(body string, maxDepth int, f *fields) error {
+	dec := xml.NewDecoder(strings.NewReader(body))
+	var (
+		path []string
+		text []*strings.Builder
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if len(path) > 0 {
+				return fmt.Errorf("XML格式错误: 元素%s未结束", path[len(path)-1])
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("XML格式错误: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(path) >= maxDepth {
+				return fmt.Errorf("XML嵌套超过%d层", maxDepth)
+			}
+			path = append(path, t.Name.Local)
+			text = append(text, &strings.Builder{})
+			prefix := "/" + strings.Join(path, "/")
+			for _, attr := range t.Attr {
+				if err := f.add(prefix+"/@"+attr.Name.Local, attr.Value); err != nil {
+					return err
+				}
+			}
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1].Write(t)
+			}
+		case xml.EndElement:
+			value := strings.TrimSpace(text[len(text)-1].String())
+			if value != "" {
+				if err := f.add("/"+strings.Join(path, "/"), value); err != nil {
+					return err
+				}
+			}
+			path = path[:len(path)-1]
+			text = text[:len(text)-1]
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package reqbody 请求体处理，按Content-Encoding解压、按Content-Type解析表单、multipart、JSON和XML，
+// 解析出的字段作为 request_args_post、request_body_json、request_body_xml 规则变量，
+// multipart上传的文件检查扩展名、文件头和大小后作为 FILES 等规则变量
+package reqbody
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+)
+
+// 默认限制
+const (
+	defaultMaxBodySize = 1 << 20 // 解压后请求体最大长度
+	defaultMaxDepth    = 32      // JSON和XML最大嵌套层数
+	defaultMaxFields   = 1000    // 最多解析的字段数
+
+	defaultMaxFileSize   = 10 << 20 // 单个上传文件最大长度
+	defaultMaxUploadSize = 50 << 20 // 上传文件总长度
+)
+
+// Options 请求体处理配置，配置项为0时使用默认值
+type Options struct {
+	MaxBodySize int // 解压后请求体最大长度(字节)，超出部分不解析
+	MaxDepth    int // JSON和XML最大嵌套层数
+	MaxFields   int // 最多解析的字段数(表单字段、multipart部分、JSON叶子节点、XML元素和属性)
+
+	MaxFileSize       int      // 单个上传文件最大长度(字节)，超出时记录file_too_large
+	MaxUploadSize     int      // 上传文件总长度(字节)，超出时记录total_too_large
+	BlockedExtensions []string // 禁止上传的扩展名，为空时使用默认列表
+
+	// UploadAction 上传文件有违规时的处理，为空或block时拦截，log时只记录违规，由规则决定是否拦截
+	UploadAction model.ActionType
+
+	// UndecodableAction 请求体无法完整解码(base64或解压失败、不支持的Content-Encoding、解压后超过长度限制)时的处理，
+	// 为空或block时拦截，log时只记录原因继续检查
+	UndecodableAction model.ActionType
+}
+
+// Processor 请求体处理器，作为请求信息补充在规则匹配前执行
+type Processor struct {
+	opts    Options
+	blocked map[string]bool
+}
+
+// NewProcessor 创建请求体处理器
+func NewProcessor(opts Options) *Processor {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultMaxDepth
+	}
+	if opts.MaxFields <= 0 {
+		opts.MaxFields = defaultMaxFields
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = defaultMaxFileSize
+	}
+	if opts.MaxUploadSize <= 0 {
+		opts.MaxUploadSize = defaultMaxUploadSize
+	}
+	if len(opts.BlockedExtensions) == 0 {
+		opts.BlockedExtensions = defaultBlockedExtensions
+	}
+	if opts.UploadAction == "" {
+		opts.UploadAction = model.ActionBlock
+	}
+	if opts.UndecodableAction == "" {
+		opts.UndecodableAction = model.ActionBlock
+	}
+	return &Processor{opts: opts, blocked: blockedExtensions(opts.BlockedExtensions)}
+}
+
+// Enrich 解码并解析请求体，解压后的内容替换原请求体，已解析过的请求不重复处理
+// 未压缩的请求体不截断，超过长度限制时只限制解析，request_body 规则仍检查完整内容
+func (p *Processor) Enrich(ctx context.Context, req *model.CheckRequest) error {
+	if req == nil || req.RequestBody != nil {
+		return nil
+	}
+	body, parsed := p.Process(req.Headers, req.Body, req.BodyEncoding)
+	req.Body = body
+	req.BodyEncoding = ""
+	req.RequestBody = parsed
+	if parsed.Error != "" {
+		metrics.RecordRequestBodyError(parsed.Processor)
+	}
+	for _, violation := range parsed.UploadViolations() {
+		metrics.RecordUploadViolation(violation)
+	}
+	return nil
+}
+
+// Process 处理请求体，返回规则检查的请求体和解析结果
+// 解压或解析失败、超出限制时在结果中记录原因，已解析的字段仍然返回
+// 无法完整解码时返回原请求体(解压后超过长度限制时为解压出的前max_body_size字节)，并按UndecodableAction决定是否拦截
+// multipart请求体超过长度限制时仍完整读取，以统计上传文件的实际大小
+func (p *Processor) Process(headers model.Fields, body, encoding string) (string, *model.RequestBody) {
+	parsed := &model.RequestBody{}
+	if body == "" {
+		return body, parsed
+	}
+
+	if encoding == model.BodyEncodingBase64 {
+		raw, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			p.undecodable(parsed, fmt.Sprintf("请求体base64解码失败: %v", err))
+			return body, parsed
+		}
+		body = string(raw)
+	}
+
+	decoded, truncated, err := decode(body, headers.GetFold("Content-Encoding"), p.opts.MaxBodySize)
+	if err != nil {
+		p.undecodable(parsed, err.Error())
+		return body, parsed
+	}
+	body = decoded
+	inspect := body
+	if len(body) > p.opts.MaxBodySize {
+		inspect = body[:p.opts.MaxBodySize]
+		if truncated {
+			// 解压时最多读取max_body_size+1字节，其余内容未解压，规则无法检查
+			body = inspect
+			p.undecodable(parsed, fmt.Sprintf("请求体解压后超过%d字节，超出部分未解压", p.opts.MaxBodySize))
+		} else {
+			parsed.Error = fmt.Sprintf("请求体超过%d字节，超出部分未解析", p.opts.MaxBodySize)
+		}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(headers.GetFold("Content-Type"))
+	if err != nil {
+		return body, parsed
+	}
+	parsed.Processor = processorFor(mediaType)
+	if parsed.Processor == "" {
+		return body, parsed
+	}
+
+	f := &fields{max: p.opts.MaxFields}
+	switch parsed.Processor {
+	case model.BodyProcessorURLEncoded:
+		err = parseURLEncoded(inspect, f)
+		parsed.Form = f.items
+	case model.BodyProcessorMultipart:
+		upload := &uploadInspector{
+			maxFileSize:   int64(p.opts.MaxFileSize),
+			maxUploadSize: int64(p.opts.MaxUploadSize),
+			blocked:       p.blocked,
+		}
+		err = parseMultipart(body, params["boundary"], f, upload)
+		parsed.Form = f.items
+		parsed.Files = upload.files
+		if violations := parsed.UploadViolations(); len(violations) > 0 && p.opts.UploadAction == model.ActionBlock && parsed.BlockReason == "" {
+			parsed.BlockReason = fmt.Sprintf("上传文件违规: %s", strings.Join(violations, ","))
+		}
+	case model.BodyProcessorJSON:
+		err = parseJSON(inspect, p.opts.MaxDepth, f)
+		parsed.JSON = f.items
+	case model.BodyProcessorXML:
+		err = parseXML(inspect, p.opts.MaxDepth, f)
+		parsed.XML = f.items
+	}
+	if err != nil && parsed.Error == "" {
+		parsed.Error = err.Error()
+	}
+	return body, parsed
+}
+
+// undecodable 记录无法完整解码的原因，配置为拦截时设置拦截原因
+func (p *Processor) undecodable(parsed *model.RequestBody, reason string) {
+	parsed.Error = reason
+	if p.opts.UndecodableAction == model.ActionBlock {
+		parsed.BlockReason = reason
+	}
+}
+
+// processorFor Content-Type对应的处理器，不解析的类型返回空
+func processorFor(mediaType string) string {
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return model.BodyProcessorURLEncoded
+	case mediaType == "multipart/form-data":
+		return model.BodyProcessorMultipart
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return model.BodyProcessorJSON
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return model.BodyProcessorXML
+	}
+	return ""
+}
+
+// fields 已解析的字段，超过数量限制时返回错误
+type fields struct {
+	items model.Fields
+	max   int
+}
+
+// add 添加字段
+func (f *fields) add(name, value string) error {
+	return f.addField(model.Field{Name: name, Value: value})
+}
+
+// addField 添加字段，保留原始值
+func (f *fields) addField(field model.Field) error {
+	if len(f.items) >= f.max {
+		return fmt.Errorf("请求体字段数超过%d个，其余字段未解析", f.max)
+	}
+	f.items = append(f.items, field)
+	return nil
+}
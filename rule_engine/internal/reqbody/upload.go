@@ -0,0 +1,192 @@
+package reqbody
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// defaultBlockedExtensions 默认禁止上传的扩展名，服务端可执行的脚本和程序
+var defaultBlockedExtensions = []string{
+	"php", "php3", "php4", "php5", "php7", "phtml", "pht", "phar",
+	"jsp", "jspx", "jspf", "asp", "aspx", "ascx", "ashx", "asmx", "asa", "cer",
+	"cgi", "pl", "py", "rb", "sh", "bash", "ps1", "bat", "cmd", "com", "exe", "dll", "vbs",
+	"jar", "war", "shtml", "htaccess",
+}
+
+// sniffLen 识别文件类型读取的文件头长度
+const sniffLen = 512
+
+// 按文件头识别的脚本和可执行文件类型，声明为其他类型时视为不符
+const (
+	typePHP        = "application/x-httpd-php"
+	typeExecutable = "application/x-msdownload"
+	typeELF        = "application/x-elf"
+	typeScript     = "text/x-shellscript"
+)
+
+// typeAliases 同一类型的不同写法
+var typeAliases = map[string]string{
+	"image/jpg":                    "image/jpeg",
+	"image/pjpeg":                  "image/jpeg",
+	"image/x-png":                  "image/png",
+	"application/x-gzip":           "application/gzip",
+	"application/x-zip-compressed": "application/zip",
+	"application/x-pdf":            "application/pdf",
+}
+
+// uploadInspector 检查multipart请求中的上传文件
+type uploadInspector struct {
+	maxFileSize   int64
+	maxUploadSize int64
+	blocked       map[string]bool
+	total         int64
+	files         []*model.UploadFile
+}
+
+// inspect 读取文件内容，记录文件信息和违规类型，文件内容不保留
+func (u *uploadInspector) inspect(field, filename, contentType string, r io.Reader) error {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	head = head[:n]
+	rest, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return err
+	}
+
+	file := &model.UploadFile{
+		Field:        field,
+		Filename:     filename,
+		ContentType:  contentType,
+		DetectedType: detectType(head),
+		Size:         int64(n) + rest,
+	}
+	u.total += file.Size
+	if ext, double := u.blockedExtension(filename); ext != "" {
+		if double {
+			file.Violations = append(file.Violations, model.UploadViolationDoubleExtension)
+		} else {
+			file.Violations = append(file.Violations, model.UploadViolationDangerousExtension)
+		}
+	}
+	if typeMismatch(contentType, file.DetectedType) {
+		file.Violations = append(file.Violations, model.UploadViolationTypeMismatch)
+	}
+	if file.Size > u.maxFileSize {
+		file.Violations = append(file.Violations, model.UploadViolationFileTooLarge)
+	}
+	if u.total > u.maxUploadSize {
+		file.Violations = append(file.Violations, model.UploadViolationTotalTooLarge)
+	}
+	u.files = append(u.files, file)
+	return nil
+}
+
+// blockedExtension 查找文件名中禁止上传的扩展名，double表示不是最后一个扩展名
+// 文件名去掉路径，在空字符处截断，并忽略末尾的点和空格(Windows保存时会去掉)
+func (u *uploadInspector) blockedExtension(filename string) (string, bool) {
+	if i := strings.LastIndexAny(filename, `/\`); i >= 0 {
+		filename = filename[i+1:]
+	}
+	if i := strings.IndexByte(filename, 0); i >= 0 {
+		filename = filename[:i]
+	}
+	filename = strings.ToLower(strings.TrimRight(filename, ". "))
+
+	parts := strings.Split(filename, ".")
+	// 第一段为文件名主体，.htaccess 这类以点开头的文件名主体为空
+	for i := len(parts) - 1; i >= 1; i-- {
+		ext := strings.TrimSpace(parts[i])
+		if u.blocked[ext] {
+			return ext, i != len(parts)-1
+		}
+	}
+	return "", false
+}
+
+// detectType 按文件头识别文件类型，PHP标签优先于图片等类型，用于识别图片木马
+func detectType(head []byte) string {
+	if len(head) == 0 {
+		return ""
+	}
+	lower := bytes.ToLower(head)
+	switch {
+	case bytes.Contains(lower, []byte("<?php")) || bytes.Contains(head, []byte("<?=")):
+		return typePHP
+	case bytes.HasPrefix(head, []byte("MZ")):
+		return typeExecutable
+	case bytes.HasPrefix(head, []byte("\x7fELF")):
+		return typeELF
+	case bytes.HasPrefix(head, []byte("#!")):
+		return typeScript
+	}
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return normalizeType(detected)
+}
+
+// typeMismatch 判断声明的类型与识别的类型是否不符
+// 识别为文本或未知二进制时无法判断，不视为不符；声明为通用二进制类型时只有脚本和可执行文件视为不符
+func typeMismatch(declared, detected string) bool {
+	if detected == "" || detected == "text/plain" || detected == "application/octet-stream" {
+		return false
+	}
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil {
+		declared = normalizeType(mediaType)
+	} else {
+		declared = ""
+	}
+	if declared == detected {
+		return false
+	}
+	if isExecutableType(detected) {
+		return true
+	}
+	switch {
+	case declared == "" || declared == "application/octet-stream":
+		return false
+	case detected == "application/zip":
+		// docx、xlsx、jar、epub等为zip格式
+		return !strings.Contains(declared, "zip") && !strings.HasPrefix(declared, "application/vnd.") &&
+			declared != "application/java-archive" && declared != "application/epub+zip"
+	case detected == "text/xml":
+		return declared != "application/xml" && !strings.HasSuffix(declared, "+xml")
+	}
+	return true
+}
+
+// isExecutableType 是否为脚本或可执行文件类型
+func isExecutableType(t string) bool {
+	switch t {
+	case typePHP, typeExecutable, typeELF, typeScript:
+		return true
+	}
+	return false
+}
+
+// normalizeType 统一类型的写法
+func normalizeType(t string) string {
+	t = strings.ToLower(t)
+	if alias, ok := typeAliases[t]; ok {
+		return alias
+	}
+	return t
+}
+
+// blockedExtensions 扩展名列表转换为集合，忽略开头的点和大小写
+func blockedExtensions(exts []string) map[string]bool {
+	blocked := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext != "" {
+			blocked[ext] = true
+		}
+	}
+	return blocked
+}
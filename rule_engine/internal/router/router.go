@@ -2,21 +2,44 @@ package router
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xwaf/rule_engine/internal/errors"
 	"github.com/xwaf/rule_engine/internal/handler"
 	"github.com/xwaf/rule_engine/internal/middleware"
 	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
 )
 
 // RouterConfig 路由配置
 type RouterConfig struct {
-	RuleHandler    *handler.RuleHandler
-	IPHandler      *handler.IPRuleHandler
-	CCHandler      *handler.CCRuleHandler
-	VersionHandler *handler.RuleVersionHandler
-	ConfigHandler  *handler.ConfigHandler
+	RuleHandler     *handler.RuleHandler
+	IPHandler       *handler.IPRuleHandler
+	CCHandler       *handler.CCRuleHandler
+	VersionHandler  *handler.RuleVersionHandler
+	ConfigHandler   *handler.ConfigHandler
+	ResponseHandler *handler.ResponseCheckHandler
+	BatchHandler    *handler.BatchCheckHandler
+	TemplateHandler *handler.TemplateHandler
+	ReleaseHandler  *handler.ReleaseHandler
+	ChangeHandler   *handler.ChangeRequestHandler
+	BypassHandler   *handler.BypassHandler
+	NodeHandler     *handler.NodeHandler
+	UploadHandler   *handler.UploadHandler
+	HealthHandler   *handler.HealthHandler
+	ReloadHandler   *handler.ConfigReloadHandler
+
+	// EnforceReview 为true时规则、名单、CC规则只能通过变更请求修改
+	EnforceReview bool
+
+	// TrustedProxies 可传入身份请求头的认证网关地址
+	TrustedProxies []*net.IPNet
+
+	// MetricsPath 非空时在该路径提供Prometheus指标接口
+	MetricsPath string
 }
 
 // Validate 验证路由配置
@@ -36,6 +59,36 @@ func (c *RouterConfig) Validate() error {
 	if c.ConfigHandler == nil {
 		return errors.NewError(errors.ErrConfig, "配置处理器不能为空")
 	}
+	if c.ResponseHandler == nil {
+		return errors.NewError(errors.ErrConfig, "响应检查处理器不能为空")
+	}
+	if c.BatchHandler == nil {
+		return errors.NewError(errors.ErrConfig, "批量检查处理器不能为空")
+	}
+	if c.TemplateHandler == nil {
+		return errors.NewError(errors.ErrConfig, "规则模板处理器不能为空")
+	}
+	if c.ReleaseHandler == nil {
+		return errors.NewError(errors.ErrConfig, "发布处理器不能为空")
+	}
+	if c.ChangeHandler == nil {
+		return errors.NewError(errors.ErrConfig, "变更请求处理器不能为空")
+	}
+	if c.BypassHandler == nil {
+		return errors.NewError(errors.ErrConfig, "旁路处理器不能为空")
+	}
+	if c.NodeHandler == nil {
+		return errors.NewError(errors.ErrConfig, "节点处理器不能为空")
+	}
+	if c.UploadHandler == nil {
+		return errors.NewError(errors.ErrConfig, "上传违规事件处理器不能为空")
+	}
+	if c.HealthHandler == nil {
+		return errors.NewError(errors.ErrConfig, "健康检查处理器不能为空")
+	}
+	if c.ReloadHandler == nil {
+		return errors.NewError(errors.ErrConfig, "配置重新加载处理器不能为空")
+	}
 	return nil
 }
 
@@ -53,9 +106,28 @@ func SetupRouter(cfg *RouterConfig) (*gin.Engine, error) {
 	// 基础中间件
 	r.Use(middleware.Cors())
 	r.Use(middleware.RequestID())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.Identity(cfg.TrustedProxies))
 	r.Use(middleware.Logger())
 	r.Use(middleware.Recovery())
 	r.Use(middleware.ErrorHandler())
+	r.Use(metrics.MetricsMiddleware())
+
+	if cfg.MetricsPath != "" {
+		r.GET(cfg.MetricsPath, gin.WrapH(metrics.MetricsHandler()))
+	}
+
+	// 存活和就绪检查
+	r.GET("/healthz", cfg.HealthHandler.Liveness)
+	r.GET("/readyz", cfg.HealthHandler.Readiness)
+
+	// 开启强制审批后，直接修改规则、名单、CC规则以及直接发布、回滚规则集的接口被拒绝
+	reviewed := func(h gin.HandlerFunc) gin.HandlerFunc {
+		if cfg.EnforceReview {
+			return middleware.ReviewRequired()
+		}
+		return h
+	}
 
 	// API路由组
 	api := r.Group("/api/v1")
@@ -63,14 +135,22 @@ func SetupRouter(cfg *RouterConfig) (*gin.Engine, error) {
 		// 规则相关路由
 		rules := api.Group("/rules")
 		{
-			rules.POST("", cfg.RuleHandler.CreateRule)
-			rules.PUT("/:id", validateIDParam(), cfg.RuleHandler.UpdateRule)
-			rules.DELETE("/:id", validateIDParam(), cfg.RuleHandler.DeleteRule)
+			rules.POST("", reviewed(cfg.RuleHandler.CreateRule))
+			rules.PUT("/:id", validateIDParam(), reviewed(cfg.RuleHandler.UpdateRule))
+			rules.DELETE("/:id", validateIDParam(), reviewed(cfg.RuleHandler.DeleteRule))
 			rules.GET("/:id", validateIDParam(), cfg.RuleHandler.GetRule)
 			rules.GET("", cfg.RuleHandler.ListRules)
 			rules.POST("/reload", cfg.RuleHandler.ReloadRules)
 			rules.GET("/version", cfg.RuleHandler.GetRuleVersion)
 			rules.GET("/events", cfg.RuleHandler.GetRuleUpdateEvent)
+			rules.POST("/check", cfg.RuleHandler.CheckRule)
+			rules.POST("/check-response", cfg.ResponseHandler.CheckResponse)
+			rules.POST("/check:method", customMethod("method", map[string]gin.HandlerFunc{
+				"batch": cfg.BatchHandler.CheckBatch,
+			}))
+			rules.GET("/lint", cfg.RuleHandler.LintRules)
+			rules.POST("/lint", cfg.RuleHandler.LintRule)
+			rules.GET("/expiring", cfg.RuleHandler.ListExpiringRules)
 
 			// 规则版本相关路由
 			versions := rules.Group("/:rule_id/versions")
@@ -89,12 +169,77 @@ func SetupRouter(cfg *RouterConfig) (*gin.Engine, error) {
 			}
 		}
 
+		// 规则模板相关路由
+		templates := api.Group("/templates")
+		{
+			templates.GET("", cfg.TemplateHandler.ListTemplates)
+			templates.POST("/reload", cfg.TemplateHandler.ReloadTemplates)
+			templates.GET("/upgrades", cfg.TemplateHandler.ListUpgrades)
+			templates.GET("/:id", validateIDParam(), cfg.TemplateHandler.GetTemplate)
+			templates.GET("/:id/upgrades", validateIDParam(), cfg.TemplateHandler.ListUpgrades)
+			templates.POST("/:id/instantiate", validateIDParam(), reviewed(cfg.TemplateHandler.Instantiate))
+		}
+
+		// 规则集发布相关路由
+		releases := api.Group("/releases")
+		{
+			releases.POST("", reviewed(cfg.ReleaseHandler.Publish))
+			releases.GET("", cfg.ReleaseHandler.ListReleases)
+			releases.GET("/diff", cfg.ReleaseHandler.DiffReleases)
+			releases.GET("/:id", validateIDParam(), cfg.ReleaseHandler.GetRelease)
+			releases.POST("/:id/rollback", validateIDParam(), reviewed(cfg.ReleaseHandler.Rollback))
+		}
+
+		// 规则变更请求相关路由
+		changes := api.Group("/change-requests")
+		{
+			changes.POST("", cfg.ChangeHandler.CreateChangeRequest)
+			changes.GET("", cfg.ChangeHandler.ListChangeRequests)
+			changes.GET("/:id", validateIDParam(), cfg.ChangeHandler.GetChangeRequest)
+			changes.PUT("/:id", validateIDParam(), cfg.ChangeHandler.UpdateChangeRequest)
+			changes.POST("/:id/submit", validateIDParam(), cfg.ChangeHandler.SubmitChangeRequest)
+			changes.POST("/:id/comments", validateIDParam(), cfg.ChangeHandler.CommentChangeRequest)
+			changes.POST("/:id/approve", validateIDParam(), cfg.ChangeHandler.ApproveChangeRequest)
+			changes.POST("/:id/reject", validateIDParam(), cfg.ChangeHandler.RejectChangeRequest)
+			changes.POST("/:id/publish", validateIDParam(), cfg.ChangeHandler.PublishChangeRequest)
+			changes.POST("/:id/cancel", validateIDParam(), cfg.ChangeHandler.CancelChangeRequest)
+		}
+
+		// 旁路配置相关路由
+		bypasses := api.Group("/bypasses")
+		{
+			bypasses.POST("", cfg.BypassHandler.CreateBypass)
+			bypasses.GET("", cfg.BypassHandler.ListBypasses)
+			bypasses.GET("/attempts", cfg.BypassHandler.ListAttempts)
+			bypasses.POST("/tokens", cfg.BypassHandler.IssueToken)
+			bypasses.GET("/keys", cfg.BypassHandler.ListKeys)
+			bypasses.POST("/keys/rotate", cfg.BypassHandler.RotateKey)
+			bypasses.POST("/keys/:key_id/revoke", cfg.BypassHandler.RevokeKey)
+			bypasses.GET("/:id", validateIDParam(), cfg.BypassHandler.GetBypass)
+			bypasses.PUT("/:id", validateIDParam(), cfg.BypassHandler.UpdateBypass)
+			bypasses.DELETE("/:id", validateIDParam(), cfg.BypassHandler.DeleteBypass)
+		}
+
+		// 上传违规事件
+		api.GET("/uploads/events", cfg.UploadHandler.ListEvents)
+
+		// WAF节点注册、心跳和状态
+		nodes := api.Group("/nodes")
+		{
+			nodes.POST("/register", cfg.NodeHandler.Register)
+			nodes.GET("", cfg.NodeHandler.ListNodes)
+			nodes.GET("/fleet", cfg.NodeHandler.FleetStatus)
+			nodes.POST("/:node_id/heartbeat", cfg.NodeHandler.Heartbeat)
+			nodes.GET("/:node_id", cfg.NodeHandler.GetNode)
+			nodes.DELETE("/:node_id", cfg.NodeHandler.DeleteNode)
+		}
+
 		// IP规则相关路由
 		ips := api.Group("/ips")
 		{
-			ips.POST("", cfg.IPHandler.CreateIPRule)
-			ips.PUT("/:id", validateIDParam(), cfg.IPHandler.UpdateIPRule)
-			ips.DELETE("/:id", validateIDParam(), cfg.IPHandler.DeleteIPRule)
+			ips.POST("", reviewed(cfg.IPHandler.CreateIPRule))
+			ips.PUT("/:id", validateIDParam(), reviewed(cfg.IPHandler.UpdateIPRule))
+			ips.DELETE("/:id", validateIDParam(), reviewed(cfg.IPHandler.DeleteIPRule))
 			ips.GET("/:id", validateIDParam(), cfg.IPHandler.GetIPRule)
 			ips.GET("", cfg.IPHandler.ListIPRules)
 		}
@@ -102,9 +247,9 @@ func SetupRouter(cfg *RouterConfig) (*gin.Engine, error) {
 		// CC防护规则相关路由
 		cc := api.Group("/cc-rules")
 		{
-			cc.POST("", cfg.CCHandler.CreateCCRule)
-			cc.PUT("/:id", validateIDParam(), cfg.CCHandler.UpdateCCRule)
-			cc.DELETE("/:id", validateIDParam(), cfg.CCHandler.DeleteCCRule)
+			cc.POST("", reviewed(cfg.CCHandler.CreateCCRule))
+			cc.PUT("/:id", validateIDParam(), reviewed(cfg.CCHandler.UpdateCCRule))
+			cc.DELETE("/:id", validateIDParam(), reviewed(cfg.CCHandler.DeleteCCRule))
 			cc.GET("/:id", validateIDParam(), cfg.CCHandler.GetCCRule)
 			cc.GET("", cfg.CCHandler.ListCCRules)
 			cc.GET("/check/:uri", validateURIParam(), cfg.CCHandler.CheckCCLimit)
@@ -116,6 +261,8 @@ func SetupRouter(cfg *RouterConfig) (*gin.Engine, error) {
 			configGroup.GET("/mode", cfg.ConfigHandler.GetMode)
 			configGroup.PUT("/mode", cfg.ConfigHandler.UpdateMode)
 			configGroup.GET("/mode/logs", cfg.ConfigHandler.GetModeChangeLogs)
+			configGroup.GET("/reload", cfg.ReloadHandler.GetReloadStatus)
+			configGroup.POST("/reload", cfg.ReloadHandler.Reload)
 		}
 	}
 
@@ -123,6 +270,21 @@ func SetupRouter(cfg *RouterConfig) (*gin.Engine, error) {
 	return r, nil
 }
 
+// customMethod 分发 /资源:方法 形式的路由，如 /rules/check:batch
+// gin将冒号后的部分作为路径参数，参数值包含冒号，不是已注册的方法时返回404
+func customMethod(param string, handlers map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.Param(param)
+		if strings.HasPrefix(value, ":") {
+			if h, ok := handlers[value[1:]]; ok {
+				h(c)
+				return
+			}
+		}
+		c.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
 // validateIDParam 验证ID参数中间件
 func validateIDParam(paramName ...string) gin.HandlerFunc {
 	name := "id"
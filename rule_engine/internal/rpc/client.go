@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/xwaf/rule_engine/api/proto/checkpb"
+	"github.com/xwaf/rule_engine/internal/model"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+)
+
+// Client 规则检查gRPC客户端，供Go编写的前端和工具使用
+type Client struct {
+	client checkpb.CheckServiceClient
+}
+
+// NewClient 创建客户端，conn由调用方创建和关闭
+// 每个请求的traceparent字段取自调用的ctx；创建conn时添加 grpc.WithStatsHandler(otelgrpc.NewClientHandler()) 可同时记录客户端Span
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{client: checkpb.NewCheckServiceClient(conn)}
+}
+
+// Check 检查单个请求
+func (c *Client) Check(ctx context.Context, req *model.CheckRequest, opts ...grpc.CallOption) (*model.CheckResult, error) {
+	resp, err := c.client.Check(ctx, fromModelRequest(0, req, traceparentFromContext(ctx)), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return toCheckResponse(resp).Result, nil
+}
+
+// CheckStream 打开检查流，ctx取消时流关闭
+func (c *Client) CheckStream(ctx context.Context, opts ...grpc.CallOption) (*Stream, error) {
+	stream, err := c.client.CheckStream(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{stream: stream}, nil
+}
+
+// Stream 检查流，Send和Recv可以在不同的goroutine中调用，但各自不能并发调用
+type Stream struct {
+	stream checkpb.CheckService_CheckStreamClient
+}
+
+// Send 发送请求，id原样返回在对应的结果中；ctx中的链路随请求传到服务端，只用于关联链路，不控制流的生命周期
+func (s *Stream) Send(ctx context.Context, id uint64, req *model.CheckRequest) error {
+	return s.stream.Send(fromModelRequest(id, req, traceparentFromContext(ctx)))
+}
+
+// Recv 接收结果，结果顺序与请求顺序相同
+func (s *Stream) Recv() (*CheckResponse, error) {
+	resp, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return toCheckResponse(resp), nil
+}
+
+// CloseSend 不再发送请求，服务端返回剩余结果后结束流
+func (s *Stream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// traceparentFromContext 当前链路的W3C traceparent，没有有效链路时为空
+func traceparentFromContext(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceparentKey)
+}
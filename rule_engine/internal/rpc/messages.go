@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"github.com/xwaf/rule_engine/api/proto/checkpb"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// CheckResponse 流式检查的单个结果
+type CheckResponse struct {
+	ID     uint64             // 对应请求的序号
+	Result *model.CheckResult // 检查结果，Error不为空时为nil
+	Error  *Error             // 流式检查中单个请求失败的原因
+}
+
+// Error 单个请求的错误，错误码与REST接口相同
+type Error struct {
+	Code    int32
+	Message string
+}
+
+// toModelRequest 转换为引擎的检查请求，traceparent由调用方单独处理
+func toModelRequest(m *checkpb.CheckRequest) *model.CheckRequest {
+	r := &model.CheckRequest{
+		ClientIP:     m.GetClientIp(),
+		URI:          m.GetUri(),
+		Method:       m.GetMethod(),
+		Headers:      toModelFields(m.GetHeaders()),
+		Args:         toModelFields(m.GetArgs()),
+		Body:         string(m.GetBody()),
+		BodyEncoding: m.GetBodyEncoding(),
+		RequestID:    m.GetRequestId(),
+	}
+	for _, t := range m.GetRuleTypes() {
+		r.RuleTypes = append(r.RuleTypes, model.RuleType(t))
+	}
+	if g := m.GetGeo(); g != nil {
+		r.Geo = &model.GeoInfo{
+			Country: g.GetCountry(),
+			Region:  g.GetRegion(),
+			City:    g.GetCity(),
+			ASN:     g.GetAsn(),
+			ASOrg:   g.GetAsOrg(),
+		}
+	}
+	if bot := m.GetBot(); bot != nil {
+		r.Bot = &model.BotInfo{
+			Category: model.BotCategory(bot.GetCategory()),
+			Name:     bot.GetName(),
+			Score:    int(bot.GetScore()),
+			Verified: bot.GetVerified(),
+			Reasons:  bot.GetReasons(),
+		}
+	}
+	if f := m.GetFingerprint(); f != nil {
+		r.Fingerprint = &model.FingerprintInfo{
+			JA3:    f.GetJa3(),
+			JA3Raw: f.GetJa3Raw(),
+			JA4:    f.GetJa4(),
+			HTTP2:  f.GetHttp2(),
+		}
+	}
+	if resp := m.GetResponse(); resp != nil {
+		r.Response = &model.ResponseData{
+			StatusCode: int(resp.GetStatusCode()),
+			Headers:    resp.GetHeaders(),
+			Body:       string(resp.GetBody()),
+		}
+	}
+	return r
+}
+
+// fromModelRequest 由引擎的检查请求构造gRPC请求
+func fromModelRequest(id uint64, r *model.CheckRequest, traceparent string) *checkpb.CheckRequest {
+	m := &checkpb.CheckRequest{Id: id, Traceparent: traceparent}
+	if r == nil {
+		return m
+	}
+	m.ClientIp = r.ClientIP
+	m.Uri = r.URI
+	m.Method = r.Method
+	m.Headers = fromModelFields(r.Headers)
+	m.Args = fromModelFields(r.Args)
+	m.Body = []byte(r.Body)
+	m.BodyEncoding = r.BodyEncoding
+	m.RequestId = r.RequestID
+	for _, t := range r.RuleTypes {
+		m.RuleTypes = append(m.RuleTypes, string(t))
+	}
+	if g := r.Geo; g != nil {
+		m.Geo = &checkpb.GeoInfo{Country: g.Country, Region: g.Region, City: g.City, Asn: g.ASN, AsOrg: g.ASOrg}
+	}
+	if bot := r.Bot; bot != nil {
+		m.Bot = &checkpb.BotInfo{
+			Category: string(bot.Category),
+			Name:     bot.Name,
+			Score:    int32(bot.Score),
+			Verified: bot.Verified,
+			Reasons:  bot.Reasons,
+		}
+	}
+	if f := r.Fingerprint; f != nil {
+		m.Fingerprint = &checkpb.FingerprintInfo{Ja3: f.JA3, Ja3Raw: f.JA3Raw, Ja4: f.JA4, Http2: f.HTTP2}
+	}
+	if resp := r.Response; resp != nil {
+		m.Response = &checkpb.ResponseData{
+			StatusCode: int32(resp.StatusCode),
+			Headers:    resp.Headers,
+			Body:       []byte(resp.Body),
+		}
+	}
+	return m
+}
+
+// fromModelResult 由检查结果构造gRPC响应，匹配的规则只返回摘要字段
+func fromModelResult(id uint64, r *model.CheckResult) *checkpb.CheckResponse {
+	m := &checkpb.CheckResponse{Id: id}
+	if r == nil {
+		return m
+	}
+	m.Matched = r.Matched
+	m.Action = string(r.Action)
+	m.Message = r.Message
+	m.Source = r.Source
+	if r.MaskedBody != "" {
+		m.MaskedBody = []byte(r.MaskedBody)
+	}
+	if rule := r.MatchedRule; rule != nil {
+		m.MatchedRule = &checkpb.MatchedRule{
+			Id:       rule.ID,
+			Name:     rule.Name,
+			Type:     string(rule.Type),
+			Severity: string(rule.Severity),
+			Priority: int32(rule.Priority),
+			Version:  rule.Version,
+		}
+	}
+	for _, file := range r.Uploads {
+		m.Uploads = append(m.Uploads, &checkpb.UploadFile{
+			Field:        file.Field,
+			Filename:     file.Filename,
+			ContentType:  file.ContentType,
+			DetectedType: file.DetectedType,
+			Size:         file.Size,
+			Violations:   file.Violations,
+		})
+	}
+	return m
+}
+
+// toCheckResponse 转换gRPC响应，匹配的规则只包含摘要字段
+func toCheckResponse(m *checkpb.CheckResponse) *CheckResponse {
+	resp := &CheckResponse{ID: m.GetId()}
+	if e := m.GetError(); e != nil {
+		resp.Error = &Error{Code: e.GetCode(), Message: e.GetMessage()}
+		return resp
+	}
+	r := &model.CheckResult{
+		Matched:    m.GetMatched(),
+		Action:     model.ActionType(m.GetAction()),
+		Message:    m.GetMessage(),
+		Source:     m.GetSource(),
+		MaskedBody: string(m.GetMaskedBody()),
+	}
+	if rule := m.GetMatchedRule(); rule != nil {
+		r.MatchedRule = &model.Rule{
+			ID:       rule.GetId(),
+			Name:     rule.GetName(),
+			Type:     model.RuleType(rule.GetType()),
+			Severity: model.SeverityType(rule.GetSeverity()),
+			Priority: int(rule.GetPriority()),
+			Version:  rule.GetVersion(),
+		}
+	}
+	for _, file := range m.GetUploads() {
+		r.Uploads = append(r.Uploads, &model.UploadFile{
+			Field:        file.GetField(),
+			Filename:     file.GetFilename(),
+			ContentType:  file.GetContentType(),
+			DetectedType: file.GetDetectedType(),
+			Size:         file.GetSize(),
+			Violations:   file.GetViolations(),
+		})
+	}
+	resp.Result = r
+	return resp
+}
+
+// toModelFields 按顺序转换请求头或参数
+func toModelFields(fields []*checkpb.Field) model.Fields {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make(model.Fields, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, model.Field{Name: f.GetName(), Value: f.GetValue(), Raw: f.GetRaw()})
+	}
+	return out
+}
+
+// fromModelFields 按顺序转换请求头或参数
+func fromModelFields(fields model.Fields) []*checkpb.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	out := make([]*checkpb.Field, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, &checkpb.Field{Name: f.Name, Value: f.Value, Raw: f.Raw})
+	}
+	return out
+}
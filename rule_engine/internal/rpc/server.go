@@ -0,0 +1,243 @@
+// Package rpc 规则检查gRPC接口，消息和服务由api/proto/check.proto生成到api/proto/checkpb
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xwaf/rule_engine/api/proto/checkpb"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/service"
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	requestIDKey      = "x-request-id"     // 请求ID元数据
+	traceparentKey    = "traceparent"      // W3C Trace Context字段
+	errorCodeTrailer  = "x-waf-error-code" // 单次检查失败时在trailer中返回的错误码
+	defaultMaxMessage = 4 << 20
+)
+
+// Options gRPC服务配置，配置项为0时使用默认值
+type Options struct {
+	MaxMessageSize       int           // 单个消息最大字节数
+	MaxConcurrentStreams uint32        // 每个连接的最大并发流数，0表示不限制
+	KeepaliveTime        time.Duration // 连接空闲该时长后发送keepalive探测，0表示使用gRPC默认值
+}
+
+// Server 规则检查gRPC服务，与REST检查接口调用同一个RuleService，共享已编译的规则快照
+type Server struct {
+	checkpb.UnimplementedCheckServiceServer
+	ruleService service.RuleService
+	server      *grpc.Server
+}
+
+// NewServer 创建规则检查gRPC服务
+func NewServer(ruleService service.RuleService, opts Options) *Server {
+	if ruleService == nil {
+		panic(errors.NewError(errors.ErrConfig, "规则服务不能为空"))
+	}
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = defaultMaxMessage
+	}
+
+	serverOpts := []grpc.ServerOption{
+		// 按W3C traceparent获取上游链路并为每次调用创建服务端Span，没有traceparent时由x-request-id生成链路ID
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.MaxRecvMsgSize(opts.MaxMessageSize),
+		grpc.MaxSendMsgSize(opts.MaxMessageSize),
+		grpc.ChainUnaryInterceptor(recoverUnary),
+		grpc.ChainStreamInterceptor(recoverStream),
+	}
+	if opts.MaxConcurrentStreams > 0 {
+		serverOpts = append(serverOpts, grpc.MaxConcurrentStreams(opts.MaxConcurrentStreams))
+	}
+	if opts.KeepaliveTime > 0 {
+		serverOpts = append(serverOpts,
+			grpc.KeepaliveParams(keepalive.ServerParameters{Time: opts.KeepaliveTime}),
+			// 允许前端在长连接流上以不低于服务端探测间隔一半的频率发送keepalive
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: opts.KeepaliveTime / 2, PermitWithoutStream: true}),
+		)
+	}
+
+	s := &Server{ruleService: ruleService}
+	s.server = grpc.NewServer(serverOpts...)
+	checkpb.RegisterCheckServiceServer(s.server, s)
+	return s
+}
+
+// Serve 在监听器上提供服务，直到Stop
+func (s *Server) Serve(lis net.Listener) error {
+	logger.Infof("启动gRPC检查服务: %s", lis.Addr())
+	if err := s.server.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+		return errors.NewError(errors.ErrSystem, fmt.Sprintf("gRPC服务异常退出: %v", err))
+	}
+	return nil
+}
+
+// Stop 停止接收新连接并等待进行中的请求完成，ctx结束时强制关闭
+// 流式检查的流由前端关闭，超时后强制关闭
+func (s *Server) Stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Warnf("等待gRPC请求完成超时，强制关闭")
+		s.server.Stop()
+		<-done
+	}
+}
+
+// Check 检查单个请求
+func (s *Server) Check(ctx context.Context, req *checkpb.CheckRequest) (*checkpb.CheckResponse, error) {
+	start := time.Now()
+	result, err := s.evaluate(withTraceparent(ctx, req.GetTraceparent()), toModelRequest(req))
+	if err != nil {
+		logger.Errorf("gRPC检查失败: RequestID=%s, Error=%v", incomingRequestID(ctx), err)
+		metrics.RecordGRPCCheck("Check", grpcCode(err).String(), time.Since(start))
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(errorCodeTrailer, fmt.Sprintf("%d", err.Code)))
+		return nil, status.Error(grpcCode(err), err.Error())
+	}
+	metrics.RecordGRPCCheck("Check", codes.OK.String(), time.Since(start))
+	return fromModelResult(req.GetId(), result), nil
+}
+
+// CheckStream 依次检查流中的请求并按接收顺序返回结果，单个请求失败时在结果中返回错误
+func (s *Server) CheckStream(stream checkpb.CheckService_CheckStreamServer) error {
+	ctx := stream.Context()
+	requestID := incomingRequestID(ctx)
+	var count int64
+	logger.Infof("gRPC检查流开始: RequestID=%s", requestID)
+	defer func() {
+		logger.Infof("gRPC检查流结束: RequestID=%s, Count=%d", requestID, count)
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		count++
+
+		start := time.Now()
+		var resp *checkpb.CheckResponse
+		code := codes.OK
+		result, checkErr := s.evaluate(withTraceparent(ctx, req.GetTraceparent()), toModelRequest(req))
+		if checkErr != nil {
+			logger.Errorf("gRPC流式检查失败: RequestID=%s, ID=%d, Error=%v", requestID, req.GetId(), checkErr)
+			resp = &checkpb.CheckResponse{Id: req.GetId(), Error: &checkpb.Error{Code: int32(checkErr.Code), Message: checkErr.Error()}}
+			code = grpcCode(checkErr)
+		} else {
+			resp = fromModelResult(req.GetId(), result)
+		}
+		metrics.RecordGRPCCheck("CheckStream", code.String(), time.Since(start))
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// evaluate 验证并检查请求，与REST检查接口的处理相同
+func (s *Server) evaluate(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, *errors.Error) {
+	if err := req.Validate(); err != nil {
+		return nil, asError(err, errors.ErrValidation)
+	}
+	result, err := s.ruleService.CheckRequest(ctx, req)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检查规则匹配失败: %v", err))
+	}
+	return result, nil
+}
+
+// withTraceparent 请求中带traceparent时以其为父链路，流式检查中的每个请求分别关联到前端各自的链路
+func withTraceparent(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceparentKey: traceparent})
+}
+
+// recoverUnary 单次调用panic时返回Internal错误，不影响其他请求
+func recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("gRPC请求处理异常: Method=%s, RequestID=%s, Panic=%v\n%s", info.FullMethod, incomingRequestID(ctx), r, debug.Stack())
+			err = status.Error(codes.Internal, "服务内部错误")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoverStream 流处理panic时关闭该流，不影响其他流
+func recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("gRPC流处理异常: Method=%s, RequestID=%s, Panic=%v\n%s", info.FullMethod, incomingRequestID(ss.Context()), r, debug.Stack())
+			err = status.Error(codes.Internal, "服务内部错误")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// incomingRequestID 从元数据获取请求ID，未传入时生成
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+	return uuid.New().String()
+}
+
+// asError 转换为errors.Error，其他错误使用指定的错误码
+func asError(err error, code errors.ErrorCode) *errors.Error {
+	if e, ok := err.(*errors.Error); ok {
+		return e
+	}
+	return errors.NewError(code, err.Error())
+}
+
+// grpcCode 错误码对应的gRPC状态码
+func grpcCode(e *errors.Error) codes.Code {
+	switch {
+	case e.IsValidationError():
+		return codes.InvalidArgument
+	case e.IsNotFound():
+		return codes.NotFound
+	case e.IsConflict():
+		return codes.AlreadyExists
+	case e.Code == errors.ErrAuthFailed:
+		return codes.Unauthenticated
+	case e.IsSecurityError():
+		return codes.PermissionDenied
+	case e.Code == errors.ErrRateLimit:
+		return codes.ResourceExhausted
+	case e.IsRequestError():
+		return codes.InvalidArgument
+	case e.ShouldRetry():
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
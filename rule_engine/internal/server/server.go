@@ -3,7 +3,9 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +20,41 @@ type Config struct {
 	ReadTimeout     int    `yaml:"read_timeout"`
 	WriteTimeout    int    `yaml:"write_timeout"`
 	ShutdownTimeout int    `yaml:"shutdown_timeout"`
+	// TrustedProxies 可传入 X-User-ID、X-User-Roles 身份请求头的认证网关地址(IP或CIDR)，为空时只信任本机
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// defaultTrustedProxies 未配置认证网关时只信任本机
+var defaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// TrustedNets 解析认证网关地址，单个IP按/32或/128处理
+func (c *Config) TrustedNets() ([]*net.IPNet, error) {
+	proxies := c.TrustedProxies
+	if len(proxies) == 0 {
+		proxies = defaultTrustedProxies
+	}
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的认证网关地址: %q", proxy))
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("无效的认证网关地址: %q", proxy))
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
 }
 
 // Validate 验证配置
@@ -34,6 +71,9 @@ func (c *Config) Validate() error {
 	if c.ShutdownTimeout <= 0 {
 		return errors.NewError(errors.ErrConfig, "关闭超时时间必须大于0")
 	}
+	if _, err := c.TrustedNets(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// 批量检查默认配置
+const defaultBatchCheckMaxItems = 1000 // 单次最多检查的请求数
+
+// BatchCheckService 批量检查服务接口
+type BatchCheckService interface {
+	// CheckBatch 并行检查多个请求，按请求顺序返回结果，单个请求失败不影响其他请求
+	CheckBatch(ctx context.Context, req *model.BatchCheckRequest) (*model.BatchCheckResult, error)
+}
+
+// BatchCheckOptions 批量检查配置
+type BatchCheckOptions struct {
+	MaxItems int // 单次最多检查的请求数
+	Workers  int // 所有批量检查共享的并行数，默认为CPU数
+}
+
+// batchCheckService 批量检查服务实现，逐个调用规则服务的无副作用检查，与单个检查接口的判定相同
+type batchCheckService struct {
+	ruleService RuleService
+	maxItems    int
+	// slots 进程内所有批量检查共享的并行检查名额，并发的批量请求不会超过该并行数
+	slots chan struct{}
+}
+
+// NewBatchCheckService 创建批量检查服务
+func NewBatchCheckService(ruleService RuleService, opts BatchCheckOptions) BatchCheckService {
+	if ruleService == nil {
+		panic(errors.NewError(errors.ErrConfig, "规则服务不能为空"))
+	}
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = defaultBatchCheckMaxItems
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	return &batchCheckService{
+		ruleService: ruleService,
+		maxItems:    opts.MaxItems,
+		slots:       make(chan struct{}, opts.Workers),
+	}
+}
+
+// CheckBatch 并行检查多个请求
+// 所有批量请求共享workers个并行名额，ctx结束后未开始的请求记为失败
+func (s *batchCheckService) CheckBatch(ctx context.Context, req *model.BatchCheckRequest) (*model.BatchCheckResult, error) {
+	if err := req.Validate(s.maxItems); err != nil {
+		return nil, err
+	}
+
+	items := make([]*model.BatchCheckItem, len(req.Items))
+	var wg sync.WaitGroup
+	for index := range req.Items {
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			// 不再等待名额，checkItem直接记为已取消
+			items[index] = s.checkItem(ctx, index, req.Items[index])
+			continue
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer func() {
+				<-s.slots
+				wg.Done()
+			}()
+			items[index] = s.checkItem(ctx, index, req.Items[index])
+		}(index)
+	}
+	wg.Wait()
+
+	result := &model.BatchCheckResult{Total: len(items), Items: items}
+	for _, item := range items {
+		switch {
+		case item.Error != nil:
+			result.Failed++
+		case item.Result.Matched:
+			result.Matched++
+		}
+	}
+	return result, nil
+}
+
+// checkItem 检查单个请求，检查异常时只记为该请求失败
+func (s *batchCheckService) checkItem(ctx context.Context, index int, req *model.CheckRequest) (item *model.BatchCheckItem) {
+	item = &model.BatchCheckItem{Index: index}
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("批量检查异常: Index=%d, Panic=%v", index, r)
+			item.Result = nil
+			item.Error = batchCheckError(errors.NewError(errors.ErrRuntime, fmt.Sprintf("检查异常: %v", r)))
+		}
+	}()
+
+	if err := ctx.Err(); err != nil {
+		item.Error = batchCheckError(errors.NewError(errors.ErrSystem, fmt.Sprintf("请求已取消: %v", err)))
+		return item
+	}
+	if req == nil {
+		item.Error = batchCheckError(errors.NewError(errors.ErrValidation, "请求不能为空"))
+		return item
+	}
+	if err := req.Validate(); err != nil {
+		item.Error = batchCheckError(err)
+		return item
+	}
+	// 离线分析和日志回放不应累加CC计数或记录事件，使用无副作用检查
+	result, err := s.ruleService.DryRunRequest(ctx, req)
+	if err != nil {
+		item.Error = batchCheckError(errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检查规则匹配失败: %v", err)))
+		return item
+	}
+	item.Result = result
+	return item
+}
+
+// batchCheckError 转换为单个请求的错误，错误码与单个检查接口相同
+func batchCheckError(err error) *model.BatchCheckError {
+	if e, ok := err.(*errors.Error); ok {
+		return &model.BatchCheckError{Code: e.Code, Message: e.Error()}
+	}
+	return &model.BatchCheckError{Code: errors.ErrSystem, Message: err.Error()}
+}
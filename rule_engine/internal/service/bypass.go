@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+const (
+	defaultBypassMaxDuration     = 7 * 24 * time.Hour
+	defaultBypassRefreshInterval = 30 * time.Second
+	defaultBypassAttemptBuffer   = 4096
+	defaultBypassTokenHeader     = "X-WAF-Bypass-Token"
+	defaultBypassTokenMaxTTL     = 7 * 24 * time.Hour
+	bypassKeySize                = 32
+	bypassFlushInterval          = time.Second
+	bypassFlushBatch             = 500
+)
+
+// BypassService 旁路服务接口
+type BypassService interface {
+	// 旁路配置管理，配置必须设置结束时间且有效期不超过最大时长
+	CreateBypass(ctx context.Context, config *model.BypassConfig) error
+	UpdateBypass(ctx context.Context, config *model.BypassConfig) error
+	DeleteBypass(ctx context.Context, id int64) error
+	GetBypass(ctx context.Context, id int64) (*model.BypassConfig, error)
+	ListBypasses(ctx context.Context, activeOnly bool, page, size int) ([]*model.BypassConfig, int64, error)
+
+	// 旁路尝试记录
+	ListAttempts(ctx context.Context, query *model.BypassAttemptQuery, page, size int) ([]*model.BypassAttempt, int64, error)
+
+	// 旁路令牌，令牌按路径和方法限定范围，使用生效的密钥签名
+	IssueToken(ctx context.Context, claims *model.BypassTokenClaims, ttl time.Duration) (string, error)
+	ListKeys(ctx context.Context) ([]*model.BypassKey, error)
+	RotateKey(ctx context.Context, operator int64) (*model.BypassKey, error)
+	RevokeKey(ctx context.Context, keyID string) error
+
+	// CheckBypass 在规则检查前判定请求是否旁路，未命中时返回nil
+	CheckBypass(ctx context.Context, req *model.CheckRequest) (*model.BypassDecision, error)
+
+	// Reload 重新加载并编译未过期的旁路配置和令牌密钥
+	Reload(ctx context.Context) error
+
+	// Run 定期刷新旁路配置并写入尝试记录，直到ctx取消
+	Run(ctx context.Context)
+}
+
+// BypassOptions 旁路服务配置
+type BypassOptions struct {
+	MaxDuration     time.Duration // 旁路配置最大有效期
+	RefreshInterval time.Duration // 旁路配置刷新间隔
+	AttemptBuffer   int           // 待写入的尝试记录缓冲数，缓冲满时丢弃并记录日志
+	TokenHeader     string        // 旁路令牌请求头
+	TokenMaxTTL     time.Duration // 旁路令牌最大有效期
+}
+
+// bypassSnapshot 已加载的旁路配置和令牌密钥
+type bypassSnapshot struct {
+	entries []*model.CompiledBypass
+	keys    map[string]*model.BypassKey
+}
+
+// bypassService 旁路服务实现
+type bypassService struct {
+	repo     repository.BypassRepository
+	opts     BypassOptions
+	snapshot atomic.Value // *bypassSnapshot
+	attempts chan *model.BypassAttempt
+	dropped  int64
+}
+
+// NewBypassService 创建旁路服务，配置项为0时使用默认值
+func NewBypassService(repo repository.BypassRepository, opts BypassOptions) BypassService {
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = defaultBypassMaxDuration
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = defaultBypassRefreshInterval
+	}
+	if opts.AttemptBuffer <= 0 {
+		opts.AttemptBuffer = defaultBypassAttemptBuffer
+	}
+	if opts.TokenHeader == "" {
+		opts.TokenHeader = defaultBypassTokenHeader
+	}
+	if opts.TokenMaxTTL <= 0 {
+		opts.TokenMaxTTL = defaultBypassTokenMaxTTL
+	}
+	s := &bypassService{
+		repo:     repo,
+		opts:     opts,
+		attempts: make(chan *model.BypassAttempt, opts.AttemptBuffer),
+	}
+	s.snapshot.Store(&bypassSnapshot{keys: map[string]*model.BypassKey{}})
+	return s
+}
+
+// CreateBypass 创建旁路配置
+func (s *bypassService) CreateBypass(ctx context.Context, config *model.BypassConfig) error {
+	if err := s.validate(config); err != nil {
+		return err
+	}
+	config.ID = 0
+	if err := s.repo.CreateBypass(ctx, config); err != nil {
+		return err
+	}
+	logger.Infof("创建旁路配置: BypassID=%d, Mode=%s, EndTime=%d, Operator=%d, Reason=%s",
+		config.ID, config.Mode, config.EndTime, config.CreatedBy, config.Reason)
+	s.reloadAfterWrite(ctx)
+	return nil
+}
+
+// UpdateBypass 更新旁路配置
+func (s *bypassService) UpdateBypass(ctx context.Context, config *model.BypassConfig) error {
+	if err := s.validate(config); err != nil {
+		return err
+	}
+	existing, err := s.repo.GetBypass(ctx, config.ID)
+	if err != nil {
+		return err
+	}
+	config.CreatedBy = existing.CreatedBy
+	config.CreatedAt = existing.CreatedAt
+	if err := s.repo.UpdateBypass(ctx, config); err != nil {
+		return err
+	}
+	logger.Infof("更新旁路配置: BypassID=%d, Mode=%s, EndTime=%d, Operator=%d", config.ID, config.Mode, config.EndTime, config.UpdatedBy)
+	s.reloadAfterWrite(ctx)
+	return nil
+}
+
+// DeleteBypass 删除旁路配置
+func (s *bypassService) DeleteBypass(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteBypass(ctx, id); err != nil {
+		return err
+	}
+	logger.Infof("删除旁路配置: BypassID=%d", id)
+	s.reloadAfterWrite(ctx)
+	return nil
+}
+
+// GetBypass 获取旁路配置
+func (s *bypassService) GetBypass(ctx context.Context, id int64) (*model.BypassConfig, error) {
+	return s.repo.GetBypass(ctx, id)
+}
+
+// ListBypasses 获取旁路配置列表
+func (s *bypassService) ListBypasses(ctx context.Context, activeOnly bool, page, size int) ([]*model.BypassConfig, int64, error) {
+	var activeAt int64
+	if activeOnly {
+		activeAt = time.Now().Unix()
+	}
+	return s.repo.ListBypasses(ctx, activeAt, (page-1)*size, size)
+}
+
+// ListAttempts 获取旁路尝试记录
+func (s *bypassService) ListAttempts(ctx context.Context, query *model.BypassAttemptQuery, page, size int) ([]*model.BypassAttempt, int64, error) {
+	return s.repo.ListAttempts(ctx, query, (page-1)*size, size)
+}
+
+// IssueToken 签发旁路令牌，令牌本身不保存，只记录日志
+func (s *bypassService) IssueToken(ctx context.Context, claims *model.BypassTokenClaims, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > s.opts.TokenMaxTTL {
+		return "", errors.NewError(errors.ErrValidation, fmt.Sprintf("令牌有效期必须在0到%s之间", s.opts.TokenMaxTTL))
+	}
+
+	var key *model.BypassKey
+	for _, k := range s.snapshot.Load().(*bypassSnapshot).keys {
+		if k.Status == model.BypassKeyActive && (key == nil || k.ID > key.ID) {
+			key = k
+		}
+	}
+	if key == nil {
+		return "", errors.NewError(errors.ErrValidation, "没有生效的旁路令牌密钥，请先轮换生成密钥")
+	}
+
+	now := time.Now()
+	claims.ID = uuid.New().String()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	token, err := model.SignBypassToken(key, claims)
+	if err != nil {
+		return "", err
+	}
+	logger.Infof("签发旁路令牌: TokenID=%s, Subject=%s, KeyID=%s, Paths=%v, Methods=%v, ExpiresAt=%d, Operator=%d",
+		claims.ID, claims.Subject, key.KeyID, claims.Paths, claims.Methods, claims.ExpiresAt, claims.IssuedBy)
+	return token, nil
+}
+
+// ListKeys 获取旁路令牌密钥
+func (s *bypassService) ListKeys(ctx context.Context) ([]*model.BypassKey, error) {
+	return s.repo.ListKeys(ctx)
+}
+
+// RotateKey 生成新的签名密钥，原密钥只用于验证已签发的令牌
+func (s *bypassService) RotateKey(ctx context.Context, operator int64) (*model.BypassKey, error) {
+	secret := make([]byte, bypassKeySize)
+	keyID := make([]byte, 8)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("生成旁路令牌密钥失败: %v", err))
+	}
+	if _, err := rand.Read(keyID); err != nil {
+		return nil, errors.NewError(errors.ErrSystem, fmt.Sprintf("生成旁路令牌密钥失败: %v", err))
+	}
+
+	key := &model.BypassKey{
+		KeyID:     hex.EncodeToString(keyID),
+		Secret:    secret,
+		Status:    model.BypassKeyActive,
+		CreatedBy: operator,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.RotateKey(ctx, key); err != nil {
+		return nil, err
+	}
+	logger.Infof("轮换旁路令牌密钥: KeyID=%s, Operator=%d", key.KeyID, operator)
+	s.reloadAfterWrite(ctx)
+	return key, nil
+}
+
+// RevokeKey 吊销密钥，使用该密钥签发的令牌立即失效
+func (s *bypassService) RevokeKey(ctx context.Context, keyID string) error {
+	if err := s.repo.RevokeKey(ctx, keyID, time.Now()); err != nil {
+		return err
+	}
+	logger.Infof("吊销旁路令牌密钥: KeyID=%s", keyID)
+	s.reloadAfterWrite(ctx)
+	return nil
+}
+
+// validate 验证旁路配置，要求限定时间且部分旁路和监控模式至少有一类条件
+func (s *bypassService) validate(config *model.BypassConfig) error {
+	if err := model.ValidateBypassConfig(config); err != nil {
+		return err
+	}
+	if config.Mode == model.BypassModeNone {
+		return nil
+	}
+	now := time.Now().Unix()
+	if config.EndTime <= now {
+		return errors.NewError(errors.ErrValidation, "旁路结束时间必须晚于当前时间")
+	}
+	start := config.StartTime
+	if start < now {
+		start = now
+	}
+	if time.Duration(config.EndTime-start)*time.Second > s.opts.MaxDuration {
+		return errors.NewError(errors.ErrValidation, fmt.Sprintf("旁路有效期不能超过%s", s.opts.MaxDuration))
+	}
+	return nil
+}
+
+// reloadAfterWrite 配置修改后立即生效，失败时等待下次定时刷新
+func (s *bypassService) reloadAfterWrite(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		logger.Errorf("刷新旁路配置失败: %v", err)
+	}
+}
+
+// Reload 重新加载旁路配置
+func (s *bypassService) Reload(ctx context.Context) error {
+	configs, _, err := s.repo.ListBypasses(ctx, time.Now().Unix(), 0, 0)
+	if err != nil {
+		return err
+	}
+	keys, err := s.repo.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*model.CompiledBypass, 0, len(configs))
+	for _, config := range configs {
+		if config.Mode == model.BypassModeNone {
+			continue
+		}
+		compiled, err := model.CompileBypass(config)
+		if err != nil {
+			// 保存时已验证，跳过被直接修改的无效配置
+			logger.Errorf("编译旁路配置失败: BypassID=%d, Error=%v", config.ID, err)
+			continue
+		}
+		entries = append(entries, compiled)
+	}
+	// 部分旁路和完全旁路优先于监控模式
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Config.Mode != model.BypassModeMonitor && entries[j].Config.Mode == model.BypassModeMonitor
+	})
+
+	snapshot := &bypassSnapshot{
+		entries: entries,
+		keys:    make(map[string]*model.BypassKey, len(keys)),
+	}
+	for _, key := range keys {
+		snapshot.keys[key.KeyID] = key
+	}
+	s.snapshot.Store(snapshot)
+	return nil
+}
+
+// CheckBypass 判定请求是否旁路，并记录每次满足部分或全部条件的尝试
+// 携带令牌的请求先验证令牌，验证失败时记录尝试并继续按旁路配置判定
+func (s *bypassService) CheckBypass(ctx context.Context, req *model.CheckRequest) (*model.BypassDecision, error) {
+	snapshot := s.snapshot.Load().(*bypassSnapshot)
+	now := time.Now().Unix()
+
+	if token := req.Headers.GetFold(s.opts.TokenHeader); token != "" {
+		claims, err := model.VerifyBypassToken(token, snapshot.keys, now)
+		switch {
+		case err != nil:
+			s.record(ctx, req, &model.BypassAttempt{Mode: model.BypassModeToken, Reason: err.Error()}, now)
+		case !claims.Allows(req.Method, req.URI):
+			s.record(ctx, req, &model.BypassAttempt{Mode: model.BypassModeToken, TokenID: claims.ID, Reason: fmt.Sprintf("令牌不允许该请求: %s", claims.Subject)}, now)
+		default:
+			s.record(ctx, req, &model.BypassAttempt{Mode: model.BypassModeToken, TokenID: claims.ID, Success: true, Reason: fmt.Sprintf("令牌验证通过: %s", claims.Subject)}, now)
+			return &model.BypassDecision{Token: claims, Allowed: true}, nil
+		}
+	}
+
+	var decision *model.BypassDecision
+	for _, entry := range snapshot.entries {
+		if !entry.ActiveAt(now) {
+			continue
+		}
+		matched, allowed := entry.Match(req)
+		if !matched {
+			continue
+		}
+
+		reason := "部分满足旁路条件"
+		if allowed {
+			reason = "满足旁路条件"
+			if decision != nil {
+				reason = fmt.Sprintf("满足旁路条件，已由旁路配置#%d处理", decision.Config.ID)
+			}
+		}
+		s.record(ctx, req, &model.BypassAttempt{
+			BypassID: entry.Config.ID,
+			Mode:     entry.Config.Mode,
+			Success:  allowed && decision == nil,
+			Reason:   reason,
+		}, now)
+		if allowed && decision == nil {
+			decision = &model.BypassDecision{Config: entry.Config, Allowed: true}
+		}
+	}
+	return decision, nil
+}
+
+// record 补充请求信息后异步记录旁路尝试，请求头只记录名称，无副作用检查时不记录
+func (s *bypassService) record(ctx context.Context, req *model.CheckRequest, attempt *model.BypassAttempt, now int64) {
+	if isDryRun(ctx) {
+		return
+	}
+	names := req.Headers.Names(true)
+	sort.Strings(names)
+
+	attempt.RequestID = req.RequestID
+	attempt.IP = req.ClientIP
+	attempt.URL = req.URI
+	attempt.Headers = strings.Join(names, ",")
+	attempt.Timestamp = now
+	select {
+	case s.attempts <- attempt:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+// Run 定期刷新配置并批量写入尝试记录
+func (s *bypassService) Run(ctx context.Context) {
+	refresh := time.NewTicker(s.opts.RefreshInterval)
+	defer refresh.Stop()
+	flush := time.NewTicker(bypassFlushInterval)
+	defer flush.Stop()
+
+	batch := make([]*model.BypassAttempt, 0, bypassFlushBatch)
+	write := func(ctx context.Context) {
+		if dropped := atomic.SwapInt64(&s.dropped, 0); dropped > 0 {
+			logger.Warnf("旁路尝试记录缓冲已满，丢弃%d条记录", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.repo.CreateAttempts(ctx, batch); err != nil {
+			logger.Errorf("写入旁路尝试记录失败: Count=%d, Error=%v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 退出前写入剩余记录
+			for {
+				select {
+				case attempt := <-s.attempts:
+					batch = append(batch, attempt)
+					continue
+				default:
+				}
+				break
+			}
+			write(context.Background())
+			return
+		case attempt := <-s.attempts:
+			batch = append(batch, attempt)
+			if len(batch) >= bypassFlushBatch {
+				write(ctx)
+			}
+		case <-flush.C:
+			write(ctx)
+		case <-refresh.C:
+			if err := s.Reload(ctx); err != nil {
+				logger.Errorf("刷新旁路配置失败: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// ChangeRequestService 规则变更请求服务接口
+// 变更以草稿形式提交，自动执行静态检查和测试用例，经其他审批人批准后发布
+type ChangeRequestService interface {
+	// Create 创建变更请求草稿
+	Create(ctx context.Context, cr *model.ChangeRequest, operator int64) error
+
+	// Update 修改草稿或被驳回的变更请求，只有作者可以修改
+	Update(ctx context.Context, cr *model.ChangeRequest, operator int64) error
+
+	// Get 获取变更请求及历史记录
+	Get(ctx context.Context, id int64) (*model.ChangeRequest, error)
+
+	// List 获取变更请求列表，status为空时返回全部
+	List(ctx context.Context, status model.ChangeRequestStatus, page, size int) ([]*model.ChangeRequest, int64, error)
+
+	// Submit 执行检查并提交审批，检查未通过时保持草稿并返回ErrRuleConflict
+	Submit(ctx context.Context, id, operator int64) (*model.ChangeRequest, error)
+
+	// Comment 添加评论
+	Comment(ctx context.Context, id, operator int64, comment string) (*model.ChangeEvent, error)
+
+	// Approve 批准变更请求，审批人必须具有审批角色且不能是作者
+	Approve(ctx context.Context, id, operator int64, roles []string, comment string) (*model.ChangeRequest, error)
+
+	// Reject 驳回变更请求
+	Reject(ctx context.Context, id, operator int64, roles []string, comment string) (*model.ChangeRequest, error)
+
+	// Publish 应用已批准的变更并发布规则集，作者或审批人可以发布
+	Publish(ctx context.Context, id, operator int64, roles []string) (*model.ChangeRequest, error)
+
+	// Cancel 取消未发布的变更请求，只有作者可以取消
+	Cancel(ctx context.Context, id, operator int64, comment string) (*model.ChangeRequest, error)
+}
+
+// changeRequestService 规则变更请求服务实现
+type changeRequestService struct {
+	repo           repository.ChangeRequestRepository
+	ruleService    RuleService
+	ipService      IPRuleService
+	ccService      CCRuleService
+	releaseService ReleaseService
+	approverRole   string
+}
+
+// NewChangeRequestService 创建规则变更请求服务，approverRole为空时使用model.RoleApprover
+func NewChangeRequestService(repo repository.ChangeRequestRepository, ruleService RuleService, ipService IPRuleService,
+	ccService CCRuleService, releaseService ReleaseService, approverRole string) ChangeRequestService {
+	if approverRole == "" {
+		approverRole = model.RoleApprover
+	}
+	return &changeRequestService{
+		repo:           repo,
+		ruleService:    ruleService,
+		ipService:      ipService,
+		ccService:      ccService,
+		releaseService: releaseService,
+		approverRole:   approverRole,
+	}
+}
+
+// Create 创建变更请求
+func (s *changeRequestService) Create(ctx context.Context, cr *model.ChangeRequest, operator int64) error {
+	if err := cr.Validate(); err != nil {
+		return err
+	}
+	for _, item := range cr.Items {
+		item.AppliedAt = nil
+	}
+	cr.ID = 0
+	cr.Status = model.ChangeRequestDraft
+	cr.Author = operator
+	cr.Approver = 0
+	cr.ReleaseID = 0
+	cr.Checks = nil
+	cr.SubmittedAt, cr.ApprovedAt, cr.PublishedAt = nil, nil, nil
+
+	if err := s.repo.CreateChangeRequest(ctx, cr); err != nil {
+		return err
+	}
+	s.record(ctx, cr, model.ChangeEventCreate, "", operator, "")
+	return nil
+}
+
+// Update 修改变更请求，被驳回的变更请求修改后回到草稿
+func (s *changeRequestService) Update(ctx context.Context, cr *model.ChangeRequest, operator int64) error {
+	old, err := s.repo.GetChangeRequest(ctx, cr.ID)
+	if err != nil {
+		return err
+	}
+	if old.Author != operator {
+		return errors.NewError(errors.ErrPermDenied, "只有作者可以修改变更请求")
+	}
+	if !old.Editable() {
+		return errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("变更请求当前状态不可修改: %s", old.Status))
+	}
+	if err := cr.Validate(); err != nil {
+		return err
+	}
+
+	for _, item := range cr.Items {
+		item.AppliedAt = nil
+	}
+	cr.Status = model.ChangeRequestDraft
+	cr.Author = old.Author
+	cr.Approver = 0
+	cr.ReleaseID = 0
+	cr.Checks = nil
+	cr.SubmittedAt, cr.ApprovedAt, cr.PublishedAt = nil, nil, nil
+	cr.CreatedAt = old.CreatedAt
+
+	if err := s.repo.UpdateChangeRequest(ctx, cr); err != nil {
+		return err
+	}
+	s.record(ctx, cr, model.ChangeEventUpdate, old.Status, operator, "")
+	return nil
+}
+
+// Get 获取变更请求
+func (s *changeRequestService) Get(ctx context.Context, id int64) (*model.ChangeRequest, error) {
+	cr, err := s.repo.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Events, err = s.repo.ListEvents(ctx, id); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// List 获取变更请求列表
+func (s *changeRequestService) List(ctx context.Context, status model.ChangeRequestStatus, page, size int) ([]*model.ChangeRequest, int64, error) {
+	return s.repo.ListChangeRequests(ctx, status, (page-1)*size, size)
+}
+
+// Submit 提交审批
+func (s *changeRequestService) Submit(ctx context.Context, id, operator int64) (*model.ChangeRequest, error) {
+	cr, err := s.repo.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Author != operator {
+		return nil, errors.NewError(errors.ErrPermDenied, "只有作者可以提交变更请求")
+	}
+	if !cr.Editable() {
+		return nil, errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("变更请求当前状态不可提交: %s", cr.Status))
+	}
+
+	checks, err := s.check(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	cr.Checks = checks
+
+	from := cr.Status
+	if !checks.Passed {
+		// 保存检查结果供作者查看，状态不变
+		if err := s.repo.UpdateChangeRequest(ctx, cr); err != nil {
+			return nil, err
+		}
+		return nil, errors.NewError(errors.ErrRuleConflict, checks)
+	}
+
+	now := time.Now()
+	cr.Status = model.ChangeRequestPending
+	cr.SubmittedAt = &now
+	if err := s.repo.UpdateChangeRequest(ctx, cr); err != nil {
+		return nil, err
+	}
+	s.record(ctx, cr, model.ChangeEventSubmit, from, operator, "")
+	return cr, nil
+}
+
+// Comment 添加评论
+func (s *changeRequestService) Comment(ctx context.Context, id, operator int64, comment string) (*model.ChangeEvent, error) {
+	if comment == "" {
+		return nil, errors.NewError(errors.ErrValidation, "评论内容不能为空")
+	}
+	cr, err := s.repo.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.record(ctx, cr, model.ChangeEventComment, cr.Status, operator, comment), nil
+}
+
+// Approve 批准变更请求
+func (s *changeRequestService) Approve(ctx context.Context, id, operator int64, roles []string, comment string) (*model.ChangeRequest, error) {
+	cr, err := s.review(ctx, id, operator, roles)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Checks == nil || !cr.Checks.Passed {
+		return nil, errors.NewError(errors.ErrRuleConflict, "变更请求检查未通过，不能批准")
+	}
+
+	now := time.Now()
+	cr.Status = model.ChangeRequestApproved
+	cr.Approver = operator
+	cr.ApprovedAt = &now
+	if err := s.repo.UpdateChangeRequest(ctx, cr); err != nil {
+		return nil, err
+	}
+	s.record(ctx, cr, model.ChangeEventApprove, model.ChangeRequestPending, operator, comment)
+	return cr, nil
+}
+
+// Reject 驳回变更请求
+func (s *changeRequestService) Reject(ctx context.Context, id, operator int64, roles []string, comment string) (*model.ChangeRequest, error) {
+	if comment == "" {
+		return nil, errors.NewError(errors.ErrValidation, "驳回时必须填写原因")
+	}
+	cr, err := s.review(ctx, id, operator, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	cr.Status = model.ChangeRequestRejected
+	cr.Approver = operator
+	if err := s.repo.UpdateChangeRequest(ctx, cr); err != nil {
+		return nil, err
+	}
+	s.record(ctx, cr, model.ChangeEventReject, model.ChangeRequestPending, operator, comment)
+	return cr, nil
+}
+
+// review 获取待审批的变更请求并校验审批人
+func (s *changeRequestService) review(ctx context.Context, id, operator int64, roles []string) (*model.ChangeRequest, error) {
+	if !hasRole(roles, s.approverRole) {
+		return nil, errors.NewError(errors.ErrPermDenied, fmt.Sprintf("审批需要%s角色", s.approverRole))
+	}
+	cr, err := s.repo.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Author == operator {
+		return nil, errors.NewError(errors.ErrPermDenied, "不能审批自己提交的变更请求")
+	}
+	if cr.Status != model.ChangeRequestPending {
+		return nil, errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("变更请求当前状态不可审批: %s", cr.Status))
+	}
+	return cr, nil
+}
+
+// Publish 发布变更请求
+func (s *changeRequestService) Publish(ctx context.Context, id, operator int64, roles []string) (*model.ChangeRequest, error) {
+	cr, err := s.repo.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Author != operator && !hasRole(roles, s.approverRole) {
+		return nil, errors.NewError(errors.ErrPermDenied, "只有作者或审批人可以发布变更请求")
+	}
+	if cr.Status != model.ChangeRequestApproved {
+		return nil, errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("变更请求未批准，不能发布: %s", cr.Status))
+	}
+
+	// 批准后规则集可能已变化，发布前重新检查未生效的变更
+	checks, err := s.check(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	if !checks.Passed {
+		return nil, errors.NewError(errors.ErrRuleConflict, checks)
+	}
+
+	description := fmt.Sprintf("变更请求 #%d: %s", cr.ID, cr.Title)
+	if item := cr.Rollback(); item != nil {
+		release, err := s.releaseService.Rollback(ctx, item.TargetID, operator, description)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		item.AppliedAt = &now
+		return s.published(ctx, cr, release, operator)
+	}
+
+	// 先删除、再修改、最后新建，避免被删除或修改的规则影响新规则的检查
+	for _, op := range []model.ChangeOperation{model.ChangeOperationDelete, model.ChangeOperationUpdate, model.ChangeOperationCreate} {
+		for _, item := range cr.Items {
+			if item.Operation != op || item.AppliedAt != nil {
+				continue
+			}
+			if err := s.apply(ctx, cr, item, operator); err != nil {
+				logger.Errorf("应用变更失败: ChangeRequestID=%d, Target=%s, Operation=%s, TargetID=%d, Error=%v",
+					cr.ID, item.Target, item.Operation, item.TargetID, err)
+				// 记录已生效的变更项，修复后可重新发布
+				if uerr := s.repo.UpdateChangeRequest(ctx, cr); uerr != nil {
+					logger.Errorf("保存变更请求失败: ChangeRequestID=%d, Error=%v", cr.ID, uerr)
+				}
+				return nil, err
+			}
+			now := time.Now()
+			item.AppliedAt = &now
+		}
+	}
+
+	release, err := s.releaseService.Publish(ctx, operator, description)
+	if err != nil {
+		if uerr := s.repo.UpdateChangeRequest(ctx, cr); uerr != nil {
+			logger.Errorf("保存变更请求失败: ChangeRequestID=%d, Error=%v", cr.ID, uerr)
+		}
+		return nil, err
+	}
+	return s.published(ctx, cr, release, operator)
+}
+
+// published 记录变更请求已发布及生成的规则集发布
+func (s *changeRequestService) published(ctx context.Context, cr *model.ChangeRequest, release *model.RulesetRelease, operator int64) (*model.ChangeRequest, error) {
+	now := time.Now()
+	cr.Status = model.ChangeRequestPublished
+	cr.ReleaseID = release.ID
+	cr.PublishedAt = &now
+	if err := s.repo.UpdateChangeRequest(ctx, cr); err != nil {
+		return nil, err
+	}
+	s.record(ctx, cr, model.ChangeEventPublish, model.ChangeRequestApproved, operator, "")
+	logger.Infof("发布变更请求: ChangeRequestID=%d, ReleaseID=%d, Operator=%d", cr.ID, release.ID, operator)
+	return cr, nil
+}
+
+// Cancel 取消变更请求
+func (s *changeRequestService) Cancel(ctx context.Context, id, operator int64, comment string) (*model.ChangeRequest, error) {
+	cr, err := s.repo.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Author != operator {
+		return nil, errors.NewError(errors.ErrPermDenied, "只有作者可以取消变更请求")
+	}
+	if cr.Status == model.ChangeRequestPublished || cr.Status == model.ChangeRequestCancelled {
+		return nil, errors.NewError(errors.ErrRuleConflict, fmt.Sprintf("变更请求当前状态不可取消: %s", cr.Status))
+	}
+	for _, item := range cr.Items {
+		if item.AppliedAt != nil {
+			return nil, errors.NewError(errors.ErrRuleConflict, "变更请求已部分发布，请修复后重新发布")
+		}
+	}
+
+	from := cr.Status
+	cr.Status = model.ChangeRequestCancelled
+	if err := s.repo.UpdateChangeRequest(ctx, cr); err != nil {
+		return nil, err
+	}
+	s.record(ctx, cr, model.ChangeEventCancel, from, operator, comment)
+	return cr, nil
+}
+
+// check 对未生效的变更执行规则静态检查和测试用例
+func (s *changeRequestService) check(ctx context.Context, cr *model.ChangeRequest) (*model.ChangeChecks, error) {
+	checks := &model.ChangeChecks{
+		Tests:     make([]*model.RuleTestResult, 0),
+		CheckedAt: time.Now(),
+	}
+
+	candidates := make([]*model.Rule, 0)
+	deleted := make([]int64, 0)
+	for _, item := range cr.Items {
+		if item.AppliedAt != nil {
+			continue
+		}
+		if err := s.checkTarget(ctx, item); err != nil {
+			return nil, err
+		}
+		if item.Target != model.ChangeTargetRule {
+			continue
+		}
+		if item.Operation == model.ChangeOperationDelete {
+			deleted = append(deleted, item.TargetID)
+			continue
+		}
+
+		rule, err := item.Rule()
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, rule)
+		if len(item.TestCases) == 0 {
+			continue
+		}
+		results, err := s.ruleService.TestRule(ctx, rule, item.TestCases)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if result.Error != "" {
+				checks.Failed++
+			}
+		}
+		checks.Tests = append(checks.Tests, results...)
+	}
+
+	report, err := s.ruleService.LintChanges(ctx, candidates, deleted)
+	if err != nil {
+		return nil, err
+	}
+	checks.Lint = report
+	checks.Passed = !report.HasErrors() && checks.Failed == 0
+	return checks, nil
+}
+
+// checkTarget 修改和删除的对象必须存在
+func (s *changeRequestService) checkTarget(ctx context.Context, item *model.ChangeItem) error {
+	if item.Operation == model.ChangeOperationCreate {
+		return nil
+	}
+	var err error
+	switch item.Target {
+	case model.ChangeTargetRule:
+		_, err = s.ruleService.GetRule(ctx, item.TargetID)
+	case model.ChangeTargetIP:
+		_, err = s.ipService.GetIPRule(ctx, item.TargetID)
+	case model.ChangeTargetRelease:
+		_, err = s.releaseService.GetRelease(ctx, item.TargetID)
+	default:
+		_, err = s.ccService.GetCCRule(ctx, item.TargetID)
+	}
+	if err != nil {
+		return errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("变更对象不存在: %s:%d", item.Target, item.TargetID))
+	}
+	return nil
+}
+
+// apply 应用一项变更，新建的对象ID回写到TargetID
+func (s *changeRequestService) apply(ctx context.Context, cr *model.ChangeRequest, item *model.ChangeItem, operator int64) error {
+	switch item.Target {
+	case model.ChangeTargetRule:
+		return s.applyRule(ctx, cr, item, operator)
+	case model.ChangeTargetIP:
+		if item.Operation == model.ChangeOperationDelete {
+			return s.ipService.DeleteIPRule(ctx, item.TargetID)
+		}
+		rule, err := item.IPRule()
+		if err != nil {
+			return err
+		}
+		rule.UpdatedBy = cr.Author
+		if item.Operation == model.ChangeOperationUpdate {
+			return s.ipService.UpdateIPRule(ctx, rule)
+		}
+		rule.CreatedBy = cr.Author
+		if err := s.ipService.CreateIPRule(ctx, rule); err != nil {
+			return err
+		}
+		item.TargetID = rule.ID
+		return nil
+	default:
+		if item.Operation == model.ChangeOperationDelete {
+			return s.ccService.DeleteCCRule(ctx, item.TargetID)
+		}
+		rule, err := item.CCRule()
+		if err != nil {
+			return err
+		}
+		if item.Operation == model.ChangeOperationUpdate {
+			return s.ccService.UpdateCCRule(ctx, rule)
+		}
+		if err := s.ccService.CreateCCRule(ctx, rule); err != nil {
+			return err
+		}
+		item.TargetID = rule.ID
+		return nil
+	}
+}
+
+// applyRule 应用规则变更并记录规则审计日志
+func (s *changeRequestService) applyRule(ctx context.Context, cr *model.ChangeRequest, item *model.ChangeItem, operator int64) error {
+	audit := &model.RuleAuditLog{
+		RuleID:    item.TargetID,
+		Action:    string(item.Operation),
+		Operator:  strconv.FormatInt(operator, 10),
+		CreatedAt: time.Now(),
+	}
+
+	var old *model.Rule
+	if item.Operation != model.ChangeOperationCreate {
+		var err error
+		if old, err = s.ruleService.GetRule(ctx, item.TargetID); err != nil {
+			return err
+		}
+		audit.OldValue = toJSON(old)
+	}
+
+	switch item.Operation {
+	case model.ChangeOperationDelete:
+		if err := s.ruleService.DeleteRule(ctx, item.TargetID); err != nil {
+			return err
+		}
+	case model.ChangeOperationUpdate:
+		rule, err := item.Rule()
+		if err != nil {
+			return err
+		}
+		rule.CreatedBy, rule.CreatedAt = old.CreatedBy, old.CreatedAt
+		rule.UpdatedBy = cr.Author
+		if err := s.ruleService.UpdateRule(ctx, rule); err != nil {
+			return err
+		}
+		audit.NewValue = toJSON(rule)
+	default:
+		rule, err := item.Rule()
+		if err != nil {
+			return err
+		}
+		rule.CreatedBy, rule.UpdatedBy = cr.Author, cr.Author
+		if err := s.ruleService.CreateRule(ctx, rule); err != nil {
+			return err
+		}
+		item.TargetID = rule.ID
+		audit.RuleID = rule.ID
+		audit.NewValue = toJSON(rule)
+	}
+
+	// 仅记录错误，不影响发布
+	if err := s.ruleService.CreateRuleAuditLog(ctx, audit); err != nil {
+		logger.Errorf("记录规则审计日志失败: ChangeRequestID=%d, RuleID=%d, Error=%v", cr.ID, audit.RuleID, err)
+	}
+	return nil
+}
+
+// record 记录变更请求历史，并同步写入规则审计日志（规则ID为0）
+func (s *changeRequestService) record(ctx context.Context, cr *model.ChangeRequest, action model.ChangeEventAction,
+	from model.ChangeRequestStatus, operator int64, comment string) *model.ChangeEvent {
+	event := &model.ChangeEvent{
+		ChangeRequestID: cr.ID,
+		Action:          action,
+		FromStatus:      from,
+		ToStatus:        cr.Status,
+		Operator:        operator,
+		Comment:         comment,
+		CreatedAt:       time.Now(),
+	}
+	// 状态已保存，历史记录失败时只记录日志
+	if err := s.repo.CreateEvent(ctx, event); err != nil {
+		logger.Errorf("记录变更请求历史失败: ChangeRequestID=%d, Action=%s, Error=%v", cr.ID, action, err)
+	}
+
+	audit := &model.RuleAuditLog{
+		Action:    "change_request_" + string(action),
+		Operator:  strconv.FormatInt(operator, 10),
+		NewValue:  toJSON(event),
+		CreatedAt: event.CreatedAt,
+	}
+	if err := s.ruleService.CreateRuleAuditLog(ctx, audit); err != nil {
+		logger.Errorf("记录规则审计日志失败: ChangeRequestID=%d, Action=%s, Error=%v", cr.ID, action, err)
+	}
+	logger.Infof("变更请求%s: ChangeRequestID=%d, Status=%s->%s, Operator=%d", action, cr.ID, from, cr.Status, operator)
+	return event
+}
+
+// hasRole 判断角色列表是否包含指定角色
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// toJSON 序列化审计日志内容
+func toJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
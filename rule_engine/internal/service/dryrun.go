@@ -0,0 +1,17 @@
+package service
+
+import "context"
+
+// dryRunKey 无副作用检查的上下文键
+type dryRunKey struct{}
+
+// withDryRun 标记为无副作用检查：判定与正常检查相同，但不修改任何状态
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// isDryRun 是否为无副作用检查，会记录请求或修改计数的补充信息、旁路和规则处理器需跳过写入
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
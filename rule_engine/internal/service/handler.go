@@ -4,13 +4,15 @@ import (
 	"context"
 	"fmt"
 	"regexp"
-	"sync"
+	"strconv"
+	"strings"
 
 	"encoding/json"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/leakage"
 	"github.com/xwaf/rule_engine/internal/model"
 )
 
@@ -31,6 +33,10 @@ func NewDefaultRuleFactory() RuleFactory {
 	factory.handlers[model.RuleTypeRegex] = &regexRuleHandler{}
 	factory.handlers[model.RuleTypeSQLi] = &sqlInjectionRuleHandler{}
 	factory.handlers[model.RuleTypeXSS] = &xssRuleHandler{}
+	factory.handlers[model.RuleTypeGeo] = &geoRuleHandler{}
+	factory.handlers[model.RuleTypeBot] = &botRuleHandler{}
+	factory.handlers[model.RuleTypeFingerprint] = &fingerprintRuleHandler{}
+	factory.handlers[model.RuleTypeLeakage] = &leakageRuleHandler{}
 
 	return factory
 }
@@ -45,9 +51,7 @@ func (f *defaultRuleFactory) CreateRuleHandler(ruleType model.RuleType) (RuleHan
 }
 
 // ipRuleHandler IP规则处理器
-type ipRuleHandler struct {
-	regexCache sync.Map // 用于缓存编译后的正则表达式
-}
+type ipRuleHandler struct{}
 
 func (h *ipRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.CheckRequest) (bool, error) {
 	if ctx == nil {
@@ -60,20 +64,10 @@ func (h *ipRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.
 		return false, errors.NewError(errors.ErrRuleEngine, "请求不能为空")
 	}
 
-	// 从缓存中获取正则表达式
-	cached, ok := h.regexCache.Load(rule.ID)
-	var re *regexp.Regexp
-	var err error
-
-	if !ok {
-		// 如果缓存中没有，则编译并存储
-		re, err = regexp.Compile(rule.Pattern)
-		if err != nil {
-			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("编译IP规则正则表达式失败: %v", err))
-		}
-		h.regexCache.Store(rule.ID, re)
-	} else {
-		re = cached.(*regexp.Regexp)
+	// 使用规则快照加载时预编译的正则
+	re, err := compiledRegexp(ctx, rule.Pattern)
+	if err != nil {
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("编译IP规则正则表达式失败: %v", err))
 	}
 
 	return re.MatchString(req.ClientIP), nil
@@ -120,6 +114,15 @@ func (h *ccRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.
 	// 构造Redis键
 	key := fmt.Sprintf("cc:%d:%s", rule.ID, req.ClientIP)
 
+	// 无副作用检查只读取当前计数，按计入本次请求后的计数判定
+	if isDryRun(ctx) {
+		count, err := h.rdb.Get(ctx, key).Int64()
+		if err != nil && err != redis.Nil {
+			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("redis操作失败: %v", err))
+		}
+		return count+1 > params.MaxReqs, nil
+	}
+
 	// 使用Redis的MULTI/EXEC保证原子性
 	pipe := h.rdb.Pipeline()
 
@@ -142,9 +145,7 @@ func (h *ccRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.
 }
 
 // regexRuleHandler 正则规则处理器
-type regexRuleHandler struct {
-	regexCache sync.Map // 用于缓存编译后的正则表达式
-}
+type regexRuleHandler struct{}
 
 func (h *regexRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.CheckRequest) (bool, error) {
 	if ctx == nil {
@@ -157,44 +158,371 @@ func (h *regexRuleHandler) Match(ctx context.Context, rule *model.Rule, req *mod
 		return false, errors.NewError(errors.ErrRuleEngine, "请求不能为空")
 	}
 
-	// 从缓存中获取正则表达式
-	cached, ok := h.regexCache.Load(rule.ID)
-	var re *regexp.Regexp
-	var err error
+	// 使用规则快照加载时预编译的正则
+	re, err := compiledRegexp(ctx, rule.Pattern)
+	if err != nil {
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("编译正则表达式失败: %v", err))
+	}
 
-	if !ok {
-		// 如果缓存中没有，则编译并存储
-		re, err = regexp.Compile(rule.Pattern)
+	// 请求字段选择器
+	if values, ok, err := targetValues(ctx, rule.RuleVariable, req); ok {
 		if err != nil {
-			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("编译正则表达式失败: %v", err))
+			return false, err
 		}
-		h.regexCache.Store(rule.ID, re)
-	} else {
-		re = cached.(*regexp.Regexp)
+		for _, v := range values {
+			if re.MatchString(v.Value) {
+				return true, nil
+			}
+		}
+		return false, nil
 	}
 
 	// 根据规则变量类型检查不同的请求部分
 	switch rule.RuleVariable {
-	case model.RuleVarRequestURI:
-		return re.MatchString(req.URI), nil
-	case model.RuleVarRequestHeaders:
-		for _, v := range req.Headers {
-			if re.MatchString(v) {
+	case model.RuleVarRequestBodyErr:
+		return req.RequestBody != nil && req.RequestBody.Error != "" && re.MatchString(req.RequestBody.Error), nil
+	case model.RuleVarGeoCountry, model.RuleVarGeoRegion, model.RuleVarGeoASN:
+		value, ok := geoField(req, rule.RuleVariable)
+		return ok && re.MatchString(value), nil
+	case model.RuleVarBotCategory, model.RuleVarBotName:
+		value, ok := botField(req, rule.RuleVariable)
+		return ok && re.MatchString(value), nil
+	case model.RuleVarJA3, model.RuleVarJA4, model.RuleVarHTTP2:
+		value, ok := fingerprintField(req, rule.RuleVariable)
+		return ok && re.MatchString(value), nil
+	case model.RuleVarResponse, model.RuleVarResponseStatus, model.RuleVarResponseHeaders, model.RuleVarResponseBody:
+		for _, value := range responseFields(req, rule.RuleVariable) {
+			if re.MatchString(value) {
 				return true, nil
 			}
 		}
-	case model.RuleVarRequestArgs:
-		for _, v := range req.Args {
-			if re.MatchString(v) {
+	default:
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
+	}
+
+	return false, nil
+}
+
+// geoRuleHandler 地理位置/ASN规则处理器
+// Pattern 为逗号分隔的取值列表（如 "CN,RU" 或 "AS4134,4837"），
+// Params 可选 {"uri_pattern": "^/admin"} 限定生效的URI
+type geoRuleHandler struct{}
+
+func (h *geoRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.CheckRequest) (bool, error) {
+	if ctx == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "上下文不能为空")
+	}
+	if rule == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "规则不能为空")
+	}
+	if req == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "请求不能为空")
+	}
+
+	switch rule.RuleVariable {
+	case model.RuleVarGeoCountry, model.RuleVarGeoRegion, model.RuleVarGeoASN:
+	default:
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
+	}
+
+	// 检查URI生效范围
+	if inScope, err := matchURIScope(ctx, rule.Params, req.URI); err != nil || !inScope {
+		return false, err
+	}
+
+	value, ok := geoField(req, rule.RuleVariable)
+	if !ok {
+		return false, nil
+	}
+
+	for _, item := range strings.Split(rule.Pattern, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if rule.RuleVariable == model.RuleVarGeoASN {
+			asn, err := model.ParseASN(item)
+			if err != nil {
+				return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("无效的ASN规则: %v", err))
+			}
+			item = strconv.FormatUint(asn, 10)
+		}
+		if strings.EqualFold(item, value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchURIScope 检查请求URI是否在规则参数 uri_pattern 限定的范围内，未配置时始终生效
+func matchURIScope(ctx context.Context, rawParams, uri string) (bool, error) {
+	if rawParams == "" {
+		return true, nil
+	}
+	var params struct {
+		URIPattern string `json:"uri_pattern"` // 生效的URI正则
+	}
+	if err := json.Unmarshal([]byte(rawParams), &params); err != nil {
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("解析规则参数失败: %v", err))
+	}
+	if params.URIPattern == "" {
+		return true, nil
+	}
+
+	re, err := compiledRegexp(ctx, params.URIPattern)
+	if err != nil {
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("编译URI正则表达式失败: %v", err))
+	}
+	return re.MatchString(uri), nil
+}
+
+// geoField 获取请求中的地理位置字段，未知时返回false
+func geoField(req *model.CheckRequest, variable model.RuleVariable) (string, bool) {
+	if req.Geo == nil {
+		return "", false
+	}
+	switch variable {
+	case model.RuleVarGeoCountry:
+		return req.Geo.Country, req.Geo.Country != ""
+	case model.RuleVarGeoRegion:
+		return req.Geo.Region, req.Geo.Region != ""
+	case model.RuleVarGeoASN:
+		return strconv.FormatUint(req.Geo.ASN, 10), req.Geo.ASN > 0
+	}
+	return "", false
+}
+
+// botRuleHandler 机器人规则处理器
+// 规则变量为分类或签名名称时 Pattern 为逗号分隔的取值列表（如 "scanner,fake_crawler"），
+// 为评分时 Pattern 为评分阈值，评分大于等于阈值即命中；
+// Params 可选 {"uri_pattern": "^/login"} 限定生效的URI
+type botRuleHandler struct{}
+
+func (h *botRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.CheckRequest) (bool, error) {
+	if ctx == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "上下文不能为空")
+	}
+	if rule == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "规则不能为空")
+	}
+	if req == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "请求不能为空")
+	}
+
+	switch rule.RuleVariable {
+	case model.RuleVarBotCategory, model.RuleVarBotName, model.RuleVarBotScore:
+	default:
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
+	}
+
+	// 检查URI生效范围
+	if inScope, err := matchURIScope(ctx, rule.Params, req.URI); err != nil || !inScope {
+		return false, err
+	}
+
+	if req.Bot == nil {
+		return false, nil
+	}
+
+	if rule.RuleVariable == model.RuleVarBotScore {
+		threshold, err := strconv.Atoi(strings.TrimSpace(rule.Pattern))
+		if err != nil {
+			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("无效的机器人评分阈值: %s", rule.Pattern))
+		}
+		return req.Bot.Score >= threshold, nil
+	}
+
+	value, ok := botField(req, rule.RuleVariable)
+	if !ok {
+		return false, nil
+	}
+	for _, item := range strings.Split(rule.Pattern, ",") {
+		if item = strings.TrimSpace(item); item != "" && strings.EqualFold(item, value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// botField 获取请求中的客户端分类字段，未知时返回false
+func botField(req *model.CheckRequest, variable model.RuleVariable) (string, bool) {
+	if req.Bot == nil {
+		return "", false
+	}
+	switch variable {
+	case model.RuleVarBotCategory:
+		return string(req.Bot.Category), req.Bot.Category != ""
+	case model.RuleVarBotName:
+		return req.Bot.Name, req.Bot.Name != ""
+	case model.RuleVarBotScore:
+		return strconv.Itoa(req.Bot.Score), true
+	}
+	return "", false
+}
+
+// 指纹匹配操作符
+const (
+	fingerprintOpIn       = "in"       // 等于列表中任一值（默认）
+	fingerprintOpPrefix   = "prefix"   // 以列表中任一值开头，如JA4的 "t13d" 段
+	fingerprintOpContains = "contains" // 包含列表中任一值
+	fingerprintOpRegex    = "regex"    // 正则匹配
+)
+
+// fingerprintRuleHandler 客户端指纹规则处理器
+// Pattern 为逗号分隔的指纹列表（regex操作符时为正则），
+// Params 可选 {"operator": "in|prefix|contains|regex", "uri_pattern": "^/api"}
+type fingerprintRuleHandler struct{}
+
+func (h *fingerprintRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.CheckRequest) (bool, error) {
+	if ctx == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "上下文不能为空")
+	}
+	if rule == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "规则不能为空")
+	}
+	if req == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "请求不能为空")
+	}
+
+	switch rule.RuleVariable {
+	case model.RuleVarJA3, model.RuleVarJA4, model.RuleVarHTTP2:
+	default:
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
+	}
+
+	// 解析规则参数
+	operator := fingerprintOpIn
+	if rule.Params != "" {
+		var params struct {
+			Operator string `json:"operator"` // 匹配操作符
+		}
+		if err := json.Unmarshal([]byte(rule.Params), &params); err != nil {
+			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("解析指纹规则参数失败: %v", err))
+		}
+		if params.Operator != "" {
+			operator = params.Operator
+		}
+	}
+
+	// 检查URI生效范围
+	if inScope, err := matchURIScope(ctx, rule.Params, req.URI); err != nil || !inScope {
+		return false, err
+	}
+
+	value, ok := fingerprintField(req, rule.RuleVariable)
+	if !ok {
+		return false, nil
+	}
+
+	if operator == fingerprintOpRegex {
+		re, err := compiledRegexp(ctx, rule.Pattern)
+		if err != nil {
+			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("编译指纹正则表达式失败: %v", err))
+		}
+		return re.MatchString(value), nil
+	}
+
+	for _, item := range strings.Split(rule.Pattern, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		switch operator {
+		case fingerprintOpIn:
+			if strings.EqualFold(value, item) {
 				return true, nil
 			}
+		case fingerprintOpPrefix:
+			if strings.HasPrefix(value, item) {
+				return true, nil
+			}
+		case fingerprintOpContains:
+			if strings.Contains(value, item) {
+				return true, nil
+			}
+		default:
+			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的指纹匹配操作符: %s", operator))
+		}
+	}
+	return false, nil
+}
+
+// fingerprintField 获取请求中的指纹字段，未知时返回false
+func fingerprintField(req *model.CheckRequest, variable model.RuleVariable) (string, bool) {
+	if req.Fingerprint == nil {
+		return "", false
+	}
+	switch variable {
+	case model.RuleVarJA3:
+		return req.Fingerprint.JA3, req.Fingerprint.JA3 != ""
+	case model.RuleVarJA4:
+		return req.Fingerprint.JA4, req.Fingerprint.JA4 != ""
+	case model.RuleVarHTTP2:
+		return req.Fingerprint.HTTP2, req.Fingerprint.HTTP2 != ""
+	}
+	return "", false
+}
+
+// responseFields 获取请求中的响应字段，非响应阶段时返回空
+func responseFields(req *model.CheckRequest, variable model.RuleVariable) []string {
+	if req.Response == nil {
+		return nil
+	}
+	var values []string
+	switch variable {
+	case model.RuleVarResponseStatus:
+		values = append(values, strconv.Itoa(req.Response.StatusCode))
+	case model.RuleVarResponseHeaders, model.RuleVarResponse:
+		for _, v := range req.Response.Headers {
+			values = append(values, v)
 		}
-	case model.RuleVarRequestBody:
-		return re.MatchString(req.Body), nil
+	}
+	if variable == model.RuleVarResponseBody || variable == model.RuleVarResponse {
+		values = append(values, req.Response.Body)
+	}
+	return values
+}
+
+// leakageRuleHandler 响应信息泄露规则处理器
+// Pattern 为逗号分隔的检测器列表（stack_trace,sql_error,card_number,internal_ip），"all" 表示全部检测器；
+// 规则变量为空时检查响应体，Params 可选 {"uri_pattern": "^/api"} 限定生效的URI
+type leakageRuleHandler struct{}
+
+func (h *leakageRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model.CheckRequest) (bool, error) {
+	if ctx == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "上下文不能为空")
+	}
+	if rule == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "规则不能为空")
+	}
+	if req == nil {
+		return false, errors.NewError(errors.ErrRuleEngine, "请求不能为空")
+	}
+
+	variable := rule.RuleVariable
+	switch variable {
+	case "":
+		variable = model.RuleVarResponseBody
+	case model.RuleVarResponse, model.RuleVarResponseHeaders, model.RuleVarResponseBody:
 	default:
 		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
 	}
 
+	// 检查URI生效范围
+	if inScope, err := matchURIScope(ctx, rule.Params, req.URI); err != nil || !inScope {
+		return false, err
+	}
+
+	detectors := strings.Split(rule.Pattern, ",")
+	for _, value := range responseFields(req, variable) {
+		findings, err := leakage.Scan(value, detectors)
+		if err != nil {
+			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("信息泄露检测失败: %v", err))
+		}
+		if len(findings) > 0 {
+			return true, nil
+		}
+	}
 	return false, nil
 }
 
@@ -214,29 +542,19 @@ func (h *sqlInjectionRuleHandler) Match(ctx context.Context, rule *model.Rule, r
 
 	detector := model.NewSQLInjectionDetector()
 
-	switch rule.RuleVariable {
-	case model.RuleVarRequestURI:
-		if isInjection, err := detector.DetectInjection(req.URI); err != nil {
-			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检测SQL注入失败: %v", err))
-		} else if isInjection {
-			return true, nil
-		}
-	case model.RuleVarRequestArgs:
-		for _, v := range req.Args {
-			if isInjection, err := detector.DetectInjection(v); err != nil {
-				return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检测SQL注入失败: %v", err))
-			} else if isInjection {
-				return true, nil
-			}
-		}
-	case model.RuleVarRequestBody:
-		if isInjection, err := detector.DetectInjection(req.Body); err != nil {
+	values, ok, err := targetValues(ctx, rule.RuleVariable, req)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
+	}
+	for _, v := range values {
+		if isInjection, err := detector.DetectInjection(v.Value); err != nil {
 			return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检测SQL注入失败: %v", err))
 		} else if isInjection {
 			return true, nil
 		}
-	default:
-		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
 	}
 
 	return false, nil
@@ -256,29 +574,34 @@ func (h *xssRuleHandler) Match(ctx context.Context, rule *model.Rule, req *model
 		return false, errors.NewError(errors.ErrRuleEngine, "请求不能为空")
 	}
 
-	// 根据规则变量类型检查不同的请求部分
-	switch rule.RuleVariable {
-	case model.RuleVarRequestURI:
-		if containsXSS(req.URI) {
-			return true, nil
-		}
-	case model.RuleVarRequestArgs:
-		for _, v := range req.Args {
-			if containsXSS(v) {
-				return true, nil
-			}
-		}
-	case model.RuleVarRequestBody:
-		if containsXSS(req.Body) {
+	values, ok, err := targetValues(ctx, rule.RuleVariable, req)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
+	}
+	for _, v := range values {
+		if containsXSS(v.Value) {
 			return true, nil
 		}
-	default:
-		return false, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("不支持的规则变量类型: %s", rule.RuleVariable))
 	}
 
 	return false, nil
 }
 
+// targetValues 取出规则变量选择的请求字段，规则变量不是请求字段选择器时返回false
+func targetValues(ctx context.Context, variable model.RuleVariable, req *model.CheckRequest) ([]model.TargetValue, bool, error) {
+	if !model.IsTargetVariable(variable) {
+		return nil, false, nil
+	}
+	selector, err := targetSelector(ctx, variable)
+	if err != nil {
+		return nil, true, err
+	}
+	return selector.Values(req), true, nil
+}
+
 // containsXSS 检查是否包含XSS攻击
 func containsXSS(input string) bool {
 	patterns := []string{
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+)
+
+// 健康检查默认配置
+const (
+	defaultHealthTimeout         = 2 * time.Second
+	defaultHealthDegradedLatency = 500 * time.Millisecond
+	defaultHealthMaxSnapshotAge  = 5 * time.Minute
+)
+
+// HealthService 健康检查服务接口
+type HealthService interface {
+	// Liveness 进程存活，不检查依赖
+	Liveness() *model.HealthReport
+	// Readiness 检查所有组件，关键组件不可用时未就绪
+	Readiness(ctx context.Context) *model.HealthReport
+}
+
+// HealthCheck 组件健康检查，Check返回的Status为空时按是否返回错误判定
+type HealthCheck struct {
+	Name     string
+	Critical bool // 关键组件不可用时服务未就绪
+	Check    func(ctx context.Context) (*model.ComponentHealth, error)
+}
+
+// HealthOptions 健康检查配置
+type HealthOptions struct {
+	NodeID          string        // 节点标识，用于指标标签
+	Timeout         time.Duration // 单个组件检查超时
+	DegradedLatency time.Duration // 检查耗时超过该值时记为degraded
+}
+
+// healthService 健康检查服务实现
+type healthService struct {
+	opts    HealthOptions
+	checks  []HealthCheck
+	started time.Time
+}
+
+// NewHealthService 创建健康检查服务，配置项为0时使用默认值
+func NewHealthService(opts HealthOptions, checks ...HealthCheck) HealthService {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultHealthTimeout
+	}
+	if opts.DegradedLatency <= 0 {
+		opts.DegradedLatency = defaultHealthDegradedLatency
+	}
+	return &healthService{
+		opts:    opts,
+		checks:  checks,
+		started: time.Now(),
+	}
+}
+
+// Liveness 进程存活
+func (s *healthService) Liveness() *model.HealthReport {
+	now := time.Now()
+	return &model.HealthReport{
+		Status:    model.HealthUp,
+		Ready:     true,
+		NodeID:    s.opts.NodeID,
+		Uptime:    int64(now.Sub(s.started).Seconds()),
+		CheckedAt: now,
+	}
+}
+
+// Readiness 并发检查所有组件
+// 任一关键组件不可用时状态为down且未就绪；非关键组件不可用或任一组件降级时状态为degraded
+func (s *healthService) Readiness(ctx context.Context) *model.HealthReport {
+	components := make([]*model.ComponentHealth, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			components[i] = s.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	report := &model.HealthReport{
+		Status:     model.HealthUp,
+		Ready:      true,
+		NodeID:     s.opts.NodeID,
+		Uptime:     int64(now.Sub(s.started).Seconds()),
+		CheckedAt:  now,
+		Components: components,
+	}
+	for _, c := range components {
+		switch {
+		case c.Status == model.HealthDown && c.Critical:
+			report.Ready = false
+			report.Status = model.HealthDown
+		case c.Status != model.HealthUp && report.Status == model.HealthUp:
+			report.Status = model.HealthDegraded
+		}
+	}
+	return report
+}
+
+// run 执行单个检查，超时未返回时记为down
+func (s *healthService) run(ctx context.Context, check HealthCheck) *model.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	type result struct {
+		health *model.ComponentHealth
+		err    error
+	}
+	start := time.Now()
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("检查异常: %v", r)}
+			}
+		}()
+		health, err := check.Check(ctx)
+		done <- result{health: health, err: err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("检查超时(%s)", s.opts.Timeout)
+	}
+	latency := time.Since(start)
+
+	health := res.health
+	if health == nil {
+		health = &model.ComponentHealth{}
+	}
+	health.Name = check.Name
+	health.Critical = check.Critical
+	health.LatencyMs = latency.Milliseconds()
+	switch {
+	case res.err != nil:
+		health.Status = model.HealthDown
+		health.Message = res.err.Error()
+	case health.Status == "":
+		health.Status = model.HealthUp
+	}
+	if health.Status == model.HealthUp && latency > s.opts.DegradedLatency {
+		health.Status = model.HealthDegraded
+		health.Message = fmt.Sprintf("检查耗时%s超过%s", latency.Round(time.Millisecond), s.opts.DegradedLatency)
+	}
+	metrics.RecordComponentHealth(check.Name, s.opts.NodeID, health.Status != model.HealthDown, latency)
+	return health
+}
+
+// PingCheck 通过ping检查依赖连通性，如MySQL、Redis
+func PingCheck(name string, critical bool, ping func(ctx context.Context) error) HealthCheck {
+	return HealthCheck{
+		Name:     name,
+		Critical: critical,
+		Check: func(ctx context.Context) (*model.ComponentHealth, error) {
+			return nil, ping(ctx)
+		},
+	}
+}
+
+// RuleSnapshotCheck 检查规则快照，未加载时不可用，快照过期、同步失败或落后数据库版本时降级
+func RuleSnapshotCheck(rules RuleService, maxAge time.Duration) HealthCheck {
+	if maxAge <= 0 {
+		maxAge = defaultHealthMaxSnapshotAge
+	}
+	return HealthCheck{
+		Name:     "rule_snapshot",
+		Critical: true,
+		Check: func(ctx context.Context) (*model.ComponentHealth, error) {
+			status := rules.SnapshotStatus()
+			if !status.Loaded {
+				if status.LastError != "" {
+					return nil, fmt.Errorf("规则快照未加载: %s", status.LastError)
+				}
+				return nil, fmt.Errorf("规则快照未加载")
+			}
+
+			age := time.Since(status.LoadedAt)
+			health := &model.ComponentHealth{
+				Status: model.HealthUp,
+				Details: map[string]interface{}{
+					"version":        status.Version,
+					"latest_version": status.LatestVersion,
+					"version_lag":    status.VersionLag(),
+					"rule_count":     status.RuleCount,
+					"loaded_at":      status.LoadedAt,
+					"checked_at":     status.CheckedAt,
+					"age_seconds":    int64(age.Seconds()),
+				},
+			}
+			switch {
+			case age > maxAge:
+				health.Status = model.HealthDegraded
+				health.Message = fmt.Sprintf("规则快照已%s未更新", age.Round(time.Second))
+			case status.LastError != "":
+				health.Status = model.HealthDegraded
+				health.Message = fmt.Sprintf("规则同步失败: %s", status.LastError)
+			case status.VersionLag() > 0:
+				health.Status = model.HealthDegraded
+				health.Message = fmt.Sprintf("规则快照落后数据库%d个版本", status.VersionLag())
+			}
+			return health, nil
+		},
+	}
+}
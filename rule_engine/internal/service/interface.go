@@ -19,14 +19,33 @@ type RuleService interface {
 	BatchDeleteRules(ctx context.Context, ids []int64) error
 	GetRule(ctx context.Context, id int64) (*model.Rule, error)
 	ListRules(ctx context.Context, query *repository.RuleQuery) ([]*model.Rule, int64, error)
+	ListExpiringRules(ctx context.Context, within time.Duration) ([]*model.RuleExpiration, error)
 
 	// 规则检查
 	CheckRequest(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error)
+	// DryRunRequest 无副作用的规则检查，判定与CheckRequest相同，但不累加CC计数，
+	// 不记录旁路尝试、上传违规事件、响应阶段请求信息和判定指标，用于批量检查和日志回放
+	DryRunRequest(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error)
+
+	// 规则静态检查，报告正则代价、被覆盖的规则和允许/阻止冲突
+	LintRules(ctx context.Context) (*model.LintReport, error)
+	LintRule(ctx context.Context, rule *model.Rule) (*model.LintReport, error)
+	LintChanges(ctx context.Context, candidates []*model.Rule, deletedIDs []int64) (*model.LintReport, error)
+
+	// 规则测试，使用规则处理器执行测试用例，不保存规则
+	TestRule(ctx context.Context, rule *model.Rule, cases []*model.RuleTestCase) ([]*model.RuleTestResult, error)
 
 	// 规则同步
 	ReloadRules(ctx context.Context) error
 	GetVersion(ctx context.Context) (int64, error)
 
+	// 规则快照，检查使用内存中按优先级排序的启用规则
+	LoadSnapshot(ctx context.Context) error
+	SnapshotStatus() *model.RuleSnapshotStatus
+	RunSync(ctx context.Context, syncInterval, checkInterval time.Duration)
+	// SetSyncIntervals 修改运行中RunSync的同步间隔，值<=0的间隔保持不变
+	SetSyncIntervals(syncInterval, checkInterval time.Duration)
+
 	// 规则导入导出
 	ImportRules(ctx context.Context, rules []*model.Rule) error
 	ExportRules(ctx context.Context, query *repository.RuleQuery) ([]*model.Rule, error)
@@ -52,3 +71,22 @@ type RuleHandler interface {
 	// 规则匹配
 	Match(ctx context.Context, rule *model.Rule, req *model.CheckRequest) (bool, error)
 }
+
+// RequestEnricher 请求信息补充接口，在规则匹配前为请求填充地理位置等派生字段
+type RequestEnricher interface {
+	Enrich(ctx context.Context, req *model.CheckRequest) error
+}
+
+// ListChecker 名单检查接口，在规则匹配前按IP、地理位置和指纹名单给出判定
+type ListChecker interface {
+	// CheckLists 未命中任何名单时返回nil
+	CheckLists(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error)
+	// LoadLists 加载名单到内存，随规则快照一起刷新
+	LoadLists(ctx context.Context) error
+}
+
+// BypassChecker 旁路检查接口，在名单和规则检查前判定请求是否旁路
+type BypassChecker interface {
+	// CheckBypass 未满足任何旁路配置时返回nil
+	CheckBypass(ctx context.Context, req *model.CheckRequest) (*model.BypassDecision, error)
+}
@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/xwaf/rule_engine/internal/errors"
@@ -21,12 +24,22 @@ type IPRuleService interface {
 	IsIPBlocked(ctx context.Context, ip string) (bool, error)
 	IsIPWhitelisted(ctx context.Context, ip string) (bool, error)
 	CheckIP(ctx context.Context, ip string) (bool, error)
+	IsGeoBlocked(ctx context.Context, geo *model.GeoInfo) (bool, error)
+	CheckLists(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error)
+	LoadLists(ctx context.Context) error
 }
 
+// listLoadPageSize 加载名单时每页读取的条目数
+const listLoadPageSize = 1000
+
+// listIndex 名单条目索引，按条目类型和值查找，加载后只读
+type listIndex map[model.IPEntryType]map[string]*model.IPRule
+
 // ipRuleService IP规则服务实现
 type ipRuleService struct {
 	ipRepo    repository.IPRuleRepository
 	cacheRepo repository.CacheRepository
+	index     atomic.Value // listIndex，未加载时逐条查询数据库
 }
 
 // NewIPRuleService 创建IP规则服务
@@ -62,6 +75,7 @@ func (s *ipRuleService) CreateIPRule(ctx context.Context, rule *model.IPRule) er
 	if err := s.updateIPRuleCache(ctx, rule); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("更新IP规则缓存失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	return nil
 }
@@ -91,6 +105,7 @@ func (s *ipRuleService) UpdateIPRule(ctx context.Context, rule *model.IPRule) er
 	if err := s.updateIPRuleCache(ctx, rule); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("更新IP规则缓存失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	return nil
 }
@@ -106,6 +121,7 @@ func (s *ipRuleService) DeleteIPRule(ctx context.Context, id int64) error {
 	if err := s.deleteIPRuleCache(ctx, id); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("删除IP规则缓存失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	return nil
 }
@@ -202,6 +218,146 @@ func (s *ipRuleService) CheckIP(ctx context.Context, ip string) (bool, error) {
 	return false, nil
 }
 
+// IsGeoBlocked 检查来源国家或ASN是否在黑名单中，白名单条目优先
+func (s *ipRuleService) IsGeoBlocked(ctx context.Context, geo *model.GeoInfo) (bool, error) {
+	white, black, err := s.matchListEntries(ctx, listEntries(&model.CheckRequest{Geo: geo}))
+	if err != nil {
+		return false, err
+	}
+	return white == nil && black != nil, nil
+}
+
+// CheckLists 按IP、国家、ASN和指纹名单检查请求，白名单优先，未命中任何名单时返回nil
+func (s *ipRuleService) CheckLists(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error) {
+	white, black, err := s.matchListEntries(ctx, listEntries(req))
+	if err != nil {
+		return nil, err
+	}
+	if white != nil {
+		return &model.CheckResult{
+			Matched: true,
+			Action:  model.ActionAllow,
+			Source:  model.CheckSourceList,
+			Message: fmt.Sprintf("命中白名单: %s=%s", white.EntryType, white.IP),
+		}, nil
+	}
+	if black != nil {
+		return &model.CheckResult{
+			Matched: true,
+			Action:  model.ActionBlock,
+			Source:  model.CheckSourceList,
+			Message: fmt.Sprintf("命中黑名单: %s=%s", black.EntryType, black.IP),
+		}, nil
+	}
+	return nil, nil
+}
+
+// listEntry 待检查的名单条目
+type listEntry struct {
+	entryType model.IPEntryType
+	value     string
+}
+
+// listEntries 从请求中提取需要检查的名单条目
+func listEntries(req *model.CheckRequest) []listEntry {
+	var entries []listEntry
+	if req.ClientIP != "" {
+		entries = append(entries, listEntry{model.IPEntryTypeIP, req.ClientIP})
+	}
+	if geo := req.Geo; geo != nil {
+		if geo.Country != "" {
+			entries = append(entries, listEntry{model.IPEntryTypeCountry, geo.Country})
+		}
+		if geo.ASN > 0 {
+			entries = append(entries, listEntry{model.IPEntryTypeASN, strconv.FormatUint(geo.ASN, 10)})
+		}
+	}
+	if fp := req.Fingerprint; fp != nil {
+		if fp.JA3 != "" {
+			entries = append(entries, listEntry{model.IPEntryTypeJA3, strings.ToLower(fp.JA3)})
+		}
+		if fp.JA4 != "" {
+			entries = append(entries, listEntry{model.IPEntryTypeJA4, fp.JA4})
+		}
+		if fp.HTTP2 != "" {
+			entries = append(entries, listEntry{model.IPEntryTypeHTTP2, fp.HTTP2})
+		}
+	}
+	return entries
+}
+
+// matchListEntries 查找命中的白名单和生效中的黑名单条目
+func (s *ipRuleService) matchListEntries(ctx context.Context, entries []listEntry) (*model.IPRule, *model.IPRule, error) {
+	var black *model.IPRule
+	for _, entry := range entries {
+		rule, err := s.getEntryRule(ctx, entry.entryType, entry.value)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rule == nil {
+			continue
+		}
+		switch rule.IPType {
+		case model.IPListTypeWhite:
+			return rule, nil, nil
+		case model.IPListTypeBlack:
+			if black == nil && (rule.BlockType == model.BlockTypePermanent || time.Now().Before(rule.ExpireTime)) {
+				black = rule
+			}
+		}
+	}
+	return nil, black, nil
+}
+
+// LoadLists 从数据库加载全部名单条目，加载后检查请求时只在内存中匹配
+// 随规则快照一起定时刷新，本实例修改名单后立即刷新
+func (s *ipRuleService) LoadLists(ctx context.Context) error {
+	index := make(listIndex)
+	for offset := 0; ; offset += listLoadPageSize {
+		rules, total, err := s.ipRepo.ListIPRules(ctx, &model.IPRuleQuery{}, offset, listLoadPageSize)
+		if err != nil {
+			return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("加载名单失败: %v", err))
+		}
+		for _, rule := range rules {
+			entryType := rule.EntryType
+			if entryType == "" {
+				entryType = model.IPEntryTypeIP
+			}
+			if index[entryType] == nil {
+				index[entryType] = make(map[string]*model.IPRule)
+			}
+			index[entryType][rule.IP] = rule
+		}
+		if len(rules) < listLoadPageSize || int64(offset+len(rules)) >= total {
+			break
+		}
+	}
+	s.index.Store(index)
+	return nil
+}
+
+// reloadAfterWrite 名单写入后刷新索引，失败时由定时同步重试
+func (s *ipRuleService) reloadAfterWrite(ctx context.Context) {
+	if err := s.LoadLists(ctx); err != nil {
+		logger.Errorf("刷新名单失败: %v", err)
+	}
+}
+
+// getEntryRule 获取名单条目，不存在时返回nil；名单已加载时不查询数据库
+func (s *ipRuleService) getEntryRule(ctx context.Context, entryType model.IPEntryType, value string) (*model.IPRule, error) {
+	if index, ok := s.index.Load().(listIndex); ok {
+		return index[entryType][value], nil
+	}
+	rule, err := s.ipRepo.GetIPRuleByEntry(ctx, entryType, value)
+	if err != nil {
+		if e, ok := err.(*errors.Error); ok && e.IsNotFound() {
+			return nil, nil
+		}
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("获取名单条目失败: %v", err))
+	}
+	return rule, nil
+}
+
 // 缓存相关的辅助方法
 func (s *ipRuleService) updateIPRuleCache(ctx context.Context, rule *model.IPRule) error {
 	key := fmt.Sprintf("ip_rule:%d", rule.ID)
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+)
+
+const (
+	defaultNodeHeartbeatInterval = 30 * time.Second
+	defaultNodeStaleMissed       = 3
+	defaultNodeDownMissed        = 6
+)
+
+// RuleVersionSource 引擎当前规则版本，RuleService实现该接口
+type RuleVersionSource interface {
+	GetVersion(ctx context.Context) (int64, error)
+}
+
+// NodeService WAF节点服务接口
+type NodeService interface {
+	// Register 注册节点，已注册的节点需提供相同的节点令牌，重新注册时更新节点信息并标记为在线
+	Register(ctx context.Context, reg *model.NodeRegistration) (*model.NodeHeartbeatAck, error)
+
+	// Heartbeat 记录节点心跳，令牌不一致时返回ErrAuthFailed
+	Heartbeat(ctx context.Context, nodeID, token string, hb *model.NodeHeartbeat) (*model.NodeHeartbeatAck, error)
+
+	// 节点查询，返回的节点包含按心跳时间计算的状态和落后版本数
+	GetNode(ctx context.Context, nodeID string) (*model.Node, error)
+	ListNodes(ctx context.Context, status model.NodeStatus) ([]*model.Node, error)
+	DeleteNode(ctx context.Context, nodeID string) error
+
+	// FleetStatus 统计节点状态、版本差异和同步错误
+	FleetStatus(ctx context.Context) (*model.FleetStatus, error)
+
+	// Run 定期将错过心跳的节点标记为下线并更新指标，直到ctx取消
+	Run(ctx context.Context)
+}
+
+// NodeOptions 节点服务配置
+type NodeOptions struct {
+	HeartbeatInterval time.Duration // 节点心跳间隔，注册和心跳时返回给节点
+	StaleMissed       int           // 错过该次数的心跳后视为stale
+	DownMissed        int           // 错过该次数的心跳后标记为下线
+	CheckInterval     time.Duration // 检查下线节点的间隔，为0时等于心跳间隔
+	RegisterToken     string        // 注册令牌，为空时不检查
+}
+
+// nodeService 节点服务实现
+type nodeService struct {
+	repo     repository.NodeRepository
+	versions RuleVersionSource
+	opts     NodeOptions
+}
+
+// NewNodeService 创建节点服务，配置项为0时使用默认值
+func NewNodeService(repo repository.NodeRepository, versions RuleVersionSource, opts NodeOptions) NodeService {
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = defaultNodeHeartbeatInterval
+	}
+	if opts.StaleMissed <= 0 {
+		opts.StaleMissed = defaultNodeStaleMissed
+	}
+	if opts.DownMissed <= opts.StaleMissed {
+		opts.DownMissed = opts.StaleMissed + defaultNodeDownMissed - defaultNodeStaleMissed
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = opts.HeartbeatInterval
+	}
+	return &nodeService{
+		repo:     repo,
+		versions: versions,
+		opts:     opts,
+	}
+}
+
+// Register 注册节点
+func (s *nodeService) Register(ctx context.Context, reg *model.NodeRegistration) (*model.NodeHeartbeatAck, error) {
+	if err := reg.Validate(); err != nil {
+		return nil, err
+	}
+	if s.opts.RegisterToken != "" &&
+		subtle.ConstantTimeCompare([]byte(reg.RegisterToken), []byte(s.opts.RegisterToken)) != 1 {
+		logger.Warnf("节点注册令牌错误: NodeID=%s, Address=%s", reg.NodeID, reg.Address)
+		return nil, errors.NewError(errors.ErrAuthFailed, "注册令牌错误")
+	}
+
+	now := time.Now()
+	node, err := s.repo.GetNode(ctx, reg.NodeID)
+	if err != nil {
+		if e, ok := err.(*errors.Error); !ok || !e.IsNotFound() {
+			return nil, err
+		}
+		node = &model.Node{
+			NodeID:          reg.NodeID,
+			TokenHash:       hashNodeToken(reg.Token),
+			Hostname:        reg.Hostname,
+			Address:         reg.Address,
+			AgentVersion:    reg.AgentVersion,
+			Status:          model.NodeOnline,
+			LastHeartbeatAt: &now,
+			RegisteredAt:    now,
+		}
+		if err := s.repo.CreateNode(ctx, node); err != nil {
+			return nil, err
+		}
+		logger.Infof("节点注册: NodeID=%s, Hostname=%s, Address=%s, AgentVersion=%s",
+			node.NodeID, node.Hostname, node.Address, node.AgentVersion)
+		return s.ack(ctx, node), nil
+	}
+
+	if !checkNodeToken(node, reg.Token) {
+		logger.Warnf("节点令牌错误: NodeID=%s, Address=%s", reg.NodeID, reg.Address)
+		return nil, errors.NewError(errors.ErrAuthFailed, fmt.Sprintf("节点令牌错误: %s", reg.NodeID))
+	}
+	node.Hostname = reg.Hostname
+	node.Address = reg.Address
+	node.AgentVersion = reg.AgentVersion
+	node.Status = model.NodeOnline
+	node.LastHeartbeatAt = &now
+	if err := s.repo.UpdateNode(ctx, node); err != nil {
+		return nil, err
+	}
+	logger.Infof("节点重新注册: NodeID=%s, Hostname=%s, Address=%s, AgentVersion=%s",
+		node.NodeID, node.Hostname, node.Address, node.AgentVersion)
+	return s.ack(ctx, node), nil
+}
+
+// Heartbeat 记录节点心跳
+func (s *nodeService) Heartbeat(ctx context.Context, nodeID, token string, hb *model.NodeHeartbeat) (*model.NodeHeartbeatAck, error) {
+	if err := model.ValidateNodeID(nodeID); err != nil {
+		return nil, err
+	}
+	if err := hb.Validate(); err != nil {
+		return nil, err
+	}
+	node, err := s.repo.GetNode(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if !checkNodeToken(node, token) {
+		logger.Warnf("节点令牌错误: NodeID=%s", nodeID)
+		return nil, errors.NewError(errors.ErrAuthFailed, fmt.Sprintf("节点令牌错误: %s", nodeID))
+	}
+
+	now := time.Now()
+	if node.Status == model.NodeDown {
+		logger.Infof("节点恢复在线: NodeID=%s, LastHeartbeat=%v", nodeID, node.LastHeartbeatAt)
+	}
+	if hb.SyncError != "" && hb.SyncError != node.SyncError {
+		logger.Warnf("节点规则同步失败: NodeID=%s, AppliedVersion=%d, Error=%s", nodeID, hb.AppliedVersion, hb.SyncError)
+	}
+	node.Status = model.NodeOnline
+	node.AppliedVersion = hb.AppliedVersion
+	node.Mode = hb.Mode
+	node.Health = hb.Health
+	if node.Health == "" {
+		node.Health = model.HealthUp
+	}
+	node.HealthMessage = hb.HealthMessage
+	node.Counters = hb.Counters
+	node.SyncError = hb.SyncError
+	if hb.SyncError != "" {
+		node.LastSyncError = hb.SyncError
+		node.LastSyncErrorAt = &now
+	}
+	node.LastHeartbeatAt = &now
+	if err := s.repo.UpdateNode(ctx, node); err != nil {
+		return nil, err
+	}
+
+	ack := s.ack(ctx, node)
+	metrics.RecordNodeHeartbeat(nodeID, hb.SyncError == "", ack.VersionLag)
+	return ack, nil
+}
+
+// GetNode 获取节点
+func (s *nodeService) GetNode(ctx context.Context, nodeID string) (*model.Node, error) {
+	node, err := s.repo.GetNode(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	s.annotate(node, s.targetVersion(ctx), time.Now())
+	return node, nil
+}
+
+// ListNodes 获取节点列表，status为空时返回全部节点
+func (s *nodeService) ListNodes(ctx context.Context, status model.NodeStatus) ([]*model.Node, error) {
+	switch status {
+	case "", model.NodeOnline, model.NodeStale, model.NodeDown:
+	default:
+		return nil, errors.NewError(errors.ErrValidation, fmt.Sprintf("无效的节点状态: %s", status))
+	}
+	nodes, err := s.repo.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, now := s.targetVersion(ctx), time.Now()
+	result := make([]*model.Node, 0, len(nodes))
+	for _, node := range nodes {
+		s.annotate(node, target, now)
+		if status == "" || node.Status == status {
+			result = append(result, node)
+		}
+	}
+	return result, nil
+}
+
+// DeleteNode 删除节点，节点下线后不再使用时调用
+func (s *nodeService) DeleteNode(ctx context.Context, nodeID string) error {
+	if err := s.repo.DeleteNode(ctx, nodeID); err != nil {
+		return err
+	}
+	metrics.DeleteNodeMetrics(nodeID)
+	logger.Infof("删除节点: NodeID=%s", nodeID)
+	return nil
+}
+
+// FleetStatus 统计节点状态
+func (s *nodeService) FleetStatus(ctx context.Context) (*model.FleetStatus, error) {
+	nodes, err := s.repo.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	status := &model.FleetStatus{
+		TargetVersion: s.targetVersion(ctx),
+		Total:         len(nodes),
+		StaleNodes:    make([]*model.Node, 0),
+		LaggingNodes:  make([]*model.Node, 0),
+		SyncErrors:    make([]*model.Node, 0),
+		CheckedAt:     now,
+	}
+
+	first := true
+	for _, node := range nodes {
+		s.annotate(node, status.TargetVersion, now)
+		switch node.Status {
+		case model.NodeOnline:
+			status.Online++
+		case model.NodeStale:
+			status.Stale++
+		case model.NodeDown:
+			status.Down++
+		}
+		if node.Status != model.NodeOnline {
+			status.StaleNodes = append(status.StaleNodes, node)
+		}
+		if node.SyncError != "" {
+			status.SyncErrors = append(status.SyncErrors, node)
+		}
+		if node.Status == model.NodeDown {
+			continue
+		}
+		if node.VersionLag > 0 {
+			status.LaggingNodes = append(status.LaggingNodes, node)
+		}
+		if node.VersionLag > status.MaxLag {
+			status.MaxLag = node.VersionLag
+		}
+		if first || node.AppliedVersion < status.MinVersion {
+			status.MinVersion = node.AppliedVersion
+		}
+		if first || node.AppliedVersion > status.MaxVersion {
+			status.MaxVersion = node.AppliedVersion
+		}
+		first = false
+	}
+	status.VersionSkew = status.MaxVersion - status.MinVersion
+	metrics.RecordFleetStatus(status.Online, status.Stale, status.Down)
+	return status, nil
+}
+
+// Run 定期标记下线节点
+func (s *nodeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.markDown(ctx)
+		}
+	}
+}
+
+// markDown 将错过心跳次数达到阈值的节点标记为下线，并更新节点状态指标
+func (s *nodeService) markDown(ctx context.Context) {
+	before := time.Now().Add(-time.Duration(s.opts.DownMissed) * s.opts.HeartbeatInterval)
+	marked, err := s.repo.MarkDown(ctx, before)
+	if err != nil {
+		logger.Errorf("标记下线节点失败: %v", err)
+		return
+	}
+	for _, nodeID := range marked {
+		logger.Warnf("节点心跳超时，标记为下线: NodeID=%s, Missed=%d", nodeID, s.opts.DownMissed)
+	}
+	if _, err := s.FleetStatus(ctx); err != nil {
+		logger.Errorf("统计节点状态失败: %v", err)
+	}
+}
+
+// annotate 计算节点的心跳错过次数、状态和落后版本数
+// 保存的状态只有online和down，错过心跳达到stale阈值但尚未标记下线的节点视为stale，
+// 达到下线阈值但后台任务尚未标记的节点也视为down
+func (s *nodeService) annotate(node *model.Node, target int64, now time.Time) {
+	if node.LastHeartbeatAt != nil {
+		if elapsed := now.Sub(*node.LastHeartbeatAt); elapsed > 0 {
+			node.MissedHeartbeats = int(elapsed / s.opts.HeartbeatInterval)
+		}
+	}
+	if node.Status != model.NodeDown {
+		switch {
+		case node.MissedHeartbeats >= s.opts.DownMissed:
+			node.Status = model.NodeDown
+		case node.MissedHeartbeats >= s.opts.StaleMissed:
+			node.Status = model.NodeStale
+		default:
+			node.Status = model.NodeOnline
+		}
+	}
+	if target > node.AppliedVersion {
+		node.VersionLag = target - node.AppliedVersion
+	}
+}
+
+// ack 生成注册和心跳的响应
+func (s *nodeService) ack(ctx context.Context, node *model.Node) *model.NodeHeartbeatAck {
+	target := s.targetVersion(ctx)
+	ack := &model.NodeHeartbeatAck{
+		NodeID:            node.NodeID,
+		Status:            node.Status,
+		TargetVersion:     target,
+		HeartbeatInterval: int64(s.opts.HeartbeatInterval / time.Second),
+	}
+	if target > node.AppliedVersion {
+		ack.VersionLag = target - node.AppliedVersion
+	}
+	return ack
+}
+
+// targetVersion 获取引擎当前的规则版本，获取失败时返回0，不计算落后版本数
+func (s *nodeService) targetVersion(ctx context.Context) int64 {
+	if s.versions == nil {
+		return 0
+	}
+	version, err := s.versions.GetVersion(ctx)
+	if err != nil {
+		logger.Warnf("获取规则版本失败，不计算节点落后版本数: %v", err)
+		return 0
+	}
+	return version
+}
+
+// hashNodeToken 计算节点令牌的SHA-256
+func hashNodeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkNodeToken 检查节点令牌
+func checkNodeToken(node *model.Node, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashNodeToken(token)), []byte(node.TokenHash)) == 1
+}
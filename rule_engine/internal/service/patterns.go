@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// compiledPatterns 规则快照中的规则预编译的正则和请求字段选择器，加载后只读，随快照一起替换和释放
+type compiledPatterns struct {
+	regexps   map[string]*regexp.Regexp
+	selectors map[model.RuleVariable]*model.TargetSelector
+}
+
+// compilePatterns 预编译规则使用的正则和请求字段选择器
+// 无效的模式不预编译，匹配时重新编译并返回错误
+func compilePatterns(rules []*model.Rule) *compiledPatterns {
+	p := &compiledPatterns{
+		regexps:   make(map[string]*regexp.Regexp),
+		selectors: make(map[model.RuleVariable]*model.TargetSelector),
+	}
+	for _, rule := range rules {
+		for _, pattern := range rulePatterns(rule) {
+			if _, ok := p.regexps[pattern]; ok {
+				continue
+			}
+			if re, err := regexp.Compile(pattern); err == nil {
+				p.regexps[pattern] = re
+			}
+		}
+		if _, ok := p.selectors[rule.RuleVariable]; ok || !model.IsTargetVariable(rule.RuleVariable) {
+			continue
+		}
+		if selector, err := model.ParseTargetSelector(rule.RuleVariable); err == nil {
+			p.selectors[rule.RuleVariable] = selector
+		}
+	}
+	return p
+}
+
+// rulePatterns 规则匹配时使用的正则：IP、正则和regex操作符的指纹规则的Pattern，以及参数中的uri_pattern
+func rulePatterns(rule *model.Rule) []string {
+	var params struct {
+		Operator   string `json:"operator"`
+		URIPattern string `json:"uri_pattern"`
+	}
+	if rule.Params != "" {
+		_ = json.Unmarshal([]byte(rule.Params), &params)
+	}
+
+	var patterns []string
+	switch rule.Type {
+	case model.RuleTypeIP, model.RuleTypeRegex:
+		patterns = append(patterns, rule.Pattern)
+	case model.RuleTypeFingerprint:
+		if params.Operator == fingerprintOpRegex {
+			patterns = append(patterns, rule.Pattern)
+		}
+	}
+	if params.URIPattern != "" {
+		patterns = append(patterns, params.URIPattern)
+	}
+	return patterns
+}
+
+// patternsKey 当前规则快照预编译结果的上下文键
+type patternsKey struct{}
+
+// withPatterns 指定本次检查使用的预编译结果
+func withPatterns(ctx context.Context, patterns *compiledPatterns) context.Context {
+	if patterns == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, patternsKey{}, patterns)
+}
+
+// compiledRegexp 获取已编译的正则，不在当前快照中时（规则测试、响应阶段检查、快照未加载）临时编译，不缓存
+func compiledRegexp(ctx context.Context, pattern string) (*regexp.Regexp, error) {
+	if patterns, ok := ctx.Value(patternsKey{}).(*compiledPatterns); ok {
+		if re, ok := patterns.regexps[pattern]; ok {
+			return re, nil
+		}
+	}
+	return regexp.Compile(pattern)
+}
+
+// targetSelector 获取规则变量解析后的请求字段选择器，不在当前快照中时临时解析
+func targetSelector(ctx context.Context, variable model.RuleVariable) (*model.TargetSelector, error) {
+	if patterns, ok := ctx.Value(patternsKey{}).(*compiledPatterns); ok {
+		if selector, ok := patterns.selectors[variable]; ok {
+			return selector, nil
+		}
+	}
+	selector, err := model.ParseTargetSelector(variable)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("解析规则变量失败: %v", err))
+	}
+	return selector, nil
+}
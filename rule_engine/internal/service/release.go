@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// ReleaseService 规则集发布服务接口
+type ReleaseService interface {
+	// Publish 将当前规则集（规则、规则组、名单、CC规则、配置）发布为不可修改的快照
+	Publish(ctx context.Context, operator int64, description string) (*model.RulesetRelease, error)
+
+	// GetRelease 获取发布及其快照
+	GetRelease(ctx context.Context, id int64) (*model.RulesetRelease, error)
+
+	// ListReleases 获取发布列表，不含快照内容
+	ListReleases(ctx context.Context, page, size int) ([]*model.RulesetRelease, int64, error)
+
+	// Diff 比较两次发布，toID为0时与当前未发布的规则集比较
+	Diff(ctx context.Context, fromID, toID int64) (*model.ReleaseDiff, error)
+
+	// Rollback 将历史发布的快照恢复为当前规则集，并作为新发布记录
+	Rollback(ctx context.Context, id, operator int64, description string) (*model.RulesetRelease, error)
+}
+
+// releaseService 规则集发布服务实现
+type releaseService struct {
+	repo        repository.ReleaseRepository
+	ruleService RuleService
+}
+
+// NewReleaseService 创建规则集发布服务，ruleService用于回滚后刷新规则缓存
+func NewReleaseService(repo repository.ReleaseRepository, ruleService RuleService) ReleaseService {
+	return &releaseService{
+		repo:        repo,
+		ruleService: ruleService,
+	}
+}
+
+// Publish 发布当前规则集
+func (s *releaseService) Publish(ctx context.Context, operator int64, description string) (*model.RulesetRelease, error) {
+	release := &model.RulesetRelease{
+		Action:      model.ReleaseActionPublish,
+		Description: description,
+		CreatedBy:   operator,
+	}
+	if err := s.repo.CreateRelease(ctx, release); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("发布规则集: ReleaseID=%d, Hash=%s, Rules=%d, Operator=%d", release.ID, release.Hash, release.RuleCount, operator)
+	return release, nil
+}
+
+// GetRelease 获取发布
+func (s *releaseService) GetRelease(ctx context.Context, id int64) (*model.RulesetRelease, error) {
+	release, err := s.repo.GetRelease(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := release.Load(); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// ListReleases 获取发布列表
+func (s *releaseService) ListReleases(ctx context.Context, page, size int) ([]*model.RulesetRelease, int64, error) {
+	return s.repo.ListReleases(ctx, (page-1)*size, size)
+}
+
+// Diff 比较两次发布
+func (s *releaseService) Diff(ctx context.Context, fromID, toID int64) (*model.ReleaseDiff, error) {
+	from, err := s.GetRelease(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	var to *model.RulesetRelease
+	if toID > 0 {
+		to, err = s.GetRelease(ctx, toID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// 当前规则集按发布的方式序列化后再比较，避免数据库读取和JSON解析的差异
+		snapshot, err := s.repo.Snapshot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		to = &model.RulesetRelease{Snapshot: snapshot}
+		if err := to.Seal(); err != nil {
+			return nil, err
+		}
+		if err := to.Load(); err != nil {
+			return nil, err
+		}
+	}
+
+	diff := model.DiffSnapshots(from.Snapshot, to.Snapshot)
+	diff.FromID = fromID
+	diff.ToID = toID
+	return diff, nil
+}
+
+// Rollback 回滚到历史发布
+func (s *releaseService) Rollback(ctx context.Context, id, operator int64, description string) (*model.RulesetRelease, error) {
+	source, err := s.GetRelease(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if description == "" {
+		description = fmt.Sprintf("回滚到发布 #%d", id)
+	}
+	release := &model.RulesetRelease{
+		Action:      model.ReleaseActionRollback,
+		SourceID:    id,
+		Description: description,
+		Snapshot:    source.Snapshot,
+		CreatedBy:   operator,
+	}
+	if err := s.repo.RestoreRelease(ctx, release); err != nil {
+		return nil, err
+	}
+	logger.Infof("回滚规则集: ReleaseID=%d, SourceID=%d, Hash=%s, Operator=%d", release.ID, id, release.Hash, operator)
+
+	// 规则已恢复，缓存刷新失败时只记录日志，可通过 /rules/reload 重试
+	if err := s.ruleService.ReloadRules(ctx); err != nil {
+		logger.Errorf("回滚后刷新规则缓存失败: ReleaseID=%d, Error=%v", release.ID, err)
+	}
+	return release, nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/leakage"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+	"github.com/xwaf/rule_engine/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 响应检查默认配置
+const (
+	defaultMaxResponseBody   = 64 * 1024       // 响应体最大检查长度
+	defaultRequestContextTTL = 5 * time.Minute // 请求上下文保留时间
+	defaultMaxContexts       = 100000          // 请求上下文最大数量
+)
+
+// ResponseService 响应阶段检查服务接口
+type ResponseService interface {
+	RequestEnricher
+
+	// CheckResponse 按请求ID关联请求阶段的信息，检查响应阶段规则
+	CheckResponse(ctx context.Context, req *model.ResponseCheckRequest) (*model.CheckResult, error)
+}
+
+// ResponseOptions 响应检查配置
+type ResponseOptions struct {
+	MaxBodySize int           // 响应体最大检查长度(字节)，超出部分不检查
+	ContextTTL  time.Duration // 请求上下文保留时间
+	MaxContexts int           // 请求上下文最大数量，达到上限时不再记录新的请求
+}
+
+// responseService 响应阶段检查服务实现
+type responseService struct {
+	repo        repository.RuleRepository
+	factory     RuleFactory
+	maxBodySize int
+	maxContexts int
+	requests    *cache.Cache // 请求ID -> 请求阶段检查时的请求信息，只保存在本实例
+}
+
+// NewResponseService 创建响应检查服务
+// 服务同时作为 RequestEnricher 注册到规则服务，记录带请求ID的请求信息供响应阶段使用
+// 请求信息保存在本实例内存中，同一请求的请求阶段和响应阶段需发送到同一实例
+func NewResponseService(repo repository.RuleRepository, factory RuleFactory, opts ResponseOptions) ResponseService {
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMaxResponseBody
+	}
+	if opts.ContextTTL <= 0 {
+		opts.ContextTTL = defaultRequestContextTTL
+	}
+	if opts.MaxContexts <= 0 {
+		opts.MaxContexts = defaultMaxContexts
+	}
+	return &responseService{
+		repo:        repo,
+		factory:     factory,
+		maxBodySize: opts.MaxBodySize,
+		maxContexts: opts.MaxContexts,
+		requests:    cache.New(opts.ContextTTL, opts.ContextTTL),
+	}
+}
+
+// Enrich 记录请求信息，请求未携带请求ID或为无副作用检查时忽略
+// 数量达到上限时不记录，响应阶段只检查响应数据；过期的请求信息由后台定期清理
+func (s *responseService) Enrich(ctx context.Context, req *model.CheckRequest) error {
+	if req == nil || req.RequestID == "" || isDryRun(ctx) {
+		return nil
+	}
+	if s.requests.ItemCount() >= s.maxContexts {
+		metrics.RecordRequestContextDropped()
+		return nil
+	}
+	snapshot := *req
+	snapshot.Body = ""
+	snapshot.RequestBody = nil
+	snapshot.Response = nil
+	s.requests.SetDefault(req.RequestID, &snapshot)
+	return nil
+}
+
+// CheckResponse 检查响应阶段规则
+// 按优先级依次匹配：脱敏规则累积生效并继续匹配，其他规则命中即结束；
+// 阻止动作优先于脱敏，否则存在脱敏时返回脱敏动作和脱敏后的响应体
+func (s *responseService) CheckResponse(ctx context.Context, resp *model.ResponseCheckRequest) (*model.CheckResult, error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "responseService.CheckResponse", trace.SpanKindInternal,
+		attribute.String("request_id", resp.RequestID),
+	)
+	defer span.End()
+
+	if resp.TruncateBody(s.maxBodySize) {
+		logger.Infof("响应体超出检查长度已截断: RequestID=%s, MaxSize=%d", resp.RequestID, s.maxBodySize)
+	}
+
+	req := s.requestContext(resp)
+	result, err := s.checkResponse(ctx, resp, req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		metrics.RecordCheckError(metrics.PhaseResponse)
+		return nil, err
+	}
+	span.SetAttributes(
+		attribute.String("waf.action", string(result.Action)),
+		attribute.Bool("waf.matched", result.Matched),
+	)
+	metrics.RecordCheck(metrics.PhaseResponse, req, result.Action, metrics.ModeEnforce, time.Since(start))
+	return result, nil
+}
+
+// checkResponse 按优先级执行响应阶段规则
+func (s *responseService) checkResponse(ctx context.Context, resp *model.ResponseCheckRequest, req *model.CheckRequest) (*model.CheckResult, error) {
+	rules, _, err := s.repo.ListRules(ctx, &repository.RuleQuery{
+		Status: model.StatusEnabled,
+	})
+	if err != nil {
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("获取规则列表失败: %v", err))
+	}
+	model.SortRulesByPriority(rules)
+
+	var decided *model.CheckResult
+	var maskRule *model.Rule
+	body := resp.Body
+	now := time.Now()
+	for _, rule := range rules {
+		if rule.GetPhase() != model.RulePhaseResponse || !hasRuleType(resp.RuleTypes, rule.Type) || !rule.ActiveAt(now) {
+			continue
+		}
+
+		handler, err := s.factory.CreateRuleHandler(rule.Type)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("创建规则处理器失败: %v", err))
+		}
+		matched, err := matchRule(ctx, handler, rule, req)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则匹配失败: %v", err))
+		}
+		if !matched {
+			continue
+		}
+
+		if rule.Action == model.ActionMask {
+			masked, err := s.mask(ctx, rule, body)
+			if err != nil {
+				return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("响应脱敏失败: %v", err))
+			}
+			body = masked
+			if maskRule == nil {
+				maskRule = rule
+			}
+			continue
+		}
+
+		decided = &model.CheckResult{
+			Matched:     true,
+			Action:      rule.Action,
+			MatchedRule: rule,
+			Source:      model.CheckSourceRule,
+			Message:     fmt.Sprintf("命中规则: %s", rule.Name),
+		}
+		break
+	}
+
+	if decided != nil && (decided.Action == model.ActionBlock || maskRule == nil) {
+		return decided, nil
+	}
+	if maskRule != nil {
+		return &model.CheckResult{
+			Matched:     true,
+			Action:      model.ActionMask,
+			MatchedRule: maskRule,
+			Source:      model.CheckSourceRule,
+			Message:     fmt.Sprintf("命中规则: %s", maskRule.Name),
+			MaskedBody:  body,
+		}, nil
+	}
+
+	return &model.CheckResult{
+		Matched: false,
+		Action:  model.ActionAllow,
+		Message: "未命中任何规则",
+	}, nil
+}
+
+// requestContext 构建响应阶段的检查请求，请求信息已过期时只包含响应数据
+func (s *responseService) requestContext(resp *model.ResponseCheckRequest) *model.CheckRequest {
+	req := &model.CheckRequest{RequestID: resp.RequestID}
+	start := time.Now()
+	cached, ok := s.requests.Get(resp.RequestID)
+	metrics.RecordCacheOperation("request_context", ok, time.Since(start))
+	if ok {
+		snapshot := *cached.(*model.CheckRequest)
+		req = &snapshot
+	} else {
+		logger.Warnf("未找到请求阶段信息，仅检查响应数据: RequestID=%s", resp.RequestID)
+	}
+	req.Response = &model.ResponseData{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Headers,
+		Body:       resp.Body,
+	}
+	return req
+}
+
+// mask 按规则对响应体脱敏：信息泄露规则替换检测器命中内容，正则规则替换正则命中内容
+func (s *responseService) mask(ctx context.Context, rule *model.Rule, body string) (string, error) {
+	switch rule.Type {
+	case model.RuleTypeLeakage:
+		return leakage.Mask(body, strings.Split(rule.Pattern, ","))
+	case model.RuleTypeRegex:
+		re, err := compiledRegexp(ctx, rule.Pattern)
+		if err != nil {
+			return "", errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("编译正则表达式失败: %v", err))
+		}
+		return leakage.MaskRanges(body, re.FindAllStringIndex(body, -1)), nil
+	default:
+		return "", errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则类型不支持脱敏: %s", rule.Type))
+	}
+}
@@ -3,26 +3,52 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/lint"
 	"github.com/xwaf/rule_engine/internal/model"
 	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"github.com/xwaf/rule_engine/pkg/metrics"
+	"github.com/xwaf/rule_engine/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ruleService 规则服务实现
 type ruleService struct {
-	repo    repository.RuleRepository
-	factory RuleFactory
-	cache   repository.RuleCache
+	repo      repository.RuleRepository
+	factory   RuleFactory
+	cache     repository.RuleCache
+	lists     ListChecker
+	bypass    BypassChecker
+	enrichers []RequestEnricher
+	linter    *lint.Linter
+
+	// snapshot 检查使用的启用规则快照(*ruleSnapshot)，未加载时直接查询数据库
+	snapshot atomic.Value
+	loadMu   sync.Mutex
+	statusMu sync.Mutex
+	status   model.RuleSnapshotStatus
+	// intervals 同步间隔修改通知，RunSync收到后重置定时器
+	intervals chan syncIntervals
 }
 
-// NewRuleService 创建规则服务
-func NewRuleService(repo repository.RuleRepository, factory RuleFactory, cache repository.RuleCache) RuleService {
+// NewRuleService 创建规则服务，lists为空时不检查名单，bypass为空时不检查旁路
+func NewRuleService(repo repository.RuleRepository, factory RuleFactory, cache repository.RuleCache, lists ListChecker, bypass BypassChecker, enrichers ...RequestEnricher) RuleService {
 	return &ruleService{
-		repo:    repo,
-		factory: factory,
-		cache:   cache,
+		repo:      repo,
+		factory:   factory,
+		cache:     cache,
+		lists:     lists,
+		bypass:    bypass,
+		enrichers: enrichers,
+		linter:    lint.New(lint.DefaultOptions()),
+		intervals: make(chan syncIntervals, 1),
 	}
 }
 
@@ -32,11 +58,15 @@ func (s *ruleService) CreateRule(ctx context.Context, rule *model.Rule) error {
 	if err := rule.Validate(); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则验证失败: %v", err))
 	}
+	if err := s.lintRules(ctx, []*model.Rule{rule}); err != nil {
+		return err
+	}
 
 	// 创建规则
 	if err := s.repo.CreateRule(ctx, rule); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("创建规则失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	// 更新缓存
 	if err := s.cache.SetRule(ctx, rule); err != nil {
@@ -52,11 +82,15 @@ func (s *ruleService) UpdateRule(ctx context.Context, rule *model.Rule) error {
 	if err := rule.Validate(); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则验证失败: %v", err))
 	}
+	if err := s.lintRules(ctx, []*model.Rule{rule}); err != nil {
+		return err
+	}
 
 	// 更新规则
 	if err := s.repo.UpdateRule(ctx, rule); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("更新规则失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	// 更新缓存
 	if err := s.cache.SetRule(ctx, rule); err != nil {
@@ -72,6 +106,7 @@ func (s *ruleService) DeleteRule(ctx context.Context, id int64) error {
 	if err := s.repo.DeleteRule(ctx, id); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("删除规则失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	// 删除缓存
 	if err := s.cache.DeleteRule(ctx, id); err != nil {
@@ -114,9 +149,8 @@ func (s *ruleService) ListRules(ctx context.Context, query *repository.RuleQuery
 	return rules, total, nil
 }
 
-// CheckRequest 检查规则匹配
-func (s *ruleService) CheckRequest(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error) {
-	// 获取所有规则
+// ListExpiringRules 获取将在within内过期的启用规则，按过期时间排序
+func (s *ruleService) ListExpiringRules(ctx context.Context, within time.Duration) ([]*model.RuleExpiration, error) {
 	rules, _, err := s.repo.ListRules(ctx, &repository.RuleQuery{
 		Status: model.StatusEnabled,
 	})
@@ -124,23 +158,189 @@ func (s *ruleService) CheckRequest(ctx context.Context, req *model.CheckRequest)
 		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("获取规则列表失败: %v", err))
 	}
 
-	// 按优先级排序规则
-	model.SortRulesByPriority(rules)
+	now := time.Now()
+	deadline := now.Add(within).Unix()
+	expirations := make([]*model.RuleExpiration, 0)
+	for _, rule := range rules {
+		if rule.EndTime == 0 || rule.EndTime <= now.Unix() || rule.EndTime > deadline {
+			continue
+		}
+		expirations = append(expirations, &model.RuleExpiration{
+			Rule:      rule,
+			ExpiresAt: time.Unix(rule.EndTime, 0),
+			Active:    rule.ActiveAt(now),
+		})
+	}
+	sort.Slice(expirations, func(i, j int) bool {
+		return expirations[i].ExpiresAt.Before(expirations[j].ExpiresAt)
+	})
+	return expirations, nil
+}
+
+// CheckRequest 检查规则匹配
+func (s *ruleService) CheckRequest(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error) {
+	return s.check(ctx, "ruleService.CheckRequest", req)
+}
+
+// DryRunRequest 无副作用的规则检查
+func (s *ruleService) DryRunRequest(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error) {
+	return s.check(withDryRun(ctx), "ruleService.DryRunRequest", req)
+}
+
+// check 检查规则匹配，记录Span，无副作用检查时不记录判定指标
+func (s *ruleService) check(ctx context.Context, spanName string, req *model.CheckRequest) (*model.CheckResult, error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, spanName, trace.SpanKindInternal,
+		attribute.String("client_ip", req.ClientIP),
+	)
+	defer span.End()
+
+	dryRun := isDryRun(ctx)
+	result, mode, err := s.checkRequest(ctx, req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		if !dryRun {
+			metrics.RecordCheckError(metrics.PhaseRequest)
+		}
+		return nil, err
+	}
+	if req.RequestBody != nil && len(req.RequestBody.Files) > 0 {
+		result.Uploads = req.RequestBody.Files
+		span.SetAttributes(attribute.Int64("waf.upload_files", int64(len(result.Uploads))))
+	}
+	span.SetAttributes(
+		attribute.String("waf.action", string(result.Action)),
+		attribute.String("waf.mode", mode),
+		attribute.Bool("waf.matched", result.Matched),
+	)
+	if result.MatchedRule != nil {
+		span.SetAttributes(attribute.Int64("waf.rule_id", result.MatchedRule.ID))
+	}
+	if !dryRun {
+		metrics.RecordCheck(metrics.PhaseRequest, req, result.Action, mode, time.Since(start))
+	}
+	return result, nil
+}
+
+// checkRequest 依次补充请求信息、检查名单、判定旁路和检查规则，并返回判定模式
+func (s *ruleService) checkRequest(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, string, error) {
+	// 补充请求信息，失败时不影响规则匹配
+	stageStart := time.Now()
+	for _, enricher := range s.enrichers {
+		if err := enricher.Enrich(ctx, req); err != nil {
+			logger.Warnf("补充请求信息失败: ClientIP=%s, Error=%v", req.ClientIP, err)
+		}
+	}
+	metrics.RecordCheckStage(metrics.StageEnrich, time.Since(stageStart))
+
+	// 名单在旁路之前检查，生效中的黑名单不能被旁路配置或旁路令牌绕过
+	listResult, err := s.checkLists(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	if listResult != nil {
+		return listResult, metrics.ModeEnforce, nil
+	}
+
+	// 检查旁路配置，部分旁路和完全旁路直接放行，监控模式继续检查但不拦截
+	var bypass *model.BypassDecision
+	if s.bypass != nil {
+		stageStart = time.Now()
+		decision, err := s.bypass.CheckBypass(ctx, req)
+		metrics.RecordCheckStage(metrics.StageBypass, time.Since(stageStart))
+		if err != nil {
+			return nil, "", errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检查旁路配置失败: %v", err))
+		}
+		if decision != nil && decision.Allowed {
+			if !decision.Monitor() {
+				return &model.CheckResult{
+					Matched: false,
+					Action:  model.ActionAllow,
+					Message: fmt.Sprintf("命中%s", decision),
+				}, metrics.ModeBypass, nil
+			}
+			bypass = decision
+		}
+	}
+
+	result, err := s.checkRules(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+	if bypass == nil {
+		return result, metrics.ModeEnforce, nil
+	}
+	if result.Matched && result.Action != model.ActionAllow && result.Action != model.ActionLog {
+		result.Message = fmt.Sprintf("%s (%s处于监控模式，%s降级为log)", result.Message, bypass, result.Action)
+		result.Action = model.ActionLog
+	}
+	return result, metrics.ModeMonitor, nil
+}
+
+// matchRule 执行单条规则匹配，记录匹配耗时和Span，无副作用检查时不记录匹配指标
+func matchRule(ctx context.Context, handler RuleHandler, rule *model.Rule, req *model.CheckRequest) (bool, error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "matcher."+string(rule.Type), trace.SpanKindInternal,
+		attribute.Int64("rule_id", rule.ID),
+		attribute.String("rule_type", string(rule.Type)),
+	)
+	defer span.End()
+
+	matched, err := handler.Match(ctx, rule, req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return false, err
+	}
+	span.SetAttributes(attribute.Bool("matched", matched))
+	if !isDryRun(ctx) {
+		metrics.RecordRuleMatch(rule, matched, time.Since(start))
+	}
+	return matched, nil
+}
+
+// checkLists 检查IP/地理位置/指纹名单，名单是独立于规则类型的访问控制，不受rule_types限制，未命中任何名单时返回nil
+func (s *ruleService) checkLists(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error) {
+	if s.lists == nil {
+		return nil, nil
+	}
+	listStart := time.Now()
+	result, err := s.lists.CheckLists(ctx, req)
+	metrics.RecordCheckStage(metrics.StageLists, time.Since(listStart))
+	if err != nil {
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("检查名单失败: %v", err))
+	}
+	return result, nil
+}
+
+// checkRules 按请求体处理结果和规则检查请求
+func (s *ruleService) checkRules(ctx context.Context, req *model.CheckRequest) (*model.CheckResult, error) {
+	// 请求体无法完整检查时按请求体处理配置拦截，名单白名单仍然放行
+	if req.RequestBody != nil && req.RequestBody.BlockReason != "" {
+		return &model.CheckResult{
+			Matched: true,
+			Action:  model.ActionBlock,
+			Source:  model.CheckSourceRequestBody,
+			Message: req.RequestBody.BlockReason,
+		}, nil
+	}
+
+	// 获取所有规则
+	rulesStart := time.Now()
+	defer func() {
+		metrics.RecordCheckStage(metrics.StageRules, time.Since(rulesStart))
+	}()
+	rules, patterns, err := s.enabledRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withPatterns(ctx, patterns)
 
 	// 检查每个规则
+	now := time.Now()
 	for _, rule := range rules {
-		// 检查规则类型是否需要处理
-		if len(req.RuleTypes) > 0 {
-			matched := false
-			for _, t := range req.RuleTypes {
-				if rule.Type == t {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				continue
-			}
+		// 检查规则阶段和类型是否需要处理，不在生效时间窗口内的规则跳过
+		if rule.GetPhase() != model.RulePhaseRequest || !hasRuleType(req.RuleTypes, rule.Type) || !rule.ActiveAt(now) {
+			continue
 		}
 
 		// 获取规则处理器
@@ -150,7 +350,7 @@ func (s *ruleService) CheckRequest(ctx context.Context, req *model.CheckRequest)
 		}
 
 		// 执行规则匹配
-		matched, err := handler.Match(ctx, rule, req)
+		matched, err := matchRule(ctx, handler, rule, req)
 		if err != nil {
 			return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则匹配失败: %v", err))
 		}
@@ -161,6 +361,7 @@ func (s *ruleService) CheckRequest(ctx context.Context, req *model.CheckRequest)
 				Matched:     true,
 				Action:      rule.Action,
 				MatchedRule: rule,
+				Source:      model.CheckSourceRule,
 				Message:     fmt.Sprintf("命中规则: %s", rule.Name),
 			}, nil
 		}
@@ -174,6 +375,19 @@ func (s *ruleService) CheckRequest(ctx context.Context, req *model.CheckRequest)
 	}, nil
 }
 
+// hasRuleType 判断规则类型是否在请求的检查范围内，未指定时检查所有类型
+func hasRuleType(types []model.RuleType, ruleType model.RuleType) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == ruleType {
+			return true
+		}
+	}
+	return false
+}
+
 // ReloadRules 重新加载规则
 func (s *ruleService) ReloadRules(ctx context.Context) error {
 	// 清空缓存
@@ -193,6 +407,94 @@ func (s *ruleService) ReloadRules(ctx context.Context) error {
 		}
 	}
 
+	return s.LoadSnapshot(ctx)
+}
+
+// LintRules 检查整个规则集
+func (s *ruleService) LintRules(ctx context.Context) (*model.LintReport, error) {
+	rules, _, err := s.repo.ListRules(ctx, &repository.RuleQuery{})
+	if err != nil {
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("获取规则列表失败: %v", err))
+	}
+	return s.linter.CheckAll(rules), nil
+}
+
+// LintRule 检查单条规则及其与已有规则的冲突，不保存规则
+func (s *ruleService) LintRule(ctx context.Context, rule *model.Rule) (*model.LintReport, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, errors.NewError(errors.ErrRuleValidation, fmt.Sprintf("规则验证失败: %v", err))
+	}
+	return s.LintChanges(ctx, []*model.Rule{rule}, nil)
+}
+
+// LintChanges 检查一组待保存的规则，deletedIDs中的已有规则视为已删除
+func (s *ruleService) LintChanges(ctx context.Context, candidates []*model.Rule, deletedIDs []int64) (*model.LintReport, error) {
+	rules, _, err := s.repo.ListRules(ctx, &repository.RuleQuery{})
+	if err != nil {
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("获取规则列表失败: %v", err))
+	}
+
+	deleted := make(map[int64]bool, len(deletedIDs))
+	for _, id := range deletedIDs {
+		deleted[id] = true
+	}
+	existing := make([]*model.Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !deleted[rule.ID] {
+			existing = append(existing, rule)
+		}
+	}
+	return s.linter.Check(candidates, existing), nil
+}
+
+// TestRule 执行规则测试用例，用例的请求为空时记录错误
+func (s *ruleService) TestRule(ctx context.Context, rule *model.Rule, cases []*model.RuleTestCase) ([]*model.RuleTestResult, error) {
+	handler, err := s.factory.CreateRuleHandler(rule.Type)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("创建规则处理器失败: %v", err))
+	}
+
+	results := make([]*model.RuleTestResult, 0, len(cases))
+	for _, tc := range cases {
+		result := &model.RuleTestResult{TestCase: tc}
+		results = append(results, result)
+		if tc.Request == nil {
+			result.Error = "测试用例缺少请求"
+			continue
+		}
+
+		start := time.Now()
+		matched, err := handler.Match(ctx, rule, tc.Request)
+		result.Duration = time.Since(start)
+		if err != nil {
+			result.Error = fmt.Sprintf("规则匹配失败: %v", err)
+			continue
+		}
+		result.IsMatch = matched
+		if matched {
+			result.MatchResult = &model.RuleMatch{Rule: rule, Score: 1.0}
+		}
+		if matched != tc.Expected {
+			result.Error = fmt.Sprintf("期望匹配结果为%t，实际为%t", tc.Expected, matched)
+		}
+	}
+	return results, nil
+}
+
+// lintRules 保存前检查规则，存在错误时返回包含检查报告的ErrRuleConflict，仅有警告时记录日志
+func (s *ruleService) lintRules(ctx context.Context, rules []*model.Rule) error {
+	existing, _, err := s.repo.ListRules(ctx, &repository.RuleQuery{})
+	if err != nil {
+		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("获取规则列表失败: %v", err))
+	}
+
+	report := s.linter.Check(rules, existing)
+	if report.HasErrors() {
+		return errors.NewError(errors.ErrRuleConflict, report)
+	}
+	if report.Warnings > 0 {
+		logger.Warnf("规则检查存在警告: %s", report)
+	}
 	return nil
 }
 
@@ -213,11 +515,15 @@ func (s *ruleService) BatchCreateRules(ctx context.Context, rules []*model.Rule)
 			return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则验证失败: %v", err))
 		}
 	}
+	if err := s.lintRules(ctx, rules); err != nil {
+		return err
+	}
 
 	// 批量创建规则
 	if err := s.repo.BatchCreateRules(ctx, rules); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("批量创建规则失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	// 更新缓存
 	for _, rule := range rules {
@@ -237,8 +543,12 @@ func (s *ruleService) BatchUpdateRules(ctx context.Context, rules []*model.Rule)
 			return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则验证失败 (ID: %d): %v", rule.ID, err))
 		}
 	}
+	if err := s.lintRules(ctx, rules); err != nil {
+		return err
+	}
 
-	// 批量更新规则
+	// 批量更新规则，部分失败时已更新的规则同样需要刷新快照
+	defer s.reloadAfterWrite(ctx)
 	for _, rule := range rules {
 		if err := s.repo.UpdateRule(ctx, rule); err != nil {
 			return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("更新规则失败 (ID: %d): %v", rule.ID, err))
@@ -258,6 +568,7 @@ func (s *ruleService) BatchDeleteRules(ctx context.Context, ids []int64) error {
 	if err := s.repo.BatchDeleteRules(ctx, ids); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("删除规则失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 
 	// 从缓存中删除规则
 	for _, id := range ids {
@@ -316,10 +627,14 @@ func (s *ruleService) ImportRules(ctx context.Context, rules []*model.Rule) erro
 			return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("规则验证失败: %v", err))
 		}
 	}
+	if err := s.lintRules(ctx, rules); err != nil {
+		return err
+	}
 
 	if err := s.repo.ImportRules(ctx, rules); err != nil {
 		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("导入规则失败: %v", err))
 	}
+	s.reloadAfterWrite(ctx)
 	return nil
 }
 
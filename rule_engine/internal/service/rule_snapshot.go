@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+// ruleSnapshot 启用规则快照，规则已按优先级排序，加载后只读
+type ruleSnapshot struct {
+	rules    []*model.Rule
+	patterns *compiledPatterns // 规则预编译的正则和选择器，旧快照不再使用后一起释放
+	version  int64
+	loadedAt time.Time
+}
+
+// syncIntervals 规则同步间隔
+type syncIntervals struct {
+	sync  time.Duration
+	check time.Duration
+}
+
+// currentSnapshot 获取当前快照，未加载时返回nil
+func (s *ruleService) currentSnapshot() *ruleSnapshot {
+	snapshot, _ := s.snapshot.Load().(*ruleSnapshot)
+	return snapshot
+}
+
+// enabledRules 获取按优先级排序的启用规则和预编译结果，快照未加载时直接查询数据库，不预编译
+func (s *ruleService) enabledRules(ctx context.Context) ([]*model.Rule, *compiledPatterns, error) {
+	if snapshot := s.currentSnapshot(); snapshot != nil {
+		return snapshot.rules, snapshot.patterns, nil
+	}
+	rules, _, err := s.repo.ListRules(ctx, &repository.RuleQuery{
+		Status: model.StatusEnabled,
+	})
+	if err != nil {
+		return nil, nil, errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("获取规则列表失败: %v", err))
+	}
+	model.SortRulesByPriority(rules)
+	return rules, nil, nil
+}
+
+// LoadSnapshot 从数据库重新加载启用规则快照和名单
+func (s *ruleService) LoadSnapshot(ctx context.Context) error {
+	// 串行加载，避免较早开始的加载覆盖较新的快照
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	// 名单加载失败时保留上次加载的名单，不影响规则快照
+	if s.lists != nil {
+		if err := s.lists.LoadLists(ctx); err != nil {
+			logger.Errorf("加载名单失败: %v", err)
+		}
+	}
+
+	// 先读取版本号，加载期间的变更会在下次版本检查时发现
+	version, err := s.repo.GetLatestVersion(ctx)
+	var rules []*model.Rule
+	if err == nil {
+		rules, _, err = s.repo.ListRules(ctx, &repository.RuleQuery{
+			Status: model.StatusEnabled,
+		})
+	}
+
+	// 在更新状态前预编译，编译期间不阻塞状态查询
+	var patterns *compiledPatterns
+	if err == nil {
+		model.SortRulesByPriority(rules)
+		patterns = compilePatterns(rules)
+	}
+
+	now := time.Now()
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.status.CheckedAt = now
+	if err != nil {
+		s.status.LastError = err.Error()
+		return errors.NewError(errors.ErrRuleEngine, fmt.Sprintf("加载规则快照失败: %v", err))
+	}
+
+	s.snapshot.Store(&ruleSnapshot{rules: rules, patterns: patterns, version: version, loadedAt: now})
+	s.status = model.RuleSnapshotStatus{
+		Loaded:        true,
+		Version:       version,
+		LatestVersion: version,
+		RuleCount:     len(rules),
+		LoadedAt:      now,
+		CheckedAt:     now,
+	}
+	return nil
+}
+
+// reloadAfterWrite 规则写入后刷新快照，失败时由定时同步重试
+func (s *ruleService) reloadAfterWrite(ctx context.Context) {
+	if err := s.LoadSnapshot(ctx); err != nil {
+		logger.Errorf("刷新规则快照失败: %v", err)
+	}
+}
+
+// checkVersion 检查数据库规则版本，快照落后或未加载时重新加载
+func (s *ruleService) checkVersion(ctx context.Context) error {
+	latest, err := s.repo.GetLatestVersion(ctx)
+	s.statusMu.Lock()
+	s.status.CheckedAt = time.Now()
+	if err != nil {
+		s.status.LastError = err.Error()
+		s.statusMu.Unlock()
+		return err
+	}
+	s.status.LatestVersion = latest
+	s.status.LastError = ""
+	s.statusMu.Unlock()
+
+	if snapshot := s.currentSnapshot(); snapshot != nil && snapshot.version == latest {
+		return nil
+	}
+	return s.LoadSnapshot(ctx)
+}
+
+// SnapshotStatus 获取规则快照状态
+func (s *ruleService) SnapshotStatus() *model.RuleSnapshotStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	status := s.status
+	return &status
+}
+
+// RunSync 定时同步规则快照，直到ctx取消
+// 启动时立即加载，每隔checkInterval检查版本号，每隔syncInterval无条件重新加载(规则修改不一定改变版本号)
+func (s *ruleService) RunSync(ctx context.Context, syncInterval, checkInterval time.Duration) {
+	if err := s.LoadSnapshot(ctx); err != nil {
+		logger.Errorf("首次加载规则快照失败，将定时重试: %v", err)
+	}
+
+	reload := time.NewTicker(syncInterval)
+	defer reload.Stop()
+	check := time.NewTicker(checkInterval)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-check.C:
+			if err := s.checkVersion(ctx); err != nil {
+				logger.Errorf("检查规则版本失败: %v", err)
+			}
+		case <-reload.C:
+			if err := s.LoadSnapshot(ctx); err != nil {
+				logger.Errorf("同步规则快照失败: %v", err)
+			}
+		case next := <-s.intervals:
+			if next.sync > 0 {
+				reload.Reset(next.sync)
+			}
+			if next.check > 0 {
+				check.Reset(next.check)
+			}
+			logger.Infof("规则同步间隔已更新: SyncInterval=%s, CheckInterval=%s", next.sync, next.check)
+		}
+	}
+}
+
+// SetSyncIntervals 修改同步间隔，未处理的修改被新值替换
+func (s *ruleService) SetSyncIntervals(syncInterval, checkInterval time.Duration) {
+	next := syncIntervals{sync: syncInterval, check: checkInterval}
+	for {
+		select {
+		case s.intervals <- next:
+			return
+		default:
+		}
+		// 丢弃尚未处理的旧值
+		select {
+		case <-s.intervals:
+		default:
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xwaf/rule_engine/internal/errors"
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateService 规则模板服务接口
+type TemplateService interface {
+	// ListTemplates 获取模板列表，ruleType为空时返回全部
+	ListTemplates(ctx context.Context, ruleType model.RuleType) []*model.RuleTemplate
+	GetTemplate(ctx context.Context, id string) (*model.RuleTemplate, error)
+
+	// Instantiate 使用参数将模板实例化为规则，非DryRun时保存规则
+	Instantiate(ctx context.Context, id string, req *model.TemplateInstantiateRequest) ([]*model.Rule, error)
+
+	// ListUpgrades 获取由旧版本模板创建的规则，id为空时检查所有模板
+	ListUpgrades(ctx context.Context, id string) ([]*model.TemplateUpgrade, error)
+
+	// Reload 重新加载模板文件
+	Reload(ctx context.Context) error
+}
+
+// templateService 规则模板服务实现
+type templateService struct {
+	ruleService RuleService
+	paths       []string // 模板文件或目录
+
+	mutex     sync.RWMutex
+	templates map[string]*model.RuleTemplate
+}
+
+// NewTemplateService 创建规则模板服务，paths为模板文件或包含 *.yaml 模板文件的目录
+func NewTemplateService(ruleService RuleService, paths ...string) (TemplateService, error) {
+	s := &templateService{
+		ruleService: ruleService,
+		paths:       paths,
+	}
+	if err := s.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload 重新加载模板文件，加载失败时保留原有模板
+func (s *templateService) Reload(ctx context.Context) error {
+	files, err := templateFiles(s.paths)
+	if err != nil {
+		return err
+	}
+
+	templates := make(map[string]*model.RuleTemplate)
+	for _, file := range files {
+		loaded, err := loadTemplateFile(file)
+		if err != nil {
+			return err
+		}
+		for _, tpl := range loaded {
+			if existing, ok := templates[tpl.ID]; ok {
+				return errors.NewError(errors.ErrConfig, fmt.Sprintf("模板ID重复: %s (%s, %s)", tpl.ID, existing.Source, tpl.Source))
+			}
+			templates[tpl.ID] = tpl
+		}
+	}
+
+	s.mutex.Lock()
+	s.templates = templates
+	s.mutex.Unlock()
+
+	logger.Infof("加载规则模板完成: Files=%d, Templates=%d", len(files), len(templates))
+	return nil
+}
+
+// templateFiles 展开模板路径，目录按文件名顺序加载其中的 *.yaml 和 *.yml 文件
+func templateFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取模板路径失败: %v", err))
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取模板目录失败: %v", err))
+			}
+			sort.Strings(matches)
+			files = append(files, matches...)
+		}
+	}
+	return files, nil
+}
+
+// loadTemplateFile 加载模板文件，文件顶层键为模板分组
+func loadTemplateFile(file string) ([]*model.RuleTemplate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("读取模板文件失败: %v", err))
+	}
+
+	var groups map[string][]*model.RuleTemplate
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("解析模板文件失败: %s: %v", file, err))
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var templates []*model.RuleTemplate
+	for _, group := range names {
+		for i, tpl := range groups[group] {
+			if tpl == nil {
+				continue
+			}
+			tpl.Group = group
+			tpl.Source = file
+			if tpl.ID == "" {
+				tpl.ID = fmt.Sprintf("%s_%d", group, i+1)
+			}
+			if tpl.Version <= 0 {
+				tpl.Version = 1
+			}
+			if err := tpl.Validate(); err != nil {
+				return nil, errors.NewError(errors.ErrConfig, fmt.Sprintf("模板定义无效: %s: %v", file, err))
+			}
+			templates = append(templates, tpl)
+		}
+	}
+	return templates, nil
+}
+
+// ListTemplates 获取模板列表
+func (s *templateService) ListTemplates(ctx context.Context, ruleType model.RuleType) []*model.RuleTemplate {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	templates := make([]*model.RuleTemplate, 0, len(s.templates))
+	for _, tpl := range s.templates {
+		if ruleType == "" || tpl.Type == ruleType {
+			templates = append(templates, tpl)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].ID < templates[j].ID
+	})
+	return templates
+}
+
+// GetTemplate 获取模板
+func (s *templateService) GetTemplate(ctx context.Context, id string) (*model.RuleTemplate, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tpl, ok := s.templates[id]
+	if !ok {
+		return nil, errors.NewError(errors.ErrRuleNotFound, fmt.Sprintf("规则模板不存在: %s", id))
+	}
+	return tpl, nil
+}
+
+// Instantiate 实例化模板
+func (s *templateService) Instantiate(ctx context.Context, id string, req *model.TemplateInstantiateRequest) ([]*model.Rule, error) {
+	tpl, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := tpl.Instantiate(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		return rules, nil
+	}
+
+	if err := s.ruleService.BatchCreateRules(ctx, rules); err != nil {
+		return nil, err
+	}
+	logger.Infof("使用模板创建规则: Template=%s, Version=%d, Rules=%d", tpl.ID, tpl.Version, len(rules))
+	return rules, nil
+}
+
+// ListUpgrades 获取可升级的规则
+func (s *templateService) ListUpgrades(ctx context.Context, id string) ([]*model.TemplateUpgrade, error) {
+	if id != "" {
+		if _, err := s.GetTemplate(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, _, err := s.ruleService.ListRules(ctx, &repository.RuleQuery{TemplateID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	upgrades := make([]*model.TemplateUpgrade, 0)
+	for _, rule := range rules {
+		if strings.TrimSpace(rule.TemplateID) == "" {
+			continue
+		}
+		tpl, ok := s.templates[rule.TemplateID]
+		if !ok || rule.TemplateVersion >= tpl.Version {
+			continue
+		}
+		upgrades = append(upgrades, &model.TemplateUpgrade{
+			Rule:           rule,
+			CurrentVersion: rule.TemplateVersion,
+			LatestVersion:  tpl.Version,
+		})
+	}
+	return upgrades, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/xwaf/rule_engine/internal/model"
+	"github.com/xwaf/rule_engine/internal/repository"
+	"github.com/xwaf/rule_engine/pkg/logger"
+)
+
+const (
+	defaultUploadEventBuffer = 4096
+	uploadFlushInterval      = time.Second
+	uploadFlushBatch         = 500
+)
+
+// UploadEventService 上传违规事件服务接口
+type UploadEventService interface {
+	// Enrich 记录请求中有违规的上传文件，需在请求体处理之后执行
+	Enrich(ctx context.Context, req *model.CheckRequest) error
+
+	// ListEvents 获取上传违规事件，按时间倒序
+	ListEvents(ctx context.Context, query *model.UploadEventQuery, page, size int) ([]*model.UploadEvent, int64, error)
+
+	// Run 批量写入上传违规事件，直到ctx取消
+	Run(ctx context.Context)
+}
+
+// UploadEventOptions 上传违规事件服务配置
+type UploadEventOptions struct {
+	Buffer int // 待写入的事件缓冲数，缓冲满时丢弃并记录日志
+}
+
+// uploadEventService 上传违规事件服务实现
+type uploadEventService struct {
+	repo    repository.UploadEventRepository
+	events  chan *model.UploadEvent
+	dropped int64
+}
+
+// NewUploadEventService 创建上传违规事件服务，配置项为0时使用默认值
+func NewUploadEventService(repo repository.UploadEventRepository, opts UploadEventOptions) UploadEventService {
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultUploadEventBuffer
+	}
+	return &uploadEventService{
+		repo:   repo,
+		events: make(chan *model.UploadEvent, opts.Buffer),
+	}
+}
+
+// Enrich 每个有违规的上传文件异步记录一条事件，请求体处理器已设置拦截原因时记为拦截，无副作用检查时不记录
+func (s *uploadEventService) Enrich(ctx context.Context, req *model.CheckRequest) error {
+	if req == nil || req.RequestBody == nil || isDryRun(ctx) {
+		return nil
+	}
+	now := time.Now().Unix()
+	for _, file := range req.RequestBody.Files {
+		if len(file.Violations) == 0 {
+			continue
+		}
+		event := &model.UploadEvent{
+			RequestID:    req.RequestID,
+			IP:           req.ClientIP,
+			URL:          req.URI,
+			Field:        file.Field,
+			Filename:     file.Filename,
+			ContentType:  file.ContentType,
+			DetectedType: file.DetectedType,
+			Size:         file.Size,
+			Violations:   strings.Join(file.Violations, ","),
+			Blocked:      req.RequestBody.BlockReason != "",
+			Timestamp:    now,
+		}
+		select {
+		case s.events <- event:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+	}
+	return nil
+}
+
+// ListEvents 获取上传违规事件
+func (s *uploadEventService) ListEvents(ctx context.Context, query *model.UploadEventQuery, page, size int) ([]*model.UploadEvent, int64, error) {
+	return s.repo.ListUploadEvents(ctx, query, (page-1)*size, size)
+}
+
+// Run 定期批量写入上传违规事件
+func (s *uploadEventService) Run(ctx context.Context) {
+	flush := time.NewTicker(uploadFlushInterval)
+	defer flush.Stop()
+
+	batch := make([]*model.UploadEvent, 0, uploadFlushBatch)
+	write := func(ctx context.Context) {
+		if dropped := atomic.SwapInt64(&s.dropped, 0); dropped > 0 {
+			logger.Warnf("上传违规事件缓冲已满，丢弃%d条事件", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.repo.CreateUploadEvents(ctx, batch); err != nil {
+			logger.Errorf("写入上传违规事件失败: Count=%d, Error=%v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 退出前写入剩余事件
+			for {
+				select {
+				case event := <-s.events:
+					batch = append(batch, event)
+					continue
+				default:
+				}
+				break
+			}
+			write(context.Background())
+			return
+		case event := <-s.events:
+			batch = append(batch, event)
+			if len(batch) >= uploadFlushBatch {
+				write(ctx)
+			}
+		case <-flush.C:
+			write(ctx)
+		}
+	}
+}
@@ -28,6 +28,7 @@ type RuleVersionService interface {
 	GetSyncLogs(ctx context.Context, ruleID int64) ([]*model.RuleSyncLog, error)
 
 	// RollbackToVersion 回滚到指定版本
+	// 只能恢复在该版本中变更过的规则，整体回滚请使用 ReleaseService.Rollback
 	RollbackToVersion(ctx context.Context, version int64) error
 }
 
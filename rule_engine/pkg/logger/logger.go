@@ -3,6 +3,8 @@ package logger
 import (
 	"fmt"
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/xwaf/rule_engine/internal/errors"
 	"go.uber.org/zap"
@@ -10,9 +12,18 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var (
+// state 当前使用的日志记录器，重新配置时整体替换
+type state struct {
 	logger *zap.Logger
 	sugar  *zap.SugaredLogger
+	hook   *lumberjack.Logger
+	cfg    LogConfig
+}
+
+var (
+	current atomic.Pointer[state]
+	level   = zap.NewAtomicLevel()
+	mu      sync.Mutex // 串行化Init和Reconfigure
 )
 
 // LogConfig 日志配置
@@ -25,14 +36,63 @@ type LogConfig struct {
 	Compress   bool   `yaml:"compress"`
 }
 
+// ParseLevel 解析日志级别
+func ParseLevel(text string) (zapcore.Level, error) {
+	l := zapcore.InfoLevel
+	if err := l.UnmarshalText([]byte(text)); err != nil {
+		return l, errors.NewError(errors.ErrInit, fmt.Sprintf("解析日志级别失败: %v", err))
+	}
+	return l, nil
+}
+
 // Init 初始化日志
 func Init(cfg *LogConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
 	// 配置日志级别
-	level := zapcore.InfoLevel
-	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
-		return errors.NewError(errors.ErrInit, fmt.Sprintf("解析日志级别失败: %v", err))
+	l, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(l)
+	current.Store(newState(cfg))
+	return nil
+}
+
+// Reconfigure 运行期间修改日志配置
+// 只修改级别时直接生效；文件或滚动参数变化时创建新的输出，并关闭原日志文件
+func Reconfigure(cfg *LogConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	l, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	old := current.Load()
+	level.SetLevel(l)
+	if old != nil && sameOutput(&old.cfg, cfg) {
+		old.cfg.Level = cfg.Level
+		return nil
+	}
+
+	current.Store(newState(cfg))
+	if old != nil {
+		old.logger.Sync()
+		old.hook.Close()
 	}
+	return nil
+}
 
+// sameOutput 输出文件和滚动参数是否相同
+func sameOutput(a, b *LogConfig) bool {
+	return a.Filename == b.Filename && a.MaxSize == b.MaxSize && a.MaxAge == b.MaxAge &&
+		a.MaxBackups == b.MaxBackups && a.Compress == b.Compress
+}
+
+// newState 按配置创建日志记录器，级别使用共享的level
+func newState(cfg *LogConfig) *state {
 	// 配置日志输出
 	hook := &lumberjack.Logger{
 		Filename:   cfg.Filename,
@@ -65,63 +125,72 @@ func Init(cfg *LogConfig) error {
 	)
 
 	// 创建日志记录器
-	logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
-	sugar = logger.Sugar()
+	l := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
+	return &state{logger: l, sugar: l.Sugar(), hook: hook, cfg: *cfg}
+}
 
-	return nil
+// nop 初始化前使用的日志记录器，丢弃所有日志
+var nop = zap.NewNop().Sugar()
+
+// sugar 获取当前的日志记录器，未初始化时丢弃日志
+func sugar() *zap.SugaredLogger {
+	if st := current.Load(); st != nil {
+		return st.sugar
+	}
+	return nop
 }
 
 // Debug 记录调试级别日志
 func Debug(msg string, args ...interface{}) {
-	sugar.Debugf(msg, args...)
+	sugar().Debugf(msg, args...)
 }
 
 // Info 记录信息级别日志
 func Info(msg string, args ...interface{}) {
-	sugar.Infof(msg, args...)
+	sugar().Infof(msg, args...)
 }
 
 // Warn 记录警告级别日志
 func Warn(msg string, args ...interface{}) {
-	sugar.Warnf(msg, args...)
+	sugar().Warnf(msg, args...)
 }
 
 // Error 记录错误级别日志
 func Error(msg string, args ...interface{}) {
-	sugar.Errorf(msg, args...)
+	sugar().Errorf(msg, args...)
 }
 
 // Fatal 记录致命级别日志
 func Fatal(msg string, args ...interface{}) {
-	sugar.Fatalf(msg, args...)
+	sugar().Fatalf(msg, args...)
 }
 
 // Sync 同步日志
 func Sync() error {
-	return logger.Sync()
+	return current.Load().logger.Sync()
 }
 
 // With 添加字段
 func With(fields ...interface{}) *zap.SugaredLogger {
-	return sugar.With(fields...)
+	return sugar().With(fields...)
 }
 
 // Named 添加名称
 func Named(name string) *zap.Logger {
-	return logger.Named(name)
+	return current.Load().logger.Named(name)
 }
 
 // Warnf 记录格式化的警告级别日志
 func Warnf(format string, args ...interface{}) {
-	sugar.Warnf(format, args...)
+	sugar().Warnf(format, args...)
 }
 
 // Errorf 记录格式化的错误级别日志
 func Errorf(format string, args ...interface{}) {
-	sugar.Errorf(format, args...)
+	sugar().Errorf(format, args...)
 }
 
 // Infof 记录格式化的信息级别日志
 func Infof(format string, args ...interface{}) {
-	sugar.Infof(format, args...)
+	sugar().Infof(format, args...)
 }
@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/xwaf/rule_engine/internal/model"
+)
+
+// 检查阶段
+const (
+	PhaseRequest  = "request"
+	PhaseResponse = "response"
+)
+
+// 判定模式
+const (
+	ModeEnforce = "enforce" // 按规则或名单的动作执行
+	ModeMonitor = "monitor" // 旁路监控模式，拦截动作降级为记录
+	ModeBypass  = "bypass"  // 命中旁路配置或令牌，跳过检查
+)
+
+// 检查步骤
+const (
+	StageEnrich = "enrich" // 补充地理位置、机器人识别等请求信息
+	StageBypass = "bypass" // 旁路判定
+	StageLists  = "lists"  // 名单检查
+	StageRules  = "rules"  // 规则匹配
+)
+
+// 标签值溢出或为空时使用的值
+const (
+	labelOther   = "other"
+	labelUnknown = "unknown"
+)
+
+// LabelPolicy 标签基数限制，超出上限的新标签值统一记为other，避免时序数量无限增长
+type LabelPolicy struct {
+	MaxRules int // rule_id标签的最大取值数
+	MaxSites int // site标签的最大取值数
+	MaxNodes int // node_id标签的最大取值数
+}
+
+// DefaultLabelPolicy 默认标签基数限制
+func DefaultLabelPolicy() LabelPolicy {
+	return LabelPolicy{
+		MaxRules: 1000,
+		MaxSites: 100,
+		MaxNodes: 500,
+	}
+}
+
+// boundedLabel 有上限的标签值集合，先出现的值保留，超出上限后记为other
+type boundedLabel struct {
+	mu   sync.RWMutex
+	max  int
+	seen map[string]struct{}
+}
+
+func newBoundedLabel(max int) *boundedLabel {
+	return &boundedLabel{max: max, seen: make(map[string]struct{})}
+}
+
+// value 返回可用作标签的值
+func (b *boundedLabel) value(v string) string {
+	if v == "" {
+		return labelUnknown
+	}
+	b.mu.RLock()
+	_, ok := b.seen[v]
+	b.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.seen[v]; ok {
+		return v
+	}
+	if len(b.seen) >= b.max {
+		return labelOther
+	}
+	b.seen[v] = struct{}{}
+	return v
+}
+
+// remove 释放标签值占用的名额，值未记录(已记为other)时返回false
+func (b *boundedLabel) remove(v string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.seen[v]; !ok {
+		return false
+	}
+	delete(b.seen, v)
+	return true
+}
+
+var (
+	policyMu   sync.RWMutex
+	ruleLabels = newBoundedLabel(DefaultLabelPolicy().MaxRules)
+	siteLabels = newBoundedLabel(DefaultLabelPolicy().MaxSites)
+	nodeLabels = newBoundedLabel(DefaultLabelPolicy().MaxNodes)
+)
+
+// SetLabelPolicy 设置标签基数限制，应在服务启动时调用，已记录的标签值会被清空
+func SetLabelPolicy(p LabelPolicy) {
+	def := DefaultLabelPolicy()
+	if p.MaxRules <= 0 {
+		p.MaxRules = def.MaxRules
+	}
+	if p.MaxSites <= 0 {
+		p.MaxSites = def.MaxSites
+	}
+	if p.MaxNodes <= 0 {
+		p.MaxNodes = def.MaxNodes
+	}
+	policyMu.Lock()
+	ruleLabels = newBoundedLabel(p.MaxRules)
+	siteLabels = newBoundedLabel(p.MaxSites)
+	nodeLabels = newBoundedLabel(p.MaxNodes)
+	policyMu.Unlock()
+}
+
+// ruleLabel 规则ID标签
+func ruleLabel(id int64) string {
+	policyMu.RLock()
+	labels := ruleLabels
+	policyMu.RUnlock()
+	return labels.value(strconv.FormatInt(id, 10))
+}
+
+// nodeLabel 节点ID标签，节点ID来自节点上报，超出上限的节点记为other
+func nodeLabel(nodeID string) string {
+	policyMu.RLock()
+	labels := nodeLabels
+	policyMu.RUnlock()
+	return labels.value(nodeID)
+}
+
+// siteLabel 站点标签，取Host请求头并去掉端口
+func siteLabel(req *model.CheckRequest) string {
+	host := req.Headers.GetFold("Host")
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	policyMu.RLock()
+	labels := siteLabels
+	policyMu.RUnlock()
+	return labels.value(strings.ToLower(host))
+}
+
+var (
+	checkDecisionTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waf_check_decision_total",
+			Help: "检查判定次数",
+		},
+		[]string{"phase", "action", "mode", "site"},
+	)
+
+	checkLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "waf_check_latency_seconds",
+			Help:    "单次检查总耗时",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"phase"},
+	)
+
+	checkErrorTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waf_check_error_total",
+			Help: "检查失败次数",
+		},
+		[]string{"phase"},
+	)
+
+	checkStageLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "waf_check_stage_latency_seconds",
+			Help:    "检查各步骤耗时",
+			Buckets: []float64{.0001, .0005, .001, .0025, .005, .01, .025, .05, .1},
+		},
+		[]string{"stage"},
+	)
+
+	ruleLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "waf_rule_latency_seconds",
+			Help:    "单条规则匹配耗时",
+			Buckets: []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05},
+		},
+		[]string{"rule_id"},
+	)
+)
+
+// RecordCheck 记录一次检查的判定结果和总耗时
+func RecordCheck(phase string, req *model.CheckRequest, action model.ActionType, mode string, duration time.Duration) {
+	checkDecisionTotal.WithLabelValues(phase, string(action), mode, siteLabel(req)).Inc()
+	checkLatency.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// RecordCheckError 记录检查失败
+func RecordCheckError(phase string) {
+	checkErrorTotal.WithLabelValues(phase).Inc()
+}
+
+// RecordCheckStage 记录检查步骤耗时
+func RecordCheckStage(stage string, duration time.Duration) {
+	checkStageLatency.WithLabelValues(stage).Observe(duration.Seconds())
+}
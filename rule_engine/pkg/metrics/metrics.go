@@ -14,20 +14,21 @@ import (
 )
 
 var (
-	// 规则相关指标
+	// 规则相关指标，规则名称可修改且取值不受控，不作为标签，按rule_id关联规则
 	ruleMatchTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "waf_rule_match_total",
 			Help: "规则匹配总次数",
 		},
-		[]string{"rule_id", "rule_name", "rule_type", "action", "status"},
+		[]string{"rule_id", "rule_type", "action", "status"},
 	)
 
+	// 按规则类型统计的匹配器耗时
 	ruleMatchLatency = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "waf_rule_match_latency_seconds",
 			Help:    "规则匹配延迟",
-			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1},
+			Buckets: []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1},
 		},
 		[]string{"rule_type"},
 	)
@@ -102,6 +103,91 @@ var (
 		[]string{"component"},
 	)
 
+	// WAF节点指标，由节点心跳更新
+	fleetNodes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "waf_fleet_nodes",
+			Help: "各状态的WAF节点数",
+		},
+		[]string{"status"},
+	)
+
+	nodeVersionLag = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "waf_node_version_lag",
+			Help: "节点落后引擎规则版本的版本数",
+		},
+		[]string{"node_id"},
+	)
+
+	nodeHeartbeatTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waf_node_heartbeat_total",
+			Help: "节点心跳总次数",
+		},
+		[]string{"node_id"},
+	)
+
+	// gRPC检查指标，流式检查按单个请求统计
+	grpcCheckTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waf_grpc_check_total",
+			Help: "gRPC检查请求总数",
+		},
+		[]string{"method", "code"},
+	)
+
+	grpcCheckDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "waf_grpc_check_duration_seconds",
+			Help:    "gRPC检查处理时间",
+			Buckets: []float64{.0001, .0005, .001, .005, .01, .025, .05, .1, .25},
+		},
+		[]string{"method"},
+	)
+
+	// 请求体处理失败指标
+	requestBodyErrorTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waf_request_body_error_total",
+			Help: "请求体解压、解析失败或超出限制的次数",
+		},
+		[]string{"processor"},
+	)
+
+	// 上传文件违规指标
+	uploadViolationTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waf_upload_violation_total",
+			Help: "上传文件检查发现的违规次数",
+		},
+		[]string{"violation"},
+	)
+
+	// 响应阶段请求上下文指标
+	requestContextDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "waf_request_context_dropped_total",
+			Help: "请求上下文数量达到上限未记录的次数",
+		},
+	)
+
+	// 配置重新加载指标
+	configReloadTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "waf_config_reload_total",
+			Help: "配置重新加载总次数",
+		},
+		[]string{"result"},
+	)
+
+	configRestartRequired = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "waf_config_restart_required",
+			Help: "已修改但需要重启才能生效的配置项数量",
+		},
+	)
+
 	// 请求相关指标
 	requestTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -121,26 +207,28 @@ var (
 	)
 )
 
-// RecordRuleMatch 记录规则匹配
+// RecordRuleMatch 记录规则匹配次数和耗时，rule_id超出标签上限时记为other
 func RecordRuleMatch(rule *model.Rule, matched bool, duration time.Duration) {
 	status := "miss"
 	if matched {
 		status = "hit"
 	}
 
+	id := ruleLabel(rule.ID)
 	ruleMatchTotal.WithLabelValues(
-		strconv.FormatInt(rule.ID, 10),
-		rule.Name,
+		id,
 		string(rule.Type),
 		string(rule.Action),
 		status,
 	).Inc()
 
 	ruleMatchLatency.WithLabelValues(string(rule.Type)).Observe(duration.Seconds())
+	ruleLatency.WithLabelValues(id).Observe(duration.Seconds())
 }
 
-// RecordRuleSync 记录规则同步
+// RecordRuleSync 记录规则同步，node_id超出标签上限时记为other
 func RecordRuleSync(nodeID string, success bool, duration time.Duration) {
+	nodeID = nodeLabel(nodeID)
 	status := "failed"
 	statusCode := float64(0)
 	if success {
@@ -153,6 +241,68 @@ func RecordRuleSync(nodeID string, success bool, duration time.Duration) {
 	ruleSyncTotal.WithLabelValues(nodeID, status).Inc()
 }
 
+// RecordNodeHeartbeat 记录节点心跳，syncOK为节点最近一次规则同步是否成功，node_id超出标签上限时记为other
+func RecordNodeHeartbeat(nodeID string, syncOK bool, versionLag int64) {
+	nodeID = nodeLabel(nodeID)
+	statusCode := float64(0)
+	if syncOK {
+		statusCode = 1
+	}
+
+	ruleSyncStatus.WithLabelValues(nodeID).Set(statusCode)
+	nodeVersionLag.WithLabelValues(nodeID).Set(float64(versionLag))
+	nodeHeartbeatTotal.WithLabelValues(nodeID).Inc()
+}
+
+// RecordFleetStatus 记录各状态的节点数
+func RecordFleetStatus(online, stale, down int) {
+	fleetNodes.WithLabelValues("online").Set(float64(online))
+	fleetNodes.WithLabelValues("stale").Set(float64(stale))
+	fleetNodes.WithLabelValues("down").Set(float64(down))
+}
+
+// DeleteNodeMetrics 删除节点的指标并释放标签名额，节点删除后调用；记为other的节点没有单独的指标
+func DeleteNodeMetrics(nodeID string) {
+	policyMu.RLock()
+	labels := nodeLabels
+	policyMu.RUnlock()
+	if !labels.remove(nodeID) {
+		return
+	}
+	// 删除带该node_id的全部时序，释放的名额由新节点使用
+	node := prometheus.Labels{"node_id": nodeID}
+	ruleSyncStatus.DeletePartialMatch(node)
+	ruleSyncLatency.DeletePartialMatch(node)
+	ruleSyncTotal.DeletePartialMatch(node)
+	componentHealth.DeletePartialMatch(node)
+	nodeVersionLag.DeletePartialMatch(node)
+	nodeHeartbeatTotal.DeletePartialMatch(node)
+}
+
+// RecordGRPCCheck 记录gRPC检查请求，code为gRPC状态码名称
+func RecordGRPCCheck(method, code string, duration time.Duration) {
+	grpcCheckTotal.WithLabelValues(method, code).Inc()
+	grpcCheckDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// RecordRequestBodyError 记录请求体处理失败，processor为空时记为none
+func RecordRequestBodyError(processor string) {
+	if processor == "" {
+		processor = "none"
+	}
+	requestBodyErrorTotal.WithLabelValues(processor).Inc()
+}
+
+// RecordUploadViolation 记录上传文件违规，同一请求中的同类违规只记录一次
+func RecordUploadViolation(violation string) {
+	uploadViolationTotal.WithLabelValues(violation).Inc()
+}
+
+// RecordRequestContextDropped 记录因数量达到上限未记录的请求上下文
+func RecordRequestContextDropped() {
+	requestContextDroppedTotal.Inc()
+}
+
 // RecordCacheOperation 记录缓存操作
 func RecordCacheOperation(operation string, hit bool, duration time.Duration) {
 	status := "miss"
@@ -169,8 +319,9 @@ func UpdateCacheHitRatio(cacheType string, ratio float64) {
 	cacheHitRatio.WithLabelValues(cacheType).Set(ratio)
 }
 
-// RecordComponentHealth 记录组件健康状态
+// RecordComponentHealth 记录组件健康状态，node_id超出标签上限时记为other
 func RecordComponentHealth(component, nodeID string, healthy bool, checkDuration time.Duration) {
+	nodeID = nodeLabel(nodeID)
 	status := float64(0)
 	if healthy {
 		status = 1
@@ -180,6 +331,12 @@ func RecordComponentHealth(component, nodeID string, healthy bool, checkDuration
 	healthCheckLatency.WithLabelValues(component).Observe(checkDuration.Seconds())
 }
 
+// RecordConfigReload 记录配置重新加载结果和待重启生效的配置项数量
+func RecordConfigReload(result string, restartRequired int) {
+	configReloadTotal.WithLabelValues(result).Inc()
+	configRestartRequired.Set(float64(restartRequired))
+}
+
 // MetricsHandler 返回Prometheus指标处理器
 func MetricsHandler() http.Handler {
 	return promhttp.Handler()
@@ -196,14 +353,22 @@ func MetricsMiddleware() gin.HandlerFunc {
 		// 记录请求指标
 		requestTotal.With(prometheus.Labels{
 			"method": c.Request.Method,
-			"path":   c.Request.URL.Path,
+			"path":   routePath(c),
 			"status": status,
 		}).Inc()
 
 		requestDuration.With(prometheus.Labels{
 			"method": c.Request.Method,
-			"path":   c.Request.URL.Path,
+			"path":   routePath(c),
 			"status": status,
 		}).Observe(duration.Seconds())
 	}
 }
+
+// routePath 路由模板作为path标签，例如/api/v1/rules/:id，未匹配路由时为unmatched，避免按实际路径产生无限标签
+func routePath(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
+	}
+	return "unmatched"
+}
@@ -39,6 +39,6 @@ func APIMetricsMiddleware(c *gin.Context) {
 	status := strconv.Itoa(c.Writer.Status())
 	duration := time.Since(start).Seconds()
 
-	httpRequestsTotal.WithLabelValues(c.Request.Method, c.Request.URL.Path, status).Inc()
-	httpRequestDuration.WithLabelValues(c.Request.Method, c.Request.URL.Path).Observe(duration)
+	httpRequestsTotal.WithLabelValues(c.Request.Method, routePath(c), status).Inc()
+	httpRequestDuration.WithLabelValues(c.Request.Method, routePath(c)).Observe(duration)
 }
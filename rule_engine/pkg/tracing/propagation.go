@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID 请求ID请求头，没有traceparent时用于生成链路ID
+const HeaderRequestID = "X-Request-ID"
+
+// TraceIDFromRequestID 由请求ID生成链路ID，使Lua核心的请求ID和链路对应：
+// 32位十六进制的请求ID直接作为链路ID，UUID去掉连字符后使用，其他请求ID取SHA-256前16字节
+func TraceIDFromRequestID(requestID string) trace.TraceID {
+	var id trace.TraceID
+	if requestID == "" {
+		return id
+	}
+	compact := strings.ReplaceAll(requestID, "-", "")
+	if len(compact) == 32 {
+		if _, err := hex.Decode(id[:], []byte(strings.ToLower(compact))); err == nil && id.IsValid() {
+			return id
+		}
+	}
+	sum := sha256.Sum256([]byte(requestID))
+	copy(id[:], sum[:16])
+	return id
+}
+
+// traceIDKey 新链路使用的链路ID在上下文中的键
+type traceIDKey struct{}
+
+// ContextWithTraceID 指定上下文中开始新链路时使用的链路ID，已有父Span时不生效
+func ContextWithTraceID(ctx context.Context, id trace.TraceID) context.Context {
+	if !id.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, traceIDKey{}, id)
+}
+
+// ContextWithRequestID 没有上游链路时使用请求ID生成的链路ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	return ContextWithTraceID(ctx, TraceIDFromRequestID(requestID))
+}
+
+// requestIDPropagator 在traceparent之后执行：没有上游链路时由请求ID生成链路ID
+// 请求ID由前端生成并写入日志，据此可以直接按请求ID查找链路；注入时不写入任何字段
+type requestIDPropagator struct{}
+
+var _ propagation.TextMapPropagator = requestIDPropagator{}
+
+// Inject 请求ID由调用方自行传递
+func (requestIDPropagator) Inject(context.Context, propagation.TextMapCarrier) {}
+
+// Extract 没有有效的traceparent时按请求ID指定链路ID
+func (requestIDPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return ContextWithRequestID(ctx, carrier.Get(HeaderRequestID))
+}
+
+// Fields 读取的字段
+func (requestIDPropagator) Fields() []string {
+	return []string{HeaderRequestID}
+}
+
+// idGenerator 新链路优先使用上下文中指定的链路ID，其余ID随机生成
+type idGenerator struct{}
+
+// NewIDs 生成新链路的链路ID和根Span ID
+func (idGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	traceID, ok := ctx.Value(traceIDKey{}).(trace.TraceID)
+	if !ok || !traceID.IsValid() {
+		_, _ = rand.Read(traceID[:])
+	}
+	return traceID, newSpanID()
+}
+
+// NewSpanID 生成子Span ID
+func (idGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return newSpanID()
+}
+
+func newSpanID() trace.SpanID {
+	var id trace.SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
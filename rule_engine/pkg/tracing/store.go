@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// redisSpanKey Redis命令Span在上下文中的键，与调用方的当前Span区分
+type redisSpanKey struct{}
+
+// RedisHook 为每个Redis命令创建Span，管道命令记为一个pipeline Span
+// 命令Span单独保存在上下文中，不作为调用方后续操作的父Span
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+// BeforeProcess 开始命令Span
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	_, span := StartChild(ctx, "redis "+cmd.Name(), trace.SpanKindClient,
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", cmd.Name()),
+	)
+	return context.WithValue(ctx, redisSpanKey{}, span), nil
+}
+
+// AfterProcess 结束命令Span
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endRedisSpan(ctx, cmd.Err())
+	return nil
+}
+
+// BeforeProcessPipeline 开始管道Span
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	_, span := StartChild(ctx, "redis pipeline", trace.SpanKindClient,
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", "pipeline"),
+		attribute.Int64("db.redis.commands", int64(len(cmds))),
+	)
+	return context.WithValue(ctx, redisSpanKey{}, span), nil
+}
+
+// AfterProcessPipeline 结束管道Span
+func (RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && cmd.Err() != redis.Nil {
+			err = cmd.Err()
+			break
+		}
+	}
+	endRedisSpan(ctx, err)
+	return nil
+}
+
+func endRedisSpan(ctx context.Context, err error) {
+	span, ok := ctx.Value(redisSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if err != nil && err != redis.Nil {
+		RecordError(span, err)
+	}
+	span.End()
+}
+
+// gormSpanKey 当前Span在gorm实例中的键
+const gormSpanKey = "tracing:span"
+
+// RegisterGormCallbacks 注册gorm回调，为每次数据库操作创建Span，父Span取自 db.WithContext 传入的上下文，没有父Span时不记录
+// Span名称和db.system取自数据库驱动名称，如mysql、sqlite
+func RegisterGormCallbacks(db *gorm.DB) error {
+	system := db.Dialector.Name()
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			if tx.Statement.Context == nil {
+				return
+			}
+			if _, span := StartChild(tx.Statement.Context, system+" "+operation, trace.SpanKindClient,
+				attribute.String("db.system", system),
+				attribute.String("db.operation", operation),
+			); span.IsRecording() {
+				tx.InstanceSet(gormSpanKey, span)
+			}
+		}
+	}
+	after := func(tx *gorm.DB) {
+		v, ok := tx.InstanceGet(gormSpanKey)
+		if !ok {
+			return
+		}
+		span, ok := v.(trace.Span)
+		if !ok {
+			return
+		}
+		if tx.Statement.Table != "" {
+			span.SetAttributes(attribute.String("db.sql.table", tx.Statement.Table))
+		}
+		span.SetAttributes(attribute.Int64("db.rows_affected", tx.Statement.RowsAffected))
+		if tx.Error != nil && tx.Error != gorm.ErrRecordNotFound {
+			RecordError(span, tx.Error)
+		}
+		span.End()
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("tracing:before_create", before("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", after),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", before("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", after),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", before("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", before("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", after),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", before("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", before("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Package tracing 基于OpenTelemetry的链路追踪
+//
+// 使用W3C Trace Context传播链路，通过OTLP/HTTP导出到OpenTelemetry Collector，或每行一个Span的JSON写入本地文件
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName 本服务创建Span使用的instrumentation scope
+const ScopeName = "github.com/xwaf/rule_engine"
+
+// Options 链路追踪配置
+type Options struct {
+	ServiceName   string            // 服务名，写入resource的service.name
+	Exporter      string            // 导出方式：otlp、file
+	Endpoint      string            // OTLP/HTTP地址，如 http://otel-collector:4318/v1/traces
+	Headers       map[string]string // OTLP请求附加的请求头，如认证信息
+	FilePath      string            // 文件导出路径，每行一个Span的JSON
+	SampleRatio   float64           // 无上游采样决定时的采样比例(0-1)
+	BatchSize     int               // 每批导出的最大Span数
+	QueueSize     int               // 待导出Span队列长度，队列满时丢弃
+	FlushInterval time.Duration     // 导出间隔
+	Timeout       time.Duration     // 单次导出超时
+}
+
+// 导出方式
+const (
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+// disabled 未开启导出时的Provider：仍生成链路ID并传播traceparent，但不记录也不导出
+var disabled = sdktrace.NewTracerProvider(
+	sdktrace.WithSampler(sdktrace.NeverSample()),
+	sdktrace.WithIDGenerator(idGenerator{}),
+)
+
+func init() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, requestIDPropagator{}))
+	otel.SetTracerProvider(disabled)
+}
+
+// Init 初始化全局TracerProvider，返回的函数用于导出剩余Span并关闭导出
+func Init(opts Options) (func(context.Context) error, error) {
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, fmt.Errorf("无效的采样比例: %v", opts.SampleRatio)
+	}
+	if opts.ServiceName == "" {
+		opts.ServiceName = "xwaf-rule-engine"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 4096
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	exporter, err := newExporter(opts)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxExportBatchSize(opts.BatchSize),
+			sdktrace.WithMaxQueueSize(opts.QueueSize),
+			sdktrace.WithBatchTimeout(opts.FlushInterval),
+			sdktrace.WithExportTimeout(opts.Timeout),
+		),
+		// 上游traceparent带采样决定时以上游为准
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithIDGenerator(idGenerator{}),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", opts.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		// 重新加载配置时新的Provider已替换全局Provider，只在仍为当前Provider时恢复为不导出
+		if otel.GetTracerProvider() == trace.TracerProvider(provider) {
+			otel.SetTracerProvider(disabled)
+		}
+		return provider.Shutdown(ctx)
+	}, nil
+}
+
+// newExporter 创建OTLP/HTTP或文件导出
+func newExporter(opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.Exporter {
+	case ExporterOTLP:
+		if opts.Endpoint == "" {
+			return nil, fmt.Errorf("OTLP导出地址不能为空")
+		}
+		return otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(opts.Endpoint),
+			otlptracehttp.WithHeaders(opts.Headers),
+			otlptracehttp.WithTimeout(opts.Timeout),
+		)
+	case ExporterFile:
+		if opts.FilePath == "" {
+			return nil, fmt.Errorf("链路文件路径不能为空")
+		}
+		f, err := os.OpenFile(opts.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("打开链路文件失败: %v", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &fileExporter{SpanExporter: exporter, file: f}, nil
+	default:
+		return nil, fmt.Errorf("无效的链路导出方式: %s", opts.Exporter)
+	}
+}
+
+// fileExporter 关闭导出时同时关闭链路文件
+type fileExporter struct {
+	sdktrace.SpanExporter
+	file *os.File
+}
+
+// Shutdown 关闭导出和链路文件
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Start 创建Span，上下文中没有父Span时开始新的链路
+func Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(ScopeName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// StartChild 只在已有父Span时创建子Span，否则返回不记录的Span，避免后台任务的存储调用各自产生新链路
+func StartChild(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return Start(ctx, name, kind, attrs...)
+}
+
+// RecordError 记录错误并将Span状态标记为错误
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
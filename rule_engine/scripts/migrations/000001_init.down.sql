@@ -0,0 +1,8 @@
+-- 删除初始表
+DROP TABLE IF EXISTS waf_configs;
+DROP TABLE IF EXISTS ip_rules;
+DROP TABLE IF EXISTS cc_rules;
+DROP TABLE IF EXISTS rule_update_events;
+DROP TABLE IF EXISTS rule_sync_logs;
+DROP TABLE IF EXISTS rule_versions;
+DROP TABLE IF EXISTS rules;
@@ -0,0 +1,11 @@
+-- 删除国家/ASN条目
+DELETE FROM ip_rules WHERE entry_type <> 'ip';
+
+-- 恢复唯一键
+ALTER TABLE ip_rules DROP INDEX uk_entry, ADD UNIQUE KEY uk_ip (ip);
+
+-- 恢复IP字段注释
+ALTER TABLE ip_rules MODIFY COLUMN ip VARCHAR(50) NOT NULL COMMENT 'IP地址';
+
+-- 删除名单条目类型字段
+ALTER TABLE ip_rules DROP COLUMN entry_type;
@@ -0,0 +1,8 @@
+-- 添加名单条目类型字段
+ALTER TABLE ip_rules ADD COLUMN entry_type VARCHAR(20) NOT NULL DEFAULT 'ip' COMMENT '条目类型(ip/country/asn)' AFTER id;
+
+-- 更新IP字段注释
+ALTER TABLE ip_rules MODIFY COLUMN ip VARCHAR(50) NOT NULL COMMENT 'IP地址/国家代码/ASN';
+
+-- 唯一键包含条目类型
+ALTER TABLE ip_rules DROP INDEX uk_ip, ADD UNIQUE KEY uk_entry (entry_type, ip);
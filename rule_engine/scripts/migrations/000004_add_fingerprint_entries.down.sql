@@ -0,0 +1,6 @@
+-- 删除指纹条目
+DELETE FROM ip_rules WHERE entry_type IN ('ja3', 'ja4', 'http2');
+
+-- 恢复字段定义
+ALTER TABLE ip_rules MODIFY COLUMN entry_type VARCHAR(20) NOT NULL DEFAULT 'ip' COMMENT '条目类型(ip/country/asn)';
+ALTER TABLE ip_rules MODIFY COLUMN ip VARCHAR(50) NOT NULL COMMENT 'IP地址/国家代码/ASN';
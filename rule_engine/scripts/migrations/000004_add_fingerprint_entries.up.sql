@@ -0,0 +1,3 @@
+-- 名单条目支持JA3/JA4/HTTP2指纹，HTTP/2指纹长度可能超过50
+ALTER TABLE ip_rules MODIFY COLUMN entry_type VARCHAR(20) NOT NULL DEFAULT 'ip' COMMENT '条目类型(ip/country/asn/ja3/ja4/http2)';
+ALTER TABLE ip_rules MODIFY COLUMN ip VARCHAR(255) NOT NULL COMMENT 'IP地址/国家代码/ASN/指纹';
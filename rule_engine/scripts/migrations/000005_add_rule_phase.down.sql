@@ -0,0 +1,6 @@
+-- 删除响应阶段规则
+DELETE FROM rules WHERE phase = 'response';
+
+-- 删除规则检查阶段字段
+ALTER TABLE rules DROP INDEX idx_phase;
+ALTER TABLE rules DROP COLUMN phase;
@@ -0,0 +1,3 @@
+-- 添加规则检查阶段字段，已有规则均为请求阶段
+ALTER TABLE rules ADD COLUMN phase VARCHAR(20) NOT NULL DEFAULT 'request' COMMENT '检查阶段(request/response)' AFTER rule_variable;
+ALTER TABLE rules ADD INDEX idx_phase (phase);
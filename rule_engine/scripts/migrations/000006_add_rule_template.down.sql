@@ -0,0 +1,7 @@
+-- 删除模板来源字段
+ALTER TABLE rules DROP INDEX idx_template_id;
+ALTER TABLE rules DROP COLUMN template_version;
+ALTER TABLE rules DROP COLUMN template_id;
+
+-- 删除规则参数字段
+ALTER TABLE rules DROP COLUMN params;
@@ -0,0 +1,7 @@
+-- 规则参数(JSON)，模板可生成带参数的规则
+ALTER TABLE rules ADD COLUMN params TEXT COMMENT '规则参数(JSON)' AFTER pattern;
+
+-- 记录创建规则的模板及版本，用于模板升级提示
+ALTER TABLE rules ADD COLUMN template_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建规则的模板ID' AFTER hash;
+ALTER TABLE rules ADD COLUMN template_version INT NOT NULL DEFAULT 0 COMMENT '创建规则时的模板版本' AFTER template_id;
+ALTER TABLE rules ADD INDEX idx_template_id (template_id);
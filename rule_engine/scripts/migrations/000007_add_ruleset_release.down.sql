@@ -0,0 +1,5 @@
+-- 删除规则集发布表
+DROP TABLE IF EXISTS ruleset_releases;
+
+-- 删除规则组表
+DROP TABLE IF EXISTS rule_groups;
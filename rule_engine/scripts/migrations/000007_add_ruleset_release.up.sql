@@ -0,0 +1,29 @@
+-- 规则组表，发布快照包含规则组
+CREATE TABLE IF NOT EXISTS rule_groups (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '规则组ID',
+    name        VARCHAR(255) NOT NULL COMMENT '规则组名称',
+    description TEXT COMMENT '规则组描述',
+    status      TINYINT NOT NULL DEFAULT 1 COMMENT '状态',
+    created_by  BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '创建者',
+    updated_by  BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '更新者',
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='规则组表';
+
+-- 规则集发布表，每次发布保存完整规则集快照，发布后不再修改
+CREATE TABLE IF NOT EXISTS ruleset_releases (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '发布ID',
+    action      VARCHAR(20) NOT NULL DEFAULT 'publish' COMMENT '发布类型(publish/rollback)',
+    source_id   BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '回滚时的来源发布ID',
+    hash        CHAR(64) NOT NULL COMMENT '快照内容哈希(SHA-256)',
+    rule_count  INT NOT NULL DEFAULT 0 COMMENT '规则数',
+    description VARCHAR(500) NOT NULL DEFAULT '' COMMENT '发布说明',
+    content     LONGTEXT NOT NULL COMMENT '快照内容(JSON)',
+    created_by  BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '发布人',
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '发布时间',
+    PRIMARY KEY (id),
+    INDEX idx_hash (hash),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='规则集发布表';
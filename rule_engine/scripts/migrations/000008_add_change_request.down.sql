@@ -0,0 +1,8 @@
+-- 删除变更请求历史表
+DROP TABLE IF EXISTS change_request_events;
+
+-- 删除变更项表
+DROP TABLE IF EXISTS change_request_items;
+
+-- 删除变更请求表
+DROP TABLE IF EXISTS change_requests;
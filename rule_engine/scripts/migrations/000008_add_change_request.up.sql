@@ -0,0 +1,47 @@
+-- 变更请求表，批量包含规则、名单、CC规则的变更，审批通过后发布
+CREATE TABLE IF NOT EXISTS change_requests (
+    id           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '变更请求ID',
+    title        VARCHAR(255) NOT NULL COMMENT '标题',
+    description  TEXT COMMENT '说明',
+    status       VARCHAR(20) NOT NULL DEFAULT 'draft' COMMENT '状态(draft/pending/approved/rejected/published/cancelled)',
+    author       BIGINT UNSIGNED NOT NULL COMMENT '作者',
+    approver     BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '审批人',
+    release_id   BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '发布后生成的规则集发布ID',
+    checks       MEDIUMTEXT COMMENT '提交时的检查结果(JSON)',
+    submitted_at TIMESTAMP NULL DEFAULT NULL COMMENT '提交时间',
+    approved_at  TIMESTAMP NULL DEFAULT NULL COMMENT '批准时间',
+    published_at TIMESTAMP NULL DEFAULT NULL COMMENT '发布时间',
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (id),
+    INDEX idx_status (status),
+    INDEX idx_author (author)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='变更请求表';
+
+-- 变更项表
+CREATE TABLE IF NOT EXISTS change_request_items (
+    id                BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '变更项ID',
+    change_request_id BIGINT UNSIGNED NOT NULL COMMENT '变更请求ID',
+    target            VARCHAR(20) NOT NULL COMMENT '变更对象(rule/ip/cc)',
+    operation         VARCHAR(20) NOT NULL COMMENT '变更操作(create/update/delete)',
+    target_id         BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '对象ID，新建的对象发布后回写',
+    payload           TEXT COMMENT '对象内容(JSON)',
+    test_cases        MEDIUMTEXT COMMENT '规则测试用例(JSON)',
+    applied_at        TIMESTAMP NULL DEFAULT NULL COMMENT '发布时生效的时间',
+    PRIMARY KEY (id),
+    INDEX idx_change_request_id (change_request_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='变更项表';
+
+-- 变更请求历史表，记录提交、评论、审批、发布等操作
+CREATE TABLE IF NOT EXISTS change_request_events (
+    id                BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '历史记录ID',
+    change_request_id BIGINT UNSIGNED NOT NULL COMMENT '变更请求ID',
+    action            VARCHAR(20) NOT NULL COMMENT '事件',
+    from_status       VARCHAR(20) NOT NULL DEFAULT '' COMMENT '变更前状态',
+    to_status         VARCHAR(20) NOT NULL DEFAULT '' COMMENT '变更后状态',
+    operator          BIGINT UNSIGNED NOT NULL COMMENT '操作人',
+    comment           TEXT COMMENT '评论或审批意见',
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (id),
+    INDEX idx_change_request_id (change_request_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='变更请求历史表';
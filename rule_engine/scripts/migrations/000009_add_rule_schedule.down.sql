@@ -0,0 +1,5 @@
+-- 删除规则生效时间字段
+ALTER TABLE rules DROP INDEX idx_end_time;
+ALTER TABLE rules DROP COLUMN schedule;
+ALTER TABLE rules DROP COLUMN end_time;
+ALTER TABLE rules DROP COLUMN start_time;
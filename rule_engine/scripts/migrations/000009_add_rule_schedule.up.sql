@@ -0,0 +1,5 @@
+-- 规则生效时间、过期时间(Unix秒，0表示不限制)和周期生效计划
+ALTER TABLE rules ADD COLUMN start_time BIGINT NOT NULL DEFAULT 0 COMMENT '生效时间(Unix秒)，0表示立即生效' AFTER template_version;
+ALTER TABLE rules ADD COLUMN end_time BIGINT NOT NULL DEFAULT 0 COMMENT '过期时间(Unix秒)，0表示不过期' AFTER start_time;
+ALTER TABLE rules ADD COLUMN schedule VARCHAR(255) NOT NULL DEFAULT '' COMMENT '周期生效计划' AFTER end_time;
+ALTER TABLE rules ADD INDEX idx_end_time (end_time);
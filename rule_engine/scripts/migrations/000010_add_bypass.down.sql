@@ -0,0 +1,5 @@
+-- 删除旁路尝试记录表
+DROP TABLE IF EXISTS bypass_attempts;
+
+-- 删除旁路配置表
+DROP TABLE IF EXISTS bypass_configs;
@@ -0,0 +1,35 @@
+-- 旁路配置表，所有旁路必须限定结束时间
+CREATE TABLE IF NOT EXISTS bypass_configs (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '旁路配置ID',
+    mode       VARCHAR(20) NOT NULL COMMENT '旁路模式(none/monitor/partial/complete)',
+    ips        TEXT COMMENT 'IP列表(JSON)',
+    urls       TEXT COMMENT 'URL正则列表(JSON)',
+    headers    TEXT COMMENT 'Header名称列表(JSON)',
+    start_time BIGINT NOT NULL DEFAULT 0 COMMENT '开始时间(Unix秒)，0表示立即生效',
+    end_time   BIGINT NOT NULL DEFAULT 0 COMMENT '结束时间(Unix秒)',
+    reason     VARCHAR(255) NOT NULL DEFAULT '' COMMENT '旁路原因',
+    created_by BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '创建者',
+    updated_by BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '更新者',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (id),
+    INDEX idx_end_time (end_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='旁路配置表';
+
+-- 旁路尝试记录表，记录满足部分或全部旁路条件的请求
+CREATE TABLE IF NOT EXISTS bypass_attempts (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '记录ID',
+    bypass_id  BIGINT UNSIGNED NOT NULL COMMENT '旁路配置ID',
+    request_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT '请求ID',
+    ip         VARCHAR(45) NOT NULL DEFAULT '' COMMENT '来源IP',
+    url        VARCHAR(2048) NOT NULL DEFAULT '' COMMENT '请求URL',
+    headers    TEXT COMMENT '请求头名称',
+    mode       VARCHAR(20) NOT NULL COMMENT '旁路模式',
+    timestamp  BIGINT NOT NULL COMMENT '尝试时间(Unix秒)',
+    success    TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否旁路成功',
+    reason     VARCHAR(255) NOT NULL DEFAULT '' COMMENT '原因说明',
+    PRIMARY KEY (id),
+    INDEX idx_bypass_id (bypass_id),
+    INDEX idx_ip (ip),
+    INDEX idx_timestamp (timestamp)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='旁路尝试记录表';
@@ -0,0 +1,7 @@
+-- 删除旁路尝试记录的令牌ID
+ALTER TABLE bypass_attempts
+    DROP INDEX idx_token_id,
+    DROP COLUMN token_id;
+
+-- 删除旁路令牌签名密钥表
+DROP TABLE IF EXISTS bypass_keys;
@@ -0,0 +1,18 @@
+-- 旁路令牌签名密钥表，轮换后旧密钥只用于验证，吊销后令牌立即失效
+CREATE TABLE IF NOT EXISTS bypass_keys (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '密钥记录ID',
+    key_id     VARCHAR(32) NOT NULL COMMENT '密钥标识',
+    secret     VARBINARY(64) NOT NULL COMMENT 'HMAC-SHA256密钥',
+    status     VARCHAR(20) NOT NULL COMMENT '状态(active/retired/revoked)',
+    created_by BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '创建者',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    retired_at TIMESTAMP NULL DEFAULT NULL COMMENT '轮换时间',
+    revoked_at TIMESTAMP NULL DEFAULT NULL COMMENT '吊销时间',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_key_id (key_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='旁路令牌签名密钥表';
+
+-- 旁路尝试记录增加令牌ID
+ALTER TABLE bypass_attempts
+    ADD COLUMN token_id VARCHAR(64) NOT NULL DEFAULT '' COMMENT '旁路令牌ID' AFTER bypass_id,
+    ADD INDEX idx_token_id (token_id);
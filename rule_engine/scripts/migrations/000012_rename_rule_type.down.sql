@@ -0,0 +1,2 @@
+-- 恢复规则类型字段名
+ALTER TABLE rules CHANGE COLUMN type rule_type VARCHAR(20) NOT NULL COMMENT '规则类型(xss/webshell/sql_inject等)';
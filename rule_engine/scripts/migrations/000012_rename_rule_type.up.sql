@@ -0,0 +1,2 @@
+-- 规则类型字段与代码中的字段名一致
+ALTER TABLE rules CHANGE COLUMN rule_type type VARCHAR(50) NOT NULL COMMENT '规则类型(xss/webshell/sql_inject等)';
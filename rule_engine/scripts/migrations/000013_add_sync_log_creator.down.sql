@@ -0,0 +1,4 @@
+-- 删除同步日志操作者字段
+ALTER TABLE rule_sync_logs
+    DROP COLUMN created_by,
+    MODIFY COLUMN sync_type VARCHAR(50) NOT NULL COMMENT '同步类型';
@@ -0,0 +1,4 @@
+-- 同步日志记录触发同步的操作者，同步类型可不填
+ALTER TABLE rule_sync_logs
+    ADD COLUMN created_by VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作者' AFTER sync_type,
+    MODIFY COLUMN sync_type VARCHAR(50) NOT NULL DEFAULT '' COMMENT '同步类型';
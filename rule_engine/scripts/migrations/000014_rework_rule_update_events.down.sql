@@ -0,0 +1,17 @@
+-- 恢复变更列表和事件状态字段，同一版本只保留最早的事件
+DELETE e1 FROM rule_update_events e1
+    JOIN rule_update_events e2 ON e1.version = e2.version AND e1.id > e2.id;
+
+ALTER TABLE rule_update_events
+    ADD COLUMN changes TEXT NOT NULL COMMENT '变更列表(JSON)' AFTER version,
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT '事件状态' AFTER changes,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间';
+
+UPDATE rule_update_events SET changes = COALESCE(rule_diffs, '[]');
+
+ALTER TABLE rule_update_events
+    DROP INDEX idx_version,
+    DROP COLUMN rule_diffs,
+    DROP COLUMN action,
+    ADD UNIQUE KEY uk_version (version),
+    ADD INDEX idx_status (status);
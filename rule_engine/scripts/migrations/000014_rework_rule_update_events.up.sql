@@ -0,0 +1,14 @@
+-- 更新事件记录更新类型和规则差异，同一版本可以有多个事件(回滚)
+ALTER TABLE rule_update_events
+    ADD COLUMN action VARCHAR(20) NOT NULL DEFAULT 'update' COMMENT '更新类型(create/update/delete/rollback)' AFTER version,
+    ADD COLUMN rule_diffs MEDIUMTEXT COMMENT '规则差异(JSON)' AFTER action;
+
+UPDATE rule_update_events SET rule_diffs = changes;
+
+ALTER TABLE rule_update_events
+    DROP INDEX uk_version,
+    DROP INDEX idx_status,
+    DROP COLUMN changes,
+    DROP COLUMN status,
+    DROP COLUMN updated_at,
+    ADD INDEX idx_version (version);
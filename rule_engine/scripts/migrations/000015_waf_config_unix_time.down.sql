@@ -0,0 +1,26 @@
+-- 删除WAF模式变更日志表
+DROP TABLE IF EXISTS waf_mode_change_logs;
+
+-- 恢复WAF配置的时间字段，非数字的操作者记为0
+ALTER TABLE waf_configs
+    ADD COLUMN created_ts TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    ADD COLUMN updated_ts TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间';
+
+UPDATE waf_configs SET
+    created_ts = FROM_UNIXTIME(GREATEST(created_at, 1)),
+    updated_ts = FROM_UNIXTIME(GREATEST(updated_at, 1)),
+    created_by = IF(created_by REGEXP '^[0-9]+$', created_by, '0'),
+    updated_by = IF(updated_by REGEXP '^[0-9]+$', updated_by, '0');
+
+ALTER TABLE waf_configs
+    DROP INDEX idx_created_at,
+    DROP COLUMN created_at,
+    DROP COLUMN updated_at,
+    MODIFY COLUMN created_by BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '创建者',
+    MODIFY COLUMN updated_by BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '更新者';
+
+ALTER TABLE waf_configs
+    RENAME COLUMN created_ts TO created_at,
+    RENAME COLUMN updated_ts TO updated_at;
+
+ALTER TABLE waf_configs ADD INDEX idx_created_at (created_at);
@@ -0,0 +1,32 @@
+-- WAF配置的时间保存为Unix秒，操作者保存为用户名
+ALTER TABLE waf_configs
+    ADD COLUMN created_unix BIGINT NOT NULL DEFAULT (UNIX_TIMESTAMP()) COMMENT '创建时间(Unix秒)',
+    ADD COLUMN updated_unix BIGINT NOT NULL DEFAULT (UNIX_TIMESTAMP()) COMMENT '更新时间(Unix秒)';
+
+UPDATE waf_configs SET created_unix = UNIX_TIMESTAMP(created_at), updated_unix = UNIX_TIMESTAMP(updated_at);
+
+ALTER TABLE waf_configs
+    DROP INDEX idx_created_at,
+    DROP COLUMN created_at,
+    DROP COLUMN updated_at,
+    MODIFY COLUMN created_by VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者',
+    MODIFY COLUMN updated_by VARCHAR(64) NOT NULL DEFAULT '' COMMENT '更新者';
+
+ALTER TABLE waf_configs
+    RENAME COLUMN created_unix TO created_at,
+    RENAME COLUMN updated_unix TO updated_at;
+
+ALTER TABLE waf_configs ADD INDEX idx_created_at (created_at);
+
+-- WAF运行模式变更日志表
+CREATE TABLE IF NOT EXISTS waf_mode_change_logs (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '日志ID',
+    old_mode    VARCHAR(20) NOT NULL COMMENT '原模式',
+    new_mode    VARCHAR(20) NOT NULL COMMENT '新模式',
+    operator    VARCHAR(64) NOT NULL COMMENT '操作者',
+    reason      VARCHAR(255) NOT NULL COMMENT '变更原因',
+    description TEXT COMMENT '描述',
+    created_at  BIGINT NOT NULL COMMENT '变更时间(Unix秒)',
+    PRIMARY KEY (id),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='WAF模式变更日志表';
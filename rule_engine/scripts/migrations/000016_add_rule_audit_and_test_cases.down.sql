@@ -0,0 +1,5 @@
+-- 删除规则测试用例表
+DROP TABLE IF EXISTS rule_test_cases;
+
+-- 删除规则审计日志表
+DROP TABLE IF EXISTS rule_audit_logs;
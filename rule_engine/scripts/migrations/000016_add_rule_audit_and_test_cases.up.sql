@@ -0,0 +1,29 @@
+-- 规则审计日志表，操作者保存为用户名
+CREATE TABLE IF NOT EXISTS rule_audit_logs (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '日志ID',
+    rule_id    BIGINT UNSIGNED NOT NULL COMMENT '规则ID',
+    action     VARCHAR(50) NOT NULL COMMENT '操作类型(create/update/delete/import)',
+    operator   VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作者',
+    old_value  TEXT COMMENT '修改前的值',
+    new_value  TEXT COMMENT '修改后的值',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (id),
+    INDEX idx_rule_id (rule_id),
+    INDEX idx_operator (operator),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='规则审计日志表';
+
+-- 由rule_enhanced.sql创建的审计日志表操作者为数字ID
+ALTER TABLE rule_audit_logs MODIFY COLUMN operator VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作者';
+
+-- 规则测试用例表
+CREATE TABLE IF NOT EXISTS rule_test_cases (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '用例ID',
+    rule_id    BIGINT UNSIGNED NOT NULL COMMENT '规则ID',
+    request    TEXT COMMENT '测试请求(JSON)',
+    input      TEXT COMMENT '测试输入',
+    expected   TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否期望匹配',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (id),
+    INDEX idx_rule_id (rule_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='规则测试用例表';
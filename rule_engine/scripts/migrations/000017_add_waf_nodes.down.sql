@@ -0,0 +1,2 @@
+-- 删除WAF节点表
+DROP TABLE IF EXISTS waf_nodes;
@@ -0,0 +1,24 @@
+-- WAF节点表，记录节点注册信息和最近一次心跳上报的状态
+CREATE TABLE IF NOT EXISTS waf_nodes (
+    id                 BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'ID',
+    node_id            VARCHAR(128) NOT NULL COMMENT '节点标识',
+    token_hash         CHAR(64) NOT NULL COMMENT '节点令牌SHA-256',
+    hostname           VARCHAR(255) NOT NULL DEFAULT '' COMMENT '主机名',
+    address            VARCHAR(255) NOT NULL DEFAULT '' COMMENT '节点地址',
+    agent_version      VARCHAR(64) NOT NULL DEFAULT '' COMMENT '节点程序版本',
+    status             VARCHAR(20) NOT NULL DEFAULT 'online' COMMENT '节点状态(online/down)',
+    applied_version    BIGINT NOT NULL DEFAULT 0 COMMENT '已应用的规则版本',
+    mode               VARCHAR(20) NOT NULL DEFAULT '' COMMENT '运行模式',
+    health             VARCHAR(20) NOT NULL DEFAULT '' COMMENT '健康状态',
+    health_message     VARCHAR(512) NOT NULL DEFAULT '' COMMENT '健康状态说明',
+    counters           TEXT COMMENT '本地计数(JSON)',
+    sync_error         VARCHAR(512) NOT NULL DEFAULT '' COMMENT '当前同步错误',
+    last_sync_error    VARCHAR(512) NOT NULL DEFAULT '' COMMENT '最近一次同步错误',
+    last_sync_error_at DATETIME(3) NULL COMMENT '最近一次同步错误时间',
+    last_heartbeat_at  DATETIME(3) NULL COMMENT '最近一次心跳时间',
+    registered_at      DATETIME(3) NOT NULL COMMENT '注册时间',
+    updated_at         DATETIME(3) NOT NULL COMMENT '更新时间',
+    PRIMARY KEY (id),
+    UNIQUE KEY uk_node_id (node_id),
+    INDEX idx_status_heartbeat (status, last_heartbeat_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='WAF节点表';
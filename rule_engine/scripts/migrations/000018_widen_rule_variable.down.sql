@@ -0,0 +1,2 @@
+-- 恢复规则变量字段长度，存在超过20个字符的规则变量时失败，需要先修改这些规则
+ALTER TABLE rules MODIFY COLUMN rule_variable VARCHAR(20) NOT NULL DEFAULT 'request_args' COMMENT '规则变量类型';
@@ -0,0 +1,2 @@
+-- 规则变量支持请求字段选择器，如 ARGS|REQUEST_HEADERS:User-Agent|!ARGS:password
+ALTER TABLE rules MODIFY COLUMN rule_variable VARCHAR(512) NOT NULL DEFAULT 'request_args' COMMENT '规则变量或请求字段选择器';
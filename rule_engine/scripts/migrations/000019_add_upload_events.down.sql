@@ -0,0 +1,2 @@
+-- 删除上传违规事件表
+DROP TABLE IF EXISTS upload_events;
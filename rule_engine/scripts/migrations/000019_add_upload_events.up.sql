@@ -0,0 +1,19 @@
+-- 上传违规事件表，记录multipart请求中有违规的上传文件
+CREATE TABLE IF NOT EXISTS upload_events (
+    id            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '事件ID',
+    request_id    VARCHAR(64) NOT NULL DEFAULT '' COMMENT '请求ID',
+    ip            VARCHAR(45) NOT NULL DEFAULT '' COMMENT '来源IP',
+    url           VARCHAR(2048) NOT NULL DEFAULT '' COMMENT '请求URL',
+    field         VARCHAR(255) NOT NULL DEFAULT '' COMMENT '表单字段名',
+    filename      VARCHAR(255) NOT NULL DEFAULT '' COMMENT '客户端提交的文件名',
+    content_type  VARCHAR(255) NOT NULL DEFAULT '' COMMENT '声明的Content-Type',
+    detected_type VARCHAR(255) NOT NULL DEFAULT '' COMMENT '按文件头识别的类型',
+    size          BIGINT NOT NULL DEFAULT 0 COMMENT '文件大小(字节)',
+    violations    VARCHAR(255) NOT NULL DEFAULT '' COMMENT '违规类型，逗号分隔',
+    blocked       TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否拦截',
+    timestamp     BIGINT NOT NULL COMMENT '检查时间(Unix秒)',
+    PRIMARY KEY (id),
+    INDEX idx_ip (ip),
+    INDEX idx_request_id (request_id),
+    INDEX idx_timestamp (timestamp)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='上传违规事件表';
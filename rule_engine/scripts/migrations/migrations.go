@@ -0,0 +1,10 @@
+// Package migrations MySQL表结构迁移脚本，编译时内嵌到程序中
+// 文件名格式为 版本号_名称.up.sql 和 版本号_名称.down.sql，版本号递增且不能修改已发布的脚本
+package migrations
+
+import "embed"
+
+// FS 迁移脚本
+//
+//go:embed *.sql
+var FS embed.FS
@@ -1,17 +1,4 @@
--- 创建规则审计日志表
-CREATE TABLE IF NOT EXISTS rule_audit_logs (
-    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '日志ID',
-    rule_id     BIGINT UNSIGNED NOT NULL COMMENT '规则ID',
-    action      VARCHAR(50) NOT NULL COMMENT '操作类型(create/update/delete/import)',
-    operator    BIGINT UNSIGNED NOT NULL COMMENT '操作者ID',
-    old_value   TEXT COMMENT '修改前的值',
-    new_value   TEXT COMMENT '修改后的值',
-    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
-    PRIMARY KEY (id),
-    INDEX idx_rule_id (rule_id),
-    INDEX idx_operator (operator),
-    INDEX idx_created_at (created_at)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='规则审计日志表';
+-- 规则统计相关表和定时任务，需在迁移完成后执行，规则审计日志表由迁移脚本创建
 
 -- 创建规则匹配统计表
 CREATE TABLE IF NOT EXISTS rule_match_stats (